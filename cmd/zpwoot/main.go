@@ -5,7 +5,7 @@
 // @description A complete REST API for managing multiple WhatsApp sessions using Go, Fiber, PostgreSQL, and whatsmeow library.
 // @description
 // @description ## Authentication
-// @description All API endpoints (except /health and /swagger/*) require API key authentication.
+// @description All API endpoints (except /health, /swagger/* and the presigned /media/* URLs) require API key authentication.
 // @description Provide your API key in the `Authorization` header.
 //
 // @contact.name zpwoot Support
@@ -41,15 +41,19 @@ import (
 	_ "zpwoot/docs/swagger" // Import generated swagger docs
 	"zpwoot/internal/app"
 	"zpwoot/internal/domain/session"
+	"zpwoot/internal/domain/webhook"
 	"zpwoot/internal/infra/db"
 	"zpwoot/internal/infra/http/middleware"
 	"zpwoot/internal/infra/http/routers"
+	"zpwoot/internal/infra/mediastore"
 	"zpwoot/internal/infra/repository"
+	"zpwoot/internal/infra/sink"
 	"zpwoot/internal/infra/wameow"
 	"zpwoot/internal/ports"
 	"zpwoot/platform/config"
 	platformDB "zpwoot/platform/db"
 	"zpwoot/platform/logger"
+	"zpwoot/platform/secrets"
 )
 
 // Build information - set via ldflags during build
@@ -143,31 +147,120 @@ func main() {
 		return
 	}
 
+	// Cipher for the credentials webhooks send to their receivers
+	webhookCipher, err := secrets.NewCipher(cfg.WebhookEncryptionKey)
+	if err != nil {
+		appLogger.Fatal("Failed to create webhook cipher: " + err.Error())
+	}
+	if !webhookCipher.HasKey() {
+		appLogger.Info("WEBHOOK_ENCRYPTION_KEY is not set, webhooks cannot be saved with auth")
+	}
+
 	// Initialize repositories
-	repositories := repository.NewRepositories(database.GetDB(), appLogger)
+	repositories := repository.NewRepositories(database.GetDB(), webhookCipher, appLogger)
 
 	// Initialize WhatsApp manager and create whatsmeow tables
 	appLogger.Info("Initializing WhatsApp manager and creating whatsmeow tables...")
-	whatsappManager, err := initializeWhatsAppManager(database, repositories.GetSessionRepository(), appLogger)
+	whatsappManager, err := initializeWhatsAppManager(cfg, database, repositories.GetSessionRepository(), appLogger)
 	if err != nil {
 		appLogger.Fatal("Failed to initialize WhatsApp manager: " + err.Error())
 	}
 	appLogger.Info("WhatsApp manager initialized successfully with whatsmeow tables created")
 
+	// Broker sinks for webhooks whose URL is amqp://, amqps:// or nats://
+	webhookSinks := []webhook.Sink{
+		sink.NewAMQPSink(appLogger),
+		sink.NewNATSSink(appLogger),
+	}
+
+	// Keep the media of received and sent messages on local disk or in an S3-compatible bucket
+	mediaStore, err := initializeMediaStore(cfg)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize media storage: " + err.Error())
+	}
+
 	// Initialize application container with dependencies
 	container := app.NewContainer(&app.ContainerConfig{
 		SessionRepo:         repositories.GetSessionRepository(),
 		WebhookRepo:         repositories.GetWebhookRepository(),
+		WebhookDeliveryRepo: repositories.GetWebhookDeliveryRepository(),
 		ChatwootRepo:        repositories.GetChatwootRepository(),
+		EventOutboxRepo:     repositories.GetEventOutboxRepository(),
+		MessageRepo:         repositories.GetMessageRepository(),
+		Transactor:          repositories.GetTransactor(),
 		WameowManager:       whatsappManager,
 		ChatwootIntegration: nil, // Will be implemented when Chatwoot integration is needed
+		MediaStore:          mediaStore,
 		Logger:              appLogger,
 		DB:                  database.GetDB().DB,
 		Version:             Version,
 		BuildTime:           BuildTime,
 		GitCommit:           GitCommit,
+		WebhookDelivery: &webhook.DeliveryConfig{
+			Timeout:        time.Duration(cfg.WebhookTimeoutSeconds) * time.Second,
+			MaxConcurrency: cfg.WebhookMaxConcurrency,
+			UserAgent:      "zpwoot-webhook/" + Version,
+			Secret:         cfg.WebhookSecret,
+			Sinks:          webhookSinks,
+			Circuit: &webhook.CircuitConfig{
+				FailureThreshold: cfg.WebhookCircuitFailureThreshold,
+				ProbeInterval:    time.Duration(cfg.WebhookCircuitProbeIntervalSeconds) * time.Second,
+				DisableAfter:     time.Duration(cfg.WebhookCircuitDisableAfterHours) * time.Hour,
+			},
+		},
+		WebhookRetry: &webhook.RetryConfig{
+			MaxRetries: cfg.WebhookMaxRetries,
+			BaseDelay:  time.Duration(cfg.WebhookRetryBaseDelaySeconds) * time.Second,
+			MaxDelay:   time.Duration(cfg.WebhookRetryMaxDelaySeconds) * time.Second,
+		},
+		WebhookRetryWorker: &app.WebhookRetryWorkerConfig{
+			PollInterval: 5 * time.Second,
+			BatchSize:    100,
+			Retention:    time.Duration(cfg.WebhookDeliveryRetentionDays) * 24 * time.Hour,
+		},
+		EventOutbox: &app.EventOutboxConfig{
+			PollInterval: time.Second,
+			BatchSize:    100,
+			GapTimeout:   90 * time.Second,
+			GapExpiry:    10 * time.Minute,
+			Retention:    time.Duration(cfg.EventOutboxRetentionHours) * time.Hour,
+			DedupTTL:     time.Duration(cfg.EventDedupTTLHours) * time.Hour,
+		},
+		MediaRetention: &app.MediaRetentionWorkerConfig{
+			Retention: time.Duration(cfg.MediaRetentionDays) * 24 * time.Hour,
+			Interval:  time.Hour,
+		},
 	})
 
+	// Record WhatsApp events in the outbox, together with the session changes they describe
+	whatsappManager.SetWebhookHandler(container.GetWebhookUseCase())
+	whatsappManager.SetEventTransactor(repositories.GetTransactor())
+
+	// Keep the history of the messages received and sent by the sessions
+	whatsappManager.SetMessageRepository(repositories.GetMessageRepository())
+
+	// Keep the media of messages, downloaded as they arrive or on first access
+	if err := whatsappManager.SetMediaConfig(&wameow.MediaConfig{
+		Store:                  mediaStore,
+		AutoDownload:           cfg.MediaAutoDownload,
+		MaxConcurrentDownloads: cfg.MediaMaxConcurrentDownloads,
+		DownloadQueueSize:      cfg.MediaDownloadQueueSize,
+	}); err != nil {
+		appLogger.Fatal("Failed to configure media storage: " + err.Error())
+	}
+
+	// Deliver recorded events to webhooks and sinks, resuming from the stored checkpoints
+	container.GetEventOutboxDispatcher().Start()
+
+	// Register GLOBAL_WEBHOOK_URL as a subscriber for events from every session
+	syncCtx, syncCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := container.GetWebhookUseCase().SyncEnvGlobalWebhook(syncCtx, cfg.GlobalWebhookURL); err != nil {
+		appLogger.ErrorWithFields("Failed to configure global webhook", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	syncCancel()
+
 	// Initialize Fiber app
 	app := fiber.New(fiber.Config{
 		DisableStartupMessage: true, // Disable the Fiber startup banner
@@ -196,6 +289,12 @@ func main() {
 	// Connect existing sessions on startup
 	go connectOnStartup(container, appLogger)
 
+	// Retry failed webhook deliveries, including the ones pending before a restart
+	container.GetWebhookRetryWorker().Start()
+
+	// Remove media from the media store once MEDIA_RETENTION_DAYS have passed
+	container.GetMediaRetentionWorker().Start()
+
 	// Graceful shutdown
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -206,6 +305,15 @@ func main() {
 		if err := app.Shutdown(); err != nil {
 			appLogger.Error("Failed to shutdown server gracefully: " + err.Error())
 		}
+		container.GetWebhookRetryWorker().Stop()
+		container.GetMediaRetentionWorker().Stop()
+		whatsappManager.StopEventQueue()
+		whatsappManager.StopMediaDownloads()
+		whatsappManager.StopEventBus()
+		container.GetEventOutboxDispatcher().Stop()
+		for _, webhookSink := range webhookSinks {
+			webhookSink.Close()
+		}
 	}()
 
 	// Start server
@@ -222,11 +330,17 @@ func main() {
 
 // initializeWhatsAppManager creates and initializes the WhatsApp manager
 // This will automatically create the whatsmeow tables in the database
-func initializeWhatsAppManager(database *platformDB.DB, sessionRepo ports.SessionRepository, appLogger *logger.Logger) (*wameow.Manager, error) {
+func initializeWhatsAppManager(cfg *config.Config, database *platformDB.DB, sessionRepo ports.SessionRepository, appLogger *logger.Logger) (*wameow.Manager, error) {
 	appLogger.Info("Creating WhatsApp manager factory...")
 
 	// Create factory with the session repository
-	factory := wameow.NewFactory(appLogger, sessionRepo)
+	factory := wameow.NewFactory(appLogger, sessionRepo).WithEventQueueConfig(&wameow.EventQueueConfig{
+		Size:           cfg.EventQueueSize,
+		Workers:        cfg.EventQueueWorkers,
+		Overflow:       wameow.OverflowPolicy(cfg.EventQueueOverflow),
+		BlockTimeout:   time.Duration(cfg.EventQueueBlockTimeoutSeconds) * time.Second,
+		SessionTimeout: time.Duration(cfg.EventQueueSessionTimeoutSeconds) * time.Second,
+	})
 
 	appLogger.Info("Creating WhatsApp manager with database connection...")
 	manager, err := factory.CreateManager(database.GetDB().DB)
@@ -238,6 +352,38 @@ func initializeWhatsAppManager(database *platformDB.DB, sessionRepo ports.Sessio
 	return manager, nil
 }
 
+// initializeMediaStore creates the media store selected by MEDIA_STORAGE
+func initializeMediaStore(cfg *config.Config) (ports.MediaStore, error) {
+	switch cfg.MediaStorage {
+	case "local", "":
+		// Presigned URLs are signed with the API key unless a dedicated secret is set
+		secret := cfg.MediaURLSecret
+		if secret == "" {
+			secret = cfg.GlobalAPIKey
+		}
+		return mediastore.NewLocalStore(&mediastore.LocalConfig{
+			Dir:        cfg.MediaDir,
+			BaseURL:    cfg.GetServerURL(),
+			SigningKey: secret,
+		})
+	case "s3":
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return mediastore.NewS3Store(ctx, &mediastore.S3Config{
+			Endpoint:  cfg.MediaS3Endpoint,
+			Region:    cfg.MediaS3Region,
+			Bucket:    cfg.MediaS3Bucket,
+			AccessKey: cfg.MediaS3AccessKey,
+			SecretKey: cfg.MediaS3SecretKey,
+			Prefix:    cfg.MediaS3Prefix,
+			UseSSL:    cfg.MediaS3UseSSL,
+			PathStyle: cfg.MediaS3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("unknown MEDIA_STORAGE %q, expected local or s3", cfg.MediaStorage)
+	}
+}
+
 // showVersion displays version information
 func showVersion() {
 	fmt.Printf("zpwoot - WhatsApp Multi-Session API\n")
@@ -34,18 +34,20 @@ type (
 
 // Session DTOs
 type (
-	CreateSessionRequest  = session.CreateSessionRequest
-	CreateSessionResponse = session.CreateSessionResponse
-	UpdateSessionRequest  = session.UpdateSessionRequest
-	ListSessionsRequest   = session.ListSessionsRequest
-	ListSessionsResponse  = session.ListSessionsResponse
-	SessionInfoResponse   = session.SessionInfoResponse
-	SessionResponse       = session.SessionResponse
-	DeviceInfoResponse    = session.DeviceInfoResponse
-	PairPhoneRequest      = session.PairPhoneRequest
-	QRCodeResponse        = session.QRCodeResponse
-	SetProxyRequest       = session.SetProxyRequest
-	ProxyResponse         = session.ProxyResponse
+	CreateSessionRequest    = session.CreateSessionRequest
+	CreateSessionResponse   = session.CreateSessionResponse
+	UpdateSessionRequest    = session.UpdateSessionRequest
+	ListSessionsRequest     = session.ListSessionsRequest
+	ListSessionsResponse    = session.ListSessionsResponse
+	SessionInfoResponse     = session.SessionInfoResponse
+	SessionResponse         = session.SessionResponse
+	DeviceInfoResponse      = session.DeviceInfoResponse
+	PairPhoneRequest        = session.PairPhoneRequest
+	QRCodeResponse          = session.QRCodeResponse
+	SetProxyRequest         = session.SetProxyRequest
+	ProxyResponse           = session.ProxyResponse
+	SetMediaSettingsRequest = session.SetMediaSettingsRequest
+	MediaSettingsResponse   = session.MediaSettingsResponse
 )
 
 // Webhook DTOs
@@ -61,6 +63,15 @@ type (
 	TestWebhookResponse   = webhook.TestWebhookResponse
 	WebhookEventsResponse = webhook.WebhookEventsResponse
 	WebhookEventInfo      = webhook.WebhookEventInfo
+
+	PreviewTemplateRequest  = webhook.PreviewTemplateRequest
+	PreviewTemplateResponse = webhook.PreviewTemplateResponse
+
+	DeliveryResponse          = webhook.DeliveryResponse
+	ListDeliveriesRequest     = webhook.ListDeliveriesRequest
+	ListDeliveriesResponse    = webhook.ListDeliveriesResponse
+	ReplayDeadLettersRequest  = webhook.ReplayDeadLettersRequest
+	ReplayDeadLettersResponse = webhook.ReplayDeadLettersResponse
 )
 
 // Chatwoot DTOs
@@ -88,6 +99,20 @@ type (
 type (
 	SendMessageRequest  = message.SendMessageRequest
 	SendMessageResponse = message.SendMessageResponse
+
+	ListChatsRequest      = message.ListChatsRequest
+	ListChatsResponse     = message.ListChatsResponse
+	ChatResponse          = message.ChatResponse
+	ListMessagesRequest   = message.ListMessagesRequest
+	ListMessagesResponse  = message.ListMessagesResponse
+	StoredMessageResponse = message.StoredMessageResponse
+
+	SearchMessagesRequest       = message.SearchMessagesRequest
+	SearchMessagesResponse      = message.SearchMessagesResponse
+	MessageSearchResultResponse = message.MessageSearchResultResponse
+
+	MediaURLRequest  = message.MediaURLRequest
+	MediaURLResponse = message.MediaURLResponse
 )
 
 // Helper functions - re-export from common
@@ -109,9 +134,14 @@ var (
 	FromWebhook        = webhook.FromWebhook
 	FromWebhookEvent   = webhook.FromWebhookEvent
 	GetSupportedEvents = webhook.GetSupportedEvents
+	FromDelivery       = webhook.FromDelivery
 
 	// Chatwoot conversions
 	FromChatwootConfig = chatwoot.FromChatwootConfig
+
+	// Message conversions
+	FromStoredMessage       = message.FromStoredMessage
+	FromMessageSearchResult = message.FromMessageSearchResult
 )
 
 // Use Cases - interfaces for business logic orchestration
@@ -125,11 +155,21 @@ type (
 	// Webhook use cases
 	WebhookUseCase = webhook.UseCase
 
+	// Webhook background workers
+	WebhookRetryWorker       = webhook.RetryWorker
+	WebhookRetryWorkerConfig = webhook.RetryWorkerConfig
+	EventOutboxDispatcher    = webhook.OutboxDispatcher
+	EventOutboxConfig        = webhook.OutboxDispatcherConfig
+
 	// Chatwoot use cases
 	ChatwootUseCase = chatwoot.UseCase
 
 	// Message use cases
 	MessageUseCase = message.UseCase
+
+	// Message background workers
+	MediaRetentionWorker       = message.MediaRetentionWorker
+	MediaRetentionWorkerConfig = message.MediaRetentionWorkerConfig
 )
 
 // Use Case constructors
@@ -143,9 +183,16 @@ var (
 	// Webhook use case constructor
 	NewWebhookUseCase = webhook.NewUseCase
 
+	// Webhook worker constructors
+	NewWebhookRetryWorker    = webhook.NewRetryWorker
+	NewEventOutboxDispatcher = webhook.NewOutboxDispatcher
+
 	// Chatwoot use case constructor
 	NewChatwootUseCase = chatwoot.NewUseCase
 
 	// Message use case constructor
 	NewMessageUseCase = message.NewUseCase
+
+	// Message worker constructors
+	NewMediaRetentionWorker = message.NewMediaRetentionWorker
 )
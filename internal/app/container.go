@@ -20,6 +20,11 @@ type Container struct {
 	ChatwootUseCase ChatwootUseCase
 	MessageUseCase  MessageUseCase
 
+	// Background workers
+	WebhookRetryWorker    *WebhookRetryWorker
+	EventOutboxDispatcher *EventOutboxDispatcher
+	MediaRetentionWorker  *MediaRetentionWorker
+
 	// Dependencies
 	logger      *logger.Logger
 	sessionRepo ports.SessionRepository
@@ -28,18 +33,34 @@ type Container struct {
 // ContainerConfig holds configuration for creating the container
 type ContainerConfig struct {
 	// Repositories
-	SessionRepo  ports.SessionRepository
-	WebhookRepo  ports.WebhookRepository
-	ChatwootRepo ports.ChatwootRepository
+	SessionRepo         ports.SessionRepository
+	WebhookRepo         ports.WebhookRepository
+	WebhookDeliveryRepo ports.WebhookDeliveryRepository
+	ChatwootRepo        ports.ChatwootRepository
+	EventOutboxRepo     ports.EventOutboxRepository
+	MessageRepo         ports.MessageRepository
+	Transactor          ports.Transactor
 
 	// External integrations
 	WameowManager       ports.WameowManager
 	ChatwootIntegration ports.ChatwootIntegration
+	MediaStore          ports.MediaStore
 
 	// Infrastructure
 	Logger *logger.Logger
 	DB     *sql.DB
 
+	// Webhooks
+	WebhookDelivery    *webhook.DeliveryConfig
+	WebhookRetry       *webhook.RetryConfig
+	WebhookRetryWorker *WebhookRetryWorkerConfig
+
+	// Event outbox
+	EventOutbox *EventOutboxConfig
+
+	// Media
+	MediaRetention *MediaRetentionWorkerConfig
+
 	// Application metadata
 	Version   string
 	BuildTime string
@@ -55,6 +76,8 @@ func NewContainer(config *ContainerConfig) *Container {
 	)
 
 	webhookService := webhook.NewService(
+		config.WebhookRepo,
+		config.WebhookDelivery,
 		config.Logger,
 	)
 
@@ -78,9 +101,22 @@ func NewContainer(config *ContainerConfig) *Container {
 		sessionService,
 	)
 
+	// Events are recorded in the outbox and handed to its consumers by the dispatcher
+	eventOutboxDispatcher := NewEventOutboxDispatcher(
+		config.EventOutboxRepo,
+		config.EventOutbox,
+		config.Logger,
+	)
+
 	webhookUseCase := NewWebhookUseCase(
 		config.WebhookRepo,
+		config.WebhookDeliveryRepo,
+		config.EventOutboxRepo,
+		config.Transactor,
+		eventOutboxDispatcher,
 		webhookService,
+		config.WebhookRetry,
+		config.Logger,
 	)
 
 	chatwootUseCase := NewChatwootUseCase(
@@ -91,18 +127,43 @@ func NewContainer(config *ContainerConfig) *Container {
 
 	messageUseCase := NewMessageUseCase(
 		config.SessionRepo,
+		config.MessageRepo,
 		config.WameowManager,
+		config.MediaStore,
+		config.Logger,
+	)
+
+	// Register outbox consumers; webhooks include the broker sinks selected by URL scheme
+	eventOutboxDispatcher.Register("webhooks", webhookUseCase.DeliverEvent)
+
+	// Create background workers
+	webhookRetryWorker := NewWebhookRetryWorker(
+		config.WebhookDeliveryRepo,
+		config.WebhookRepo,
+		webhookService,
+		config.WebhookRetry,
+		config.WebhookRetryWorker,
+		config.Logger,
+	)
+
+	mediaRetentionWorker := NewMediaRetentionWorker(
+		config.MediaStore,
+		config.MessageRepo,
+		config.MediaRetention,
 		config.Logger,
 	)
 
 	return &Container{
-		CommonUseCase:   commonUseCase,
-		SessionUseCase:  sessionUseCase,
-		WebhookUseCase:  webhookUseCase,
-		ChatwootUseCase: chatwootUseCase,
-		MessageUseCase:  messageUseCase,
-		logger:          config.Logger,
-		sessionRepo:     config.SessionRepo,
+		CommonUseCase:         commonUseCase,
+		SessionUseCase:        sessionUseCase,
+		WebhookUseCase:        webhookUseCase,
+		ChatwootUseCase:       chatwootUseCase,
+		MessageUseCase:        messageUseCase,
+		WebhookRetryWorker:    webhookRetryWorker,
+		EventOutboxDispatcher: eventOutboxDispatcher,
+		MediaRetentionWorker:  mediaRetentionWorker,
+		logger:                config.Logger,
+		sessionRepo:           config.SessionRepo,
 	}
 }
 
@@ -121,6 +182,21 @@ func (c *Container) GetWebhookUseCase() WebhookUseCase {
 	return c.WebhookUseCase
 }
 
+// GetWebhookRetryWorker returns the webhook retry worker
+func (c *Container) GetWebhookRetryWorker() *WebhookRetryWorker {
+	return c.WebhookRetryWorker
+}
+
+// GetEventOutboxDispatcher returns the event outbox dispatcher
+func (c *Container) GetEventOutboxDispatcher() *EventOutboxDispatcher {
+	return c.EventOutboxDispatcher
+}
+
+// GetMediaRetentionWorker returns the media retention worker
+func (c *Container) GetMediaRetentionWorker() *MediaRetentionWorker {
+	return c.MediaRetentionWorker
+}
+
 // GetChatwootUseCase returns the chatwoot use case
 func (c *Container) GetChatwootUseCase() ChatwootUseCase {
 	return c.ChatwootUseCase
@@ -1,11 +1,27 @@
 package message
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"zpwoot/internal/domain/message"
+	"zpwoot/internal/domain/webhook"
+	"zpwoot/internal/ports"
 )
 
+// ErrInvalidHistoryQuery is returned when chat or message history query parameters are malformed
+var ErrInvalidHistoryQuery = errors.New("invalid history query")
+
+// ErrInvalidMediaURLRequest is returned when the validity requested for a media URL is out of range
+var ErrInvalidMediaURLRequest = errors.New("invalid media URL request")
+
 // SendMessageRequest represents the request to send a message
 type SendMessageRequest struct {
 	To          string `json:"to" validate:"required" example:"5511999999999@s.whatsapp.net"`
@@ -215,3 +231,295 @@ type DeleteResponse struct {
 	ForAll    bool      `json:"forAll" example:"true"`
 	Timestamp time.Time `json:"timestamp" example:"2024-01-01T12:00:00Z"`
 } // @name DeleteResponse
+
+// ListChatsRequest represents the query of a chat listing
+type ListChatsRequest struct {
+	Cursor string `json:"cursor,omitempty" query:"cursor" example:"MTcwNDEwODgwMDo1NTExOTk5OTk5OTk5QHMud2hhdHNhcHAubmV0"`
+	Limit  int    `json:"limit,omitempty" query:"limit" validate:"omitempty,min=1,max=100" example:"20"`
+} // @name ListChatsRequest
+
+// ListChatsResponse represents a page of chats, most recently active first
+type ListChatsResponse struct {
+	Chats      []ChatResponse `json:"chats"`
+	NextCursor string         `json:"nextCursor,omitempty" example:"MTcwNDEwODgwMDo1NTExOTk5OTk5OTk5QHMud2hhdHNhcHAubmV0"`
+	HasMore    bool           `json:"hasMore" example:"true"`
+} // @name ListChatsResponse
+
+// ChatResponse represents a chat with its latest message
+type ChatResponse struct {
+	JID          string                `json:"jid" example:"5511999999999@s.whatsapp.net"`
+	IsGroup      bool                  `json:"isGroup" example:"false"`
+	UnreadCount  int                   `json:"unreadCount" example:"3"`
+	LastActivity time.Time             `json:"lastActivity" example:"2024-01-01T12:00:00Z"`
+	LastMessage  StoredMessageResponse `json:"lastMessage"`
+} // @name ChatResponse
+
+// ListMessagesRequest represents the query of a chat history listing
+type ListMessagesRequest struct {
+	Cursor string   `json:"cursor,omitempty" query:"cursor" example:"MTcwNDEwODgwMDo1ZjNh"`
+	Limit  int      `json:"limit,omitempty" query:"limit" validate:"omitempty,min=1,max=100" example:"50"`
+	Before string   `json:"before,omitempty" query:"before" example:"2024-01-02T00:00:00Z"`
+	After  string   `json:"after,omitempty" query:"after" example:"2024-01-01T00:00:00Z"`
+	Types  []string `json:"types,omitempty" query:"types" example:"text,image"`
+	Order  string   `json:"order,omitempty" query:"order" validate:"omitempty,oneof=asc desc" example:"desc"`
+} // @name ListMessagesRequest
+
+// ListMessagesResponse represents a page of the history of a chat
+type ListMessagesResponse struct {
+	Messages   []StoredMessageResponse `json:"messages"`
+	NextCursor string                  `json:"nextCursor,omitempty" example:"MTcwNDEwODgwMDo1ZjNh"`
+	HasMore    bool                    `json:"hasMore" example:"true"`
+} // @name ListMessagesResponse
+
+// StoredMessageResponse represents a message of the history
+type StoredMessageResponse struct {
+	ID              string     `json:"id" example:"3EB0C767D71D"`
+	ChatJID         string     `json:"chatJid" example:"5511999999999@s.whatsapp.net"`
+	SenderJID       string     `json:"senderJid" example:"5511999999999@s.whatsapp.net"`
+	FromMe          bool       `json:"fromMe" example:"false"`
+	PushName        string     `json:"pushName,omitempty" example:"John Doe"`
+	Type            string     `json:"type" example:"text"`
+	Text            string     `json:"text,omitempty" example:"Hello World!"`
+	Caption         string     `json:"caption,omitempty" example:"Image caption"`
+	MimeType        string     `json:"mimeType,omitempty" example:"image/jpeg"`
+	FileName        string     `json:"fileName,omitempty" example:"document.pdf"`
+	FileSize        int64      `json:"fileSize,omitempty" example:"102400"`
+	QuotedMessageID string     `json:"quotedMessageId,omitempty" example:"3EB0C767D71C"`
+	Timestamp       time.Time  `json:"timestamp" example:"2024-01-01T12:00:00Z"`
+	ReadAt          *time.Time `json:"readAt,omitempty" example:"2024-01-01T12:05:00Z"`
+} // @name StoredMessageResponse
+
+// SearchMessagesRequest represents the query of a full-text message search
+type SearchMessagesRequest struct {
+	Q      string   `json:"q" query:"q" validate:"required,max=256" example:"pedido 12345"`
+	Chat   string   `json:"chat,omitempty" query:"chat" example:"5511999999999@s.whatsapp.net"`
+	Sender string   `json:"sender,omitempty" query:"sender" example:"5511999999999@s.whatsapp.net"`
+	Before string   `json:"before,omitempty" query:"before" example:"2024-01-02T00:00:00Z"`
+	After  string   `json:"after,omitempty" query:"after" example:"2024-01-01T00:00:00Z"`
+	Types  []string `json:"types,omitempty" query:"types" example:"text,document"`
+	Limit  int      `json:"limit,omitempty" query:"limit" validate:"omitempty,min=1,max=100" example:"20"`
+	Offset int      `json:"offset,omitempty" query:"offset" validate:"omitempty,min=0" example:"0"`
+} // @name SearchMessagesRequest
+
+// SearchMessagesResponse represents a page of search results, most relevant first
+type SearchMessagesResponse struct {
+	Results []MessageSearchResultResponse `json:"results"`
+	Limit   int                           `json:"limit" example:"20"`
+	Offset  int                           `json:"offset" example:"0"`
+	HasMore bool                          `json:"hasMore" example:"false"`
+} // @name SearchMessagesResponse
+
+// MessageSearchResultResponse represents a message matching a search
+type MessageSearchResultResponse struct {
+	SessionID string                `json:"sessionId" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Snippet   string                `json:"snippet" example:"segue o número do <mark>pedido</mark>: 12345"`
+	Rank      float64               `json:"rank" example:"0.0759"`
+	Message   StoredMessageResponse `json:"message"`
+} // @name MessageSearchResultResponse
+
+// FromStoredMessage converts a message of the history to response
+func FromStoredMessage(m *ports.StoredMessage) StoredMessageResponse {
+	response := StoredMessageResponse{
+		ID:              m.MessageID,
+		ChatJID:         m.ChatJID,
+		SenderJID:       m.SenderJID,
+		FromMe:          m.FromMe,
+		PushName:        m.PushName,
+		Type:            m.MessageType,
+		Text:            m.Text,
+		Caption:         m.Caption,
+		MimeType:        m.MimeType,
+		FileName:        m.FileName,
+		FileSize:        m.FileSize,
+		QuotedMessageID: m.QuotedMessageID,
+		Timestamp:       time.Unix(m.Timestamp, 0),
+	}
+
+	if m.ReadAt > 0 {
+		readAt := time.Unix(m.ReadAt, 0)
+		response.ReadAt = &readAt
+	}
+
+	return response
+}
+
+// ToFilter converts the request to a history filter of a chat
+func (r *ListMessagesRequest) ToFilter(sessionID, chatJID string) (*ports.MessageFilter, error) {
+	filter := &ports.MessageFilter{
+		SessionID: sessionID,
+		ChatJID:   chatJID,
+		Ascending: r.Order == "asc",
+	}
+
+	switch r.Order {
+	case "", "asc", "desc":
+	default:
+		return nil, fmt.Errorf("%w: order must be asc or desc", ErrInvalidHistoryQuery)
+	}
+
+	var err error
+	if filter.Types, err = parseMessageTypes(r.Types); err != nil {
+		return nil, err
+	}
+	if filter.Before, err = parseHistoryTime("before", r.Before); err != nil {
+		return nil, err
+	}
+	if filter.After, err = parseHistoryTime("after", r.After); err != nil {
+		return nil, err
+	}
+
+	if filter.Cursor, err = decodeCursor(r.Cursor); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// maxSearchQueryLength is the longest accepted search query, in characters
+const maxSearchQueryLength = 256
+
+// snippetHighlighter turns the highlight markers of search snippets into HTML
+var snippetHighlighter = strings.NewReplacer(ports.SearchHighlightStart, "<mark>", ports.SearchHighlightStop, "</mark>")
+
+// FromMessageSearchResult converts a search result to response. The snippet is HTML escaped
+// with matched words wrapped in <mark>, so it can be displayed as is.
+func FromMessageSearchResult(r *ports.MessageSearchResult) MessageSearchResultResponse {
+	return MessageSearchResultResponse{
+		SessionID: r.Message.SessionID,
+		Snippet:   snippetHighlighter.Replace(html.EscapeString(strings.TrimSpace(r.Snippet))),
+		Rank:      r.Rank,
+		Message:   FromStoredMessage(r.Message),
+	}
+}
+
+// ToSearch converts the request to a message search, across every session when sessionID is empty
+func (r *SearchMessagesRequest) ToSearch(sessionID string) (*ports.MessageSearch, error) {
+	query := strings.TrimSpace(r.Q)
+	if query == "" {
+		return nil, fmt.Errorf("%w: q is required", ErrInvalidHistoryQuery)
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return nil, fmt.Errorf("%w: q must be at most %d characters", ErrInvalidHistoryQuery, maxSearchQueryLength)
+	}
+	if r.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", ErrInvalidHistoryQuery)
+	}
+
+	search := &ports.MessageSearch{
+		Query:     query,
+		SessionID: sessionID,
+		Offset:    r.Offset,
+	}
+	if r.Chat != "" {
+		search.ChatJID = normalizeChatJID(r.Chat)
+	}
+	if r.Sender != "" {
+		search.SenderJID = normalizeChatJID(r.Sender)
+	}
+
+	var err error
+	if search.Types, err = parseMessageTypes(r.Types); err != nil {
+		return nil, err
+	}
+	if search.Before, err = parseHistoryTime("before", r.Before); err != nil {
+		return nil, err
+	}
+	if search.After, err = parseHistoryTime("after", r.After); err != nil {
+		return nil, err
+	}
+
+	return search, nil
+}
+
+// parseMessageTypes parses message types given comma separated, repeated or both
+func parseMessageTypes(values []string) ([]string, error) {
+	var types []string
+	for _, value := range values {
+		for _, messageType := range strings.Split(value, ",") {
+			if messageType = strings.TrimSpace(messageType); messageType == "" {
+				continue
+			}
+			if !slices.Contains(webhook.SupportedMessageTypes, messageType) {
+				return nil, fmt.Errorf("%w: unsupported message type %q", ErrInvalidHistoryQuery, messageType)
+			}
+			types = append(types, messageType)
+		}
+	}
+	return types, nil
+}
+
+// parseHistoryTime parses an optional RFC3339 time into a unix timestamp
+func parseHistoryTime(name, value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be an RFC3339 timestamp", ErrInvalidHistoryQuery, name)
+	}
+
+	return t.Unix(), nil
+}
+
+// MediaURLRequest represents the query of a presigned media URL request
+type MediaURLRequest struct {
+	Expires int `query:"expires" example:"3600"` // validity in seconds, default 3600, at most 604800
+} // @name MediaURLRequest
+
+// MediaURLResponse represents a presigned URL that downloads message media without the API key
+type MediaURLResponse struct {
+	URL       string `json:"url" example:"http://localhost:8080/media/sha256/9f/9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08?expires=1700003600&signature=..."`
+	ExpiresAt int64  `json:"expiresAt" example:"1700003600"`
+	MimeType  string `json:"mimeType" example:"image/jpeg"`
+	FileName  string `json:"fileName" example:"3EB0C767D71D.jpg"`
+	Size      int64  `json:"size" example:"48213"`
+} // @name MediaURLResponse
+
+// defaultMediaURLExpiry is the validity of a presigned media URL when none is requested
+const defaultMediaURLExpiry = time.Hour
+
+// maxMediaURLExpiry is the longest validity of a presigned media URL
+const maxMediaURLExpiry = 7 * 24 * time.Hour
+
+// ExpiresIn validates the requested validity, applying the default when unset
+func (r *MediaURLRequest) ExpiresIn() (time.Duration, error) {
+	if r.Expires == 0 {
+		return defaultMediaURLExpiry, nil
+	}
+
+	expires := time.Duration(r.Expires) * time.Second
+	if r.Expires < 0 || expires > maxMediaURLExpiry {
+		return 0, fmt.Errorf("%w: expires must be between 1 and %d seconds", ErrInvalidMediaURLRequest, int(maxMediaURLExpiry.Seconds()))
+	}
+	return expires, nil
+}
+
+// encodeCursor returns the opaque cursor of a page that ends at the given position
+func encodeCursor(timestamp int64, key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(timestamp, 10) + ":" + key))
+}
+
+// decodeCursor parses a cursor returned by encodeCursor, nil for the first page
+func decodeCursor(cursor string) (*ports.ListCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidHistoryQuery)
+	}
+
+	timestamp, key, ok := strings.Cut(string(decoded), ":")
+	if !ok || key == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidHistoryQuery)
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidHistoryQuery)
+	}
+
+	return &ports.ListCursor{Timestamp: seconds, Key: key}, nil
+}
@@ -0,0 +1,154 @@
+package message
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"zpwoot/internal/ports"
+	"zpwoot/platform/logger"
+)
+
+// MediaRetentionWorkerConfig holds settings for the media retention worker
+type MediaRetentionWorkerConfig struct {
+	// Retention is how long media is kept after it was last stored, zero keeps it forever
+	Retention time.Duration
+	// Interval is how often expired media is removed
+	Interval time.Duration
+}
+
+// DefaultMediaRetentionWorkerConfig returns the default media retention settings
+func DefaultMediaRetentionWorkerConfig() *MediaRetentionWorkerConfig {
+	return &MediaRetentionWorkerConfig{
+		Retention: 0,
+		Interval:  time.Hour,
+	}
+}
+
+// MediaRetentionWorker removes media from the media store once its retention period is over.
+// Received messages keep their media store key, so their removed media is downloaded again
+// from WhatsApp on the next access while WhatsApp still has it. Sent media cannot be downloaded
+// again, so sent messages forget the key of their removed media.
+type MediaRetentionWorker struct {
+	mediaStore  ports.MediaStore
+	messageRepo ports.MessageRepository
+	config      *MediaRetentionWorkerConfig
+	logger      *logger.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMediaRetentionWorker creates a new media retention worker
+func NewMediaRetentionWorker(
+	mediaStore ports.MediaStore,
+	messageRepo ports.MessageRepository,
+	config *MediaRetentionWorkerConfig,
+	logger *logger.Logger,
+) *MediaRetentionWorker {
+	if config == nil {
+		config = DefaultMediaRetentionWorkerConfig()
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultMediaRetentionWorkerConfig().Interval
+	}
+
+	return &MediaRetentionWorker{
+		mediaStore:  mediaStore,
+		messageRepo: messageRepo,
+		config:      config,
+		logger:      logger,
+	}
+}
+
+// Start launches the worker in the background. It does nothing when media is kept forever.
+func (w *MediaRetentionWorker) Start() {
+	if w.mediaStore == nil || w.config.Retention <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	w.logger.InfoWithFields("Starting media retention worker", map[string]interface{}{
+		"retention": w.config.Retention.String(),
+		"interval":  w.config.Interval.String(),
+	})
+
+	w.wg.Add(1)
+	go w.run(ctx)
+}
+
+// Stop signals the worker to stop and waits for a running cleanup to finish
+func (w *MediaRetentionWorker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+
+	w.cancel()
+	w.wg.Wait()
+	w.logger.Info("Media retention worker stopped")
+}
+
+// run removes expired media until the context is cancelled
+func (w *MediaRetentionWorker) run(ctx context.Context) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		w.cleanup(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// cleanup removes the media last stored before the retention period
+func (w *MediaRetentionWorker) cleanup(ctx context.Context) {
+	deleted, err := w.mediaStore.DeleteOlderThan(ctx, time.Now().Add(-w.config.Retention))
+	// Keys removed before a failure are cleared all the same
+	w.clearSentMediaKeys(ctx, deleted)
+
+	if err != nil && ctx.Err() == nil {
+		w.logger.ErrorWithFields("Failed to delete expired media", map[string]interface{}{
+			"deleted": len(deleted),
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if len(deleted) > 0 {
+		w.logger.InfoWithFields("Deleted expired media", map[string]interface{}{
+			"deleted": len(deleted),
+		})
+	}
+}
+
+// clearSentMediaKeys makes sent messages forget the keys of media that was removed
+func (w *MediaRetentionWorker) clearSentMediaKeys(ctx context.Context, keys []string) {
+	if w.messageRepo == nil || len(keys) == 0 {
+		return
+	}
+
+	cleared, err := w.messageRepo.ClearSentMediaStoreKeys(ctx, keys)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.ErrorWithFields("Failed to clear media keys of sent messages", map[string]interface{}{
+				"keys":  len(keys),
+				"error": err.Error(),
+			})
+		}
+		return
+	}
+
+	if cleared > 0 {
+		w.logger.InfoWithFields("Cleared media keys of sent messages", map[string]interface{}{
+			"cleared": cleared,
+		})
+	}
+}
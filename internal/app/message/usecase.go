@@ -2,7 +2,12 @@ package message
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
 
 	"zpwoot/internal/domain/message"
 	"zpwoot/internal/ports"
@@ -12,12 +17,20 @@ import (
 // UseCase defines the message use case interface
 type UseCase interface {
 	SendMessage(ctx context.Context, sessionID string, req *SendMessageRequest) (*SendMessageResponse, error)
+	ListChats(ctx context.Context, sessionID string, req *ListChatsRequest) (*ListChatsResponse, error)
+	ListMessages(ctx context.Context, sessionID, chatJID string, req *ListMessagesRequest) (*ListMessagesResponse, error)
+	SearchMessages(ctx context.Context, sessionID string, req *SearchMessagesRequest) (*SearchMessagesResponse, error)
+	GetMedia(ctx context.Context, sessionID, messageID string) (io.ReadCloser, *ports.MediaFile, error)
+	GetMediaURL(ctx context.Context, sessionID, messageID string, req *MediaURLRequest) (*MediaURLResponse, error)
+	OpenSignedMedia(ctx context.Context, key string, query url.Values) (io.ReadCloser, *ports.MediaFile, error)
 }
 
 // useCaseImpl implements the message use case
 type useCaseImpl struct {
 	sessionRepo   ports.SessionRepository
+	messageRepo   ports.MessageRepository
 	wameowManager ports.WameowManager
+	mediaStore    ports.MediaStore
 	mediaProcessor *message.MediaProcessor
 	logger        *logger.Logger
 }
@@ -25,12 +38,16 @@ type useCaseImpl struct {
 // NewUseCase creates a new message use case
 func NewUseCase(
 	sessionRepo ports.SessionRepository,
+	messageRepo ports.MessageRepository,
 	wameowManager ports.WameowManager,
+	mediaStore ports.MediaStore,
 	logger *logger.Logger,
 ) UseCase {
 	return &useCaseImpl{
 		sessionRepo:    sessionRepo,
+		messageRepo:    messageRepo,
 		wameowManager:  wameowManager,
+		mediaStore:     mediaStore,
 		mediaProcessor: message.NewMediaProcessor(logger),
 		logger:         logger,
 	}
@@ -143,3 +160,234 @@ func (uc *useCaseImpl) SendMessage(ctx context.Context, sessionID string, req *S
 
 	return response, nil
 }
+
+// defaultHistoryPageSize is the page size of chat and message listings without a limit
+const defaultHistoryPageSize = 20
+
+// maxHistoryPageSize is the largest page of chat and message listings
+const maxHistoryPageSize = 100
+
+// ListChats lists the chats of a session with their latest message, most recently active first
+func (uc *useCaseImpl) ListChats(ctx context.Context, sessionID string, req *ListChatsRequest) (*ListChatsResponse, error) {
+	limit, err := historyPageSize(req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := decodeCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one more chat than requested to know whether there is a next page
+	chats, err := uc.messageRepo.ListChats(ctx, &ports.ChatFilter{
+		SessionID: sessionID,
+		Cursor:    cursor,
+		Limit:     limit + 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chats: %w", err)
+	}
+
+	response := &ListChatsResponse{
+		Chats: make([]ChatResponse, 0, len(chats)),
+	}
+	if len(chats) > limit {
+		chats = chats[:limit]
+		response.HasMore = true
+	}
+
+	for _, chat := range chats {
+		response.Chats = append(response.Chats, ChatResponse{
+			JID:          chat.ChatJID,
+			IsGroup:      chat.IsGroup,
+			UnreadCount:  chat.UnreadCount,
+			LastActivity: time.Unix(chat.LastMessage.Timestamp, 0),
+			LastMessage:  FromStoredMessage(chat.LastMessage),
+		})
+	}
+
+	if response.HasMore {
+		last := chats[len(chats)-1]
+		response.NextCursor = encodeCursor(last.LastMessage.Timestamp, last.ChatJID)
+	}
+
+	return response, nil
+}
+
+// ListMessages lists the stored messages of a chat, newest first unless ascending order is requested
+func (uc *useCaseImpl) ListMessages(ctx context.Context, sessionID, chatJID string, req *ListMessagesRequest) (*ListMessagesResponse, error) {
+	limit, err := historyPageSize(req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := req.ToFilter(sessionID, normalizeChatJID(chatJID))
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = limit + 1
+
+	messages, err := uc.messageRepo.ListMessages(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	response := &ListMessagesResponse{
+		Messages: make([]StoredMessageResponse, 0, len(messages)),
+	}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		response.HasMore = true
+	}
+
+	for _, msg := range messages {
+		response.Messages = append(response.Messages, FromStoredMessage(msg))
+	}
+
+	if response.HasMore {
+		last := messages[len(messages)-1]
+		response.NextCursor = encodeCursor(last.Timestamp, last.ID)
+	}
+
+	return response, nil
+}
+
+// SearchMessages searches the stored messages of a session, or of every session when sessionID is empty,
+// most relevant first
+func (uc *useCaseImpl) SearchMessages(ctx context.Context, sessionID string, req *SearchMessagesRequest) (*SearchMessagesResponse, error) {
+	limit, err := historyPageSize(req.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := req.ToSearch(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	search.Limit = limit + 1
+
+	results, err := uc.messageRepo.Search(ctx, search)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	response := &SearchMessagesResponse{
+		Results: make([]MessageSearchResultResponse, 0, len(results)),
+		Limit:   limit,
+		Offset:  search.Offset,
+	}
+	if len(results) > limit {
+		results = results[:limit]
+		response.HasMore = true
+	}
+
+	for _, result := range results {
+		response.Results = append(response.Results, FromMessageSearchResult(result))
+	}
+
+	return response, nil
+}
+
+// GetMedia opens the media file of a stored message, downloading it from WhatsApp on first access.
+// The caller must close the returned reader.
+func (uc *useCaseImpl) GetMedia(ctx context.Context, sessionID, messageID string) (io.ReadCloser, *ports.MediaFile, error) {
+	media, err := uc.wameowManager.DownloadMedia(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get message media: %w", err)
+	}
+
+	reader, _, err := uc.mediaStore.Open(ctx, media.Key)
+	if errors.Is(err, message.ErrMediaObjectNotFound) {
+		// The retention worker removed the file in between, download it again
+		if media, err = uc.wameowManager.DownloadMedia(ctx, sessionID, messageID); err != nil {
+			return nil, nil, fmt.Errorf("failed to get message media: %w", err)
+		}
+		reader, _, err = uc.mediaStore.Open(ctx, media.Key)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open message media: %w", err)
+	}
+
+	return reader, media, nil
+}
+
+// GetMediaURL returns a presigned URL that downloads the media of a stored message without the API key
+func (uc *useCaseImpl) GetMediaURL(ctx context.Context, sessionID, messageID string, req *MediaURLRequest) (*MediaURLResponse, error) {
+	expires, err := req.ExpiresIn()
+	if err != nil {
+		return nil, err
+	}
+
+	media, err := uc.wameowManager.DownloadMedia(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message media: %w", err)
+	}
+
+	expiresAt := time.Now().Add(expires)
+	presigned, err := uc.mediaStore.PresignGet(ctx, media.Key, expires, media.MimeType, media.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign message media: %w", err)
+	}
+
+	return &MediaURLResponse{
+		URL:       presigned,
+		ExpiresAt: expiresAt.Unix(),
+		MimeType:  media.MimeType,
+		FileName:  media.FileName,
+		Size:      media.Size,
+	}, nil
+}
+
+// OpenSignedMedia opens the media under a key for a presigned URL served by this API,
+// after checking its signature. The caller must close the returned reader.
+func (uc *useCaseImpl) OpenSignedMedia(ctx context.Context, key string, query url.Values) (io.ReadCloser, *ports.MediaFile, error) {
+	server, ok := uc.mediaStore.(ports.SignedMediaServer)
+	if !ok {
+		// Presigned URLs of this store point at the store itself
+		return nil, nil, message.ErrMediaObjectNotFound
+	}
+
+	mimeType, fileName, err := server.VerifyPresigned(key, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, object, err := uc.mediaStore.Open(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if mimeType == "" {
+		mimeType = object.ContentType
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	return reader, &ports.MediaFile{
+		Key:      key,
+		MimeType: mimeType,
+		FileName: fileName,
+		Size:     object.Size,
+	}, nil
+}
+
+// historyPageSize validates the requested page size, applying the default when unset
+func historyPageSize(limit int) (int, error) {
+	if limit == 0 {
+		return defaultHistoryPageSize, nil
+	}
+	if limit < 0 || limit > maxHistoryPageSize {
+		return 0, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidHistoryQuery, maxHistoryPageSize)
+	}
+	return limit, nil
+}
+
+// normalizeChatJID accepts a bare phone number for direct chats, as the send endpoints do
+func normalizeChatJID(chatJID string) string {
+	if strings.Contains(chatJID, "@") {
+		return chatJID
+	}
+	return strings.TrimPrefix(chatJID, "+") + "@s.whatsapp.net"
+}
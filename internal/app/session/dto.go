@@ -8,17 +8,19 @@ import (
 
 // CreateSessionRequest represents the request to create a new session
 type CreateSessionRequest struct {
-	Name        string               `json:"name" validate:"required,min=3,max=50" example:"mySession"`
-	ProxyConfig *session.ProxyConfig `json:"proxyConfig,omitempty"`
+	Name              string               `json:"name" validate:"required,min=3,max=50" example:"mySession"`
+	ProxyConfig       *session.ProxyConfig `json:"proxyConfig,omitempty"`
+	MediaAutoDownload *bool                `json:"mediaAutoDownload,omitempty" example:"true"`
 } // @name SessionCreateRequest
 
 // CreateSessionResponse represents the response after creating a session
 type CreateSessionResponse struct {
-	ID          string               `json:"id" example:"session-123"`
-	Name        string               `json:"name" example:"mySession"`
-	IsConnected bool                 `json:"isConnected" example:"false"`
-	ProxyConfig *session.ProxyConfig `json:"proxyConfig,omitempty"`
-	CreatedAt   time.Time            `json:"createdAt" example:"2024-01-01T00:00:00Z"`
+	ID                string               `json:"id" example:"session-123"`
+	Name              string               `json:"name" example:"mySession"`
+	IsConnected       bool                 `json:"isConnected" example:"false"`
+	ProxyConfig       *session.ProxyConfig `json:"proxyConfig,omitempty"`
+	MediaAutoDownload *bool                `json:"mediaAutoDownload,omitempty" example:"true"`
+	CreatedAt         time.Time            `json:"createdAt" example:"2024-01-01T00:00:00Z"`
 } // @name SessionCreateResponse
 
 // UpdateSessionRequest represents the request to update a session
@@ -51,15 +53,16 @@ type SessionInfoResponse struct {
 
 // SessionResponse represents a session in responses
 type SessionResponse struct {
-	ID              string               `json:"id" example:"session-123"`
-	Name            string               `json:"name" example:"my-Wameow-session"`
-	DeviceJid       string               `json:"deviceJid,omitempty" example:"5511999999999@s.Wameow.net"`
-	IsConnected     bool                 `json:"isConnected" example:"false"`
-	ConnectionError *string              `json:"connectionError,omitempty" example:"Connection timeout"`
-	ProxyConfig     *session.ProxyConfig `json:"proxyConfig,omitempty"`
-	CreatedAt       time.Time            `json:"createdAt" example:"2024-01-01T00:00:00Z"`
-	UpdatedAt       time.Time            `json:"updatedAt" example:"2024-01-01T00:00:00Z"`
-	ConnectedAt     *time.Time           `json:"connectedAt,omitempty" example:"2024-01-01T00:00:30Z"`
+	ID                string               `json:"id" example:"session-123"`
+	Name              string               `json:"name" example:"my-Wameow-session"`
+	DeviceJid         string               `json:"deviceJid,omitempty" example:"5511999999999@s.Wameow.net"`
+	IsConnected       bool                 `json:"isConnected" example:"false"`
+	ConnectionError   *string              `json:"connectionError,omitempty" example:"Connection timeout"`
+	ProxyConfig       *session.ProxyConfig `json:"proxyConfig,omitempty"`
+	MediaAutoDownload *bool                `json:"mediaAutoDownload,omitempty" example:"true"`
+	CreatedAt         time.Time            `json:"createdAt" example:"2024-01-01T00:00:00Z"`
+	UpdatedAt         time.Time            `json:"updatedAt" example:"2024-01-01T00:00:00Z"`
+	ConnectedAt       *time.Time           `json:"connectedAt,omitempty" example:"2024-01-01T00:00:30Z"`
 } // @name SessionResponse
 
 // DeviceInfoResponse represents device information in responses
@@ -92,27 +95,40 @@ type ProxyResponse struct {
 	ProxyConfig *session.ProxyConfig `json:"proxyConfig,omitempty"`
 } // @name ProxyResponse
 
+// SetMediaSettingsRequest represents the request to set the media settings of a session.
+// A null autoDownload makes the session follow MEDIA_AUTO_DOWNLOAD.
+type SetMediaSettingsRequest struct {
+	AutoDownload *bool `json:"autoDownload" example:"true"`
+} // @name SetMediaSettingsRequest
+
+// MediaSettingsResponse represents the media settings of a session
+type MediaSettingsResponse struct {
+	AutoDownload *bool `json:"autoDownload" example:"true"`
+} // @name MediaSettingsResponse
+
 // Conversion methods
 
 // ToCreateSessionRequest converts to domain request
 func (r *CreateSessionRequest) ToCreateSessionRequest() *session.CreateSessionRequest {
 	return &session.CreateSessionRequest{
-		Name:        r.Name,
-		ProxyConfig: r.ProxyConfig,
+		Name:              r.Name,
+		ProxyConfig:       r.ProxyConfig,
+		MediaAutoDownload: r.MediaAutoDownload,
 	}
 }
 
 // FromSession converts from domain session to response
 func FromSession(s *session.Session) *SessionResponse {
 	response := &SessionResponse{
-		ID:              s.ID.String(),
-		Name:            s.Name,
-		IsConnected:     s.IsConnected,
-		ConnectionError: s.ConnectionError,
-		ProxyConfig:     s.ProxyConfig,
-		CreatedAt:       s.CreatedAt,
-		UpdatedAt:       s.UpdatedAt,
-		ConnectedAt:     s.ConnectedAt,
+		ID:                s.ID.String(),
+		Name:              s.Name,
+		IsConnected:       s.IsConnected,
+		ConnectionError:   s.ConnectionError,
+		ProxyConfig:       s.ProxyConfig,
+		MediaAutoDownload: s.MediaAutoDownload,
+		CreatedAt:         s.CreatedAt,
+		UpdatedAt:         s.UpdatedAt,
+		ConnectedAt:       s.ConnectedAt,
 	}
 
 	// Only include deviceJid if it's not empty (obtained after connection)
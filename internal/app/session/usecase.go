@@ -19,6 +19,8 @@ type UseCase interface {
 	PairPhone(ctx context.Context, sessionID string, req *PairPhoneRequest) error
 	SetProxy(ctx context.Context, sessionID string, req *SetProxyRequest) error
 	GetProxy(ctx context.Context, sessionID string) (*ProxyResponse, error)
+	SetMediaSettings(ctx context.Context, sessionID string, req *SetMediaSettingsRequest) error
+	GetMediaSettings(ctx context.Context, sessionID string) (*MediaSettingsResponse, error)
 }
 
 // useCaseImpl implements the session use case
@@ -54,11 +56,12 @@ func (uc *useCaseImpl) CreateSession(ctx context.Context, req *CreateSessionRequ
 
 	// Convert domain entity to response DTO
 	response := &CreateSessionResponse{
-		ID:          sess.ID.String(),
-		Name:        sess.Name,
-		IsConnected: sess.IsConnected,
-		ProxyConfig: sess.ProxyConfig,
-		CreatedAt:   sess.CreatedAt,
+		ID:                sess.ID.String(),
+		Name:              sess.Name,
+		IsConnected:       sess.IsConnected,
+		ProxyConfig:       sess.ProxyConfig,
+		MediaAutoDownload: sess.MediaAutoDownload,
+		CreatedAt:         sess.CreatedAt,
 	}
 
 	return response, nil
@@ -177,3 +180,22 @@ func (uc *useCaseImpl) GetProxy(ctx context.Context, sessionID string) (*ProxyRe
 
 	return response, nil
 }
+
+// SetMediaSettings configures whether the session downloads received media as messages arrive
+func (uc *useCaseImpl) SetMediaSettings(ctx context.Context, sessionID string, req *SetMediaSettingsRequest) error {
+	return uc.sessionService.SetMediaAutoDownload(ctx, sessionID, req.AutoDownload)
+}
+
+// GetMediaSettings retrieves the media settings of the session
+func (uc *useCaseImpl) GetMediaSettings(ctx context.Context, sessionID string) (*MediaSettingsResponse, error) {
+	autoDownload, err := uc.sessionService.GetMediaAutoDownload(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &MediaSettingsResponse{
+		AutoDownload: autoDownload,
+	}
+
+	return response, nil
+}
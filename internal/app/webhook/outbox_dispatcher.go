@@ -0,0 +1,521 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"zpwoot/internal/domain/webhook"
+	"zpwoot/internal/ports"
+	"zpwoot/platform/logger"
+)
+
+// OutboxConsumer handles an event read from the outbox. When it returns an error the
+// consumer stops at that event and gets it again after the poll interval, so every
+// event is handled at least once.
+type OutboxConsumer func(ctx context.Context, event *webhook.WebhookEvent) error
+
+// OutboxDispatcherConfig holds scheduling settings for the outbox dispatcher
+type OutboxDispatcherConfig struct {
+	// PollInterval is how often consumers look for events they were not woken up for,
+	// and how long a consumer waits after a failure
+	PollInterval time.Duration
+	BatchSize    int
+	// GapTimeout is how long a missing sequence is waited for before the events after it are
+	// handed over. Sequences are taken when an event is recorded but become visible when its
+	// transaction commits, so a gap is an event still being recorded or one that was rolled back.
+	// It should exceed the longest transaction recording an event, so events keep their order.
+	GapTimeout time.Duration
+	// GapExpiry is how long a skipped sequence keeps being looked for. An event committed after
+	// it was skipped is still handed over; after GapExpiry the sequence is taken as rolled back.
+	GapExpiry time.Duration
+	// Retention is how long events are kept after every consumer processed them, zero keeps them forever
+	Retention time.Duration
+	// DedupTTL is how long an inbound message or receipt is remembered so a copy WhatsApp
+	// delivers again is dropped before it is recorded, zero disables deduplication
+	DedupTTL time.Duration
+}
+
+// DefaultOutboxDispatcherConfig returns the default outbox dispatcher settings
+func DefaultOutboxDispatcherConfig() *OutboxDispatcherConfig {
+	return &OutboxDispatcherConfig{
+		PollInterval: time.Second,
+		BatchSize:    100,
+		GapTimeout:   90 * time.Second,
+		GapExpiry:    10 * time.Minute,
+		Retention:    24 * time.Hour,
+		DedupTTL:     24 * time.Hour,
+	}
+}
+
+// outboxConsumer is a registered consumer and its position in the outbox
+type outboxConsumer struct {
+	name    string
+	consume OutboxConsumer
+	wake    chan struct{}
+	// checkpoint is the last sequence before which every event was processed or given up,
+	// -1 until it has been loaded. It is what is stored, so it never moves past a skipped sequence.
+	checkpoint atomic.Int64
+
+	// position is the last sequence read, ahead of checkpoint while sequences are skipped
+	position int64
+	// gaps holds the skipped sequences and when they were skipped
+	gaps map[int64]time.Time
+	// gapsScannedAt is when the skipped sequences were last looked for
+	gapsScannedAt time.Time
+}
+
+// OutboxDispatcher feeds the events recorded in the outbox to its consumers.
+// Each consumer reads the outbox in order from its own checkpoint, which is stored after
+// every batch, so consumers resume where they stopped after a restart or a failure.
+type OutboxDispatcher struct {
+	outboxRepo ports.EventOutboxRepository
+	config     *OutboxDispatcherConfig
+	logger     *logger.Logger
+	consumers  []*outboxConsumer
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewOutboxDispatcher creates a new outbox dispatcher
+func NewOutboxDispatcher(
+	outboxRepo ports.EventOutboxRepository,
+	config *OutboxDispatcherConfig,
+	logger *logger.Logger,
+) *OutboxDispatcher {
+	if config == nil {
+		config = DefaultOutboxDispatcherConfig()
+	}
+
+	return &OutboxDispatcher{
+		outboxRepo: outboxRepo,
+		config:     config,
+		logger:     logger,
+	}
+}
+
+// Register adds a consumer under a unique name, which keys its checkpoint. It must be called before Start.
+// A consumer registered for the first time starts with the events recorded after it was registered.
+func (d *OutboxDispatcher) Register(name string, consume OutboxConsumer) {
+	consumer := &outboxConsumer{
+		name:    name,
+		consume: consume,
+		wake:    make(chan struct{}, 1),
+		gaps:    make(map[int64]time.Time),
+	}
+	consumer.checkpoint.Store(-1)
+
+	d.consumers = append(d.consumers, consumer)
+}
+
+// Notify wakes up the consumers after new events were recorded
+func (d *OutboxDispatcher) Notify() {
+	for _, consumer := range d.consumers {
+		select {
+		case consumer.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Start launches the consumers in the background
+func (d *OutboxDispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	names := make([]string, 0, len(d.consumers))
+	for _, consumer := range d.consumers {
+		names = append(names, consumer.name)
+	}
+
+	d.logger.InfoWithFields("Starting event outbox dispatcher", map[string]interface{}{
+		"consumers":     names,
+		"poll_interval": d.config.PollInterval.String(),
+		"batch_size":    d.config.BatchSize,
+	})
+
+	for _, consumer := range d.consumers {
+		d.wg.Add(1)
+		go d.run(ctx, consumer)
+	}
+
+	if d.config.Retention > 0 || d.config.DedupTTL > 0 {
+		d.wg.Add(1)
+		go d.runCleanup(ctx)
+	}
+}
+
+// Stop signals the consumers to stop and waits for in-flight events to finish
+func (d *OutboxDispatcher) Stop() {
+	if d.cancel == nil {
+		return
+	}
+
+	d.cancel()
+	d.wg.Wait()
+	d.logger.Info("Event outbox dispatcher stopped")
+}
+
+// run feeds events to one consumer until the context is cancelled
+func (d *OutboxDispatcher) run(ctx context.Context, consumer *outboxConsumer) {
+	defer d.wg.Done()
+
+	for !d.loadCheckpoint(ctx, consumer) {
+		if !d.sleep(ctx, d.config.PollInterval) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		more, failed := d.dispatchBatch(ctx, consumer)
+
+		switch {
+		case failed:
+			// Give the failing consumer time to recover instead of retrying on every new event
+			if !d.sleep(ctx, d.config.PollInterval) {
+				return
+			}
+		case more:
+			if ctx.Err() != nil {
+				return
+			}
+		default:
+			select {
+			case <-ctx.Done():
+				return
+			case <-consumer.wake:
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// loadCheckpoint reads the position of a consumer, starting new consumers at the end of the outbox
+func (d *OutboxDispatcher) loadCheckpoint(ctx context.Context, consumer *outboxConsumer) bool {
+	sequence, found, err := d.outboxRepo.GetCheckpoint(ctx, consumer.name)
+	if err == nil && !found {
+		if sequence, err = d.outboxRepo.LatestSequence(ctx); err == nil {
+			err = d.outboxRepo.SaveCheckpoint(ctx, consumer.name, sequence)
+		}
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.ErrorWithFields("Failed to load event outbox checkpoint", map[string]interface{}{
+				"consumer": consumer.name,
+				"error":    err.Error(),
+			})
+		}
+		return false
+	}
+
+	consumer.checkpoint.Store(sequence)
+	consumer.position = sequence
+	d.logger.InfoWithFields("Event outbox consumer started", map[string]interface{}{
+		"consumer":   consumer.name,
+		"checkpoint": sequence,
+		"new":        !found,
+	})
+	return true
+}
+
+// dispatchBatch hands the next batch of events to a consumer and advances its checkpoint.
+// Sessions are processed concurrently and the events of a session in order. It reports
+// whether more events are waiting, and whether the consumer failed on one of them.
+func (d *OutboxDispatcher) dispatchBatch(ctx context.Context, consumer *outboxConsumer) (bool, bool) {
+	gapFailed := d.dispatchGaps(ctx, consumer)
+	position := consumer.position
+
+	events, err := d.outboxRepo.ListAfter(ctx, position, d.config.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.ErrorWithFields("Failed to load events from outbox", map[string]interface{}{
+				"consumer": consumer.name,
+				"error":    err.Error(),
+			})
+		}
+		return false, true
+	}
+
+	ready := d.readyEvents(position, events)
+	if len(ready) == 0 {
+		d.saveCheckpoint(ctx, consumer)
+		return false, gapFailed
+	}
+
+	// Group by session so a slow session does not hold back the others
+	var sessionIDs []string
+	bySession := make(map[string][]*ports.OutboxEvent)
+	for _, event := range ready {
+		if _, ok := bySession[event.SessionID]; !ok {
+			sessionIDs = append(sessionIDs, event.SessionID)
+		}
+		bySession[event.SessionID] = append(bySession[event.SessionID], event)
+	}
+
+	var (
+		wg          sync.WaitGroup
+		mutex       sync.Mutex
+		firstFailed int64 = -1
+	)
+	for _, sessionID := range sessionIDs {
+		wg.Add(1)
+		go func(events []*ports.OutboxEvent) {
+			defer wg.Done()
+			for _, event := range events {
+				if !d.dispatchEvent(ctx, consumer, event) {
+					mutex.Lock()
+					if firstFailed == -1 || event.Sequence < firstFailed {
+						firstFailed = event.Sequence
+					}
+					mutex.Unlock()
+					return
+				}
+			}
+		}(bySession[sessionID])
+	}
+	wg.Wait()
+
+	// The position moves up to the last event before the first failure; events of other
+	// sessions processed past it are handed to the consumer again, as at-least-once allows.
+	// Sequences skipped on the way are remembered and looked for again.
+	now := time.Now()
+	next := position
+	for _, event := range ready {
+		if firstFailed != -1 && event.Sequence >= firstFailed {
+			break
+		}
+		for sequence := next + 1; sequence < event.Sequence; sequence++ {
+			consumer.gaps[sequence] = now
+		}
+		next = event.Sequence
+	}
+	consumer.position = next
+	d.saveCheckpoint(ctx, consumer)
+
+	more := firstFailed == -1 && len(ready) == len(events) && len(events) == d.config.BatchSize
+	return more, firstFailed != -1 || gapFailed
+}
+
+// dispatchGaps looks for the events of skipped sequences, at most once per poll interval,
+// and hands over those committed since. It reports whether the consumer failed on one of them.
+func (d *OutboxDispatcher) dispatchGaps(ctx context.Context, consumer *outboxConsumer) bool {
+	if len(consumer.gaps) == 0 || time.Since(consumer.gapsScannedAt) < d.config.PollInterval {
+		return false
+	}
+	consumer.gapsScannedAt = time.Now()
+
+	sequences := make([]int64, 0, len(consumer.gaps))
+	for sequence := range consumer.gaps {
+		sequences = append(sequences, sequence)
+	}
+
+	events, err := d.outboxRepo.ListSequences(ctx, sequences)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.ErrorWithFields("Failed to look for skipped outbox events", map[string]interface{}{
+				"consumer": consumer.name,
+				"error":    err.Error(),
+			})
+		}
+		return true
+	}
+
+	found := make(map[int64]bool, len(events))
+	failed := false
+	for _, event := range events {
+		found[event.Sequence] = true
+		if failed {
+			continue
+		}
+		if !d.dispatchEvent(ctx, consumer, event) {
+			// Keep the order of late events; the rest is handed over with the failed one
+			failed = true
+			continue
+		}
+		delete(consumer.gaps, event.Sequence)
+		d.logger.InfoWithFields("Handed over outbox event committed after it was skipped", map[string]interface{}{
+			"consumer":   consumer.name,
+			"sequence":   event.Sequence,
+			"event_id":   event.EventID,
+			"event_type": event.EventType,
+		})
+	}
+
+	for sequence, skippedAt := range consumer.gaps {
+		if !found[sequence] && time.Since(skippedAt) >= d.config.GapExpiry {
+			delete(consumer.gaps, sequence)
+			d.logger.DebugWithFields("Skipped outbox sequence never committed, giving up on it", map[string]interface{}{
+				"consumer": consumer.name,
+				"sequence": sequence,
+			})
+		}
+	}
+
+	return failed
+}
+
+// saveCheckpoint stores the position of a consumer, held back before the first skipped
+// sequence still looked for so a restart looks for it again
+func (d *OutboxDispatcher) saveCheckpoint(ctx context.Context, consumer *outboxConsumer) {
+	next := consumer.position
+	for sequence := range consumer.gaps {
+		if sequence-1 < next {
+			next = sequence - 1
+		}
+	}
+
+	if next <= consumer.checkpoint.Load() {
+		return
+	}
+
+	if err := d.outboxRepo.SaveCheckpoint(ctx, consumer.name, next); err != nil {
+		// Keep the position in memory; on restart the events since the stored checkpoint are handed over again
+		d.logger.WarnWithFields("Failed to store event outbox checkpoint", map[string]interface{}{
+			"consumer":   consumer.name,
+			"checkpoint": next,
+			"error":      err.Error(),
+		})
+	}
+	consumer.checkpoint.Store(next)
+}
+
+// readyEvents returns the leading events that follow the position without a gap.
+// A gap stops the batch until it is filled or the event after it is older than the gap timeout.
+func (d *OutboxDispatcher) readyEvents(position int64, events []*ports.OutboxEvent) []*ports.OutboxEvent {
+	expected := position + 1
+	for i, event := range events {
+		if event.Sequence != expected && time.Since(time.Unix(event.CreatedAt, 0)) < d.config.GapTimeout {
+			return events[:i]
+		}
+		expected = event.Sequence + 1
+	}
+	return events
+}
+
+// dispatchEvent hands one event to a consumer and reports whether it was processed
+func (d *OutboxDispatcher) dispatchEvent(ctx context.Context, consumer *outboxConsumer, outboxEvent *ports.OutboxEvent) bool {
+	event, err := webhook.DecodeWebhookEvent([]byte(outboxEvent.Payload))
+	if err != nil {
+		// Retrying won't make the event readable, so it is skipped
+		d.logger.ErrorWithFields("Skipping unreadable outbox event", map[string]interface{}{
+			"consumer": consumer.name,
+			"sequence": outboxEvent.Sequence,
+			"event_id": outboxEvent.EventID,
+			"error":    err.Error(),
+		})
+		return true
+	}
+
+	if err := consumer.consume(ctx, event); err != nil {
+		if ctx.Err() == nil {
+			d.logger.ErrorWithFields("Event outbox consumer failed, event will be retried", map[string]interface{}{
+				"consumer":   consumer.name,
+				"sequence":   outboxEvent.Sequence,
+				"event_id":   outboxEvent.EventID,
+				"event_type": outboxEvent.EventType,
+				"session_id": outboxEvent.SessionID,
+				"error":      err.Error(),
+			})
+		}
+		return false
+	}
+
+	return true
+}
+
+// runCleanup removes old events every consumer has processed, and expired dedup keys
+func (d *OutboxDispatcher) runCleanup(ctx context.Context) {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if d.config.Retention > 0 {
+				d.cleanup(ctx)
+			}
+			if d.config.DedupTTL > 0 {
+				d.cleanupDedupKeys(ctx)
+			}
+		}
+	}
+}
+
+// cleanup deletes events older than the retention period up to the lowest checkpoint
+func (d *OutboxDispatcher) cleanup(ctx context.Context) {
+	if len(d.consumers) == 0 {
+		return
+	}
+
+	lowest := int64(-1)
+	for _, consumer := range d.consumers {
+		checkpoint := consumer.checkpoint.Load()
+		if checkpoint == -1 {
+			// A consumer that has not started yet may still need every event
+			return
+		}
+		if lowest == -1 || checkpoint < lowest {
+			lowest = checkpoint
+		}
+	}
+
+	olderThan := time.Now().Add(-d.config.Retention).Unix()
+	deleted, err := d.outboxRepo.DeleteProcessed(ctx, lowest, olderThan)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.ErrorWithFields("Failed to delete old outbox events", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return
+	}
+
+	if deleted > 0 {
+		d.logger.InfoWithFields("Old outbox events deleted", map[string]interface{}{
+			"deleted":    deleted,
+			"up_to":      lowest,
+			"older_than": time.Unix(olderThan, 0),
+		})
+	}
+}
+
+// cleanupDedupKeys deletes dedup keys past their TTL
+func (d *OutboxDispatcher) cleanupDedupKeys(ctx context.Context) {
+	deleted, err := d.outboxRepo.DeleteExpiredDedupKeys(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.ErrorWithFields("Failed to delete expired event dedup keys", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return
+	}
+
+	if deleted > 0 {
+		d.logger.InfoWithFields("Expired event dedup keys deleted", map[string]interface{}{
+			"deleted": deleted,
+		})
+	}
+}
+
+// sleep waits for the given duration and reports false if the context was cancelled first
+func (d *OutboxDispatcher) sleep(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
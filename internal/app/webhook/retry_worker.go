@@ -0,0 +1,242 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"zpwoot/internal/domain/webhook"
+	"zpwoot/internal/ports"
+	"zpwoot/platform/logger"
+)
+
+// RetryWorkerConfig holds scheduling settings for the webhook retry worker
+type RetryWorkerConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// Retention is how long finished deliveries are kept, zero keeps them forever
+	Retention time.Duration
+}
+
+// DefaultRetryWorkerConfig returns the default retry worker settings
+func DefaultRetryWorkerConfig() *RetryWorkerConfig {
+	return &RetryWorkerConfig{
+		PollInterval: 5 * time.Second,
+		BatchSize:    100,
+		Retention:    7 * 24 * time.Hour,
+	}
+}
+
+// cleanupInterval is how often old deliveries are purged
+const cleanupInterval = time.Hour
+
+// RetryWorker re-attempts failed webhook deliveries stored in the delivery log.
+// Pending retries live in the database, so they are picked up again after a restart.
+type RetryWorker struct {
+	deliveryRepo   ports.WebhookDeliveryRepository
+	webhookRepo    ports.WebhookRepository
+	webhookService *webhook.Service
+	retryConfig    *webhook.RetryConfig
+	config         *RetryWorkerConfig
+	logger         *logger.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRetryWorker creates a new webhook retry worker
+func NewRetryWorker(
+	deliveryRepo ports.WebhookDeliveryRepository,
+	webhookRepo ports.WebhookRepository,
+	webhookService *webhook.Service,
+	retryConfig *webhook.RetryConfig,
+	config *RetryWorkerConfig,
+	logger *logger.Logger,
+) *RetryWorker {
+	if retryConfig == nil {
+		retryConfig = webhook.DefaultRetryConfig()
+	}
+	if config == nil {
+		config = DefaultRetryWorkerConfig()
+	}
+
+	return &RetryWorker{
+		deliveryRepo:   deliveryRepo,
+		webhookRepo:    webhookRepo,
+		webhookService: webhookService,
+		retryConfig:    retryConfig,
+		config:         config,
+		logger:         logger,
+	}
+}
+
+// Start launches the worker in the background
+func (w *RetryWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	w.logger.InfoWithFields("Starting webhook retry worker", map[string]interface{}{
+		"poll_interval": w.config.PollInterval.String(),
+		"batch_size":    w.config.BatchSize,
+		"max_retries":   w.retryConfig.MaxRetries,
+	})
+
+	w.wg.Add(1)
+	go w.run(ctx)
+}
+
+// Stop signals the worker to stop and waits for in-flight retries to finish
+func (w *RetryWorker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+
+	w.cancel()
+	w.wg.Wait()
+	w.logger.Info("Webhook retry worker stopped")
+}
+
+// run polls for due retries until the context is cancelled
+func (w *RetryWorker) run(ctx context.Context) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Time{}
+	for {
+		w.processDueRetries(ctx)
+
+		if w.config.Retention > 0 && time.Since(lastCleanup) >= cleanupInterval {
+			w.cleanup(ctx)
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processDueRetries retries one batch of failed deliveries whose backoff has elapsed
+func (w *RetryWorker) processDueRetries(ctx context.Context) {
+	deliveries, err := w.deliveryRepo.GetFailedDeliveries(ctx, w.config.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.ErrorWithFields("Failed to load webhook deliveries for retry", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *ports.WebhookDelivery) {
+			defer wg.Done()
+			w.retryDelivery(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+}
+
+// retryDelivery performs one retry attempt and schedules the next one if needed
+func (w *RetryWorker) retryDelivery(ctx context.Context, delivery *ports.WebhookDelivery) {
+	wh, err := w.webhookRepo.GetByID(ctx, delivery.WebhookID)
+	if err != nil {
+		if errors.Is(err, webhook.ErrWebhookNotFound) {
+			w.giveUp(ctx, delivery, "webhook no longer exists")
+		}
+		return
+	}
+
+	if !wh.Active {
+		w.giveUp(ctx, delivery, webhook.ErrWebhookInactive.Error())
+		return
+	}
+
+	result := w.webhookService.Redeliver(ctx, wh, delivery.SessionID, delivery.EventID, delivery.EventType, []byte(delivery.Payload))
+
+	// The circuit held the delivery back, wait for the next probe without counting an attempt
+	if errors.Is(result.Error, webhook.ErrCircuitOpen) {
+		if err := w.deliveryRepo.ScheduleRetry(ctx, delivery.ID, result.RetryAt.Unix()); err != nil {
+			w.logger.ErrorWithFields("Failed to reschedule paused webhook delivery", map[string]interface{}{
+				"delivery_id": delivery.ID,
+				"error":       err.Error(),
+			})
+		}
+		return
+	}
+
+	errMsg := ""
+	if result.Error != nil {
+		errMsg = result.Error.Error()
+	}
+
+	if err := w.deliveryRepo.UpdateDeliveryStatus(ctx, delivery.ID, result.Success, result.StatusCode, result.ResponseBody, errMsg); err != nil {
+		w.logger.ErrorWithFields("Failed to update webhook delivery", map[string]interface{}{
+			"delivery_id": delivery.ID,
+			"error":       err.Error(),
+		})
+		return
+	}
+
+	if result.Success {
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	if !w.retryConfig.CanRetry(attempts) {
+		w.giveUp(ctx, delivery, "retries exhausted")
+		return
+	}
+
+	nextRetryAt := time.Now().Add(w.retryConfig.NextDelay(attempts))
+	if err := w.deliveryRepo.ScheduleRetry(ctx, delivery.ID, nextRetryAt.Unix()); err != nil {
+		w.logger.ErrorWithFields("Failed to schedule webhook delivery retry", map[string]interface{}{
+			"delivery_id": delivery.ID,
+			"error":       err.Error(),
+		})
+		return
+	}
+
+	w.logger.DebugWithFields("Webhook delivery retry scheduled", map[string]interface{}{
+		"delivery_id":   delivery.ID,
+		"webhook_id":    delivery.WebhookID,
+		"attempts":      attempts,
+		"next_retry_at": nextRetryAt,
+	})
+}
+
+// giveUp stops retrying a delivery and moves it to the dead-letter state
+func (w *RetryWorker) giveUp(ctx context.Context, delivery *ports.WebhookDelivery, reason string) {
+	if err := w.deliveryRepo.MarkDeadLetter(ctx, delivery.ID, reason); err != nil {
+		w.logger.ErrorWithFields("Failed to dead-letter webhook delivery", map[string]interface{}{
+			"delivery_id": delivery.ID,
+			"error":       err.Error(),
+		})
+		return
+	}
+
+	w.logger.WarnWithFields("Webhook delivery moved to dead letter", map[string]interface{}{
+		"delivery_id": delivery.ID,
+		"webhook_id":  delivery.WebhookID,
+		"event_id":    delivery.EventID,
+		"attempts":    delivery.Attempts,
+		"reason":      reason,
+	})
+}
+
+// cleanup removes finished deliveries older than the retention period
+func (w *RetryWorker) cleanup(ctx context.Context) {
+	olderThan := time.Now().Add(-w.config.Retention).Unix()
+	if err := w.deliveryRepo.DeleteOldDeliveries(ctx, olderThan); err != nil && ctx.Err() == nil {
+		w.logger.ErrorWithFields("Failed to delete old webhook deliveries", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
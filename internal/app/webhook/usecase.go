@@ -2,38 +2,88 @@ package webhook
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+	"time"
 
 	"zpwoot/internal/domain/webhook"
 	"zpwoot/internal/ports"
+	"zpwoot/platform/logger"
 )
 
 // UseCase defines the webhook use case interface
 type UseCase interface {
 	SetConfig(ctx context.Context, req *SetConfigRequest) (*SetConfigResponse, error)
 	FindConfig(ctx context.Context, sessionID string) (*WebhookResponse, error)
+	GetWebhook(ctx context.Context, webhookID string) (*WebhookResponse, error)
+	GetSessionWebhook(ctx context.Context, sessionID, webhookID string) (*WebhookResponse, error)
 	UpdateWebhook(ctx context.Context, webhookID string, req *UpdateWebhookRequest) (*WebhookResponse, error)
+	ToggleWebhook(ctx context.Context, webhookID string) (*WebhookResponse, error)
 	DeleteWebhook(ctx context.Context, webhookID string) error
 	ListWebhooks(ctx context.Context, req *ListWebhooksRequest) (*ListWebhooksResponse, error)
-	TestWebhook(ctx context.Context, webhookID string, req *TestWebhookRequest) (*TestWebhookResponse, error)
+	TestWebhook(ctx context.Context, sessionID string, req *TestWebhookRequest) (*TestWebhookResponse, error)
 	GetSupportedWebhookEvents(ctx context.Context) (*WebhookEventsResponse, error)
+	GetEventSchemas(ctx context.Context) (*EventSchemasResponse, error)
+	GetEventSchema(ctx context.Context, eventType string) (map[string]interface{}, error)
+	PreviewTemplate(ctx context.Context, req *PreviewTemplateRequest) (*PreviewTemplateResponse, error)
 	ProcessWebhookEvent(ctx context.Context, event *webhook.WebhookEvent) error
+	DeliverEvent(ctx context.Context, event *webhook.WebhookEvent) error
+	SubscribeEvents(ctx context.Context, sessionID string, eventTypes []string) (*webhook.Subscription, error)
+	SyncEnvGlobalWebhook(ctx context.Context, url string) error
+	ListDeadLetters(ctx context.Context, req *ListDeliveriesRequest) (*ListDeliveriesResponse, error)
+	GetDelivery(ctx context.Context, deliveryID string) (*DeliveryResponse, error)
+	ReplayDelivery(ctx context.Context, deliveryID string) (*DeliveryResponse, error)
+	ReplayDeadLetters(ctx context.Context, req *ReplayDeadLettersRequest) (*ReplayDeadLettersResponse, error)
 }
 
 // useCaseImpl implements the webhook use case
 type useCaseImpl struct {
-	webhookRepo    ports.WebhookRepository
-	webhookService *webhook.Service
+	webhookRepo      ports.WebhookRepository
+	deliveryRepo     ports.WebhookDeliveryRepository
+	outboxRepo       ports.EventOutboxRepository
+	transactor       ports.Transactor
+	outboxDispatcher *OutboxDispatcher
+	webhookService   *webhook.Service
+	retryConfig      *webhook.RetryConfig
+	eventStream      *webhook.EventStream
+	logger           *logger.Logger
 }
 
 // NewUseCase creates a new webhook use case
 func NewUseCase(
 	webhookRepo ports.WebhookRepository,
+	deliveryRepo ports.WebhookDeliveryRepository,
+	outboxRepo ports.EventOutboxRepository,
+	transactor ports.Transactor,
+	outboxDispatcher *OutboxDispatcher,
 	webhookService *webhook.Service,
+	retryConfig *webhook.RetryConfig,
+	logger *logger.Logger,
 ) UseCase {
-	return &useCaseImpl{
-		webhookRepo:    webhookRepo,
-		webhookService: webhookService,
+	if retryConfig == nil {
+		retryConfig = webhook.DefaultRetryConfig()
 	}
+
+	uc := &useCaseImpl{
+		webhookRepo:      webhookRepo,
+		deliveryRepo:     deliveryRepo,
+		outboxRepo:       outboxRepo,
+		transactor:       transactor,
+		outboxDispatcher: outboxDispatcher,
+		webhookService:   webhookService,
+		retryConfig:      retryConfig,
+		eventStream:      webhook.NewEventStream(),
+		logger:           logger,
+	}
+
+	// Webhooks whose circuit stays open too long are deactivated here, where the event can be emitted
+	webhookService.SetDisabledHandler(uc.disableWebhook)
+
+	return uc
 }
 
 // SetConfig creates a new webhook configuration
@@ -47,12 +97,24 @@ func (uc *useCaseImpl) SetConfig(ctx context.Context, req *SetConfigRequest) (*S
 		return nil, err
 	}
 
+	if err := uc.webhookService.ValidateWebhookConfig(webhookConfig); err != nil {
+		return nil, err
+	}
+
+	// Persist webhook so it starts receiving events
+	if err := uc.webhookRepo.Create(ctx, webhookConfig); err != nil {
+		return nil, err
+	}
+
 	// Convert domain entity to response DTO
 	response := &SetConfigResponse{
 		ID:        webhookConfig.ID.String(),
 		SessionID: webhookConfig.SessionID,
 		URL:       webhookConfig.URL,
 		Events:    webhookConfig.Events,
+		Filters:   fromDomainFilters(webhookConfig.Filters),
+		Template:  fromDomainTemplate(webhookConfig.Template),
+		Auth:      fromDomainAuth(webhookConfig),
 		Active:    webhookConfig.Active,
 		CreatedAt: webhookConfig.CreatedAt,
 	}
@@ -69,10 +131,34 @@ func (uc *useCaseImpl) FindConfig(ctx context.Context, sessionID string) (*Webho
 	}
 
 	// Convert domain entity to response DTO
-	response := FromWebhook(webhookConfig)
+	response := uc.toWebhookResponse(webhookConfig)
 	return response, nil
 }
 
+// GetWebhook retrieves a webhook configuration by ID
+func (uc *useCaseImpl) GetWebhook(ctx context.Context, webhookID string) (*WebhookResponse, error) {
+	webhookConfig, err := uc.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.toWebhookResponse(webhookConfig), nil
+}
+
+// GetSessionWebhook retrieves a webhook by ID, reporting it as not found if it belongs to another session
+func (uc *useCaseImpl) GetSessionWebhook(ctx context.Context, sessionID, webhookID string) (*WebhookResponse, error) {
+	webhookConfig, err := uc.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhookConfig.SessionID == nil || *webhookConfig.SessionID != sessionID {
+		return nil, webhook.ErrWebhookNotFound
+	}
+
+	return uc.toWebhookResponse(webhookConfig), nil
+}
+
 // UpdateWebhook updates an existing webhook configuration
 func (uc *useCaseImpl) UpdateWebhook(ctx context.Context, webhookID string, req *UpdateWebhookRequest) (*WebhookResponse, error) {
 	// Convert DTO to domain request
@@ -84,14 +170,58 @@ func (uc *useCaseImpl) UpdateWebhook(ctx context.Context, webhookID string, req
 		return nil, err
 	}
 
+	if err := uc.webhookRepo.Update(ctx, webhookConfig); err != nil {
+		return nil, err
+	}
+
 	// Convert domain entity to response DTO
-	response := FromWebhook(webhookConfig)
+	response := uc.toWebhookResponse(webhookConfig)
 	return response, nil
 }
 
+// ToggleWebhook flips the active flag of a webhook
+func (uc *useCaseImpl) ToggleWebhook(ctx context.Context, webhookID string) (*WebhookResponse, error) {
+	webhookConfig, err := uc.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhookConfig.IsEnvManaged() {
+		return nil, webhook.ErrWebhookManagedByEnv
+	}
+
+	webhookConfig.Active = !webhookConfig.Active
+	webhookConfig.UpdatedAt = time.Now()
+
+	if err := uc.webhookRepo.UpdateStatus(ctx, webhookID, webhookConfig.Active); err != nil {
+		return nil, err
+	}
+	uc.webhookService.ResetCircuit(ctx, webhookConfig.ID)
+
+	uc.logger.InfoWithFields("Webhook toggled", map[string]interface{}{
+		"webhook_id": webhookID,
+		"active":     webhookConfig.Active,
+	})
+
+	return uc.toWebhookResponse(webhookConfig), nil
+}
+
 // DeleteWebhook removes a webhook configuration
 func (uc *useCaseImpl) DeleteWebhook(ctx context.Context, webhookID string) error {
-	return uc.webhookService.DeleteWebhook(ctx, webhookID)
+	webhookConfig, err := uc.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		return err
+	}
+
+	if webhookConfig.IsEnvManaged() {
+		return webhook.ErrWebhookManagedByEnv
+	}
+
+	if err := uc.webhookService.DeleteWebhook(ctx, webhookID); err != nil {
+		return err
+	}
+
+	return uc.webhookRepo.Delete(ctx, webhookID)
 }
 
 // ListWebhooks retrieves a list of webhook configurations
@@ -104,16 +234,17 @@ func (uc *useCaseImpl) ListWebhooks(ctx context.Context, req *ListWebhooksReques
 		domainReq.Limit = 20
 	}
 
-	// Get webhooks from domain service
-	webhooks, total, err := uc.webhookService.ListWebhooks(ctx, domainReq)
+	webhooks, total, err := uc.webhookRepo.List(ctx, domainReq)
 	if err != nil {
 		return nil, err
 	}
 
 	// Convert domain entities to response DTOs
-	webhookResponses := make([]WebhookResponse, len(webhooks))
-	for i, wh := range webhooks {
-		webhookResponses[i] = *FromWebhook(wh)
+	webhookResponses := make([]WebhookResponse, 0, len(webhooks))
+	for _, wh := range webhooks {
+		if wh != nil {
+			webhookResponses = append(webhookResponses, *uc.toWebhookResponse(wh))
+		}
 	}
 
 	response := &ListWebhooksResponse{
@@ -126,29 +257,35 @@ func (uc *useCaseImpl) ListWebhooks(ctx context.Context, req *ListWebhooksReques
 	return response, nil
 }
 
-// TestWebhook tests a webhook configuration by sending a test event
-func (uc *useCaseImpl) TestWebhook(ctx context.Context, webhookID string, req *TestWebhookRequest) (*TestWebhookResponse, error) {
-	// Create test event
-	testEvent := &webhook.WebhookEvent{
-		ID:        "test-" + webhookID,
-		SessionID: "test-session",
-		Type:      req.EventType,
-		Data:      req.TestData,
+// TestWebhook sends a synthetic event to a session webhook and reports the receiver's real response
+func (uc *useCaseImpl) TestWebhook(ctx context.Context, sessionID string, req *TestWebhookRequest) (*TestWebhookResponse, error) {
+	eventType := req.EventType
+	if eventType == "" {
+		eventType = "Message"
+	}
+
+	testEvent, err := webhook.NewTestEvent(sessionID, eventType, req.TestData)
+	if err != nil {
+		return nil, err
 	}
 
 	// Test webhook using domain service
-	result, err := uc.webhookService.TestWebhook(ctx, webhookID, testEvent)
+	result, err := uc.webhookService.TestWebhook(ctx, sessionID, req.WebhookID, testEvent)
 	if err != nil {
-		return &TestWebhookResponse{
-			Success: false,
-			Error:   err.Error(),
-		}, nil
+		return nil, err
 	}
 
 	response := &TestWebhookResponse{
+		WebhookID:    result.WebhookID,
+		URL:          result.URL,
+		EventID:      result.EventID,
+		EventType:    result.EventType,
 		Success:      result.Success,
 		StatusCode:   result.StatusCode,
 		ResponseTime: result.ResponseTime,
+		ResponseBody: result.ResponseBody,
+		TLSError:     result.TLSError,
+		Headers:      result.Headers,
 	}
 
 	if result.Error != nil {
@@ -163,7 +300,443 @@ func (uc *useCaseImpl) GetSupportedWebhookEvents(ctx context.Context) (*WebhookE
 	return GetSupportedEvents(), nil
 }
 
-// ProcessWebhookEvent processes a webhook event and sends it to configured webhooks
+// GetEventSchemas returns the JSON Schema of every event type with a typed payload
+func (uc *useCaseImpl) GetEventSchemas(ctx context.Context) (*EventSchemasResponse, error) {
+	schemas := make(map[string]map[string]interface{})
+	for _, eventType := range webhook.TypedEventTypes() {
+		schema, _ := webhook.EventJSONSchema(eventType)
+		schemas[eventType] = schema
+	}
+
+	return &EventSchemasResponse{
+		SchemaVersion: webhook.PayloadSchemaVersion,
+		Schemas:       schemas,
+	}, nil
+}
+
+// GetEventSchema returns the JSON Schema of a single event type
+func (uc *useCaseImpl) GetEventSchema(ctx context.Context, eventType string) (map[string]interface{}, error) {
+	schema, ok := webhook.EventJSONSchema(eventType)
+	if !ok {
+		return nil, fmt.Errorf("%w: no schema for %q", webhook.ErrUnsupportedEventType, eventType)
+	}
+
+	return schema, nil
+}
+
+// PreviewTemplate renders a sample event with a webhook template, as it would be delivered.
+// The template comes from the request, or from the saved webhook when the request has none.
+func (uc *useCaseImpl) PreviewTemplate(ctx context.Context, req *PreviewTemplateRequest) (*PreviewTemplateResponse, error) {
+	eventType := req.EventType
+	if eventType == "" {
+		eventType = "Message"
+	}
+
+	var sessionID string
+	tmpl := req.Template.toDomain()
+	if tmpl == nil && req.WebhookID != "" {
+		wh, err := uc.webhookRepo.GetByID(ctx, req.WebhookID)
+		if err != nil {
+			return nil, err
+		}
+		tmpl = wh.Template
+		if wh.SessionID != nil {
+			sessionID = *wh.SessionID
+		}
+	}
+
+	if err := tmpl.Validate(); err != nil {
+		return nil, err
+	}
+
+	event, err := webhook.NewTestEvent(sessionID, eventType, req.TestData)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := tmpl.Render(event)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PreviewTemplateResponse{
+		EventType:   eventType,
+		ContentType: rendered.ContentType,
+		Headers:     rendered.Headers,
+		Body:        string(rendered.Body),
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(rendered.ContentType); strings.HasSuffix(mediaType, "json") && !json.Valid(rendered.Body) {
+		response.Warning = "body is not valid JSON but the content type is " + mediaType
+	}
+
+	return response, nil
+}
+
+// ProcessWebhookEvent records a webhook event in the outbox, from which the dispatcher
+// delivers it to configured webhooks. When ctx carries a transaction the event is recorded
+// in it, and is only published once the transaction commits. A message or receipt already
+// recorded within the dedup TTL is dropped, so no consumer or subscriber sees it twice.
 func (uc *useCaseImpl) ProcessWebhookEvent(ctx context.Context, event *webhook.WebhookEvent) error {
-	return uc.webhookService.ProcessEvent(ctx, event)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	outboxEvent := &ports.OutboxEvent{
+		EventID:   event.ID,
+		SessionID: event.SessionID,
+		EventType: event.Type,
+		Payload:   string(payload),
+	}
+
+	recorded := false
+	err = uc.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		claimed, err := uc.claimDedupKey(ctx, event)
+		if err != nil || !claimed {
+			return err
+		}
+
+		if err := uc.outboxRepo.Append(ctx, outboxEvent); err != nil {
+			return err
+		}
+		recorded = true
+
+		uc.transactor.AfterCommit(ctx, func() {
+			// Live subscribers get the event right away, so they are not held back by slow webhook receivers
+			uc.eventStream.Publish(event)
+			uc.outboxDispatcher.Notify()
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !recorded {
+		uc.logger.DebugWithFields("Dropped duplicate event", map[string]interface{}{
+			"event_id":        event.ID,
+			"event_type":      event.Type,
+			"session_id":      event.SessionID,
+			"is_offline_sync": event.IsOfflineSync,
+		})
+	}
+
+	return nil
+}
+
+// claimDedupKey reserves the dedup key of an inbound event for the dedup TTL.
+// It reports false when an earlier copy of the event holds the key; events without
+// a key, and every event when deduplication is disabled, are always claimed.
+func (uc *useCaseImpl) claimDedupKey(ctx context.Context, event *webhook.WebhookEvent) (bool, error) {
+	ttl := uc.outboxDispatcher.config.DedupTTL
+	if ttl <= 0 || event.SessionID == "" {
+		return true, nil
+	}
+
+	key := webhook.DedupKey(event)
+	if key == "" {
+		return true, nil
+	}
+
+	return uc.outboxRepo.ClaimDedupKey(ctx, event.SessionID, key, event.ID, time.Now().Add(ttl).Unix())
+}
+
+// DeliverEvent sends an event read from the outbox to configured webhooks and records the deliveries.
+// Failed deliveries are retried by the retry worker; an error means the event must be handed over again.
+func (uc *useCaseImpl) DeliverEvent(ctx context.Context, event *webhook.WebhookEvent) error {
+	results, err := uc.webhookService.ProcessEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if err := uc.recordDelivery(ctx, event.SessionID, result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SubscribeEvents opens a live subscription to the events of a session, or of every
+// session when sessionID is empty. Empty eventTypes subscribe to every event type.
+func (uc *useCaseImpl) SubscribeEvents(ctx context.Context, sessionID string, eventTypes []string) (*webhook.Subscription, error) {
+	if invalidEvents := webhook.ValidateEvents(eventTypes); len(invalidEvents) > 0 {
+		return nil, fmt.Errorf("%w: %v", webhook.ErrUnsupportedEventType, invalidEvents)
+	}
+
+	sub := uc.eventStream.Subscribe(sessionID, eventTypes)
+
+	uc.logger.InfoWithFields("Event stream subscriber connected", map[string]interface{}{
+		"session_id":  sessionID,
+		"event_types": eventTypes,
+		"subscribers": uc.eventStream.SubscriberCount(),
+	})
+
+	return sub, nil
+}
+
+// SyncEnvGlobalWebhook makes the GLOBAL_WEBHOOK_URL subscriber match the environment.
+// It is stored like any global webhook so deliveries to it are logged and retried;
+// when the variable is unset, a previously configured one is deactivated.
+func (uc *useCaseImpl) SyncEnvGlobalWebhook(ctx context.Context, url string) error {
+	envWebhookID := webhook.EnvGlobalWebhookID.String()
+
+	existing, err := uc.webhookRepo.GetByID(ctx, envWebhookID)
+	if err != nil && !errors.Is(err, webhook.ErrWebhookNotFound) {
+		return err
+	}
+
+	if url == "" {
+		if existing != nil && existing.Active {
+			uc.logger.Info("GLOBAL_WEBHOOK_URL removed, deactivating global webhook")
+			return uc.webhookRepo.UpdateStatus(ctx, envWebhookID, false)
+		}
+		return nil
+	}
+
+	if existing == nil {
+		envWebhook := webhook.NewWebhookConfig(nil, url, "", []string{"All"})
+		envWebhook.ID = webhook.EnvGlobalWebhookID
+
+		if err := uc.webhookService.ValidateWebhookConfig(envWebhook); err != nil {
+			return fmt.Errorf("invalid GLOBAL_WEBHOOK_URL: %w", err)
+		}
+
+		uc.logger.InfoWithFields("Registering global webhook from GLOBAL_WEBHOOK_URL", map[string]interface{}{
+			"webhook_id": envWebhookID,
+			"url":        url,
+		})
+		return uc.webhookRepo.Create(ctx, envWebhook)
+	}
+
+	if existing.URL == url && existing.Active && existing.HasEvent("All") {
+		return nil
+	}
+
+	existing.URL = url
+	existing.Events = []string{"All"}
+	existing.Active = true
+	existing.UpdatedAt = time.Now()
+
+	if err := uc.webhookService.ValidateWebhookConfig(existing); err != nil {
+		return fmt.Errorf("invalid GLOBAL_WEBHOOK_URL: %w", err)
+	}
+
+	uc.logger.InfoWithFields("Updating global webhook from GLOBAL_WEBHOOK_URL", map[string]interface{}{
+		"webhook_id": envWebhookID,
+		"url":        url,
+	})
+	return uc.webhookRepo.Update(ctx, existing)
+}
+
+// recordDelivery stores the first delivery attempt and schedules a retry when it failed
+func (uc *useCaseImpl) recordDelivery(ctx context.Context, sessionID string, result *webhook.DeliveryResult) error {
+	delivery := &ports.WebhookDelivery{
+		WebhookID:    result.WebhookID,
+		SessionID:    sessionID,
+		EventID:      result.EventID,
+		EventType:    result.EventType,
+		URL:          result.URL,
+		Payload:      string(result.Payload),
+		StatusCode:   result.StatusCode,
+		ResponseBody: result.ResponseBody,
+		Latency:      result.Latency.Milliseconds(),
+		Success:      result.Success,
+		Attempts:     1,
+	}
+
+	if result.Error != nil {
+		delivery.Error = result.Error.Error()
+	}
+
+	// Payload is empty only when the event could not be encoded or rendered, which retries won't fix
+	switch {
+	case result.Success:
+		delivery.Status = webhook.DeliveryStatusDelivered
+	case errors.Is(result.Error, webhook.ErrCircuitOpen):
+		// Held back without an attempt, the retry worker sends it once the circuit lets it through
+		delivery.Status = webhook.DeliveryStatusRetrying
+		delivery.Attempts = 0
+		delivery.NextRetryAt = result.RetryAt.Unix()
+	case len(result.Payload) == 0:
+		delivery.Status = webhook.DeliveryStatusDeadLetter
+		delivery.DeadLetterReason = "event could not be encoded or rendered"
+	case uc.retryConfig.CanRetry(delivery.Attempts):
+		delivery.Status = webhook.DeliveryStatusRetrying
+		delivery.NextRetryAt = time.Now().Add(uc.retryConfig.NextDelay(delivery.Attempts)).Unix()
+	default:
+		delivery.Status = webhook.DeliveryStatusDeadLetter
+		delivery.DeadLetterReason = "retries exhausted"
+	}
+
+	if err := uc.deliveryRepo.Create(ctx, delivery); err != nil {
+		uc.logger.ErrorWithFields("Failed to record webhook delivery", map[string]interface{}{
+			"webhook_id": result.WebhookID,
+			"event_id":   result.EventID,
+			"success":    result.Success,
+			"error":      err.Error(),
+		})
+		return err
+	}
+
+	return nil
+}
+
+// toWebhookResponse converts a webhook to its response, with the state of its circuit
+func (uc *useCaseImpl) toWebhookResponse(wh *webhook.WebhookConfig) *WebhookResponse {
+	response := FromWebhook(wh)
+	response.Circuit = fromCircuitStatus(uc.webhookService.CircuitStatus(wh))
+	return response
+}
+
+// disableWebhook deactivates a webhook whose circuit stayed open too long and emits a WebhookDisabled event
+func (uc *useCaseImpl) disableWebhook(ctx context.Context, wh *webhook.WebhookConfig, circuit webhook.CircuitStatus) {
+	webhookID := wh.ID.String()
+	failingFor := time.Since(circuit.OpenedAt).Round(time.Minute)
+
+	if err := uc.webhookRepo.UpdateStatus(ctx, webhookID, false); err != nil {
+		uc.logger.ErrorWithFields("Failed to deactivate failing webhook", map[string]interface{}{
+			"webhook_id": webhookID,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	uc.logger.ErrorWithFields("Webhook deactivated after failing for too long", map[string]interface{}{
+		"webhook_id":    webhookID,
+		"session_id":    wh.SessionID,
+		"failures":      circuit.Failures,
+		"failing_since": circuit.OpenedAt,
+		"last_error":    circuit.LastError,
+	})
+
+	// Never put broker credentials in the event
+	redactedURL := wh.URL
+	if target, err := url.Parse(wh.URL); err == nil {
+		redactedURL = target.Redacted()
+	}
+
+	sessionID := ""
+	if wh.SessionID != nil {
+		sessionID = *wh.SessionID
+	}
+
+	event := webhook.NewWebhookEvent(sessionID, "WebhookDisabled", &webhook.WebhookDisabledEventData{
+		WebhookID:    webhookID,
+		URL:          redactedURL,
+		Reason:       fmt.Sprintf("deliveries kept failing for %s", failingFor),
+		Failures:     circuit.Failures,
+		FailingSince: circuit.OpenedAt,
+		LastError:    circuit.LastError,
+	})
+	if err := uc.ProcessWebhookEvent(ctx, event); err != nil {
+		uc.logger.ErrorWithFields("Failed to emit WebhookDisabled event", map[string]interface{}{
+			"webhook_id": webhookID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// ListDeadLetters lists deliveries that exhausted their retries
+func (uc *useCaseImpl) ListDeadLetters(ctx context.Context, req *ListDeliveriesRequest) (*ListDeliveriesResponse, error) {
+	filter, err := req.ToDeliveryFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	// Set defaults
+	if filter.Limit == 0 {
+		filter.Limit = 20
+	}
+
+	deliveries, total, err := uc.deliveryRepo.ListDeadLetters(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryResponses := make([]DeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		deliveryResponses[i] = *FromDelivery(delivery)
+	}
+
+	return &ListDeliveriesResponse{
+		Deliveries: deliveryResponses,
+		Total:      total,
+		Limit:      filter.Limit,
+		Offset:     filter.Offset,
+	}, nil
+}
+
+// GetDelivery retrieves a single delivery with its payload and last response
+func (uc *useCaseImpl) GetDelivery(ctx context.Context, deliveryID string) (*DeliveryResponse, error) {
+	delivery, err := uc.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromDelivery(delivery), nil
+}
+
+// ReplayDelivery sends a recorded delivery again right away and returns the updated record
+func (uc *useCaseImpl) ReplayDelivery(ctx context.Context, deliveryID string) (*DeliveryResponse, error) {
+	delivery, err := uc.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	wh, err := uc.webhookRepo.GetByID(ctx, delivery.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+	// Inactive webhooks receive nothing, the retry worker gives their deliveries up as well
+	if !wh.Active {
+		return nil, fmt.Errorf("%w: reactivate it to replay its deliveries", webhook.ErrWebhookInactive)
+	}
+
+	uc.logger.InfoWithFields("Replaying webhook delivery", map[string]interface{}{
+		"delivery_id": delivery.ID,
+		"webhook_id":  delivery.WebhookID,
+		"event_id":    delivery.EventID,
+		"status":      delivery.Status,
+	})
+
+	// Manual replays ignore an open circuit, they are how operators check a fixed receiver
+	result := uc.webhookService.Replay(ctx, wh, delivery.SessionID, delivery.EventID, delivery.EventType, []byte(delivery.Payload))
+
+	errMsg := ""
+	if result.Error != nil {
+		errMsg = result.Error.Error()
+	}
+
+	if err := uc.deliveryRepo.UpdateDeliveryStatus(ctx, delivery.ID, result.Success, result.StatusCode, result.ResponseBody, errMsg); err != nil {
+		return nil, err
+	}
+
+	return uc.GetDelivery(ctx, delivery.ID)
+}
+
+// ReplayDeadLetters requeues matching dead-lettered deliveries for the retry worker.
+// Each requeued delivery gets one more attempt and returns to the dead letter if it fails again.
+func (uc *useCaseImpl) ReplayDeadLetters(ctx context.Context, req *ReplayDeadLettersRequest) (*ReplayDeadLettersResponse, error) {
+	filter, err := req.ToDeliveryFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	requeued, err := uc.deliveryRepo.RequeueDeadLetters(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.logger.InfoWithFields("Dead-lettered webhook deliveries requeued", map[string]interface{}{
+		"webhook_id": filter.WebhookID,
+		"session_id": filter.SessionID,
+		"since":      filter.Since,
+		"until":      filter.Until,
+		"requeued":   requeued,
+	})
+
+	return &ReplayDeadLettersResponse{Requeued: requeued}, nil
 }
@@ -1,10 +1,25 @@
 package message
 
 import (
+	"errors"
 	"strings"
 	"time"
 )
 
+// Message history errors
+var (
+	// ErrMessageNotFound is returned when a message is not in the message history
+	ErrMessageNotFound = errors.New("message not found")
+	// ErrMessageHasNoMedia is returned when downloading the media of a message without media
+	ErrMessageHasNoMedia = errors.New("message has no media")
+	// ErrMediaExpired is returned when WhatsApp no longer keeps the media of a message
+	ErrMediaExpired = errors.New("media is no longer available on WhatsApp servers")
+	// ErrMediaObjectNotFound is returned when the media store keeps nothing under a key
+	ErrMediaObjectNotFound = errors.New("media object not found")
+	// ErrInvalidMediaSignature is returned for presigned media URLs that were altered or expired
+	ErrInvalidMediaSignature = errors.New("invalid or expired media signature")
+)
+
 // MessageType represents the type of message
 type MessageType string
 
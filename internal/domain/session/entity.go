@@ -8,18 +8,19 @@ import (
 )
 
 type Session struct {
-	ID              uuid.UUID    `json:"id" db:"id"`
-	Name            string       `json:"name" db:"name"`
-	DeviceJid       string       `json:"deviceJid" db:"device_jid"`
-	IsConnected     bool         `json:"isConnected" db:"is_connected"`
-	ConnectionError *string      `json:"connectionError,omitempty" db:"connection_error"`
-	QRCode          string       `json:"qrCode,omitempty" db:"qr_code"`
-	QRCodeExpiresAt *time.Time   `json:"qrCodeExpiresAt,omitempty" db:"qr_code_expires_at"`
-	ProxyConfig     *ProxyConfig `json:"proxyConfig,omitempty"`
-	CreatedAt       time.Time    `json:"createdAt" db:"created_at"`
-	UpdatedAt       time.Time    `json:"updatedAt" db:"updated_at"`
-	ConnectedAt     *time.Time   `json:"connectedAt,omitempty" db:"connected_at"`
-	LastSeen        *time.Time   `json:"lastSeen,omitempty" db:"last_seen"`
+	ID                uuid.UUID    `json:"id" db:"id"`
+	Name              string       `json:"name" db:"name"`
+	DeviceJid         string       `json:"deviceJid" db:"device_jid"`
+	IsConnected       bool         `json:"isConnected" db:"is_connected"`
+	ConnectionError   *string      `json:"connectionError,omitempty" db:"connection_error"`
+	QRCode            string       `json:"qrCode,omitempty" db:"qr_code"`
+	QRCodeExpiresAt   *time.Time   `json:"qrCodeExpiresAt,omitempty" db:"qr_code_expires_at"`
+	ProxyConfig       *ProxyConfig `json:"proxyConfig,omitempty"`
+	MediaAutoDownload *bool        `json:"mediaAutoDownload,omitempty" db:"media_auto_download"`
+	CreatedAt         time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt         time.Time    `json:"updatedAt" db:"updated_at"`
+	ConnectedAt       *time.Time   `json:"connectedAt,omitempty" db:"connected_at"`
+	LastSeen          *time.Time   `json:"lastSeen,omitempty" db:"last_seen"`
 }
 
 // SessionInfo represents detailed session information
@@ -63,8 +64,9 @@ type ProxyConfig struct {
 }
 
 type CreateSessionRequest struct {
-	Name        string       `json:"name" validate:"required,min=1,max=100"`
-	ProxyConfig *ProxyConfig `json:"proxyConfig,omitempty"`
+	Name              string       `json:"name" validate:"required,min=1,max=100"`
+	ProxyConfig       *ProxyConfig `json:"proxyConfig,omitempty"`
+	MediaAutoDownload *bool        `json:"mediaAutoDownload,omitempty"`
 }
 
 type UpdateSessionRequest struct {
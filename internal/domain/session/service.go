@@ -21,6 +21,7 @@ type Repository interface {
 	List(ctx context.Context, req *ListSessionsRequest) ([]*Session, int, error)
 	Update(ctx context.Context, session *Session) error
 	Delete(ctx context.Context, id string) error
+	UpdateMediaAutoDownload(ctx context.Context, id string, enabled *bool) error
 }
 
 type WameowManager interface {
@@ -34,6 +35,7 @@ type WameowManager interface {
 	GetDeviceInfo(sessionID string) (*DeviceInfo, error)
 	SetProxy(sessionID string, config *ProxyConfig) error
 	GetProxy(sessionID string) (*ProxyConfig, error)
+	SetMediaAutoDownload(sessionID string, enabled *bool) error
 }
 
 func NewService(repo Repository, Wameow WameowManager) *Service {
@@ -50,6 +52,7 @@ func (s *Service) CreateSession(ctx context.Context, req *CreateSessionRequest)
 	// Create new session
 	session := NewSession(req.Name)
 	session.ProxyConfig = req.ProxyConfig
+	session.MediaAutoDownload = req.MediaAutoDownload
 
 	// Save to database
 	if err := s.repo.Create(ctx, session); err != nil {
@@ -267,3 +270,38 @@ func (s *Service) GetProxy(ctx context.Context, id string) (*ProxyConfig, error)
 
 	return session.ProxyConfig, nil
 }
+
+func (s *Service) SetMediaAutoDownload(ctx context.Context, id string, enabled *bool) error {
+	session, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "failed to get session")
+	}
+
+	if session == nil {
+		return errors.ErrNotFound
+	}
+
+	// Store the setting first, a client loaded later reads it from the session
+	if err := s.repo.UpdateMediaAutoDownload(ctx, id, enabled); err != nil {
+		return errors.Wrap(err, "failed to update session")
+	}
+
+	if err := s.Wameow.SetMediaAutoDownload(id, enabled); err != nil {
+		return errors.Wrap(err, "failed to set media auto download")
+	}
+
+	return nil
+}
+
+func (s *Service) GetMediaAutoDownload(ctx context.Context, id string) (*bool, error) {
+	session, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get session")
+	}
+
+	if session == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	return session.MediaAutoDownload, nil
+}
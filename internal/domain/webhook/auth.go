@@ -0,0 +1,245 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Authentication types of webhook receivers
+const (
+	AuthTypeBasic  = "basic"
+	AuthTypeBearer = "bearer"
+	AuthTypeOAuth2 = "oauth2"
+)
+
+// How the OAuth2 client authenticates to the token endpoint
+const (
+	OAuth2AuthStyleHeader = "header" // HTTP basic, the default
+	OAuth2AuthStyleBody   = "body"   // client_id and client_secret form fields
+)
+
+// redactedValue replaces credentials in the request headers reported back to users
+const redactedValue = "[redacted]"
+
+// credentialHeaders are always redacted and may only be set through the encrypted auth
+var credentialHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+}
+
+// WebhookAuth holds the credentials a webhook presents to its receiver, typically an API gateway.
+// Custom headers and the client certificate can be combined with any type.
+// The repository stores it encrypted, and API responses only describe it.
+type WebhookAuth struct {
+	Type     string             `json:"type,omitempty"` // basic, bearer, oauth2, or empty for headers and TLS only
+	Headers  map[string]string  `json:"headers,omitempty"`
+	Username string             `json:"username,omitempty"`
+	Password string             `json:"password,omitempty"`
+	Token    string             `json:"token,omitempty"`
+	OAuth2   *OAuth2Credentials `json:"oauth2,omitempty"`
+	TLS      *ClientTLS         `json:"tls,omitempty"`
+}
+
+// OAuth2Credentials are the client credentials exchanged for an access token before delivering
+type OAuth2Credentials struct {
+	TokenURL     string   `json:"token_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes,omitempty"`
+	Audience     string   `json:"audience,omitempty"`
+	AuthStyle    string   `json:"auth_style,omitempty"` // header (default) or body
+}
+
+// ClientTLS is the client certificate presented to receivers that require mutual TLS
+type ClientTLS struct {
+	Certificate   string `json:"certificate"`              // PEM, may include the intermediate chain
+	PrivateKey    string `json:"private_key"`              // PEM
+	CACertificate string `json:"ca_certificate,omitempty"` // PEM bundle trusted in addition to the system roots
+}
+
+// IsEmpty reports whether the webhook sends no credentials
+func (a *WebhookAuth) IsEmpty() bool {
+	return a == nil || (a.Type == "" && len(a.Headers) == 0 && a.TLS == nil)
+}
+
+// Validate checks that the credentials of the chosen type are complete and usable
+func (a *WebhookAuth) Validate() error {
+	if a.IsEmpty() {
+		return nil
+	}
+
+	switch a.Type {
+	case "":
+	case AuthTypeBasic:
+		if a.Username == "" || strings.Contains(a.Username, ":") {
+			return fmt.Errorf("%w: basic auth requires a username without colons", ErrInvalidWebhookAuth)
+		}
+	case AuthTypeBearer:
+		if a.Token == "" || strings.ContainsAny(a.Token, "\r\n") {
+			return fmt.Errorf("%w: bearer auth requires a token on a single line", ErrInvalidWebhookAuth)
+		}
+	case AuthTypeOAuth2:
+		if err := a.OAuth2.validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWebhookAuth, err)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported type %q, expected basic, bearer or oauth2", ErrInvalidWebhookAuth, a.Type)
+	}
+
+	if err := validateHeaders(a.Headers); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhookAuth, err)
+	}
+	if a.Type != "" {
+		for name := range a.Headers {
+			if http.CanonicalHeaderKey(name) == "Authorization" {
+				return fmt.Errorf("%w: the Authorization header is set by %s auth", ErrInvalidWebhookAuth, a.Type)
+			}
+		}
+	}
+
+	if a.TLS != nil {
+		if _, err := a.TLS.config(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWebhookAuth, err)
+		}
+	}
+
+	return nil
+}
+
+// Apply sets the static headers and the basic or bearer Authorization header on a request.
+// OAuth2 tokens are fetched and set by the deliverer.
+func (a *WebhookAuth) Apply(header http.Header) {
+	if a == nil {
+		return
+	}
+
+	for name, value := range a.Headers {
+		header.Set(name, value)
+	}
+
+	switch a.Type {
+	case AuthTypeBasic:
+		req := &http.Request{Header: header}
+		req.SetBasicAuth(a.Username, a.Password)
+	case AuthTypeBearer:
+		header.Set("Authorization", "Bearer "+a.Token)
+	}
+}
+
+// Redact returns a copy of the request headers with the credentials replaced
+func (a *WebhookAuth) Redact(header http.Header) http.Header {
+	redacted := header.Clone()
+	for name := range credentialHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, redactedValue)
+		}
+	}
+	if a == nil {
+		return redacted
+	}
+
+	for name := range a.Headers {
+		redacted.Set(name, redactedValue)
+	}
+	return redacted
+}
+
+// HeaderNames returns the names of the custom headers, sorted
+func (a *WebhookAuth) HeaderNames() []string {
+	if a == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(a.Headers))
+	for name := range a.Headers {
+		names = append(names, http.CanonicalHeaderKey(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate checks the OAuth2 client credentials
+func (c *OAuth2Credentials) validate() error {
+	if c == nil {
+		return fmt.Errorf("oauth2 auth requires oauth2 credentials")
+	}
+
+	tokenURL, err := url.Parse(c.TokenURL)
+	if err != nil || (tokenURL.Scheme != "http" && tokenURL.Scheme != "https") || tokenURL.Host == "" {
+		return fmt.Errorf("oauth2 token_url must be an absolute http or https URL")
+	}
+	if c.ClientID == "" || c.ClientSecret == "" {
+		return fmt.Errorf("oauth2 auth requires client_id and client_secret")
+	}
+	if c.AuthStyle != "" && c.AuthStyle != OAuth2AuthStyleHeader && c.AuthStyle != OAuth2AuthStyleBody {
+		return fmt.Errorf("oauth2 auth_style must be %s or %s", OAuth2AuthStyleHeader, OAuth2AuthStyleBody)
+	}
+	return nil
+}
+
+// cacheKey identifies the credentials in the token cache
+func (c *OAuth2Credentials) cacheKey() string {
+	return fingerprint(c)
+}
+
+// config builds the TLS configuration presenting the client certificate
+func (t *ClientTLS) config() (*tls.Config, error) {
+	certificate, err := tls.X509KeyPair([]byte(t.Certificate), []byte(t.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate or private key: %v", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if t.CACertificate != "" {
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM([]byte(t.CACertificate)) {
+			return nil, fmt.Errorf("ca_certificate contains no PEM certificate")
+		}
+		config.RootCAs = roots
+	}
+
+	return config, nil
+}
+
+// Leaf returns the subject and expiry of the client certificate
+func (t *ClientTLS) Leaf() (string, time.Time, error) {
+	block, _ := pem.Decode([]byte(t.Certificate))
+	if block == nil {
+		return "", time.Time{}, fmt.Errorf("certificate is not PEM encoded")
+	}
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return certificate.Subject.String(), certificate.NotAfter, nil
+}
+
+// cacheKey identifies the certificate in the client cache
+func (t *ClientTLS) cacheKey() string {
+	return fingerprint(t)
+}
+
+// fingerprint hashes a value so cached clients and tokens are dropped when the credentials change
+func fingerprint(v interface{}) string {
+	encoded, _ := json.Marshal(v)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
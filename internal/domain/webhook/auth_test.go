@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestWebhookAuthValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    *WebhookAuth
+		wantErr bool
+	}{
+		{name: "nil", auth: nil},
+		{name: "empty", auth: &WebhookAuth{}},
+		{name: "basic", auth: &WebhookAuth{Type: AuthTypeBasic, Username: "user", Password: "pass"}},
+		{name: "basic without username", auth: &WebhookAuth{Type: AuthTypeBasic, Password: "pass"}, wantErr: true},
+		{name: "basic username with colon", auth: &WebhookAuth{Type: AuthTypeBasic, Username: "us:er"}, wantErr: true},
+		{name: "bearer", auth: &WebhookAuth{Type: AuthTypeBearer, Token: "token"}},
+		{name: "bearer without token", auth: &WebhookAuth{Type: AuthTypeBearer}, wantErr: true},
+		{name: "bearer token with newline", auth: &WebhookAuth{Type: AuthTypeBearer, Token: "to\nken"}, wantErr: true},
+		{name: "oauth2 without credentials", auth: &WebhookAuth{Type: AuthTypeOAuth2}, wantErr: true},
+		{name: "unsupported type", auth: &WebhookAuth{Type: "digest"}, wantErr: true},
+		{name: "headers only", auth: &WebhookAuth{Headers: map[string]string{"X-Api-Key": "key", "Authorization": "Custom key"}}},
+		{name: "authorization header with bearer", auth: &WebhookAuth{Type: AuthTypeBearer, Token: "token", Headers: map[string]string{"authorization": "Custom key"}}, wantErr: true},
+		{name: "reserved header", auth: &WebhookAuth{Headers: map[string]string{"Host": "example.com"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.auth.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidWebhookAuth) {
+				t.Errorf("Validate() error = %v, want ErrInvalidWebhookAuth", err)
+			}
+		})
+	}
+}
+
+func TestWebhookAuthRedact(t *testing.T) {
+	tests := []struct {
+		name   string
+		auth   *WebhookAuth
+		header http.Header
+		want   http.Header
+	}{
+		{
+			name:   "nil auth still redacts authorization",
+			header: http.Header{"Authorization": {"Bearer token"}, "Content-Type": {"application/json"}},
+			want:   http.Header{"Authorization": {redactedValue}, "Content-Type": {"application/json"}},
+		},
+		{
+			name:   "proxy authorization",
+			header: http.Header{"Proxy-Authorization": {"Basic dXNlcjpwYXNz"}},
+			want:   http.Header{"Proxy-Authorization": {redactedValue}},
+		},
+		{
+			name:   "custom auth headers",
+			auth:   &WebhookAuth{Headers: map[string]string{"x-api-key": "key"}},
+			header: http.Header{"X-Api-Key": {"key"}, "X-Source": {"zpwoot"}},
+			want:   http.Header{"X-Api-Key": {redactedValue}, "X-Source": {"zpwoot"}},
+		},
+		{
+			name:   "bearer",
+			auth:   &WebhookAuth{Type: AuthTypeBearer, Token: "token"},
+			header: http.Header{"Authorization": {"Bearer token"}},
+			want:   http.Header{"Authorization": {redactedValue}},
+		},
+		{
+			name:   "no credentials",
+			header: http.Header{"Content-Type": {"application/json"}},
+			want:   http.Header{"Content-Type": {"application/json"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.auth.Redact(tt.header)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Redact() = %v, want %v", got, tt.want)
+			}
+			for name := range tt.want {
+				if got.Get(name) != tt.want.Get(name) {
+					t.Errorf("Redact()[%s] = %q, want %q", name, got.Get(name), tt.want.Get(name))
+				}
+			}
+			if tt.header.Get("Authorization") != "" && tt.header.Get("Authorization") == redactedValue {
+				t.Error("Redact() changed the request headers")
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package webhook
+
+// EventTypeInfo describes a supported webhook event type
+type EventTypeInfo struct {
+	Type        string
+	Category    string
+	Description string
+}
+
+// Event categories used to group the catalogue
+const (
+	EventCategoryMessages     = "messages"
+	EventCategoryGroups       = "groups"
+	EventCategoryConnection   = "connection"
+	EventCategoryPrivacy      = "privacy"
+	EventCategorySync         = "sync"
+	EventCategoryCalls        = "calls"
+	EventCategoryPresence     = "presence"
+	EventCategoryIdentity     = "identity"
+	EventCategoryErrors       = "errors"
+	EventCategoryNewsletter   = "newsletter"
+	EventCategoryFacebook     = "facebook"
+	EventCategoryWebhooks     = "webhooks"
+	EventCategorySubscription = "subscription"
+)
+
+// eventTypeDetails holds the category and description of each supported event type
+var eventTypeDetails = map[string]EventTypeInfo{
+	// Messages and Communication
+	"Message":              {Category: EventCategoryMessages, Description: "A message was received or sent from another device of the account"},
+	"UndecryptableMessage": {Category: EventCategoryMessages, Description: "A message was received but could not be decrypted"},
+	"Receipt":              {Category: EventCategoryMessages, Description: "A message was delivered, read or played"},
+	"MediaRetry":           {Category: EventCategoryMessages, Description: "The phone answered a request to re-upload expired media"},
+	"ReadReceipt":          {Category: EventCategoryMessages, Description: "Messages were marked as read"},
+
+	// Groups and Contacts
+	"GroupInfo":       {Category: EventCategoryGroups, Description: "Group metadata or participants changed"},
+	"JoinedGroup":     {Category: EventCategoryGroups, Description: "The account joined or was added to a group"},
+	"Picture":         {Category: EventCategoryGroups, Description: "A contact or group changed its profile picture"},
+	"BlocklistChange": {Category: EventCategoryGroups, Description: "A contact was blocked or unblocked"},
+	"Blocklist":       {Category: EventCategoryGroups, Description: "The full blocklist was received"},
+
+	// Connection and Session
+	"Connected":                   {Category: EventCategoryConnection, Description: "The session connected to WhatsApp"},
+	"Disconnected":                {Category: EventCategoryConnection, Description: "The session lost its connection to WhatsApp"},
+	"ConnectFailure":              {Category: EventCategoryConnection, Description: "WhatsApp refused the connection"},
+	"KeepAliveRestored":           {Category: EventCategoryConnection, Description: "Keepalive pings succeed again after a timeout"},
+	"KeepAliveTimeout":            {Category: EventCategoryConnection, Description: "A keepalive ping timed out"},
+	"LoggedOut":                   {Category: EventCategoryConnection, Description: "The device was logged out and the session must be paired again"},
+	"ClientOutdated":              {Category: EventCategoryConnection, Description: "WhatsApp rejected the client version as outdated"},
+	"TemporaryBan":                {Category: EventCategoryConnection, Description: "The account was temporarily banned"},
+	"StreamError":                 {Category: EventCategoryConnection, Description: "WhatsApp sent an unknown stream error"},
+	"StreamReplaced":              {Category: EventCategoryConnection, Description: "Another client connected with the same credentials"},
+	"PairSuccess":                 {Category: EventCategoryConnection, Description: "The QR code was scanned and the device paired"},
+	"PairError":                   {Category: EventCategoryConnection, Description: "Pairing failed after the QR code was scanned"},
+	"QR":                          {Category: EventCategoryConnection, Description: "A new QR code is available for pairing"},
+	"QRTimeout":                   {Category: EventCategoryConnection, Description: "No QR code was scanned before the pairing window closed"},
+	"QRScannedWithoutMultidevice": {Category: EventCategoryConnection, Description: "The QR code was scanned by a phone without multi-device enabled"},
+
+	// Privacy and Settings
+	"PrivacySettings": {Category: EventCategoryPrivacy, Description: "Privacy settings changed"},
+	"PushNameSetting": {Category: EventCategoryPrivacy, Description: "The account display name changed"},
+	"UserAbout":       {Category: EventCategoryPrivacy, Description: "A contact changed their about text"},
+
+	// Synchronization and State
+	"AppState":             {Category: EventCategorySync, Description: "An app state patch was received"},
+	"AppStateSyncComplete": {Category: EventCategorySync, Description: "An app state collection finished syncing"},
+	"HistorySync":          {Category: EventCategorySync, Description: "A chunk of message history was received from the phone"},
+	"OfflineSyncCompleted": {Category: EventCategorySync, Description: "Events queued while offline were all delivered"},
+	"OfflineSyncPreview":   {Category: EventCategorySync, Description: "Summary of the events queued while offline"},
+
+	// Calls
+	"CallOffer":        {Category: EventCategoryCalls, Description: "An incoming call was offered"},
+	"CallAccept":       {Category: EventCategoryCalls, Description: "A call was accepted"},
+	"CallTerminate":    {Category: EventCategoryCalls, Description: "A call ended"},
+	"CallOfferNotice":  {Category: EventCategoryCalls, Description: "An incoming group call was offered"},
+	"CallRelayLatency": {Category: EventCategoryCalls, Description: "Relay latency was reported for a call"},
+
+	// Presence and Activity
+	"Presence":     {Category: EventCategoryPresence, Description: "A contact came online or went offline"},
+	"ChatPresence": {Category: EventCategoryPresence, Description: "A contact started or stopped typing or recording"},
+
+	// Identity
+	"IdentityChange": {Category: EventCategoryIdentity, Description: "A contact's encryption identity changed"},
+
+	// Errors
+	"CATRefreshError": {Category: EventCategoryErrors, Description: "Refreshing the client access token failed"},
+
+	// Newsletter (Wameow Channels)
+	"NewsletterJoin":       {Category: EventCategoryNewsletter, Description: "The account joined a channel"},
+	"NewsletterLeave":      {Category: EventCategoryNewsletter, Description: "The account left a channel"},
+	"NewsletterMuteChange": {Category: EventCategoryNewsletter, Description: "A channel was muted or unmuted"},
+	"NewsletterLiveUpdate": {Category: EventCategoryNewsletter, Description: "A channel received new messages or reactions"},
+
+	// Facebook/Meta Bridge
+	"FBMessage": {Category: EventCategoryFacebook, Description: "A message was received through the Meta bridge"},
+
+	// Webhooks
+	"WebhookDisabled": {Category: EventCategoryWebhooks, Description: "A webhook was deactivated after failing for too long"},
+
+	// Special - receives all events
+	"All": {Category: EventCategorySubscription, Description: "Subscribes the webhook to every event type"},
+}
+
+// EventCatalog returns the supported event types in the order of SupportedEventTypes
+func EventCatalog() []EventTypeInfo {
+	catalog := make([]EventTypeInfo, 0, len(SupportedEventTypes))
+	for _, eventType := range SupportedEventTypes {
+		info := eventTypeDetails[eventType]
+		info.Type = eventType
+		catalog = append(catalog, info)
+	}
+	return catalog
+}
@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrCircuitOpen is returned for deliveries held back because the webhook keeps failing
+var ErrCircuitOpen = errors.New("webhook circuit is open")
+
+// CircuitConfig controls when deliveries to a failing webhook are paused and when it is deactivated
+type CircuitConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit, zero disables the breaker
+	FailureThreshold int
+	// ProbeInterval is how long deliveries stay paused before one is let through to probe the receiver
+	ProbeInterval time.Duration
+	// DisableAfter deactivates webhooks whose circuit stayed open this long, zero never deactivates
+	DisableAfter time.Duration
+}
+
+// DefaultCircuitConfig returns the default circuit breaker settings
+func DefaultCircuitConfig() *CircuitConfig {
+	return &CircuitConfig{
+		FailureThreshold: 10,
+		ProbeInterval:    time.Minute,
+		DisableAfter:     24 * time.Hour,
+	}
+}
+
+// CircuitTransition is the change of circuit state caused by a delivery outcome
+type CircuitTransition int
+
+// Circuit transitions reported by CircuitBreaker.Record
+const (
+	CircuitUnchanged CircuitTransition = iota
+	CircuitOpened                      // the failure threshold was reached, deliveries are paused
+	CircuitClosed                      // a delivery succeeded while open, deliveries resume
+	CircuitExpired                     // the circuit stayed open past DisableAfter, the webhook must be deactivated
+)
+
+// CircuitStatus describes the circuit of a webhook
+type CircuitStatus struct {
+	Open        bool
+	Failures    int
+	OpenedAt    time.Time
+	NextProbeAt time.Time
+	LastError   string
+}
+
+// circuit is the failure state of one webhook
+type circuit struct {
+	failures    int
+	open        bool
+	openedAt    time.Time
+	nextProbeAt time.Time
+	lastError   string
+}
+
+// CircuitBreaker tracks consecutive delivery failures per webhook. Once a webhook fails
+// FailureThreshold times in a row its circuit opens: deliveries are paused and one is let
+// through every ProbeInterval. A successful probe closes the circuit.
+// State is kept in memory; open circuits are stored with the webhook by the service and
+// restored after a restart, so DisableAfter counts from when the circuit first opened.
+type CircuitBreaker struct {
+	config   *CircuitConfig
+	mu       sync.Mutex
+	circuits map[uuid.UUID]*circuit
+	restored map[uuid.UUID]bool // webhooks whose stored circuit was already restored
+}
+
+// NewCircuitBreaker creates a circuit breaker
+func NewCircuitBreaker(config *CircuitConfig) *CircuitBreaker {
+	if config == nil {
+		config = DefaultCircuitConfig()
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = DefaultCircuitConfig().ProbeInterval
+	}
+
+	return &CircuitBreaker{
+		config:   config,
+		circuits: make(map[uuid.UUID]*circuit),
+		restored: make(map[uuid.UUID]bool),
+	}
+}
+
+// Restore reopens the stored circuit of a webhook the first time the webhook is seen after a
+// restart. Later calls are ignored, the state in memory is newer than the stored one.
+// The next delivery is let through to probe the receiver.
+func (b *CircuitBreaker) Restore(webhookID uuid.UUID, failures int, openedAt time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.restored[webhookID] {
+		return
+	}
+	b.restored[webhookID] = true
+
+	if _, ok := b.circuits[webhookID]; ok || openedAt.IsZero() || b.config.FailureThreshold <= 0 {
+		return
+	}
+	b.circuits[webhookID] = &circuit{
+		failures:    failures,
+		open:        true,
+		openedAt:    openedAt,
+		nextProbeAt: time.Now(),
+	}
+}
+
+// Allow reports whether a delivery to the webhook may be attempted. While the circuit is open
+// only one delivery per probe interval is allowed; the others get the time of the next probe.
+func (b *CircuitBreaker) Allow(webhookID uuid.UUID) (bool, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[webhookID]
+	if !ok || !c.open {
+		return true, time.Time{}
+	}
+
+	now := time.Now()
+	if now.Before(c.nextProbeAt) {
+		return false, c.nextProbeAt
+	}
+
+	// Hold back everything else until this probe has had time to finish
+	c.nextProbeAt = now.Add(b.config.ProbeInterval)
+	return true, time.Time{}
+}
+
+// Record updates the circuit of the webhook with the outcome of a delivery attempt.
+// It returns the resulting transition and the circuit as it was after the attempt.
+func (b *CircuitBreaker) Record(webhookID uuid.UUID, success bool, errMsg string) (CircuitTransition, CircuitStatus) {
+	if b.config.FailureThreshold <= 0 {
+		return CircuitUnchanged, CircuitStatus{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[webhookID]
+	if success {
+		if !ok {
+			return CircuitUnchanged, CircuitStatus{}
+		}
+		delete(b.circuits, webhookID)
+		if c.open {
+			return CircuitClosed, c.status()
+		}
+		return CircuitUnchanged, CircuitStatus{}
+	}
+
+	if !ok {
+		c = &circuit{}
+		b.circuits[webhookID] = c
+	}
+	c.failures++
+	c.lastError = errMsg
+
+	now := time.Now()
+	if !c.open {
+		if c.failures < b.config.FailureThreshold {
+			return CircuitUnchanged, c.status()
+		}
+		c.open = true
+		c.openedAt = now
+		c.nextProbeAt = now.Add(b.config.ProbeInterval)
+		return CircuitOpened, c.status()
+	}
+
+	if b.config.DisableAfter > 0 && now.Sub(c.openedAt) >= b.config.DisableAfter {
+		delete(b.circuits, webhookID)
+		return CircuitExpired, c.status()
+	}
+
+	c.nextProbeAt = now.Add(b.config.ProbeInterval)
+	return CircuitUnchanged, c.status()
+}
+
+// Status returns the circuit of the webhook
+func (b *CircuitBreaker) Status(webhookID uuid.UUID) CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[webhookID]
+	if !ok {
+		return CircuitStatus{}
+	}
+	return c.status()
+}
+
+// Reset closes the circuit of the webhook, after it was changed or reactivated
+func (b *CircuitBreaker) Reset(webhookID uuid.UUID) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.circuits, webhookID)
+	b.restored[webhookID] = true
+}
+
+// status returns a copy of the circuit state
+func (c *circuit) status() CircuitStatus {
+	return CircuitStatus{
+		Open:        c.open,
+		Failures:    c.failures,
+		OpenedAt:    c.openedAt,
+		NextProbeAt: c.nextProbeAt,
+		LastError:   c.lastError,
+	}
+}
@@ -0,0 +1,210 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"zpwoot/platform/logger"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	// Each outcome is a delivery attempt: true succeeded, false failed
+	tests := []struct {
+		name     string
+		outcomes []bool
+		want     CircuitTransition
+		wantOpen bool
+		failures int
+	}{
+		{name: "failures below the threshold", outcomes: []bool{false, false}, want: CircuitUnchanged, failures: 2},
+		{name: "threshold opens the circuit", outcomes: []bool{false, false, false}, want: CircuitOpened, wantOpen: true, failures: 3},
+		{name: "failing probe keeps it open", outcomes: []bool{false, false, false, false}, want: CircuitUnchanged, wantOpen: true, failures: 4},
+		{name: "successful probe closes it", outcomes: []bool{false, false, false, true}, want: CircuitClosed},
+		{name: "success resets the count", outcomes: []bool{false, false, true, false, false}, want: CircuitUnchanged, failures: 2},
+		{name: "success on a closed circuit", outcomes: []bool{true}, want: CircuitUnchanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := NewCircuitBreaker(&CircuitConfig{FailureThreshold: 3, ProbeInterval: time.Minute, DisableAfter: time.Hour})
+			id := uuid.New()
+
+			var transition CircuitTransition
+			for _, success := range tt.outcomes {
+				transition, _ = breaker.Record(id, success, "receiver answered 500")
+			}
+
+			if transition != tt.want {
+				t.Errorf("last transition = %d, want %d", transition, tt.want)
+			}
+			status := breaker.Status(id)
+			if status.Open != tt.wantOpen || status.Failures != tt.failures {
+				t.Errorf("status = open %v with %d failures, want open %v with %d", status.Open, status.Failures, tt.wantOpen, tt.failures)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerAllowsOneProbePerInterval(t *testing.T) {
+	breaker := NewCircuitBreaker(&CircuitConfig{FailureThreshold: 1, ProbeInterval: time.Minute})
+	id := uuid.New()
+
+	if allowed, _ := breaker.Allow(id); !allowed {
+		t.Fatal("closed circuit held back a delivery")
+	}
+	breaker.Record(id, false, "timeout")
+
+	allowed, retryAt := breaker.Allow(id)
+	if allowed {
+		t.Fatal("open circuit let a delivery through before the probe interval")
+	}
+	if until := time.Until(retryAt); until <= 0 || until > time.Minute {
+		t.Errorf("next probe in %s, want within the probe interval", until)
+	}
+
+	breaker.Reset(id)
+	if allowed, _ := breaker.Allow(id); !allowed {
+		t.Error("reset circuit held back a delivery")
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	breaker := NewCircuitBreaker(&CircuitConfig{FailureThreshold: 0})
+	id := uuid.New()
+
+	for i := 0; i < 100; i++ {
+		if transition, _ := breaker.Record(id, false, "timeout"); transition != CircuitUnchanged {
+			t.Fatalf("transition = %d with the breaker disabled", transition)
+		}
+	}
+	if allowed, _ := breaker.Allow(id); !allowed {
+		t.Error("disabled breaker held back a delivery")
+	}
+}
+
+func TestCircuitBreakerDisableAfter(t *testing.T) {
+	tests := []struct {
+		name         string
+		disableAfter time.Duration
+		openFor      time.Duration
+		want         CircuitTransition
+	}{
+		{name: "open longer than DisableAfter", disableAfter: 24 * time.Hour, openFor: 25 * time.Hour, want: CircuitExpired},
+		{name: "open shorter than DisableAfter", disableAfter: 24 * time.Hour, openFor: time.Hour, want: CircuitUnchanged},
+		{name: "zero never deactivates", disableAfter: 0, openFor: 1000 * time.Hour, want: CircuitUnchanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := NewCircuitBreaker(&CircuitConfig{FailureThreshold: 3, ProbeInterval: time.Minute, DisableAfter: tt.disableAfter})
+			id := uuid.New()
+			breaker.Restore(id, 3, time.Now().Add(-tt.openFor))
+
+			transition, status := breaker.Record(id, false, "connection refused")
+			if transition != tt.want {
+				t.Fatalf("transition = %d, want %d", transition, tt.want)
+			}
+			if tt.want == CircuitExpired {
+				if status.Failures != 4 || status.LastError != "connection refused" {
+					t.Errorf("expired status = %+v", status)
+				}
+				if breaker.Status(id).Open {
+					t.Error("expired circuit is still tracked")
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerRestore(t *testing.T) {
+	breaker := NewCircuitBreaker(&CircuitConfig{FailureThreshold: 3, ProbeInterval: time.Minute, DisableAfter: time.Hour})
+	id := uuid.New()
+	openedAt := time.Now().Add(-10 * time.Minute)
+
+	breaker.Restore(id, 5, openedAt)
+	status := breaker.Status(id)
+	if !status.Open || status.Failures != 5 || !status.OpenedAt.Equal(openedAt) {
+		t.Fatalf("restored status = %+v", status)
+	}
+	if allowed, _ := breaker.Allow(id); !allowed {
+		t.Error("restored circuit held back the first probe")
+	}
+
+	// The state in memory wins over the stored one from then on
+	breaker.Record(id, true, "")
+	breaker.Restore(id, 5, openedAt)
+	if breaker.Status(id).Open {
+		t.Error("stale stored circuit reopened a closed circuit")
+	}
+}
+
+// circuitRepository records the circuits saved by the service
+type circuitRepository struct {
+	mu       sync.Mutex
+	openedAt map[string]*time.Time
+	failures map[string]int
+}
+
+func (r *circuitRepository) GetByID(ctx context.Context, id string) (*WebhookConfig, error) {
+	return nil, ErrWebhookNotFound
+}
+
+func (r *circuitRepository) GetBySessionID(ctx context.Context, sessionID string) ([]*WebhookConfig, error) {
+	return nil, nil
+}
+
+func (r *circuitRepository) GetGlobalWebhooks(ctx context.Context) ([]*WebhookConfig, error) {
+	return nil, nil
+}
+
+func (r *circuitRepository) SaveCircuit(ctx context.Context, id string, failures int, openedAt *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.openedAt[id] = openedAt
+	r.failures[id] = failures
+	return nil
+}
+
+func TestServiceStoresTheCircuitAcrossRestarts(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	repo := &circuitRepository{openedAt: make(map[string]*time.Time), failures: make(map[string]int)}
+	config := &DeliveryConfig{
+		Timeout: 5 * time.Second,
+		Circuit: &CircuitConfig{FailureThreshold: 2, ProbeInterval: time.Minute, DisableAfter: time.Hour},
+	}
+	service := NewService(repo, config, logger.New("error"))
+
+	wh := &WebhookConfig{ID: uuid.New(), URL: receiver.URL, Active: true}
+	for i := 0; i < 2; i++ {
+		service.Replay(context.Background(), wh, "session-1", uuid.NewString(), "Message", []byte(`{}`))
+	}
+
+	id := wh.ID.String()
+	if repo.openedAt[id] == nil || repo.failures[id] != 2 {
+		t.Fatalf("stored circuit = opened at %v with %d failures, want an open circuit with 2", repo.openedAt[id], repo.failures[id])
+	}
+
+	// After a restart the stored circuit is open again and keeps its original opening time
+	stored := *repo.openedAt[id]
+	restarted := NewService(repo, config, logger.New("error"))
+	wh.CircuitOpenedAt = &stored
+	wh.CircuitFailures = repo.failures[id]
+	if status := restarted.CircuitStatus(wh); !status.Open || !status.OpenedAt.Equal(stored) {
+		t.Fatalf("circuit after restart = %+v", status)
+	}
+
+	restarted.ResetCircuit(context.Background(), wh.ID)
+	if repo.openedAt[id] != nil {
+		t.Error("reset did not clear the stored circuit")
+	}
+}
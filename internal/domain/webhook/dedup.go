@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// DedupKey identifies an inbound event by what it describes rather than by its event ID,
+// so the same message or receipt delivered twice by WhatsApp, after a reconnect or during
+// offline sync, is recognized. Keys are made of the event kind, the chat, the sender and the
+// message IDs, and hashed to a fixed length. Events that carry no message ID return an empty
+// key and are never deduplicated.
+func DedupKey(event *WebhookEvent) string {
+	var parts []string
+
+	switch data := event.Data.(type) {
+	case *MessageEventData:
+		parts = []string{event.Type, data.Chat, data.Sender, data.ID}
+	case *UndecryptableMessageEventData:
+		parts = []string{event.Type, data.Chat, data.Sender, data.ID}
+	case *FBMessageEventData:
+		parts = []string{event.Type, data.Chat, data.Sender, data.ID}
+	case *ReceiptEventData:
+		// Receipt and ReadReceipt share the payload; the receipt type tells delivered from read and played
+		ids := append([]string(nil), data.MessageIDs...)
+		sort.Strings(ids)
+		parts = []string{event.Type, data.Type, data.Chat, data.Sender, strings.Join(ids, ",")}
+	default:
+		return ""
+	}
+
+	if parts[len(parts)-1] == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
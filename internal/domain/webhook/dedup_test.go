@@ -0,0 +1,90 @@
+package webhook
+
+import "testing"
+
+func TestDedupKey(t *testing.T) {
+	const (
+		chat   = "5511999999999@s.whatsapp.net"
+		sender = "5511888888888@s.whatsapp.net"
+	)
+	message := &WebhookEvent{Type: "Message", Data: &MessageEventData{ID: "3EB0C767D71D", Chat: chat, Sender: sender}}
+	receipt := &WebhookEvent{Type: "Receipt", Data: &ReceiptEventData{Type: "read", Chat: chat, Sender: sender, MessageIDs: []string{"A", "B"}}}
+
+	tests := []struct {
+		name      string
+		event     *WebhookEvent
+		same      *WebhookEvent // nil when the key must differ from the key of other
+		other     *WebhookEvent
+		wantEmpty bool
+	}{
+		{
+			name:  "redelivered message",
+			event: message,
+			same:  &WebhookEvent{ID: "other-event-id", Type: "Message", Data: &MessageEventData{ID: "3EB0C767D71D", Chat: chat, Sender: sender, Text: "hi"}},
+		},
+		{
+			name:  "message in another chat",
+			event: message,
+			other: &WebhookEvent{Type: "Message", Data: &MessageEventData{ID: "3EB0C767D71D", Chat: "120363025246125888@g.us", Sender: sender}},
+		},
+		{
+			name:  "receipt with the IDs in another order",
+			event: receipt,
+			same:  &WebhookEvent{Type: "Receipt", Data: &ReceiptEventData{Type: "read", Chat: chat, Sender: sender, MessageIDs: []string{"B", "A"}}},
+		},
+		{
+			name:  "delivered and read receipts of the same messages",
+			event: receipt,
+			other: &WebhookEvent{Type: "Receipt", Data: &ReceiptEventData{Type: "delivered", Chat: chat, Sender: sender, MessageIDs: []string{"A", "B"}}},
+		},
+		{
+			name:      "receipt with no IDs",
+			event:     &WebhookEvent{Type: "Receipt", Data: &ReceiptEventData{Type: "read", Chat: chat, Sender: sender}},
+			wantEmpty: true,
+		},
+		{
+			name:      "receipt with an empty ID list",
+			event:     NewWebhookEvent("session-1", "Receipt", &ReceiptEventData{Type: "read", Chat: chat, Sender: sender}),
+			wantEmpty: true,
+		},
+		{
+			name:      "message without an ID",
+			event:     &WebhookEvent{Type: "Message", Data: &MessageEventData{Chat: chat, Sender: sender}},
+			wantEmpty: true,
+		},
+		{
+			name:      "event without a typed payload",
+			event:     &WebhookEvent{Type: "Connected", Data: map[string]interface{}{"id": "3EB0C767D71D"}},
+			wantEmpty: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := DedupKey(tt.event)
+			if tt.wantEmpty {
+				if key != "" {
+					t.Fatalf("DedupKey() = %q, want empty", key)
+				}
+				return
+			}
+			if len(key) != 64 {
+				t.Fatalf("DedupKey() = %q, want a hex SHA-256", key)
+			}
+			if tt.same != nil && DedupKey(tt.same) != key {
+				t.Errorf("keys differ for the same %s", tt.event.Type)
+			}
+			if tt.other != nil && DedupKey(tt.other) == key {
+				t.Errorf("keys match for different %s events", tt.event.Type)
+			}
+		})
+	}
+}
+
+func TestDedupKeyDoesNotReorderTheReceipt(t *testing.T) {
+	data := &ReceiptEventData{Type: "read", MessageIDs: []string{"B", "A"}}
+	DedupKey(&WebhookEvent{Type: "Receipt", Data: data})
+	if data.MessageIDs[0] != "B" {
+		t.Errorf("message IDs = %v, DedupKey sorted the payload", data.MessageIDs)
+	}
+}
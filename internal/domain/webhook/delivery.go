@@ -0,0 +1,336 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"zpwoot/platform/logger"
+)
+
+// Header names sent with every webhook delivery
+const (
+	HeaderEvent     = "X-Zpwoot-Event"
+	HeaderEventID   = "X-Zpwoot-Event-Id"
+	HeaderTimestamp = "X-Zpwoot-Timestamp"
+	HeaderSignature = "X-Zpwoot-Signature"
+)
+
+// Delivery log statuses
+const (
+	DeliveryStatusDelivered  = "delivered"
+	DeliveryStatusRetrying   = "retrying"
+	DeliveryStatusDeadLetter = "dead_letter"
+)
+
+// maxResponseBodySize limits how much of the receiver response is kept
+const maxResponseBodySize = 4096
+
+// DeliveryConfig holds settings for outgoing webhook requests
+type DeliveryConfig struct {
+	Timeout        time.Duration
+	MaxConcurrency int
+	UserAgent      string
+	// Secret signs deliveries for webhooks that have no secret of their own
+	Secret string
+	// Sinks publish to webhook URLs that are not http or https, picked by URL scheme
+	Sinks []Sink
+	// Circuit pauses and eventually deactivates webhooks that keep failing
+	Circuit *CircuitConfig
+}
+
+// DefaultDeliveryConfig returns the default webhook delivery settings
+func DefaultDeliveryConfig() *DeliveryConfig {
+	return &DeliveryConfig{
+		Timeout:        10 * time.Second,
+		MaxConcurrency: 20,
+		UserAgent:      "zpwoot-webhook/1.0",
+	}
+}
+
+// DeliveryResult represents the outcome of a single webhook delivery
+type DeliveryResult struct {
+	WebhookID      string
+	EventID        string
+	EventType      string
+	URL            string
+	Payload        []byte
+	RequestHeaders http.Header
+	StatusCode     int
+	ResponseBody   string
+	Latency        time.Duration
+	Success        bool
+	TLSError       string
+	Error          error
+	// RetryAt is set when the delivery was held back by an open circuit, to when it may be attempted again
+	RetryAt time.Time
+}
+
+// Deliverer sends webhook events to receivers over HTTP
+type Deliverer struct {
+	client     *http.Client
+	config     *DeliveryConfig
+	sinks      map[string]Sink // URL scheme -> sink
+	slots      chan struct{}
+	tlsClients sync.Map // client certificate fingerprint -> *http.Client
+	tokens     sync.Map // OAuth2 credentials fingerprint -> *cachedToken
+	logger     *logger.Logger
+}
+
+// NewDeliverer creates a new webhook deliverer
+func NewDeliverer(config *DeliveryConfig, logger *logger.Logger) *Deliverer {
+	if config == nil {
+		config = DefaultDeliveryConfig()
+	}
+
+	maxConcurrency := config.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = DefaultDeliveryConfig().MaxConcurrency
+	}
+
+	sinks := make(map[string]Sink)
+	for _, sink := range config.Sinks {
+		for _, scheme := range sink.Schemes() {
+			sinks[scheme] = sink
+		}
+	}
+
+	return &Deliverer{
+		client: &http.Client{
+			Timeout: config.Timeout,
+		},
+		config: config,
+		sinks:  sinks,
+		slots:  make(chan struct{}, maxConcurrency),
+		logger: logger,
+	}
+}
+
+// ValidateTarget checks that a webhook URL is an http or https URL, or targets a registered sink
+func (d *Deliverer) ValidateTarget(target *url.URL) error {
+	if target.Scheme == "http" || target.Scheme == "https" {
+		if target.Host == "" {
+			return fmt.Errorf("%w: must be an absolute http or https URL", ErrInvalidWebhookURL)
+		}
+		return nil
+	}
+
+	sink, ok := d.sinks[target.Scheme]
+	if !ok {
+		return fmt.Errorf("%w: unsupported scheme %q, expected one of %s", ErrInvalidWebhookURL, target.Scheme, strings.Join(d.schemes(), ", "))
+	}
+
+	if err := sink.Validate(target); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhookURL, err)
+	}
+	return nil
+}
+
+// schemes returns the webhook URL schemes the deliverer can send to
+func (d *Deliverer) schemes() []string {
+	schemes := []string{"http", "https"}
+	for scheme := range d.sinks {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes[2:])
+	return schemes
+}
+
+// Deliver posts an event to the webhook URL, waiting for a free delivery slot first.
+// The body is the JSON event, or the event rendered by the webhook template.
+func (d *Deliverer) Deliver(ctx context.Context, wh *WebhookConfig, event *WebhookEvent) *DeliveryResult {
+	rendered, err := wh.RenderEvent(event)
+	if err != nil {
+		return &DeliveryResult{
+			WebhookID: wh.ID.String(),
+			EventID:   event.ID,
+			EventType: event.Type,
+			URL:       wh.URL,
+			Error:     err,
+		}
+	}
+
+	return d.DeliverPayload(ctx, wh, event.SessionID, event.ID, event.Type, rendered.Body)
+}
+
+// DeliverPayload posts an already encoded event to the webhook URL, with the content type
+// and headers of the webhook template and the credentials of the webhook auth. It is used for retries so the receiver gets the same
+// body as the first attempt.
+func (d *Deliverer) DeliverPayload(ctx context.Context, wh *WebhookConfig, sessionID, eventID, eventType string, payload []byte) *DeliveryResult {
+	result := &DeliveryResult{
+		WebhookID: wh.ID.String(),
+		EventID:   eventID,
+		EventType: eventType,
+		URL:       wh.URL,
+		Payload:   payload,
+	}
+
+	// Acquire a delivery slot to limit concurrent outgoing requests
+	select {
+	case d.slots <- struct{}{}:
+		defer func() { <-d.slots }()
+	case <-ctx.Done():
+		result.Error = fmt.Errorf("%w: %v", ErrWebhookDeliveryFailed, ctx.Err())
+		return result
+	}
+
+	if target, err := url.Parse(wh.URL); err == nil {
+		if sink, ok := d.sinks[target.Scheme]; ok {
+			d.publish(ctx, sink, target, sessionID, wh, payload, result)
+			return result
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(payload))
+	if err != nil {
+		result.Error = fmt.Errorf("failed to create webhook request: %w", err)
+		return result
+	}
+
+	client, err := d.clientFor(wh)
+	if err != nil {
+		result.Error = fmt.Errorf("%w: %v", ErrWebhookDeliveryFailed, err)
+		return result
+	}
+
+	req.Header.Set("User-Agent", d.config.UserAgent)
+	wh.Template.ApplyHeaders(req.Header)
+	if err := d.authorize(ctx, client, wh, req.Header); err != nil {
+		result.Error = fmt.Errorf("%w: %v", ErrWebhookDeliveryFailed, err)
+		return result
+	}
+	req.Header.Set("Content-Type", wh.Template.contentType())
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderEventID, eventID)
+
+	// Sign timestamp + body so receivers can verify authenticity and reject replays
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if secret := d.signingSecret(wh); secret != "" {
+		req.Header.Set(HeaderSignature, SignPayload(secret, timestamp, payload))
+	}
+	// Never keep credentials in the delivery log
+	result.RequestHeaders = wh.Auth.Redact(req.Header)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.TLSError = tlsErrorMessage(err)
+		result.Error = fmt.Errorf("%w: %v", ErrWebhookDeliveryFailed, err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+	result.StatusCode = resp.StatusCode
+	result.ResponseBody = string(body)
+	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+
+	if !result.Success {
+		result.Error = fmt.Errorf("%w: receiver responded with HTTP %d", ErrWebhookDeliveryFailed, resp.StatusCode)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		// The token may have been revoked before it expired, get a new one for the next attempt
+		d.invalidateToken(wh)
+	}
+
+	return result
+}
+
+// publish sends the event to a broker sink and records the outcome in result
+func (d *Deliverer) publish(ctx context.Context, sink Sink, target *url.URL, sessionID string, wh *WebhookConfig, payload []byte, result *DeliveryResult) {
+	// Never keep broker credentials in the delivery log
+	result.URL = target.Redacted()
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	msg := &SinkMessage{
+		SessionID:   sessionID,
+		EventID:     result.EventID,
+		EventType:   result.EventType,
+		ContentType: wh.Template.contentType(),
+		Headers:     make(map[string]string),
+		Payload:     payload,
+	}
+	if wh.Template != nil {
+		for name, value := range wh.Template.Headers {
+			msg.Headers[name] = value
+		}
+	}
+	msg.Headers[HeaderEvent] = result.EventType
+	msg.Headers[HeaderEventID] = result.EventID
+	msg.Headers[HeaderTimestamp] = timestamp
+	if secret := d.signingSecret(wh); secret != "" {
+		msg.Headers[HeaderSignature] = SignPayload(secret, timestamp, payload)
+	}
+
+	result.RequestHeaders = make(http.Header)
+	for name, value := range msg.Headers {
+		result.RequestHeaders.Set(name, value)
+	}
+
+	if d.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.config.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	confirmation, err := sink.Publish(ctx, target, msg)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Error = fmt.Errorf("%w: %v", ErrWebhookDeliveryFailed, err)
+		return
+	}
+
+	result.ResponseBody = confirmation
+	result.Success = true
+}
+
+// signingSecret returns the webhook secret, falling back to the global secret
+func (d *Deliverer) signingSecret(wh *WebhookConfig) string {
+	if wh.Secret != "" {
+		return wh.Secret
+	}
+	return d.config.Secret
+}
+
+// tlsErrorMessage describes the TLS failure behind a request error, or returns an empty string
+func tlsErrorMessage(err error) string {
+	var (
+		verifyErr   *tls.CertificateVerificationError
+		unknownErr  x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+		invalidErr  x509.CertificateInvalidError
+		recordErr   tls.RecordHeaderError
+		alertErr    tls.AlertError
+	)
+
+	switch {
+	case errors.As(err, &unknownErr):
+		return "certificate signed by unknown authority: " + unknownErr.Error()
+	case errors.As(err, &hostnameErr):
+		return "certificate hostname mismatch: " + hostnameErr.Error()
+	case errors.As(err, &invalidErr):
+		return "invalid certificate: " + invalidErr.Error()
+	case errors.As(err, &verifyErr):
+		return "certificate verification failed: " + verifyErr.Error()
+	case errors.As(err, &recordErr):
+		return "server did not respond with TLS: " + recordErr.Error()
+	case errors.As(err, &alertErr):
+		return "TLS handshake rejected by server: " + alertErr.Error()
+	}
+
+	return ""
+}
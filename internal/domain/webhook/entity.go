@@ -1,7 +1,10 @@
 package webhook
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,43 +12,76 @@ import (
 
 // WebhookConfig represents webhook configuration
 type WebhookConfig struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	SessionID *string   `json:"session_id,omitempty" db:"session_id"` // null for global webhooks
-	URL       string    `json:"url" db:"url"`
-	Secret    string    `json:"secret,omitempty" db:"secret"`
-	Events    []string  `json:"events" db:"events"`
-	Active    bool      `json:"active" db:"active"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        uuid.UUID        `json:"id" db:"id"`
+	SessionID *string          `json:"session_id,omitempty" db:"session_id"` // null for global webhooks
+	URL       string           `json:"url" db:"url"`
+	Secret    string           `json:"secret,omitempty" db:"secret"`
+	Events    []string         `json:"events" db:"events"`
+	Filters   *WebhookFilters  `json:"filters,omitempty" db:"filters"`
+	Template  *WebhookTemplate `json:"template,omitempty" db:"template"`
+	Auth      *WebhookAuth     `json:"-" db:"auth"` // never serialized, stored encrypted
+	Active    bool             `json:"active" db:"active"`
+	CreatedAt time.Time        `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at" db:"updated_at"`
+
+	// AuthError is set when the stored credentials could not be decrypted, for example after
+	// WEBHOOK_ENCRYPTION_KEY changed. Deliveries fail with it until the auth is set again.
+	AuthError string `json:"-" db:"-"`
+	// EncryptedAuth keeps the stored ciphertext of credentials that could not be decrypted,
+	// so saving the webhook writes it back unchanged until the auth is set again
+	EncryptedAuth string `json:"-" db:"-"`
+	// CircuitOpenedAt and CircuitFailures are the stored open circuit of the webhook, nil when
+	// it is closed. The service restores them after a restart.
+	CircuitOpenedAt *time.Time `json:"-" db:"-"`
+	CircuitFailures int        `json:"-" db:"-"`
 }
 
 // Domain errors
 var (
-	ErrWebhookNotFound       = errors.New("webhook not found")
-	ErrWebhookAlreadyExists  = errors.New("webhook already exists")
-	ErrInvalidWebhookURL     = errors.New("invalid webhook URL")
-	ErrWebhookDeliveryFailed = errors.New("webhook delivery failed")
+	ErrWebhookNotFound        = errors.New("webhook not found")
+	ErrWebhookAlreadyExists   = errors.New("webhook already exists")
+	ErrInvalidWebhookURL      = errors.New("invalid webhook URL")
+	ErrWebhookDeliveryFailed  = errors.New("webhook delivery failed")
+	ErrDeliveryNotFound       = errors.New("webhook delivery not found")
+	ErrUnsupportedEventType   = errors.New("unsupported event type")
+	ErrInvalidWebhookEvents   = errors.New("invalid webhook events")
+	ErrInvalidWebhookFilters  = errors.New("invalid webhook filters")
+	ErrInvalidWebhookTemplate = errors.New("invalid webhook template")
+	ErrInvalidWebhookAuth     = errors.New("invalid webhook auth")
+	ErrInvalidTestData        = errors.New("invalid test data")
+	ErrWebhookManagedByEnv    = errors.New("webhook is managed by GLOBAL_WEBHOOK_URL")
+	ErrWebhookInactive        = errors.New("webhook is inactive")
 )
 
+// EnvGlobalWebhookID is the fixed ID of the global webhook configured through GLOBAL_WEBHOOK_URL
+var EnvGlobalWebhookID = uuid.NewSHA1(uuid.NameSpaceURL, []byte("zpwoot:GLOBAL_WEBHOOK_URL"))
+
 // SetConfigRequest represents a request to create a webhook
 type SetConfigRequest struct {
-	SessionID *string  `json:"session_id,omitempty" validate:"omitempty,uuid"`
-	URL       string   `json:"url" validate:"required,url"`
-	Secret    string   `json:"secret,omitempty"`
-	Events    []string `json:"events" validate:"required,min=1"`
+	SessionID *string          `json:"session_id,omitempty" validate:"omitempty,uuid"`
+	URL       string           `json:"url" validate:"required,url"`
+	Secret    string           `json:"secret,omitempty"`
+	Events    []string         `json:"events" validate:"required,min=1"`
+	Filters   *WebhookFilters  `json:"filters,omitempty"`
+	Template  *WebhookTemplate `json:"template,omitempty"`
+	Auth      *WebhookAuth     `json:"auth,omitempty"`
 }
 
 // UpdateWebhookRequest represents a request to update a webhook
 type UpdateWebhookRequest struct {
-	URL    *string  `json:"url,omitempty" validate:"omitempty,url"`
-	Secret *string  `json:"secret,omitempty"`
-	Events []string `json:"events,omitempty" validate:"omitempty,min=1"`
-	Active *bool    `json:"active,omitempty"`
+	URL      *string          `json:"url,omitempty" validate:"omitempty,url"`
+	Secret   *string          `json:"secret,omitempty"`
+	Events   []string         `json:"events,omitempty" validate:"omitempty,min=1"`
+	Filters  *WebhookFilters  `json:"filters,omitempty"`  // an empty object clears the filters
+	Template *WebhookTemplate `json:"template,omitempty"` // an empty object restores the standard JSON body
+	Auth     *WebhookAuth     `json:"auth,omitempty"`     // replaces the whole block, an empty object removes it
+	Active   *bool            `json:"active,omitempty"`
 }
 
 // ListWebhooksRequest represents filters for listing webhooks
 type ListWebhooksRequest struct {
 	SessionID *string `json:"session_id,omitempty" query:"session_id"`
+	Global    bool    `json:"global,omitempty" query:"global"` // only webhooks without session
 	Active    *bool   `json:"active,omitempty" query:"active"`
 	Limit     int     `json:"limit,omitempty" query:"limit" validate:"omitempty,min=1,max=100"`
 	Offset    int     `json:"offset,omitempty" query:"offset" validate:"omitempty,min=0"`
@@ -53,11 +89,15 @@ type ListWebhooksRequest struct {
 
 // WebhookEvent represents an event to be sent to webhooks
 type WebhookEvent struct {
-	ID        string                 `json:"id"`
-	SessionID string                 `json:"session_id"`
-	Type      string                 `json:"type"`
-	Timestamp time.Time              `json:"timestamp"`
-	Data      map[string]interface{} `json:"data"`
+	ID          string    `json:"id"`
+	SessionID   string    `json:"session_id"`
+	SessionName string    `json:"session_name,omitempty"`
+	Type        string    `json:"type"`
+	Timestamp   time.Time `json:"timestamp"`
+	Test        bool      `json:"test,omitempty"`
+	// IsOfflineSync is set on events the server replayed while the session caught up on what it missed offline
+	IsOfflineSync bool        `json:"is_offline_sync"`
+	Data          interface{} `json:"data"` // typed payload such as *MessageEventData
 }
 
 // List of supported event types
@@ -90,6 +130,7 @@ var SupportedEventTypes = []string{
 	"PairSuccess",
 	"PairError",
 	"QR",
+	"QRTimeout",
 	"QRScannedWithoutMultidevice",
 
 	// Privacy and Settings
@@ -130,6 +171,9 @@ var SupportedEventTypes = []string{
 	// Facebook/Meta Bridge
 	"FBMessage",
 
+	// Webhooks
+	"WebhookDisabled",
+
 	// Special - receives all events
 	"All",
 }
@@ -179,6 +223,16 @@ func (w *WebhookConfig) IsGlobal() bool {
 	return w.SessionID == nil
 }
 
+// IsEnvManaged returns true if the webhook is the one configured through GLOBAL_WEBHOOK_URL
+func (w *WebhookConfig) IsEnvManaged() bool {
+	return w.ID == EnvGlobalWebhookID
+}
+
+// Accepts reports whether the webhook subscribes to the event type and the event passes its filters
+func (w *WebhookConfig) Accepts(event *WebhookEvent) bool {
+	return w.HasEvent(event.Type) && w.Filters.Matches(event)
+}
+
 // HasEvent checks if the webhook is configured to receive a specific event type
 func (w *WebhookConfig) HasEvent(eventType string) bool {
 	for _, event := range w.Events {
@@ -200,14 +254,39 @@ func (w *WebhookConfig) Update(req *UpdateWebhookRequest) {
 	if req.Events != nil {
 		w.Events = req.Events
 	}
+	if req.Filters != nil {
+		w.Filters = req.Filters
+		if req.Filters.IsEmpty() {
+			w.Filters = nil
+		}
+	}
+	if req.Template != nil {
+		w.Template = req.Template
+		if req.Template.IsEmpty() {
+			w.Template = nil
+		}
+	}
+	if req.Auth != nil {
+		w.Auth = req.Auth
+		w.AuthError = ""
+		w.EncryptedAuth = ""
+		if req.Auth.IsEmpty() {
+			w.Auth = nil
+		}
+	}
 	if req.Active != nil {
 		w.Active = *req.Active
 	}
 	w.UpdatedAt = time.Now()
 }
 
-// NewWebhookEvent creates a new webhook event
-func NewWebhookEvent(sessionID, eventType string, data map[string]interface{}) *WebhookEvent {
+// NewWebhookEvent creates a new webhook event, stamping typed payloads with the schema version
+func NewWebhookEvent(sessionID, eventType string, data interface{}) *WebhookEvent {
+	if payload, ok := data.(versioned); ok {
+		payload.setSchemaVersion(PayloadSchemaVersion)
+	}
+	fillRequiredCollections(reflect.ValueOf(data))
+
 	return &WebhookEvent{
 		ID:        uuid.New().String(),
 		SessionID: sessionID,
@@ -216,3 +295,33 @@ func NewWebhookEvent(sessionID, eventType string, data map[string]interface{}) *
 		Data:      data,
 	}
 }
+
+// DecodeWebhookEvent decodes an event encoded with json.Marshal. The payload of typed
+// event types is restored to its struct, so filters see the same event that was encoded.
+func DecodeWebhookEvent(encoded []byte) (*WebhookEvent, error) {
+	var raw struct {
+		WebhookEvent
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(encoded, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook event: %w", err)
+	}
+
+	event := raw.WebhookEvent
+	if len(raw.Data) == 0 {
+		return &event, nil
+	}
+
+	var data interface{}
+	if _, typed := eventPayloads[event.Type]; typed {
+		data = SamplePayload(event.Type)
+		if err := json.Unmarshal(raw.Data, data); err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %w", event.Type, err)
+		}
+	} else if err := json.Unmarshal(raw.Data, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode %s payload: %w", event.Type, err)
+	}
+	event.Data = data
+
+	return &event, nil
+}
@@ -0,0 +1,235 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Chat type filter values
+const (
+	ChatTypeGroup  = "group"
+	ChatTypeDirect = "direct"
+)
+
+// Filter modes for fromMe and status broadcasts
+const (
+	FilterInclude = "include"
+	FilterExclude = "exclude"
+	FilterOnly    = "only"
+)
+
+// StatusBroadcastJID is the chat used by WhatsApp for status updates
+const StatusBroadcastJID = "status@broadcast"
+
+// Message content types reported in the message_type field of Message events
+const (
+	MessageTypeText         = "text"
+	MessageTypeImage        = "image"
+	MessageTypeVideo        = "video"
+	MessageTypeAudio        = "audio"
+	MessageTypeDocument     = "document"
+	MessageTypeSticker      = "sticker"
+	MessageTypeLocation     = "location"
+	MessageTypeLiveLocation = "live_location"
+	MessageTypeContact      = "contact"
+	MessageTypeReaction     = "reaction"
+	MessageTypePoll         = "poll"
+	MessageTypePollVote     = "poll_vote"
+	MessageTypeEdit         = "edit"
+	MessageTypeRevoke       = "revoke"
+	MessageTypeButtonReply  = "button_reply"
+	MessageTypeListReply    = "list_reply"
+	MessageTypeGroupInvite  = "group_invite"
+	MessageTypeProtocol     = "protocol"
+	MessageTypeUnknown      = "unknown"
+)
+
+// SupportedMessageTypes lists the values accepted in a message type filter
+var SupportedMessageTypes = []string{
+	MessageTypeText,
+	MessageTypeImage,
+	MessageTypeVideo,
+	MessageTypeAudio,
+	MessageTypeDocument,
+	MessageTypeSticker,
+	MessageTypeLocation,
+	MessageTypeLiveLocation,
+	MessageTypeContact,
+	MessageTypeReaction,
+	MessageTypePoll,
+	MessageTypePollVote,
+	MessageTypeEdit,
+	MessageTypeRevoke,
+	MessageTypeButtonReply,
+	MessageTypeListReply,
+	MessageTypeGroupInvite,
+	MessageTypeProtocol,
+	MessageTypeUnknown,
+}
+
+// WebhookFilters narrows the events delivered to a webhook by their content.
+// Each filter only applies to events that carry the field it looks at, so
+// connection events are never dropped by chat or message type filters.
+type WebhookFilters struct {
+	AllowChats      []string `json:"allow_chats,omitempty"`
+	DenyChats       []string `json:"deny_chats,omitempty"`
+	AllowSenders    []string `json:"allow_senders,omitempty"`
+	DenySenders     []string `json:"deny_senders,omitempty"`
+	ChatType        string   `json:"chat_type,omitempty"`        // group, direct or empty for both
+	FromMe          string   `json:"from_me,omitempty"`          // include (default), exclude or only
+	MessageTypes    []string `json:"message_types,omitempty"`    // empty means every type
+	StatusBroadcast string   `json:"status_broadcast,omitempty"` // include (default), exclude or only
+}
+
+// IsEmpty reports whether no filter is set
+func (f *WebhookFilters) IsEmpty() bool {
+	return f == nil ||
+		len(f.AllowChats) == 0 && len(f.DenyChats) == 0 &&
+			len(f.AllowSenders) == 0 && len(f.DenySenders) == 0 &&
+			f.ChatType == "" && f.FromMe == "" && len(f.MessageTypes) == 0 && f.StatusBroadcast == ""
+}
+
+// Validate checks that every filter value is supported
+func (f *WebhookFilters) Validate() error {
+	if f == nil {
+		return nil
+	}
+
+	switch f.ChatType {
+	case "", ChatTypeGroup, ChatTypeDirect:
+	default:
+		return fmt.Errorf("%w: chat_type must be %q or %q", ErrInvalidWebhookFilters, ChatTypeGroup, ChatTypeDirect)
+	}
+
+	if !isFilterMode(f.FromMe) {
+		return fmt.Errorf("%w: from_me must be %q, %q or %q", ErrInvalidWebhookFilters, FilterInclude, FilterExclude, FilterOnly)
+	}
+
+	if !isFilterMode(f.StatusBroadcast) {
+		return fmt.Errorf("%w: status_broadcast must be %q, %q or %q", ErrInvalidWebhookFilters, FilterInclude, FilterExclude, FilterOnly)
+	}
+
+	for _, messageType := range f.MessageTypes {
+		if !isSupportedMessageType(messageType) {
+			return fmt.Errorf("%w: unsupported message type %q", ErrInvalidWebhookFilters, messageType)
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether the event passes every filter
+func (f *WebhookFilters) Matches(event *WebhookEvent) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	fields := filterFieldsOf(event.Data)
+
+	if fields.chat != "" {
+		if len(f.AllowChats) > 0 && !matchesJID(f.AllowChats, fields.chat) {
+			return false
+		}
+		if matchesJID(f.DenyChats, fields.chat) {
+			return false
+		}
+		if !matchesMode(f.StatusBroadcast, fields.chat == StatusBroadcastJID) {
+			return false
+		}
+		if f.ChatType != "" && (f.ChatType == ChatTypeGroup) != fields.isGroupChat() {
+			return false
+		}
+	}
+
+	if fields.sender != "" {
+		if len(f.AllowSenders) > 0 && !matchesJID(f.AllowSenders, fields.sender) {
+			return false
+		}
+		if matchesJID(f.DenySenders, fields.sender) {
+			return false
+		}
+	}
+
+	if fields.fromMe != nil && !matchesMode(f.FromMe, *fields.fromMe) {
+		return false
+	}
+
+	if fields.messageType != "" && len(f.MessageTypes) > 0 && !containsString(f.MessageTypes, fields.messageType) {
+		return false
+	}
+
+	return true
+}
+
+// isFilterMode reports whether the value is a valid include/exclude/only mode
+func isFilterMode(mode string) bool {
+	switch mode {
+	case "", FilterInclude, FilterExclude, FilterOnly:
+		return true
+	}
+	return false
+}
+
+// matchesMode applies an include/exclude/only mode to a boolean property of the event
+func matchesMode(mode string, value bool) bool {
+	switch mode {
+	case FilterExclude:
+		return !value
+	case FilterOnly:
+		return value
+	}
+	return true
+}
+
+// isGroupChat uses the is_group field when present and falls back to the JID server
+func (f filterFields) isGroupChat() bool {
+	if f.isGroup != nil {
+		return *f.isGroup
+	}
+	return strings.HasSuffix(f.chat, "@g.us")
+}
+
+// matchesJID reports whether the JID is in the list. Entries may be full JIDs
+// or bare phone numbers, and agent and device suffixes are ignored.
+func matchesJID(list []string, jid string) bool {
+	if len(list) == 0 {
+		return false
+	}
+
+	user, server := splitJID(jid)
+	for _, entry := range list {
+		entryUser, entryServer := splitJID(entry)
+		if entryUser == user && (entryServer == "" || entryServer == server) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitJID returns the user and server parts of a JID, dropping the agent and device
+// suffixes of user.agent:device@server
+func splitJID(jid string) (string, string) {
+	user, server := jid, ""
+	if at := strings.IndexByte(jid, '@'); at >= 0 {
+		user, server = jid[:at], jid[at+1:]
+	}
+	if suffix := strings.IndexAny(user, ".:"); suffix >= 0 {
+		user = user[:suffix]
+	}
+	return user, server
+}
+
+// isSupportedMessageType reports whether the value is a known message content type
+func isSupportedMessageType(messageType string) bool {
+	return containsString(SupportedMessageTypes, messageType)
+}
+
+// containsString reports whether the slice contains the value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import "testing"
+
+func TestSplitJID(t *testing.T) {
+	tests := []struct {
+		jid        string
+		wantUser   string
+		wantServer string
+	}{
+		{jid: "5511999999999@s.whatsapp.net", wantUser: "5511999999999", wantServer: "s.whatsapp.net"},
+		{jid: "5511999999999:12@s.whatsapp.net", wantUser: "5511999999999", wantServer: "s.whatsapp.net"},
+		{jid: "5511999999999.1:12@s.whatsapp.net", wantUser: "5511999999999", wantServer: "s.whatsapp.net"},
+		{jid: "123456789012345:3@lid", wantUser: "123456789012345", wantServer: "lid"},
+		{jid: "120363025246125888@g.us", wantUser: "120363025246125888", wantServer: "g.us"},
+		{jid: "5511999999999", wantUser: "5511999999999", wantServer: ""},
+		{jid: "status@broadcast", wantUser: "status", wantServer: "broadcast"},
+		{jid: "", wantUser: "", wantServer: ""},
+	}
+
+	for _, tt := range tests {
+		user, server := splitJID(tt.jid)
+		if user != tt.wantUser || server != tt.wantServer {
+			t.Errorf("splitJID(%q) = %q, %q, want %q, %q", tt.jid, user, server, tt.wantUser, tt.wantServer)
+		}
+	}
+}
+
+func TestWebhookFiltersMatches(t *testing.T) {
+	const (
+		contact = "5511999999999@s.whatsapp.net"
+		group   = "120363025246125888@g.us"
+	)
+	message := func(chat, sender string, fromMe bool, messageType string) *WebhookEvent {
+		return &WebhookEvent{Type: "Message", Data: &MessageEventData{
+			Chat:        chat,
+			Sender:      sender,
+			FromMe:      fromMe,
+			IsGroup:     chat == group,
+			MessageType: messageType,
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		filters *WebhookFilters
+		event   *WebhookEvent
+		want    bool
+	}{
+		{name: "no filters", filters: nil, event: message(contact, contact, false, MessageTypeText), want: true},
+		{name: "allowed chat", filters: &WebhookFilters{AllowChats: []string{contact}}, event: message(contact, contact, false, MessageTypeText), want: true},
+		{name: "chat not allowed", filters: &WebhookFilters{AllowChats: []string{contact}}, event: message(group, contact, false, MessageTypeText), want: false},
+		{name: "allowed chat as a bare number", filters: &WebhookFilters{AllowChats: []string{"5511999999999"}}, event: message(contact, contact, false, MessageTypeText), want: true},
+		{name: "denied chat", filters: &WebhookFilters{DenyChats: []string{group}}, event: message(group, contact, false, MessageTypeText), want: false},
+		{name: "denied sender with device suffix", filters: &WebhookFilters{DenySenders: []string{contact}}, event: message(group, "5511999999999:12@s.whatsapp.net", false, MessageTypeText), want: false},
+		{name: "allowed sender with agent suffix", filters: &WebhookFilters{AllowSenders: []string{"5511999999999"}}, event: message(group, "5511999999999.1:12@s.whatsapp.net", false, MessageTypeText), want: true},
+		{name: "same user on another server", filters: &WebhookFilters{AllowSenders: []string{contact}}, event: message(group, "5511999999999@lid", false, MessageTypeText), want: false},
+		{name: "groups only", filters: &WebhookFilters{ChatType: ChatTypeGroup}, event: message(contact, contact, false, MessageTypeText), want: false},
+		{name: "direct only", filters: &WebhookFilters{ChatType: ChatTypeDirect}, event: message(contact, contact, false, MessageTypeText), want: true},
+		{name: "exclude own messages", filters: &WebhookFilters{FromMe: FilterExclude}, event: message(contact, contact, true, MessageTypeText), want: false},
+		{name: "only own messages", filters: &WebhookFilters{FromMe: FilterOnly}, event: message(contact, contact, false, MessageTypeText), want: false},
+		{name: "exclude status broadcasts", filters: &WebhookFilters{StatusBroadcast: FilterExclude}, event: message(StatusBroadcastJID, contact, false, MessageTypeImage), want: false},
+		{name: "message type allowed", filters: &WebhookFilters{MessageTypes: []string{MessageTypeImage}}, event: message(contact, contact, false, MessageTypeImage), want: true},
+		{name: "message type filtered out", filters: &WebhookFilters{MessageTypes: []string{MessageTypeImage}}, event: message(contact, contact, false, MessageTypeText), want: false},
+		{
+			name:    "receipt without message IDs follows the chat filters",
+			filters: &WebhookFilters{DenyChats: []string{contact}},
+			event:   &WebhookEvent{Type: "Receipt", Data: &ReceiptEventData{Chat: contact, Sender: contact}},
+			want:    false,
+		},
+		{
+			name:    "receipt is not dropped by message type filters",
+			filters: &WebhookFilters{MessageTypes: []string{MessageTypeImage}, FromMe: FilterOnly},
+			event:   &WebhookEvent{Type: "Receipt", Data: &ReceiptEventData{Chat: contact, Sender: contact}},
+			want:    true,
+		},
+		{
+			name:    "connection events pass chat filters",
+			filters: &WebhookFilters{AllowChats: []string{group}, ChatType: ChatTypeGroup},
+			event:   &WebhookEvent{Type: "Connected", Data: map[string]interface{}{"status": "connected"}},
+			want:    true,
+		},
+		{
+			name:    "untyped payload uses its chat field",
+			filters: &WebhookFilters{AllowChats: []string{group}},
+			event:   &WebhookEvent{Type: "Custom", Data: map[string]interface{}{"chat": contact}},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filters.Matches(tt.event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
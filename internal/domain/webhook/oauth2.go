@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin renews access tokens before they expire, so a delivery never carries a token
+// that expires in flight
+const tokenExpiryMargin = 30 * time.Second
+
+// defaultTokenLifetime is used when the token endpoint does not say when the token expires
+const defaultTokenLifetime = time.Hour
+
+// maxTokenResponseSize limits how much of the token endpoint response is read
+const maxTokenResponseSize = 64 << 10
+
+// cachedToken is an OAuth2 access token shared by the deliveries of the webhooks using the same
+// credentials. The mutex makes concurrent deliveries wait for a single token request.
+type cachedToken struct {
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// tokenResponse is the token endpoint response of RFC 6749 section 5.1
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// authorize sets the credentials of the webhook on a request, fetching an OAuth2 token if needed
+func (d *Deliverer) authorize(ctx context.Context, client *http.Client, wh *WebhookConfig, header http.Header) error {
+	if wh.AuthError != "" {
+		return fmt.Errorf("webhook auth is unavailable: %s", wh.AuthError)
+	}
+	if wh.Auth == nil {
+		return nil
+	}
+
+	wh.Auth.Apply(header)
+
+	if wh.Auth.Type == AuthTypeOAuth2 {
+		token, err := d.accessToken(ctx, client, wh.Auth.OAuth2)
+		if err != nil {
+			return err
+		}
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	return nil
+}
+
+// clientFor returns the HTTP client presenting the client certificate of the webhook.
+// Clients are cached by certificate so connections are reused across deliveries.
+func (d *Deliverer) clientFor(wh *WebhookConfig) (*http.Client, error) {
+	if wh.Auth == nil || wh.Auth.TLS == nil {
+		return d.client, nil
+	}
+
+	key := wh.Auth.TLS.cacheKey()
+	if cached, ok := d.tlsClients.Load(key); ok {
+		return cached.(*http.Client), nil
+	}
+
+	tlsConfig, err := wh.Auth.TLS.config()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhookAuth, err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client := &http.Client{
+		Timeout:   d.config.Timeout,
+		Transport: transport,
+	}
+
+	actual, _ := d.tlsClients.LoadOrStore(key, client)
+	return actual.(*http.Client), nil
+}
+
+// accessToken returns a cached access token for the credentials, requesting a new one when it
+// is missing or about to expire
+func (d *Deliverer) accessToken(ctx context.Context, client *http.Client, credentials *OAuth2Credentials) (string, error) {
+	entry, _ := d.tokens.LoadOrStore(credentials.cacheKey(), &cachedToken{})
+	token := entry.(*cachedToken)
+
+	token.mu.Lock()
+	defer token.mu.Unlock()
+
+	if token.accessToken != "" && time.Now().Before(token.expiresAt) {
+		return token.accessToken, nil
+	}
+
+	response, err := d.requestToken(ctx, client, credentials)
+	if err != nil {
+		return "", err
+	}
+
+	lifetime := defaultTokenLifetime
+	if response.ExpiresIn > 0 {
+		lifetime = time.Duration(response.ExpiresIn) * time.Second
+	}
+	if lifetime > 2*tokenExpiryMargin {
+		lifetime -= tokenExpiryMargin
+	}
+
+	token.accessToken = response.AccessToken
+	token.expiresAt = time.Now().Add(lifetime)
+
+	d.logger.DebugWithFields("OAuth2 access token obtained", map[string]interface{}{
+		"token_url":  credentials.TokenURL,
+		"client_id":  credentials.ClientID,
+		"expires_in": lifetime.String(),
+	})
+
+	return token.accessToken, nil
+}
+
+// invalidateToken drops the cached token of the webhook, after the receiver rejected it
+func (d *Deliverer) invalidateToken(wh *WebhookConfig) {
+	if wh.Auth == nil || wh.Auth.Type != AuthTypeOAuth2 {
+		return
+	}
+
+	if entry, ok := d.tokens.Load(wh.Auth.OAuth2.cacheKey()); ok {
+		token := entry.(*cachedToken)
+		token.mu.Lock()
+		token.accessToken = ""
+		token.mu.Unlock()
+	}
+}
+
+// requestToken runs the client credentials grant of RFC 6749 section 4.4
+func (d *Deliverer) requestToken(ctx context.Context, client *http.Client, credentials *OAuth2Credentials) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(credentials.Scopes) > 0 {
+		form.Set("scope", strings.Join(credentials.Scopes, " "))
+	}
+	if credentials.Audience != "" {
+		form.Set("audience", credentials.Audience)
+	}
+	if credentials.AuthStyle == OAuth2AuthStyleBody {
+		form.Set("client_id", credentials.ClientID)
+		form.Set("client_secret", credentials.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, credentials.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OAuth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", d.config.UserAgent)
+	if credentials.AuthStyle != OAuth2AuthStyleBody {
+		req.SetBasicAuth(url.QueryEscape(credentials.ClientID), url.QueryEscape(credentials.ClientSecret))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request OAuth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseSize))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("OAuth2 token endpoint responded with HTTP %d: %s", resp.StatusCode, truncate(string(body), 256))
+	}
+
+	var response tokenResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode OAuth2 token response: %w", err)
+	}
+	if response.AccessToken == "" {
+		return nil, fmt.Errorf("OAuth2 token response has no access_token")
+	}
+	if response.TokenType != "" && !strings.EqualFold(response.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported OAuth2 token type %q", response.TokenType)
+	}
+
+	return &response, nil
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,465 @@
+package webhook
+
+import (
+	"time"
+)
+
+// PayloadSchemaVersion is the version of the event payload contract.
+// Adding optional fields keeps the version; renaming, removing or changing
+// the type of a field bumps the major number.
+const PayloadSchemaVersion = "1.0"
+
+// Payload holds the fields shared by every typed event payload
+type Payload struct {
+	SchemaVersion string `json:"schema_version" description:"Version of the payload schema"`
+}
+
+// versioned is implemented by payloads that embed Payload
+type versioned interface {
+	setSchemaVersion(version string)
+}
+
+// setSchemaVersion stamps the payload with the schema version
+func (p *Payload) setSchemaVersion(version string) {
+	p.SchemaVersion = version
+}
+
+// MessageEventData is the payload of Message events
+type MessageEventData struct {
+	Payload
+	ID              string    `json:"id" description:"WhatsApp message ID"`
+	Chat            string    `json:"chat" description:"JID of the chat the message belongs to"`
+	Sender          string    `json:"sender" description:"JID of the message author"`
+	FromMe          bool      `json:"from_me" description:"Whether the message was sent by this account"`
+	IsGroup         bool      `json:"is_group" description:"Whether the chat is a group"`
+	PushName        string    `json:"push_name,omitempty" description:"Display name of the sender"`
+	Type            string    `json:"type" description:"WhatsApp message category (text, media, reaction, poll)"`
+	MessageType     string    `json:"message_type" description:"Content type used by webhook filters (text, image, reaction, ...)"`
+	Timestamp       time.Time `json:"timestamp" description:"When the message was sent"`
+	Text            string    `json:"text,omitempty" description:"Text of text messages"`
+	Caption         string    `json:"caption,omitempty" description:"Caption of media messages"`
+	MimeType        string    `json:"mime_type,omitempty" description:"MIME type of media messages"`
+	FileName        string    `json:"file_name,omitempty" description:"File name of document messages"`
+	QuotedMessageID string    `json:"quoted_message_id,omitempty" description:"ID of the message this one replies to"`
+	IsViewOnce      bool      `json:"is_view_once,omitempty" description:"Whether the message can be viewed only once"`
+	IsEphemeral     bool      `json:"is_ephemeral,omitempty" description:"Whether the message disappears after a while"`
+	IsEdit          bool      `json:"is_edit,omitempty" description:"Whether the message edits an earlier one"`
+}
+
+// ReceiptEventData is the payload of Receipt events
+type ReceiptEventData struct {
+	Payload
+	MessageIDs []string  `json:"message_ids" description:"IDs of the messages the receipt refers to"`
+	Chat       string    `json:"chat" description:"JID of the chat"`
+	Sender     string    `json:"sender" description:"JID of the user that sent the receipt"`
+	IsGroup    bool      `json:"is_group" description:"Whether the chat is a group"`
+	Type       string    `json:"type" description:"Receipt type; empty means delivered (read, played, sender, ...)"`
+	Timestamp  time.Time `json:"timestamp" description:"When the receipt was sent"`
+}
+
+// PresenceEventData is the payload of Presence events
+type PresenceEventData struct {
+	Payload
+	From        string     `json:"from" description:"JID of the contact"`
+	Unavailable bool       `json:"unavailable" description:"Whether the contact went offline"`
+	LastSeen    *time.Time `json:"last_seen,omitempty" description:"Last time the contact was online, when shared"`
+}
+
+// ChatPresenceEventData is the payload of ChatPresence events
+type ChatPresenceEventData struct {
+	Payload
+	Chat    string `json:"chat" description:"JID of the chat"`
+	Sender  string `json:"sender" description:"JID of the user typing or recording"`
+	IsGroup bool   `json:"is_group" description:"Whether the chat is a group"`
+	State   string `json:"state" description:"composing or paused"`
+	Media   string `json:"media,omitempty" description:"audio when recording a voice message"`
+}
+
+// GroupInfoEventData is the payload of GroupInfo events
+type GroupInfoEventData struct {
+	Payload
+	JID       string    `json:"jid" description:"JID of the group"`
+	Sender    string    `json:"sender,omitempty" description:"JID of the user that made the change"`
+	Timestamp time.Time `json:"timestamp" description:"When the change happened"`
+	Name      string    `json:"name,omitempty" description:"New group name"`
+	Topic     string    `json:"topic,omitempty" description:"New group description"`
+	Locked    *bool     `json:"locked,omitempty" description:"Whether only admins can edit group info"`
+	Announce  *bool     `json:"announce,omitempty" description:"Whether only admins can send messages"`
+	Ephemeral *bool     `json:"ephemeral,omitempty" description:"Whether disappearing messages are on"`
+	Join      []string  `json:"join,omitempty" description:"JIDs of participants that joined"`
+	Leave     []string  `json:"leave,omitempty" description:"JIDs of participants that left"`
+	Promote   []string  `json:"promote,omitempty" description:"JIDs of participants promoted to admin"`
+	Demote    []string  `json:"demote,omitempty" description:"JIDs of participants demoted from admin"`
+}
+
+// CallEventData is the payload of CallOffer, CallAccept, CallTerminate, CallOfferNotice and CallRelayLatency events
+type CallEventData struct {
+	Payload
+	CallID         string    `json:"call_id" description:"ID of the call"`
+	From           string    `json:"from" description:"JID of the other party"`
+	Creator        string    `json:"creator" description:"JID of the user that started the call"`
+	Timestamp      time.Time `json:"timestamp" description:"When the event happened"`
+	IsGroup        bool      `json:"is_group,omitempty" description:"Whether it is a group call"`
+	GroupJID       string    `json:"group_jid,omitempty" description:"JID of the group, for group calls"`
+	Media          string    `json:"media,omitempty" description:"audio or video, when known"`
+	Reason         string    `json:"reason,omitempty" description:"Why the call ended (CallTerminate only)"`
+	RemotePlatform string    `json:"remote_platform,omitempty" description:"Platform of the caller (CallOffer and CallAccept only)"`
+	RemoteVersion  string    `json:"remote_version,omitempty" description:"WhatsApp version of the caller (CallOffer and CallAccept only)"`
+}
+
+// ConnectionEventData is the payload of Connected and Disconnected events
+type ConnectionEventData struct {
+	Payload
+	Connected bool `json:"connected" description:"Whether the session is connected"`
+}
+
+// LoggedOutEventData is the payload of LoggedOut events
+type LoggedOutEventData struct {
+	Payload
+	Reason    string `json:"reason" description:"Why the device was logged out"`
+	OnConnect bool   `json:"on_connect" description:"Whether the logout happened while connecting"`
+}
+
+// QREventData is the payload of QR events
+type QREventData struct {
+	Payload
+	Code      string     `json:"code" description:"Raw QR code content"`
+	Image     string     `json:"image" description:"QR code as a PNG data URI"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" description:"When WhatsApp replaces this code with the next one"`
+}
+
+// PairSuccessEventData is the payload of PairSuccess events
+type PairSuccessEventData struct {
+	Payload
+	DeviceJID    string `json:"device_jid" description:"JID of the paired device"`
+	BusinessName string `json:"business_name,omitempty" description:"Business name of the account, if any"`
+	Platform     string `json:"platform,omitempty" description:"Platform of the phone"`
+}
+
+// PairErrorEventData is the payload of PairError events
+type PairErrorEventData struct {
+	Payload
+	DeviceJID string `json:"device_jid" description:"JID of the device that failed to pair"`
+	Error     string `json:"error" description:"Pairing error"`
+}
+
+// UndecryptableMessageEventData is the payload of UndecryptableMessage events
+type UndecryptableMessageEventData struct {
+	Payload
+	ID              string    `json:"id" description:"WhatsApp message ID"`
+	Chat            string    `json:"chat" description:"JID of the chat the message belongs to"`
+	Sender          string    `json:"sender" description:"JID of the message author"`
+	FromMe          bool      `json:"from_me" description:"Whether the message was sent by this account"`
+	IsGroup         bool      `json:"is_group" description:"Whether the chat is a group"`
+	Timestamp       time.Time `json:"timestamp" description:"When the message was sent"`
+	IsUnavailable   bool      `json:"is_unavailable" description:"Whether the message is only available on the phone, such as view-once media"`
+	UnavailableType string    `json:"unavailable_type,omitempty" description:"Why the message is unavailable (view_once, ...)"`
+	DecryptFailMode string    `json:"decrypt_fail_mode,omitempty" description:"hide when WhatsApp does not show a placeholder for the message"`
+}
+
+// MediaRetryEventData is the payload of MediaRetry events
+type MediaRetryEventData struct {
+	Payload
+	MessageID string    `json:"message_id" description:"ID of the message whose media was requested"`
+	Chat      string    `json:"chat" description:"JID of the chat the message belongs to"`
+	Sender    string    `json:"sender,omitempty" description:"JID of the message author, in groups"`
+	FromMe    bool      `json:"from_me" description:"Whether the message was sent by this account"`
+	Timestamp time.Time `json:"timestamp" description:"When the phone answered"`
+	ErrorCode int       `json:"error_code,omitempty" description:"Error code sent by the phone when the media could not be re-uploaded"`
+}
+
+// JoinedGroupEventData is the payload of JoinedGroup events
+type JoinedGroupEventData struct {
+	Payload
+	JID          string    `json:"jid" description:"JID of the group"`
+	Name         string    `json:"name" description:"Group name"`
+	Topic        string    `json:"topic,omitempty" description:"Group description"`
+	Owner        string    `json:"owner,omitempty" description:"JID of the group owner"`
+	Sender       string    `json:"sender,omitempty" description:"JID of the user that added this account"`
+	Reason       string    `json:"reason,omitempty" description:"invite when the account joined through an invite link"`
+	Type         string    `json:"type,omitempty" description:"new when the group was just created"`
+	CreatedAt    time.Time `json:"created_at" description:"When the group was created"`
+	Participants []string  `json:"participants" description:"JIDs of the group participants"`
+}
+
+// PictureEventData is the payload of Picture events
+type PictureEventData struct {
+	Payload
+	JID       string    `json:"jid" description:"JID of the contact or group"`
+	Author    string    `json:"author,omitempty" description:"JID of the user that changed the picture"`
+	Timestamp time.Time `json:"timestamp" description:"When the picture changed"`
+	Remove    bool      `json:"remove" description:"Whether the picture was removed"`
+	PictureID string    `json:"picture_id,omitempty" description:"ID of the new picture"`
+}
+
+// BlocklistChangeEventData is the payload of BlocklistChange events
+type BlocklistChangeEventData struct {
+	Payload
+	JID    string `json:"jid" description:"JID of the contact"`
+	Action string `json:"action" description:"block or unblock"`
+}
+
+// BlocklistEventData is the payload of Blocklist events
+type BlocklistEventData struct {
+	Payload
+	Action  string                     `json:"action,omitempty" description:"modify for incremental changes, empty when the full list was sent"`
+	Changes []BlocklistChangeEventData `json:"changes" description:"Contacts blocked or unblocked"`
+}
+
+// ConnectFailureEventData is the payload of ConnectFailure events
+type ConnectFailureEventData struct {
+	Payload
+	Code    int    `json:"code" description:"Failure code sent by WhatsApp"`
+	Reason  string `json:"reason" description:"Name of the failure code"`
+	Message string `json:"message,omitempty" description:"Message sent by WhatsApp"`
+}
+
+// TemporaryBanEventData is the payload of TemporaryBan events
+type TemporaryBanEventData struct {
+	Payload
+	Code             int        `json:"code" description:"Ban reason code"`
+	Reason           string     `json:"reason" description:"Ban reason"`
+	ExpiresInSeconds int64      `json:"expires_in_seconds" description:"Seconds until the ban expires, 0 when unknown"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty" description:"When the ban expires, when known"`
+}
+
+// StreamErrorEventData is the payload of StreamError events
+type StreamErrorEventData struct {
+	Payload
+	Code string `json:"code" description:"Stream error code sent by WhatsApp"`
+}
+
+// SessionNoticeEventData is the payload of ClientOutdated, StreamReplaced,
+// QRScannedWithoutMultidevice, KeepAliveRestored and CATRefreshError events
+type SessionNoticeEventData struct {
+	Payload
+	Message string `json:"message" description:"What happened to the session"`
+}
+
+// KeepAliveTimeoutEventData is the payload of KeepAliveTimeout events
+type KeepAliveTimeoutEventData struct {
+	Payload
+	ErrorCount  int       `json:"error_count" description:"Number of consecutive failed keepalive pings"`
+	LastSuccess time.Time `json:"last_success" description:"When the last keepalive ping succeeded"`
+}
+
+// PrivacySettingsEventData is the payload of PrivacySettings events
+type PrivacySettingsEventData struct {
+	Payload
+	GroupAdd     string   `json:"group_add" description:"Who can add the account to groups"`
+	LastSeen     string   `json:"last_seen" description:"Who can see the last seen time"`
+	Status       string   `json:"status" description:"Who can see status updates"`
+	Profile      string   `json:"profile" description:"Who can see the profile picture"`
+	ReadReceipts string   `json:"read_receipts" description:"Whether read receipts are sent"`
+	CallAdd      string   `json:"call_add" description:"Who can call the account"`
+	Online       string   `json:"online" description:"Who can see when the account is online"`
+	Changed      []string `json:"changed" description:"Names of the settings that changed"`
+}
+
+// PushNameSettingEventData is the payload of PushNameSetting events
+type PushNameSettingEventData struct {
+	Payload
+	Name      string    `json:"name" description:"New display name of the account"`
+	Timestamp time.Time `json:"timestamp" description:"When the name changed"`
+}
+
+// UserAboutEventData is the payload of UserAbout events
+type UserAboutEventData struct {
+	Payload
+	JID       string    `json:"jid" description:"JID of the contact"`
+	Status    string    `json:"status" description:"New about text"`
+	Timestamp time.Time `json:"timestamp" description:"When the about text changed"`
+}
+
+// AppStateEventData is the payload of AppState events
+type AppStateEventData struct {
+	Payload
+	Index     []string  `json:"index" description:"Index of the patched app state entry, starting with the action name"`
+	Timestamp time.Time `json:"timestamp" description:"When the action happened"`
+}
+
+// AppStateSyncCompleteEventData is the payload of AppStateSyncComplete events
+type AppStateSyncCompleteEventData struct {
+	Payload
+	Name string `json:"name" description:"Name of the app state collection"`
+}
+
+// HistorySyncEventData is the payload of HistorySync events
+type HistorySyncEventData struct {
+	Payload
+	SyncType      string `json:"sync_type" description:"Kind of history sync (INITIAL_BOOTSTRAP, RECENT, PUSH_NAME, ...)"`
+	ChunkOrder    int    `json:"chunk_order" description:"Position of the chunk in the sync"`
+	Progress      int    `json:"progress" description:"Sync progress percentage"`
+	Conversations int    `json:"conversations" description:"Number of conversations in the chunk"`
+	PushNames     int    `json:"push_names" description:"Number of contact names in the chunk"`
+}
+
+// OfflineSyncPreviewEventData is the payload of OfflineSyncPreview events
+type OfflineSyncPreviewEventData struct {
+	Payload
+	Total          int `json:"total" description:"Total number of queued events"`
+	AppDataChanges int `json:"app_data_changes" description:"Number of queued app state changes"`
+	Messages       int `json:"messages" description:"Number of queued messages"`
+	Notifications  int `json:"notifications" description:"Number of queued notifications"`
+	Receipts       int `json:"receipts" description:"Number of queued receipts"`
+}
+
+// OfflineSyncCompletedEventData is the payload of OfflineSyncCompleted events
+type OfflineSyncCompletedEventData struct {
+	Payload
+	Count int `json:"count" description:"Number of events delivered"`
+}
+
+// IdentityChangeEventData is the payload of IdentityChange events
+type IdentityChangeEventData struct {
+	Payload
+	JID       string    `json:"jid" description:"JID of the contact"`
+	Timestamp time.Time `json:"timestamp" description:"When the identity changed"`
+	Implicit  bool      `json:"implicit" description:"Whether the change was detected from an incoming message instead of a notification"`
+}
+
+// NewsletterEventData is the payload of NewsletterJoin, NewsletterLeave and NewsletterMuteChange events
+type NewsletterEventData struct {
+	Payload
+	ID   string `json:"id" description:"JID of the channel"`
+	Name string `json:"name,omitempty" description:"Channel name (NewsletterJoin only)"`
+	Role string `json:"role,omitempty" description:"Role of the account in the channel"`
+	Mute string `json:"mute,omitempty" description:"on or off (NewsletterMuteChange only)"`
+}
+
+// NewsletterMessageData describes a channel message in NewsletterLiveUpdate events
+type NewsletterMessageData struct {
+	ID        string         `json:"id" description:"WhatsApp message ID"`
+	ServerID  int            `json:"server_id" description:"Server-side ID of the message"`
+	Type      string         `json:"type" description:"Message type"`
+	Timestamp time.Time      `json:"timestamp" description:"When the message was sent"`
+	Views     int            `json:"views" description:"Number of views"`
+	Reactions map[string]int `json:"reactions,omitempty" description:"Reaction counts by emoji"`
+}
+
+// NewsletterLiveUpdateEventData is the payload of NewsletterLiveUpdate events
+type NewsletterLiveUpdateEventData struct {
+	Payload
+	ID        string                  `json:"id" description:"JID of the channel"`
+	Timestamp time.Time               `json:"timestamp" description:"When the update was sent"`
+	Messages  []NewsletterMessageData `json:"messages" description:"Messages that were added or updated"`
+}
+
+// FBMessageEventData is the payload of FBMessage events
+type FBMessageEventData struct {
+	Payload
+	ID         string    `json:"id" description:"Message ID"`
+	Chat       string    `json:"chat" description:"JID of the chat the message belongs to"`
+	Sender     string    `json:"sender" description:"JID of the message author"`
+	FromMe     bool      `json:"from_me" description:"Whether the message was sent by this account"`
+	IsGroup    bool      `json:"is_group" description:"Whether the chat is a group"`
+	Timestamp  time.Time `json:"timestamp" description:"When the message was sent"`
+	RetryCount int       `json:"retry_count,omitempty" description:"Number of retries needed to decrypt the message"`
+}
+
+// WebhookDisabledEventData is the payload of WebhookDisabled events
+type WebhookDisabledEventData struct {
+	Payload
+	WebhookID    string    `json:"webhook_id" description:"ID of the webhook that was deactivated"`
+	URL          string    `json:"url" description:"URL of the webhook, without credentials"`
+	Reason       string    `json:"reason" description:"Why the webhook was deactivated"`
+	Failures     int       `json:"failures" description:"Consecutive failed delivery attempts"`
+	FailingSince time.Time `json:"failing_since" description:"When deliveries to the webhook were paused"`
+	LastError    string    `json:"last_error,omitempty" description:"Error of the last failed attempt"`
+}
+
+// eventPayloads maps each event type with a typed payload to a zero value of it
+var eventPayloads = map[string]interface{}{
+	"Message":                     MessageEventData{},
+	"UndecryptableMessage":        UndecryptableMessageEventData{},
+	"Receipt":                     ReceiptEventData{},
+	"MediaRetry":                  MediaRetryEventData{},
+	"ReadReceipt":                 ReceiptEventData{},
+	"Presence":                    PresenceEventData{},
+	"ChatPresence":                ChatPresenceEventData{},
+	"GroupInfo":                   GroupInfoEventData{},
+	"JoinedGroup":                 JoinedGroupEventData{},
+	"Picture":                     PictureEventData{},
+	"BlocklistChange":             BlocklistChangeEventData{},
+	"Blocklist":                   BlocklistEventData{},
+	"CallOffer":                   CallEventData{},
+	"CallAccept":                  CallEventData{},
+	"CallTerminate":               CallEventData{},
+	"CallOfferNotice":             CallEventData{},
+	"CallRelayLatency":            CallEventData{},
+	"Connected":                   ConnectionEventData{},
+	"Disconnected":                ConnectionEventData{},
+	"ConnectFailure":              ConnectFailureEventData{},
+	"KeepAliveRestored":           SessionNoticeEventData{},
+	"KeepAliveTimeout":            KeepAliveTimeoutEventData{},
+	"LoggedOut":                   LoggedOutEventData{},
+	"ClientOutdated":              SessionNoticeEventData{},
+	"TemporaryBan":                TemporaryBanEventData{},
+	"StreamError":                 StreamErrorEventData{},
+	"StreamReplaced":              SessionNoticeEventData{},
+	"QR":                          QREventData{},
+	"QRTimeout":                   SessionNoticeEventData{},
+	"QRScannedWithoutMultidevice": SessionNoticeEventData{},
+	"PairSuccess":                 PairSuccessEventData{},
+	"PairError":                   PairErrorEventData{},
+	"PrivacySettings":             PrivacySettingsEventData{},
+	"PushNameSetting":             PushNameSettingEventData{},
+	"UserAbout":                   UserAboutEventData{},
+	"AppState":                    AppStateEventData{},
+	"AppStateSyncComplete":        AppStateSyncCompleteEventData{},
+	"HistorySync":                 HistorySyncEventData{},
+	"OfflineSyncCompleted":        OfflineSyncCompletedEventData{},
+	"OfflineSyncPreview":          OfflineSyncPreviewEventData{},
+	"IdentityChange":              IdentityChangeEventData{},
+	"CATRefreshError":             SessionNoticeEventData{},
+	"NewsletterJoin":              NewsletterEventData{},
+	"NewsletterLeave":             NewsletterEventData{},
+	"NewsletterMuteChange":        NewsletterEventData{},
+	"NewsletterLiveUpdate":        NewsletterLiveUpdateEventData{},
+	"FBMessage":                   FBMessageEventData{},
+	"WebhookDisabled":             WebhookDisabledEventData{},
+}
+
+// filterFields are the event fields content filters look at
+type filterFields struct {
+	chat        string
+	sender      string
+	fromMe      *bool
+	isGroup     *bool
+	messageType string
+}
+
+// filterFieldsOf extracts the filterable fields of an event payload.
+// Untyped payloads, such as custom test data, are read by key.
+func filterFieldsOf(data interface{}) filterFields {
+	switch d := data.(type) {
+	case *MessageEventData:
+		return filterFields{chat: d.Chat, sender: d.Sender, fromMe: &d.FromMe, isGroup: &d.IsGroup, messageType: d.MessageType}
+	case *UndecryptableMessageEventData:
+		return filterFields{chat: d.Chat, sender: d.Sender, fromMe: &d.FromMe, isGroup: &d.IsGroup}
+	case *FBMessageEventData:
+		return filterFields{chat: d.Chat, sender: d.Sender, fromMe: &d.FromMe, isGroup: &d.IsGroup}
+	case *ReceiptEventData:
+		return filterFields{chat: d.Chat, sender: d.Sender, isGroup: &d.IsGroup}
+	case *MediaRetryEventData:
+		return filterFields{chat: d.Chat, sender: d.Sender, fromMe: &d.FromMe}
+	case *ChatPresenceEventData:
+		return filterFields{chat: d.Chat, sender: d.Sender, isGroup: &d.IsGroup}
+	case *GroupInfoEventData:
+		isGroup := true
+		return filterFields{chat: d.JID, sender: d.Sender, isGroup: &isGroup}
+	case map[string]interface{}:
+		fields := filterFields{}
+		fields.chat, _ = d["chat"].(string)
+		fields.sender, _ = d["sender"].(string)
+		fields.messageType, _ = d["message_type"].(string)
+		if fromMe, ok := d["from_me"].(bool); ok {
+			fields.fromMe = &fromMe
+		}
+		if isGroup, ok := d["is_group"].(bool); ok {
+			fields.isGroup = &isGroup
+		}
+		return fields
+	}
+	return filterFields{}
+}
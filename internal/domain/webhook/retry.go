@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryConfig controls how failed webhook deliveries are retried
+type RetryConfig struct {
+	// MaxRetries is the number of retries after the first failed attempt
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryConfig returns the default webhook retry settings
+func DefaultRetryConfig() *RetryConfig {
+	return &RetryConfig{
+		MaxRetries: 5,
+		BaseDelay:  10 * time.Second,
+		MaxDelay:   time.Hour,
+	}
+}
+
+// CanRetry reports whether another attempt is allowed after the given number of attempts
+func (c *RetryConfig) CanRetry(attempts int) bool {
+	return attempts <= c.MaxRetries
+}
+
+// NextDelay returns the backoff before the next attempt, doubling per attempt with jitter.
+// The delay is picked uniformly between half and the full exponential value to spread
+// retries of deliveries that failed at the same time.
+func (c *RetryConfig) NextDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := c.BaseDelay
+	for i := 1; i < attempts && delay < c.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > c.MaxDelay {
+		delay = c.MaxDelay
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
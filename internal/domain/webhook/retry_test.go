@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryConfigNextDelay(t *testing.T) {
+	config := &RetryConfig{MaxRetries: 5, BaseDelay: 10 * time.Second, MaxDelay: time.Hour}
+
+	tests := []struct {
+		name     string
+		attempts int
+		min, max time.Duration
+	}{
+		{name: "no attempts yet", attempts: 0, min: 5 * time.Second, max: 10 * time.Second},
+		{name: "first attempt", attempts: 1, min: 5 * time.Second, max: 10 * time.Second},
+		{name: "second attempt doubles", attempts: 2, min: 10 * time.Second, max: 20 * time.Second},
+		{name: "fourth attempt", attempts: 4, min: 40 * time.Second, max: 80 * time.Second},
+		{name: "beyond MaxDelay", attempts: 10, min: 30 * time.Minute, max: time.Hour},
+		{name: "far beyond MaxDelay does not overflow", attempts: 1000, min: 30 * time.Minute, max: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The jitter is random, check that many picks stay within the bounds
+			for i := 0; i < 100; i++ {
+				if got := config.NextDelay(tt.attempts); got < tt.min || got > tt.max {
+					t.Fatalf("NextDelay(%d) = %s, want between %s and %s", tt.attempts, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryConfigNextDelayWithoutBaseDelay(t *testing.T) {
+	config := &RetryConfig{MaxRetries: 3, MaxDelay: time.Minute}
+	if got := config.NextDelay(3); got != 0 {
+		t.Errorf("NextDelay(3) = %s, want 0", got)
+	}
+}
+
+func TestRetryConfigCanRetry(t *testing.T) {
+	tests := []struct {
+		maxRetries int
+		attempts   int
+		want       bool
+	}{
+		{maxRetries: 5, attempts: 1, want: true},
+		{maxRetries: 5, attempts: 5, want: true},
+		{maxRetries: 5, attempts: 6, want: false},
+		{maxRetries: 0, attempts: 1, want: false},
+		{maxRetries: 0, attempts: 0, want: true},
+	}
+
+	for _, tt := range tests {
+		config := &RetryConfig{MaxRetries: tt.maxRetries}
+		if got := config.CanRetry(tt.attempts); got != tt.want {
+			t.Errorf("RetryConfig{MaxRetries: %d}.CanRetry(%d) = %v, want %v", tt.maxRetries, tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// jsonSchemaDialect is the JSON Schema draft used by the published schemas
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// PayloadSchemaName returns the name of the typed payload of an event type, or an empty string
+func PayloadSchemaName(eventType string) string {
+	payload, ok := eventPayloads[eventType]
+	if !ok {
+		return ""
+	}
+	return reflect.TypeOf(payload).Name()
+}
+
+// SamplePayload returns an empty typed payload for the event type, used by webhook tests.
+// Event types without a typed payload get an empty object.
+func SamplePayload(eventType string) interface{} {
+	payload, ok := eventPayloads[eventType]
+	if !ok {
+		return map[string]interface{}{}
+	}
+	return reflect.New(reflect.TypeOf(payload)).Interface()
+}
+
+// NewTestEvent builds a synthetic event flagged as a test. Test data is decoded into the typed
+// payload of the event type, so filters and templates see the same fields as on real events;
+// without test data the payload is empty.
+func NewTestEvent(sessionID, eventType string, testData map[string]interface{}) (*WebhookEvent, error) {
+	if !IsValidEventType(eventType) || eventType == "All" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEventType, eventType)
+	}
+
+	payload := SamplePayload(eventType)
+	if testData != nil {
+		if _, typed := eventPayloads[eventType]; typed {
+			encoded, err := json.Marshal(testData)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidTestData, err)
+			}
+			if err := json.Unmarshal(encoded, payload); err != nil {
+				return nil, fmt.Errorf("%w: does not match the %s payload: %v", ErrInvalidTestData, eventType, err)
+			}
+		} else {
+			payload = testData
+		}
+	}
+
+	event := NewWebhookEvent(sessionID, eventType, payload)
+	event.Test = true
+	return event, nil
+}
+
+// TypedEventTypes returns the event types that have a typed payload, sorted by name
+func TypedEventTypes() []string {
+	eventTypes := make([]string, 0, len(eventPayloads))
+	for eventType := range eventPayloads {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Strings(eventTypes)
+	return eventTypes
+}
+
+// EventJSONSchema returns the JSON Schema of the full webhook body for an event type.
+// The second value is false when the event type has no typed payload.
+func EventJSONSchema(eventType string) (map[string]interface{}, bool) {
+	payload, ok := eventPayloads[eventType]
+	if !ok {
+		return nil, false
+	}
+
+	payloadType := reflect.TypeOf(payload)
+	dataSchema := schemaForType(payloadType)
+	dataSchema["title"] = payloadType.Name()
+
+	return map[string]interface{}{
+		"$schema":              jsonSchemaDialect,
+		"$id":                  "zpwoot:webhook:" + eventType + ":" + PayloadSchemaVersion,
+		"title":                eventType + " webhook event",
+		"type":                 "object",
+		"additionalProperties": true,
+		"required":             []string{"id", "session_id", "type", "timestamp", "data"},
+		"properties": map[string]interface{}{
+			"id":              map[string]interface{}{"type": "string", "description": "Unique event ID, also sent in the X-Zpwoot-Event-Id header"},
+			"session_id":      map[string]interface{}{"type": "string", "description": "ID of the session that produced the event"},
+			"session_name":    map[string]interface{}{"type": "string", "description": "Name of the session that produced the event"},
+			"type":            map[string]interface{}{"const": eventType},
+			"timestamp":       map[string]interface{}{"type": "string", "format": "date-time"},
+			"test":            map[string]interface{}{"type": "boolean", "description": "Set on events sent by the webhook test endpoint"},
+			"is_offline_sync": map[string]interface{}{"type": "boolean", "description": "Set on events the server replayed while the session caught up on what it missed offline"},
+			"data":            dataSchema,
+		},
+	}, true
+}
+
+// schemaForType builds the JSON Schema of a Go type from its json and description tags.
+// Objects allow additional properties so optional fields can be added without a version bump.
+func schemaForType(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		return schemaForType(t.Elem())
+	}
+
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		required := []string{}
+		addStructFields(t, properties, &required)
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": true,
+		}
+	}
+
+	return map[string]interface{}{}
+}
+
+// addStructFields adds the JSON fields of a struct, flattening embedded structs like encoding/json does
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			addStructFields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := schemaForType(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			schema["description"] = description
+		}
+		properties[name] = schema
+
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// fillRequiredCollections replaces the nil slices and maps of a payload with empty ones where the
+// schema marks them required, so they are encoded as [] and {} instead of null
+func fillRequiredCollections(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			fillRequiredCollections(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			fillRequiredCollections(v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			value := v.Field(i)
+			if !field.IsExported() && !field.Anonymous {
+				continue
+			}
+
+			_, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			required := field.Tag.Get("json") != "-" && !strings.Contains(options, "omitempty")
+			if required && value.CanSet() {
+				switch {
+				case value.Kind() == reflect.Slice && value.IsNil():
+					value.Set(reflect.MakeSlice(value.Type(), 0, 0))
+				case value.Kind() == reflect.Map && value.IsNil():
+					value.Set(reflect.MakeMap(value.Type()))
+				}
+			}
+			fillRequiredCollections(value)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTestEventsMatchTheirSchema(t *testing.T) {
+	for _, eventType := range TypedEventTypes() {
+		t.Run(eventType, func(t *testing.T) {
+			event, err := NewTestEvent("session-1", eventType, nil)
+			if err != nil {
+				t.Fatalf("NewTestEvent: %v", err)
+			}
+			encoded, err := json.Marshal(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body interface{}
+			if err := json.Unmarshal(encoded, &body); err != nil {
+				t.Fatal(err)
+			}
+
+			schema, _ := EventJSONSchema(eventType)
+			checkSchema(t, "$", schema, body)
+		})
+	}
+}
+
+func TestNewWebhookEventFillsRequiredSlices(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  interface{}
+		field string
+	}{
+		{name: "receipt without message IDs", data: &ReceiptEventData{}, field: "message_ids"},
+		{name: "group without participants", data: &JoinedGroupEventData{}, field: "participants"},
+		{name: "app state without index", data: &AppStateEventData{}, field: "index"},
+		{name: "newsletter update without messages", data: &NewsletterLiveUpdateEventData{}, field: "messages"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(NewWebhookEvent("session-1", "Test", tt.data).Data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var data map[string]json.RawMessage
+			if err := json.Unmarshal(encoded, &data); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(data[tt.field]); got != "[]" {
+				t.Errorf("%s = %s, want []", tt.field, got)
+			}
+		})
+	}
+
+	// Optional slices stay out of the payload
+	encoded, _ := json.Marshal(NewWebhookEvent("session-1", "GroupInfo", &GroupInfoEventData{}).Data)
+	var data map[string]json.RawMessage
+	json.Unmarshal(encoded, &data)
+	if _, ok := data["join"]; ok {
+		t.Errorf("optional join was encoded: %s", encoded)
+	}
+}
+
+// checkSchema checks a decoded JSON value against the subset of JSON Schema the generated schemas use
+func checkSchema(t *testing.T, path string, schema map[string]interface{}, value interface{}) {
+	t.Helper()
+
+	if value == nil {
+		t.Errorf("%s is null", path)
+		return
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			t.Errorf("%s = %v, want an object", path, value)
+			return
+		}
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := object[name]; !ok {
+				t.Errorf("%s.%s is required but missing", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, property := range properties {
+			if field, ok := object[name]; ok {
+				checkSchema(t, path+"."+name, property.(map[string]interface{}), field)
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			t.Errorf("%s = %v, want an array", path, value)
+			return
+		}
+		for _, item := range items {
+			checkSchema(t, path+"[]", schema["items"].(map[string]interface{}), item)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			t.Errorf("%s = %v, want a string", path, value)
+		}
+	case "integer", "number":
+		if _, ok := value.(float64); !ok {
+			t.Errorf("%s = %v, want a number", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			t.Errorf("%s = %v, want a boolean", path, value)
+		}
+	}
+}
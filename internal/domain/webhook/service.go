@@ -2,7 +2,10 @@ package webhook
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,13 +14,78 @@ import (
 
 // Service provides webhook domain operations
 type Service struct {
-	logger *logger.Logger
+	repo       Repository
+	deliverer  *Deliverer
+	breaker    *CircuitBreaker
+	onDisabled DisabledHandler
+	logger     *logger.Logger
+}
+
+// DisabledHandler deactivates a webhook whose circuit stayed open past CircuitConfig.DisableAfter
+type DisabledHandler func(ctx context.Context, wh *WebhookConfig, circuit CircuitStatus)
+
+// Repository defines the webhook persistence operations needed by the service
+type Repository interface {
+	GetByID(ctx context.Context, id string) (*WebhookConfig, error)
+	GetBySessionID(ctx context.Context, sessionID string) ([]*WebhookConfig, error)
+	GetGlobalWebhooks(ctx context.Context) ([]*WebhookConfig, error)
+	SaveCircuit(ctx context.Context, id string, failures int, openedAt *time.Time) error
 }
 
 // NewService creates a new webhook service
-func NewService(logger *logger.Logger) *Service {
+func NewService(repo Repository, deliveryConfig *DeliveryConfig, logger *logger.Logger) *Service {
+	var circuitConfig *CircuitConfig
+	if deliveryConfig != nil {
+		circuitConfig = deliveryConfig.Circuit
+	}
+
 	return &Service{
-		logger: logger,
+		repo:      repo,
+		deliverer: NewDeliverer(deliveryConfig, logger),
+		breaker:   NewCircuitBreaker(circuitConfig),
+		logger:    logger,
+	}
+}
+
+// SetDisabledHandler sets the handler that deactivates webhooks failing for too long
+func (s *Service) SetDisabledHandler(handler DisabledHandler) {
+	s.onDisabled = handler
+}
+
+// ResetCircuit gives a webhook a fresh start after it was changed or reactivated
+func (s *Service) ResetCircuit(ctx context.Context, webhookID uuid.UUID) {
+	s.breaker.Reset(webhookID)
+	s.saveCircuit(ctx, webhookID, CircuitStatus{})
+}
+
+// CircuitStatus returns the circuit of a webhook
+func (s *Service) CircuitStatus(wh *WebhookConfig) CircuitStatus {
+	s.restoreCircuit(wh)
+	return s.breaker.Status(wh.ID)
+}
+
+// restoreCircuit reopens the stored circuit of a webhook after a restart
+func (s *Service) restoreCircuit(wh *WebhookConfig) {
+	if wh.CircuitOpenedAt != nil {
+		s.breaker.Restore(wh.ID, wh.CircuitFailures, *wh.CircuitOpenedAt)
+	}
+}
+
+// saveCircuit stores the circuit of a webhook, clearing it when the circuit is closed.
+// A failure is only logged: the circuit keeps working in memory.
+func (s *Service) saveCircuit(ctx context.Context, webhookID uuid.UUID, circuit CircuitStatus) {
+	var openedAt *time.Time
+	failures := 0
+	if circuit.Open {
+		openedAt = &circuit.OpenedAt
+		failures = circuit.Failures
+	}
+
+	if err := s.repo.SaveCircuit(ctx, webhookID.String(), failures, openedAt); err != nil {
+		s.logger.WarnWithFields("Failed to store webhook circuit", map[string]interface{}{
+			"webhook_id": webhookID.String(),
+			"error":      err.Error(),
+		})
 	}
 }
 
@@ -40,40 +108,45 @@ func (s *Service) SetConfig(ctx context.Context, req *SetConfigRequest) (*Webhoo
 		UpdatedAt: time.Now(),
 	}
 
+	if !req.Filters.IsEmpty() {
+		webhook.Filters = req.Filters
+	}
+
+	if !req.Template.IsEmpty() {
+		webhook.Template = req.Template
+	}
+
+	if !req.Auth.IsEmpty() {
+		webhook.Auth = req.Auth
+	}
+
 	return webhook, nil
 }
 
-// UpdateWebhook updates an existing webhook configuration
+// UpdateWebhook loads a webhook and applies the requested changes
 func (s *Service) UpdateWebhook(ctx context.Context, webhookID string, req *UpdateWebhookRequest) (*WebhookConfig, error) {
 	s.logger.InfoWithFields("Updating webhook", map[string]interface{}{
 		"webhook_id": webhookID,
 	})
 
-	// This would typically load the existing webhook, update it, and return it
-	// For now, return a placeholder
-	id, err := uuid.Parse(webhookID)
+	webhook, err := s.repo.GetByID(ctx, webhookID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid webhook ID: %w", err)
+		return nil, err
 	}
 
-	webhook := &WebhookConfig{
-		ID:        id,
-		UpdatedAt: time.Now(),
+	if webhook.IsEnvManaged() {
+		return nil, ErrWebhookManagedByEnv
 	}
 
-	if req.URL != nil {
-		webhook.URL = *req.URL
-	}
-	if req.Secret != nil {
-		webhook.Secret = *req.Secret
-	}
-	if req.Events != nil {
-		webhook.Events = req.Events
-	}
-	if req.Active != nil {
-		webhook.Active = *req.Active
+	webhook.Update(req)
+
+	if err := s.ValidateWebhookConfig(webhook); err != nil {
+		return nil, err
 	}
 
+	// The receiver may have been fixed or replaced, let deliveries through again
+	s.breaker.Reset(webhook.ID)
+
 	return webhook, nil
 }
 
@@ -83,7 +156,9 @@ func (s *Service) DeleteWebhook(ctx context.Context, webhookID string) error {
 		"webhook_id": webhookID,
 	})
 
-	// Domain logic for webhook deletion would go here
+	if id, err := uuid.Parse(webhookID); err == nil {
+		s.breaker.Reset(id)
+	}
 	return nil
 }
 
@@ -93,63 +168,296 @@ func (s *Service) GetWebhookBySession(ctx context.Context, sessionID string) (*W
 		"session_id": sessionID,
 	})
 
-	// This would typically query the repository
-	// For now, return a placeholder
-	return nil, ErrWebhookNotFound
-}
+	webhooks, err := s.repo.GetBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
 
-// ListWebhooks retrieves webhooks with filters
-func (s *Service) ListWebhooks(ctx context.Context, req *ListWebhooksRequest) ([]*WebhookConfig, int, error) {
-	s.logger.InfoWithFields("Listing webhooks", map[string]interface{}{
-		"session_id": req.SessionID,
-		"active":     req.Active,
-		"limit":      req.Limit,
-		"offset":     req.Offset,
-	})
+	for _, wh := range webhooks {
+		if wh != nil {
+			return wh, nil
+		}
+	}
 
-	// This would typically query the repository
-	// For now, return empty results
-	return []*WebhookConfig{}, 0, nil
+	return nil, ErrWebhookNotFound
 }
 
 // TestWebhookResult represents the result of testing a webhook
 type TestWebhookResult struct {
+	WebhookID    string
+	URL          string
+	EventID      string
+	EventType    string
 	Success      bool
 	StatusCode   int
 	ResponseTime int64
+	ResponseBody string
+	TLSError     string
+	Headers      map[string]string
 	Error        error
 }
 
-// TestWebhook tests a webhook by sending a test event
-func (s *Service) TestWebhook(ctx context.Context, webhookID string, event *WebhookEvent) (*TestWebhookResult, error) {
+// TestWebhook sends a synthetic event to a session webhook and reports the real outcome.
+// When webhookID is empty the first webhook of the session is used.
+func (s *Service) TestWebhook(ctx context.Context, sessionID, webhookID string, event *WebhookEvent) (*TestWebhookResult, error) {
 	s.logger.InfoWithFields("Testing webhook", map[string]interface{}{
+		"session_id": sessionID,
 		"webhook_id": webhookID,
 		"event_type": event.Type,
 	})
 
-	// This would typically send an HTTP request to the webhook URL
-	// For now, return a mock successful result
-	return &TestWebhookResult{
-		Success:      true,
-		StatusCode:   200,
-		ResponseTime: 150,
-	}, nil
+	if !IsValidEventType(event.Type) || event.Type == "All" {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEventType, event.Type)
+	}
+
+	wh, err := s.findSessionWebhook(ctx, sessionID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := s.deliverer.Deliver(ctx, wh, event)
+
+	result := &TestWebhookResult{
+		WebhookID:    delivery.WebhookID,
+		URL:          delivery.URL,
+		EventID:      delivery.EventID,
+		EventType:    delivery.EventType,
+		Success:      delivery.Success,
+		StatusCode:   delivery.StatusCode,
+		ResponseTime: delivery.Latency.Milliseconds(),
+		ResponseBody: delivery.ResponseBody,
+		TLSError:     delivery.TLSError,
+		Headers:      make(map[string]string),
+		Error:        delivery.Error,
+	}
+
+	for name := range delivery.RequestHeaders {
+		result.Headers[name] = delivery.RequestHeaders.Get(name)
+	}
+
+	return result, nil
+}
+
+// findSessionWebhook loads a webhook and checks it belongs to the session
+func (s *Service) findSessionWebhook(ctx context.Context, sessionID, webhookID string) (*WebhookConfig, error) {
+	if webhookID == "" {
+		return s.GetWebhookBySession(ctx, sessionID)
+	}
+
+	wh, err := s.repo.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if wh.SessionID == nil || *wh.SessionID != sessionID {
+		return nil, ErrWebhookNotFound
+	}
+
+	return wh, nil
 }
 
-// ProcessEvent processes a webhook event and sends it to configured webhooks
-func (s *Service) ProcessEvent(ctx context.Context, event *WebhookEvent) error {
+// ProcessEvent processes a webhook event and sends it to configured webhooks.
+// It returns one result per subscribed webhook so callers can record failed attempts.
+func (s *Service) ProcessEvent(ctx context.Context, event *WebhookEvent) ([]*DeliveryResult, error) {
 	s.logger.InfoWithFields("Processing webhook event", map[string]interface{}{
 		"event_id":   event.ID,
 		"event_type": event.Type,
 		"session_id": event.SessionID,
 	})
 
-	// This would typically:
-	// 1. Find all active webhooks that listen to this event type
-	// 2. Send the event to each webhook
-	// 3. Record delivery attempts and results
-	// For now, just log the event
-	return nil
+	webhooks, err := s.findSubscribers(ctx, event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve webhooks for event: %w", err)
+	}
+
+	if len(webhooks) == 0 {
+		s.logger.DebugWithFields("No webhooks subscribed to event", map[string]interface{}{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+			"session_id": event.SessionID,
+		})
+		return nil, nil
+	}
+
+	results := make([]*DeliveryResult, len(webhooks))
+	var wg sync.WaitGroup
+	for i, wh := range webhooks {
+		wg.Add(1)
+		go func(i int, wh *WebhookConfig) {
+			defer wg.Done()
+			results[i] = s.deliver(ctx, wh, event)
+			s.logDeliveryResult(event.SessionID, results[i])
+		}(i, wh)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// Redeliver sends a previously recorded payload to the webhook again.
+// While the circuit of the webhook is open the payload is returned unsent, with the time of the next probe.
+func (s *Service) Redeliver(ctx context.Context, wh *WebhookConfig, sessionID, eventID, eventType string, payload []byte) *DeliveryResult {
+	s.restoreCircuit(wh)
+	if allowed, retryAt := s.breaker.Allow(wh.ID); !allowed {
+		return pausedResult(wh, eventID, eventType, payload, retryAt)
+	}
+
+	return s.Replay(ctx, wh, sessionID, eventID, eventType, payload)
+}
+
+// Replay sends a previously recorded payload even if the circuit of the webhook is open, so operators
+// can check a receiver they fixed. The outcome still counts: a successful replay closes the circuit.
+func (s *Service) Replay(ctx context.Context, wh *WebhookConfig, sessionID, eventID, eventType string, payload []byte) *DeliveryResult {
+	s.restoreCircuit(wh)
+	result := s.deliverer.DeliverPayload(ctx, wh, sessionID, eventID, eventType, payload)
+	s.logDeliveryResult(sessionID, result)
+	s.recordOutcome(ctx, wh, result)
+	return result
+}
+
+// deliver sends an event to a webhook. While its circuit is open the rendered payload is
+// returned unsent, so it can be kept in the delivery log until the next probe.
+func (s *Service) deliver(ctx context.Context, wh *WebhookConfig, event *WebhookEvent) *DeliveryResult {
+	s.restoreCircuit(wh)
+	allowed, retryAt := s.breaker.Allow(wh.ID)
+	if allowed {
+		result := s.deliverer.Deliver(ctx, wh, event)
+		s.recordOutcome(ctx, wh, result)
+		return result
+	}
+
+	rendered, err := wh.RenderEvent(event)
+	if err != nil {
+		return &DeliveryResult{
+			WebhookID: wh.ID.String(),
+			EventID:   event.ID,
+			EventType: event.Type,
+			URL:       wh.URL,
+			Error:     err,
+		}
+	}
+	return pausedResult(wh, event.ID, event.Type, rendered.Body, retryAt)
+}
+
+// pausedResult is the result of a delivery held back by an open circuit
+func pausedResult(wh *WebhookConfig, eventID, eventType string, payload []byte, retryAt time.Time) *DeliveryResult {
+	result := &DeliveryResult{
+		WebhookID: wh.ID.String(),
+		EventID:   eventID,
+		EventType: eventType,
+		URL:       wh.URL,
+		Payload:   payload,
+		RetryAt:   retryAt,
+		Error:     fmt.Errorf("%w: next attempt at %s", ErrCircuitOpen, retryAt.Format(time.RFC3339)),
+	}
+	if target, err := url.Parse(wh.URL); err == nil {
+		result.URL = target.Redacted()
+	}
+	return result
+}
+
+// recordOutcome feeds a delivery attempt to the circuit breaker and acts on the transition
+func (s *Service) recordOutcome(ctx context.Context, wh *WebhookConfig, result *DeliveryResult) {
+	// Shutdowns and events that could not be rendered say nothing about the receiver
+	if ctx.Err() != nil || len(result.Payload) == 0 {
+		return
+	}
+
+	errMsg := ""
+	if result.Error != nil {
+		errMsg = result.Error.Error()
+	}
+
+	transition, circuit := s.breaker.Record(wh.ID, result.Success, errMsg)
+	switch {
+	case transition == CircuitClosed || transition == CircuitExpired:
+		s.saveCircuit(ctx, wh.ID, CircuitStatus{})
+	case circuit.Open:
+		s.saveCircuit(ctx, wh.ID, circuit)
+	}
+
+	fields := map[string]interface{}{
+		"webhook_id": result.WebhookID,
+		"url":        result.URL,
+		"failures":   circuit.Failures,
+	}
+
+	switch transition {
+	case CircuitOpened:
+		fields["next_probe_at"] = circuit.NextProbeAt
+		fields["error"] = errMsg
+		s.logger.WarnWithFields("Webhook circuit opened, pausing deliveries", fields)
+	case CircuitClosed:
+		fields["open_for"] = time.Since(circuit.OpenedAt).Round(time.Second).String()
+		s.logger.InfoWithFields("Webhook circuit closed, resuming deliveries", fields)
+	case CircuitExpired:
+		fields["failing_since"] = circuit.OpenedAt
+		fields["error"] = errMsg
+		s.logger.ErrorWithFields("Webhook failing for too long, deactivating it", fields)
+		if s.onDisabled != nil {
+			s.onDisabled(ctx, wh, circuit)
+		}
+	}
+}
+
+// findSubscribers returns the active session and global webhooks that listen to the event type
+func (s *Service) findSubscribers(ctx context.Context, event *WebhookEvent) ([]*WebhookConfig, error) {
+	var candidates []*WebhookConfig
+
+	if event.SessionID != "" {
+		sessionWebhooks, err := s.repo.GetBySessionID(ctx, event.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, sessionWebhooks...)
+	}
+
+	globalWebhooks, err := s.repo.GetGlobalWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, globalWebhooks...)
+
+	seen := make(map[uuid.UUID]bool)
+	var subscribers []*WebhookConfig
+	for _, wh := range candidates {
+		if wh == nil || !wh.Active || !wh.Accepts(event) || seen[wh.ID] {
+			continue
+		}
+		seen[wh.ID] = true
+		subscribers = append(subscribers, wh)
+	}
+
+	return subscribers, nil
+}
+
+// logDeliveryResult logs the outcome of a webhook delivery
+func (s *Service) logDeliveryResult(sessionID string, result *DeliveryResult) {
+	fields := map[string]interface{}{
+		"webhook_id":  result.WebhookID,
+		"url":         result.URL,
+		"event_id":    result.EventID,
+		"event_type":  result.EventType,
+		"session_id":  sessionID,
+		"status_code": result.StatusCode,
+		"latency_ms":  result.Latency.Milliseconds(),
+	}
+
+	if result.Success {
+		s.logger.InfoWithFields("Webhook delivered", fields)
+		return
+	}
+
+	if errors.Is(result.Error, ErrCircuitOpen) {
+		fields["retry_at"] = result.RetryAt
+		s.logger.DebugWithFields("Webhook delivery paused by open circuit", fields)
+		return
+	}
+
+	if result.Error != nil {
+		fields["error"] = result.Error.Error()
+	}
+	s.logger.WarnWithFields("Webhook delivery failed", fields)
 }
 
 // ValidateWebhookConfig validates webhook configuration
@@ -158,10 +466,37 @@ func (s *Service) ValidateWebhookConfig(config *WebhookConfig) error {
 		return ErrInvalidWebhookURL
 	}
 
+	parsed, err := url.Parse(config.URL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhookURL, err)
+	}
+
+	if err := s.deliverer.ValidateTarget(parsed); err != nil {
+		return err
+	}
+
 	if len(config.Events) == 0 {
-		return fmt.Errorf("webhook must listen to at least one event")
+		return fmt.Errorf("%w: webhook must listen to at least one event", ErrInvalidWebhookEvents)
+	}
+
+	if invalidEvents := ValidateEvents(config.Events); len(invalidEvents) > 0 {
+		return fmt.Errorf("%w: unsupported event types: %v", ErrInvalidWebhookEvents, invalidEvents)
+	}
+
+	if err := config.Filters.Validate(); err != nil {
+		return err
+	}
+
+	if err := config.Template.Validate(); err != nil {
+		return err
+	}
+
+	if err := config.Auth.Validate(); err != nil {
+		return err
+	}
+	if !config.Auth.IsEmpty() && parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("%w: auth is only sent to http and https webhooks", ErrInvalidWebhookAuth)
 	}
 
-	// Additional validation logic would go here
 	return nil
 }
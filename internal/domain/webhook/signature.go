@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// signaturePrefix identifies the algorithm used in the signature header value
+const signaturePrefix = "sha256="
+
+// SignPayload computes the HMAC-SHA256 signature of a webhook payload.
+// The signed content is the timestamp header value, a dot and the raw request body.
+func SignPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature checks a signature header value against the expected payload signature
+func VerifySignature(secret, timestamp string, body []byte, signature string) bool {
+	if secret == "" || !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+
+	expected := SignPayload(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,70 @@
+package webhook
+
+import "testing"
+
+func TestSignPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{
+			name:      "event body",
+			secret:    "whsec_test",
+			timestamp: "1700000000",
+			body:      `{"type":"Message"}`,
+			want:      "sha256=ee2d0fc2286094fac060851270109dfe7eccee5f39c7b503403a2a8e9567ba12",
+		},
+		{
+			name:      "empty secret",
+			secret:    "",
+			timestamp: "1700000000",
+			body:      `{}`,
+			want:      "sha256=a9dc44c8eda3de70e9cbf3e488895f1abc26acb1461d3124a3cb886af35251cf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignPayload(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("SignPayload(%q, %q, %q) = %q, want %q", tt.secret, tt.timestamp, tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	const (
+		secret    = "whsec_test"
+		timestamp = "1700000000"
+	)
+	body := []byte(`{"type":"Message"}`)
+	signature := SignPayload(secret, timestamp, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		signature string
+		want      bool
+	}{
+		{name: "valid", secret: secret, timestamp: timestamp, body: string(body), signature: signature, want: true},
+		{name: "empty secret", secret: "", timestamp: timestamp, body: string(body), signature: SignPayload("", timestamp, body), want: false},
+		{name: "wrong secret", secret: "other", timestamp: timestamp, body: string(body), signature: signature, want: false},
+		{name: "other timestamp", secret: secret, timestamp: "1700000001", body: string(body), signature: signature, want: false},
+		{name: "tampered body", secret: secret, timestamp: timestamp, body: `{"type":"Receipt"}`, signature: signature, want: false},
+		{name: "missing prefix", secret: secret, timestamp: timestamp, body: string(body), signature: signature[len(signaturePrefix):], want: false},
+		{name: "empty signature", secret: secret, timestamp: timestamp, body: string(body), signature: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifySignature(tt.secret, tt.timestamp, []byte(tt.body), tt.signature); got != tt.want {
+				t.Errorf("VerifySignature(%q, %q, %q, %q) = %v, want %v", tt.secret, tt.timestamp, tt.body, tt.signature, got, tt.want)
+			}
+		})
+	}
+}
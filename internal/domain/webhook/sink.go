@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// DefaultRoutingKey is the routing key, or subject, of broker deliveries whose URL does not set one
+const DefaultRoutingKey = "zpwoot.{session_id}.{event_type}"
+
+// Sink publishes webhook events to a message broker instead of an HTTP endpoint.
+// The sink of a webhook is picked by the scheme of its URL, such as amqp:// or nats://,
+// so broker targets share filters, signatures, the delivery log and retries with HTTP webhooks.
+type Sink interface {
+	// Schemes returns the URL schemes handled by the sink
+	Schemes() []string
+	// Validate checks a webhook URL before it is saved
+	Validate(target *url.URL) error
+	// Publish sends the message and waits for the broker to confirm it.
+	// It returns a short description of the confirmation for the delivery log.
+	Publish(ctx context.Context, target *url.URL, msg *SinkMessage) (string, error)
+	// Close releases the broker connections
+	Close() error
+}
+
+// SinkMessage is an encoded event ready to be published to a broker
+type SinkMessage struct {
+	SessionID string
+	EventID   string
+	EventType string
+	// ContentType is the content type of the payload, set by the webhook template
+	ContentType string
+	// Headers carry the same X-Zpwoot-* values and template headers HTTP deliveries send
+	Headers map[string]string
+	Payload []byte
+}
+
+// RoutingKey expands the {session_id} and {event_type} placeholders of a routing key or subject pattern
+func (m *SinkMessage) RoutingKey(pattern string) string {
+	if pattern == "" {
+		pattern = DefaultRoutingKey
+	}
+	return strings.NewReplacer(
+		"{session_id}", m.SessionID,
+		"{event_type}", m.EventType,
+	).Replace(pattern)
+}
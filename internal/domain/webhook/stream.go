@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// streamBufferSize is how many events a live subscriber may fall behind before events are dropped
+const streamBufferSize = 256
+
+// EventStream fans webhook events out to live subscribers, such as WebSocket clients.
+// Publishing never blocks: a subscriber that cannot keep up loses events instead
+// of slowing down event processing.
+type EventStream struct {
+	subscribers map[*Subscription]struct{}
+	mutex       sync.RWMutex
+}
+
+// Subscription receives the events of a session, or of every session, from an EventStream
+type Subscription struct {
+	sessionID  string
+	eventTypes []string
+	events     chan *WebhookEvent
+	dropped    int64
+	stream     *EventStream
+	closeOnce  sync.Once
+}
+
+// NewEventStream creates a new event stream
+func NewEventStream() *EventStream {
+	return &EventStream{
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber. An empty sessionID receives events from every
+// session, and empty eventTypes (or "All") receive every event type.
+func (s *EventStream) Subscribe(sessionID string, eventTypes []string) *Subscription {
+	sub := &Subscription{
+		sessionID:  sessionID,
+		eventTypes: eventTypes,
+		events:     make(chan *WebhookEvent, streamBufferSize),
+		stream:     s,
+	}
+
+	s.mutex.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mutex.Unlock()
+
+	return sub
+}
+
+// Publish sends the event to every matching subscriber
+func (s *EventStream) Publish(event *WebhookEvent) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for sub := range s.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			atomic.AddInt64(&sub.dropped, 1)
+		}
+	}
+}
+
+// SubscriberCount returns the number of live subscribers
+func (s *EventStream) SubscriberCount() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.subscribers)
+}
+
+// Events returns the channel the subscriber receives events on. It is closed by Close.
+func (sub *Subscription) Events() <-chan *WebhookEvent {
+	return sub.events
+}
+
+// Dropped returns how many events were dropped because the subscriber fell behind
+func (sub *Subscription) Dropped() int64 {
+	return atomic.LoadInt64(&sub.dropped)
+}
+
+// Close unregisters the subscriber and closes its event channel
+func (sub *Subscription) Close() {
+	sub.closeOnce.Do(func() {
+		sub.stream.mutex.Lock()
+		delete(sub.stream.subscribers, sub)
+		sub.stream.mutex.Unlock()
+
+		close(sub.events)
+	})
+}
+
+// matches reports whether the subscriber wants the event
+func (sub *Subscription) matches(event *WebhookEvent) bool {
+	if sub.sessionID != "" && sub.sessionID != event.SessionID {
+		return false
+	}
+	if len(sub.eventTypes) == 0 {
+		return true
+	}
+	for _, eventType := range sub.eventTypes {
+		if eventType == "All" || eventType == event.Type {
+			return true
+		}
+	}
+	return false
+}
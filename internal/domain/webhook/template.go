@@ -0,0 +1,305 @@
+package webhook
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultContentType is the content type of deliveries whose webhook has no template, or a template without one
+const DefaultContentType = "application/json"
+
+// maxTemplateBodySize limits the size of a rendered body
+const maxTemplateBodySize = 1 << 20
+
+// reservedTemplateHeaders are set by the deliverer and cannot be replaced by template or auth headers
+var reservedTemplateHeaders = map[string]bool{
+	"Content-Type":   true,
+	"Content-Length": true,
+	"Host":           true,
+}
+
+// templateFuncs are the functions available to body templates, on top of the text/template builtins
+var templateFuncs = template.FuncMap{
+	// json encodes a value as JSON, so strings are quoted and escaped inside JSON bodies
+	"json": func(v interface{}) (string, error) {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	},
+	// default returns the fallback when the value is empty
+	"default": func(fallback, v interface{}) interface{} {
+		if v == nil || v == "" || v == false || v == 0 {
+			return fallback
+		}
+		return v
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+	// unix returns the seconds since the epoch of a time
+	"unix": func(t time.Time) int64 {
+		return t.Unix()
+	},
+}
+
+// maxCachedTemplates bounds the parsed body templates kept for deliveries
+const maxCachedTemplates = 1000
+
+// parsedTemplates caches the parsed body templates of webhooks for their deliveries
+var parsedTemplates = newTemplateCache(maxCachedTemplates)
+
+// WebhookTemplate reshapes the body and headers of the deliveries of a webhook, for receivers
+// that expect their own format. The body is a Go text/template rendered against the event, so
+// {{.Type}}, {{.SessionID}} and the fields of the typed payload such as {{.Data.Text}} are available.
+type WebhookTemplate struct {
+	Body        string            `json:"body,omitempty"`         // empty sends the standard JSON event
+	ContentType string            `json:"content_type,omitempty"` // defaults to application/json
+	Headers     map[string]string `json:"headers,omitempty"`      // sent as is with every delivery
+}
+
+// RenderedPayload is an event encoded for a webhook
+type RenderedPayload struct {
+	Body        []byte
+	ContentType string
+	Headers     map[string]string
+}
+
+// IsEmpty reports whether the template changes nothing about the delivery
+func (t *WebhookTemplate) IsEmpty() bool {
+	return t == nil || (t.Body == "" && t.ContentType == "" && len(t.Headers) == 0)
+}
+
+// Validate checks that the body parses and the content type and headers are usable
+func (t *WebhookTemplate) Validate() error {
+	if t.IsEmpty() {
+		return nil
+	}
+
+	if t.Body != "" {
+		if _, err := t.parse(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWebhookTemplate, err)
+		}
+	}
+
+	if t.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(t.ContentType); err != nil {
+			return fmt.Errorf("%w: invalid content type %q", ErrInvalidWebhookTemplate, t.ContentType)
+		}
+	}
+
+	if err := validateHeaders(t.Headers); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhookTemplate, err)
+	}
+
+	// Template headers are stored in plain text, credentials belong in the encrypted auth
+	for name := range t.Headers {
+		if canonical := textproto.CanonicalMIMEHeaderKey(name); credentialHeaders[canonical] {
+			return fmt.Errorf("%w: header %s carries credentials, set it in auth.headers so it is stored encrypted", ErrInvalidWebhookTemplate, canonical)
+		}
+	}
+
+	return nil
+}
+
+// validateHeaders checks that custom headers are well formed and leave the delivery headers alone
+func validateHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		canonical := textproto.CanonicalMIMEHeaderKey(name)
+		switch {
+		case name == "" || strings.ContainsAny(name, " \t\r\n:"):
+			return fmt.Errorf("invalid header name %q", name)
+		case strings.ContainsAny(value, "\r\n"):
+			return fmt.Errorf("header %s must not contain line breaks", name)
+		case reservedTemplateHeaders[canonical]:
+			return fmt.Errorf("header %s is set by the delivery", canonical)
+		case strings.HasPrefix(canonical, "X-Zpwoot-"):
+			return fmt.Errorf("X-Zpwoot-* headers are reserved")
+		}
+	}
+	return nil
+}
+
+// Render encodes an event for the webhook. Without a body template the event is sent as JSON.
+// The body template is parsed on every call, deliveries use WebhookConfig.RenderEvent instead.
+func (t *WebhookTemplate) Render(event *WebhookEvent) (*RenderedPayload, error) {
+	return t.render(event, t.parse)
+}
+
+// RenderEvent encodes an event for a delivery of the webhook, reusing the body template
+// parsed for earlier deliveries until the webhook is updated
+func (w *WebhookConfig) RenderEvent(event *WebhookEvent) (*RenderedPayload, error) {
+	return w.Template.render(event, func() (*template.Template, error) {
+		return parsedTemplates.get(templateCacheKey{webhookID: w.ID, updatedAt: w.UpdatedAt.UnixNano()}, w.Template)
+	})
+}
+
+// render encodes an event, getting the body template from parse
+func (t *WebhookTemplate) render(event *WebhookEvent, parse func() (*template.Template, error)) (*RenderedPayload, error) {
+	rendered := &RenderedPayload{
+		ContentType: t.contentType(),
+	}
+	if t != nil {
+		rendered.Headers = t.Headers
+	}
+
+	if t == nil || t.Body == "" {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal webhook event: %w", err)
+		}
+		rendered.Body = body
+		return rendered, nil
+	}
+
+	tmpl, err := parse()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhookTemplate, err)
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&limitedBuffer{Buffer: &body, limit: maxTemplateBodySize}, event); err != nil {
+		return nil, fmt.Errorf("%w: failed to render: %v", ErrInvalidWebhookTemplate, err)
+	}
+	rendered.Body = body.Bytes()
+
+	return rendered, nil
+}
+
+// ApplyHeaders sets the template headers on a request
+func (t *WebhookTemplate) ApplyHeaders(header http.Header) {
+	if t == nil {
+		return
+	}
+	for name, value := range t.Headers {
+		header.Set(name, value)
+	}
+}
+
+// RedactedHeaders returns the template headers with the credentials of templates saved before
+// they were rejected replaced, for API responses
+func (t *WebhookTemplate) RedactedHeaders() map[string]string {
+	if t == nil || len(t.Headers) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(t.Headers))
+	for name, value := range t.Headers {
+		if credentialHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+			value = redactedValue
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+// contentType returns the content type of the rendered body
+func (t *WebhookTemplate) contentType() string {
+	if t == nil || t.ContentType == "" {
+		return DefaultContentType
+	}
+	return t.ContentType
+}
+
+// parse parses the body template
+func (t *WebhookTemplate) parse() (*template.Template, error) {
+	return template.New("body").Funcs(templateFuncs).Option("missingkey=zero").Parse(t.Body)
+}
+
+// templateCacheKey identifies a version of a webhook, whose template changes only with updatedAt
+type templateCacheKey struct {
+	webhookID uuid.UUID
+	updatedAt int64
+}
+
+// templateCacheEntry is a parsed body template and the source it was parsed from
+type templateCacheEntry struct {
+	key  templateCacheKey
+	body string
+	tmpl *template.Template
+}
+
+// templateCache keeps the most recently used parsed templates, evicting the least recently used
+// ones past its size. Old versions of updated webhooks are never used again and age out.
+type templateCache struct {
+	mutex   sync.Mutex
+	size    int
+	entries map[templateCacheKey]*list.Element
+	order   *list.List // most recently used first
+}
+
+// newTemplateCache creates a cache holding up to size templates
+func newTemplateCache(size int) *templateCache {
+	return &templateCache{
+		size:    size,
+		entries: make(map[templateCacheKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the parsed body template of a webhook version, parsing it when missing
+func (c *templateCache) get(key templateCacheKey, t *WebhookTemplate) (*template.Template, error) {
+	c.mutex.Lock()
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*templateCacheEntry)
+		// The body is compared too, in case the webhook changed without a new updatedAt
+		if entry.body == t.Body {
+			c.order.MoveToFront(element)
+			c.mutex.Unlock()
+			return entry.tmpl, nil
+		}
+	}
+	c.mutex.Unlock()
+
+	tmpl, err := t.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+	}
+	c.entries[key] = c.order.PushFront(&templateCacheEntry{key: key, body: t.Body, tmpl: tmpl})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*templateCacheEntry).key)
+	}
+
+	return tmpl, nil
+}
+
+// len returns the number of cached templates
+func (c *templateCache) len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+// limitedBuffer fails writes past the limit, so a template cannot build an unbounded body
+type limitedBuffer struct {
+	*bytes.Buffer
+	limit int
+}
+
+// Write appends to the buffer until the limit is reached
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, fmt.Errorf("rendered body exceeds %d bytes", b.limit)
+	}
+	return b.Buffer.Write(p)
+}
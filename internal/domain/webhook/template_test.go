@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTemplateValidateAndRenderDoNotCache(t *testing.T) {
+	before := parsedTemplates.len()
+
+	for i := 0; i < 10; i++ {
+		tmpl := &WebhookTemplate{Body: `{"n":` + strconv.Itoa(i) + `,"type":{{json .Type}}}`}
+		if err := tmpl.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		if _, err := tmpl.Render(&WebhookEvent{Type: "Message"}); err != nil {
+			t.Fatalf("Render: %v", err)
+		}
+	}
+
+	if after := parsedTemplates.len(); after != before {
+		t.Fatalf("validating and previewing templates cached %d templates", after-before)
+	}
+}
+
+func TestRenderEventReusesTheParsedTemplateOfTheWebhook(t *testing.T) {
+	wh := &WebhookConfig{
+		ID:        uuid.New(),
+		Template:  &WebhookTemplate{Body: `{"type":{{json .Type}}}`},
+		UpdatedAt: time.Now(),
+	}
+	event := &WebhookEvent{Type: "Message"}
+
+	rendered, err := wh.RenderEvent(event)
+	if err != nil {
+		t.Fatalf("RenderEvent: %v", err)
+	}
+	if string(rendered.Body) != `{"type":"Message"}` {
+		t.Fatalf("body = %s", rendered.Body)
+	}
+
+	key := templateCacheKey{webhookID: wh.ID, updatedAt: wh.UpdatedAt.UnixNano()}
+	first, err := parsedTemplates.get(key, wh.Template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := parsedTemplates.get(key, wh.Template)
+	if err != nil || first != second {
+		t.Fatal("the parsed template was not reused")
+	}
+
+	// An updated webhook renders with its new template
+	wh.Template = &WebhookTemplate{Body: `{"kind":{{json .Type}}}`}
+	wh.UpdatedAt = wh.UpdatedAt.Add(time.Second)
+	rendered, err = wh.RenderEvent(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rendered.Body) != `{"kind":"Message"}` {
+		t.Fatalf("body after update = %s", rendered.Body)
+	}
+
+	// A changed body is picked up even without a new updatedAt
+	wh.Template = &WebhookTemplate{Body: `{"event":{{json .Type}}}`}
+	rendered, err = wh.RenderEvent(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rendered.Body) != `{"event":"Message"}` {
+		t.Fatalf("body after an in-place change = %s", rendered.Body)
+	}
+}
+
+func TestTemplateCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newTemplateCache(2)
+	tmpl := &WebhookTemplate{Body: `{{.Type}}`}
+	keys := []templateCacheKey{{webhookID: uuid.New()}, {webhookID: uuid.New()}, {webhookID: uuid.New()}}
+
+	first, _ := cache.get(keys[0], tmpl)
+	cache.get(keys[1], tmpl)
+	// Using the first template again makes the second the least recently used
+	cache.get(keys[0], tmpl)
+	cache.get(keys[2], tmpl)
+
+	if cache.len() != 2 {
+		t.Fatalf("cache holds %d templates, want 2", cache.len())
+	}
+	if _, ok := cache.entries[keys[1]]; ok {
+		t.Error("least recently used template was kept")
+	}
+	if again, _ := cache.get(keys[0], tmpl); again != first {
+		t.Error("recently used template was evicted")
+	}
+}
+
+func TestTemplateValidateHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		wantErr bool
+	}{
+		{name: "custom header", headers: map[string]string{"X-Source": "zpwoot"}},
+		{name: "authorization", headers: map[string]string{"Authorization": "Bearer token"}, wantErr: true},
+		{name: "lowercase authorization", headers: map[string]string{"authorization": "Bearer token"}, wantErr: true},
+		{name: "proxy authorization", headers: map[string]string{"Proxy-Authorization": "Basic dXNlcjpwYXNz"}, wantErr: true},
+		{name: "reserved header", headers: map[string]string{"Content-Type": "text/plain"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&WebhookTemplate{Headers: tt.headers}).Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidWebhookTemplate) {
+				t.Errorf("Validate() error = %v, want ErrInvalidWebhookTemplate", err)
+			}
+		})
+	}
+}
+
+func TestTemplateRedactedHeaders(t *testing.T) {
+	tmpl := &WebhookTemplate{Headers: map[string]string{"authorization": "Bearer token", "X-Source": "zpwoot"}}
+
+	headers := tmpl.RedactedHeaders()
+	if headers["authorization"] != redactedValue {
+		t.Errorf("authorization = %q, want %q", headers["authorization"], redactedValue)
+	}
+	if headers["X-Source"] != "zpwoot" {
+		t.Errorf("X-Source = %q, want %q", headers["X-Source"], "zpwoot")
+	}
+	if tmpl.Headers["authorization"] != "Bearer token" {
+		t.Error("RedactedHeaders changed the template")
+	}
+}
@@ -0,0 +1,3 @@
+-- Drop webhook deliveries table
+DROP TRIGGER IF EXISTS update_zp_webhook_deliveries_updated_at ON "zpWebhookDeliveries";
+DROP TABLE IF EXISTS "zpWebhookDeliveries";
@@ -0,0 +1,51 @@
+-- Create webhook deliveries table
+CREATE TABLE IF NOT EXISTS "zpWebhookDeliveries" (
+    "id" UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    "webhookId" UUID NOT NULL REFERENCES "zpWebhooks"("id") ON DELETE CASCADE,
+    "sessionId" UUID,
+    "eventId" VARCHAR(255) NOT NULL,
+    "eventType" VARCHAR(100) NOT NULL,
+    "url" VARCHAR(2048) NOT NULL,
+    "payload" TEXT NOT NULL,
+    "statusCode" INTEGER NOT NULL DEFAULT 0,
+    "responseBody" TEXT,
+    "latency" BIGINT NOT NULL DEFAULT 0,
+    "success" BOOLEAN NOT NULL DEFAULT false,
+    "error" TEXT,
+    "attempts" INTEGER NOT NULL DEFAULT 1,
+    "nextRetryAt" TIMESTAMP WITH TIME ZONE,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    "updatedAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS "idx_zp_webhook_deliveries_webhook_id" ON "zpWebhookDeliveries" ("webhookId");
+CREATE INDEX IF NOT EXISTS "idx_zp_webhook_deliveries_event_id" ON "zpWebhookDeliveries" ("eventId");
+CREATE INDEX IF NOT EXISTS "idx_zp_webhook_deliveries_created_at" ON "zpWebhookDeliveries" ("createdAt");
+CREATE INDEX IF NOT EXISTS "idx_zp_webhook_deliveries_next_retry_at" ON "zpWebhookDeliveries" ("nextRetryAt")
+    WHERE "success" = false AND "nextRetryAt" IS NOT NULL;
+
+-- Create trigger to automatically update updatedAt
+CREATE TRIGGER update_zp_webhook_deliveries_updated_at
+    BEFORE UPDATE ON "zpWebhookDeliveries"
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments for documentation
+COMMENT ON TABLE "zpWebhookDeliveries" IS 'Webhook delivery log used for auditing and retries';
+COMMENT ON COLUMN "zpWebhookDeliveries"."id" IS 'Unique delivery identifier';
+COMMENT ON COLUMN "zpWebhookDeliveries"."webhookId" IS 'Webhook the event was delivered to';
+COMMENT ON COLUMN "zpWebhookDeliveries"."sessionId" IS 'Session that produced the event (NULL for events without session)';
+COMMENT ON COLUMN "zpWebhookDeliveries"."eventId" IS 'Event identifier sent in the X-Zpwoot-Event-Id header';
+COMMENT ON COLUMN "zpWebhookDeliveries"."eventType" IS 'Event type';
+COMMENT ON COLUMN "zpWebhookDeliveries"."url" IS 'Webhook URL at the time of delivery';
+COMMENT ON COLUMN "zpWebhookDeliveries"."payload" IS 'Exact JSON body sent to the receiver';
+COMMENT ON COLUMN "zpWebhookDeliveries"."statusCode" IS 'HTTP status of the last attempt (0 when no response)';
+COMMENT ON COLUMN "zpWebhookDeliveries"."responseBody" IS 'Truncated response body of the last attempt';
+COMMENT ON COLUMN "zpWebhookDeliveries"."latency" IS 'Latency of the last attempt in milliseconds';
+COMMENT ON COLUMN "zpWebhookDeliveries"."success" IS 'Whether the event was delivered successfully';
+COMMENT ON COLUMN "zpWebhookDeliveries"."error" IS 'Error of the last failed attempt';
+COMMENT ON COLUMN "zpWebhookDeliveries"."attempts" IS 'Number of delivery attempts made';
+COMMENT ON COLUMN "zpWebhookDeliveries"."nextRetryAt" IS 'When the next retry is due (NULL when no retry is scheduled)';
+COMMENT ON COLUMN "zpWebhookDeliveries"."createdAt" IS 'First attempt timestamp';
+COMMENT ON COLUMN "zpWebhookDeliveries"."updatedAt" IS 'Last update timestamp';
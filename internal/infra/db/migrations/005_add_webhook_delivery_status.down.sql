@@ -0,0 +1,5 @@
+-- Remove delivery status tracking
+DROP INDEX IF EXISTS "idx_zp_webhook_deliveries_dead_letter";
+DROP INDEX IF EXISTS "idx_zp_webhook_deliveries_status";
+ALTER TABLE "zpWebhookDeliveries" DROP COLUMN IF EXISTS "deadLetterReason";
+ALTER TABLE "zpWebhookDeliveries" DROP COLUMN IF EXISTS "status";
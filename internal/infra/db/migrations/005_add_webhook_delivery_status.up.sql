@@ -0,0 +1,19 @@
+-- Track delivery lifecycle so exhausted deliveries can be dead-lettered and replayed
+ALTER TABLE "zpWebhookDeliveries" ADD COLUMN IF NOT EXISTS "status" VARCHAR(20) NOT NULL DEFAULT 'retrying';
+ALTER TABLE "zpWebhookDeliveries" ADD COLUMN IF NOT EXISTS "deadLetterReason" TEXT;
+
+-- Backfill status for existing deliveries
+UPDATE "zpWebhookDeliveries" SET "status" = CASE
+    WHEN "success" = true THEN 'delivered'
+    WHEN "nextRetryAt" IS NOT NULL THEN 'retrying'
+    ELSE 'dead_letter'
+END;
+
+-- Create indexes for dead-letter lookups
+CREATE INDEX IF NOT EXISTS "idx_zp_webhook_deliveries_status" ON "zpWebhookDeliveries" ("status");
+CREATE INDEX IF NOT EXISTS "idx_zp_webhook_deliveries_dead_letter" ON "zpWebhookDeliveries" ("webhookId", "createdAt")
+    WHERE "status" = 'dead_letter';
+
+-- Add comments for documentation
+COMMENT ON COLUMN "zpWebhookDeliveries"."status" IS 'Delivery status: delivered, retrying or dead_letter';
+COMMENT ON COLUMN "zpWebhookDeliveries"."deadLetterReason" IS 'Why the delivery stopped being retried';
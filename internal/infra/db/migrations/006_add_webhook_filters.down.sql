@@ -0,0 +1,2 @@
+-- Remove webhook content filters
+ALTER TABLE "zpWebhooks" DROP COLUMN IF EXISTS "filters";
@@ -0,0 +1,5 @@
+-- Add content filters evaluated before each delivery
+ALTER TABLE "zpWebhooks" ADD COLUMN IF NOT EXISTS "filters" JSONB;
+
+-- Add comments for documentation
+COMMENT ON COLUMN "zpWebhooks"."filters" IS 'Content filters (chats, senders, chat type, fromMe, message types, status broadcasts); NULL delivers every subscribed event';
@@ -0,0 +1,3 @@
+-- Drop event outbox tables
+DROP TABLE IF EXISTS "zpEventOutboxCheckpoints";
+DROP TABLE IF EXISTS "zpEventOutbox";
@@ -0,0 +1,32 @@
+-- Create event outbox, the durable log every normalized event is written to before delivery
+CREATE TABLE IF NOT EXISTS "zpEventOutbox" (
+    "sequence" BIGSERIAL PRIMARY KEY,
+    "eventId" VARCHAR(255) NOT NULL UNIQUE,
+    "sessionId" UUID,
+    "eventType" VARCHAR(100) NOT NULL,
+    "payload" JSONB NOT NULL,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS "idx_zp_event_outbox_created_at" ON "zpEventOutbox" ("createdAt");
+
+-- Create outbox checkpoints, the position each dispatcher has consumed the outbox up to
+CREATE TABLE IF NOT EXISTS "zpEventOutboxCheckpoints" (
+    "consumer" VARCHAR(100) PRIMARY KEY,
+    "sequence" BIGINT NOT NULL DEFAULT 0,
+    "updatedAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Add comments for documentation
+COMMENT ON TABLE "zpEventOutbox" IS 'Transactional outbox of events consumed by webhooks, broker sinks and other dispatchers';
+COMMENT ON COLUMN "zpEventOutbox"."sequence" IS 'Position of the event in the outbox, consumers read it in this order';
+COMMENT ON COLUMN "zpEventOutbox"."eventId" IS 'Event identifier sent in the X-Zpwoot-Event-Id header';
+COMMENT ON COLUMN "zpEventOutbox"."sessionId" IS 'Session that produced the event (NULL for events without session)';
+COMMENT ON COLUMN "zpEventOutbox"."eventType" IS 'Event type';
+COMMENT ON COLUMN "zpEventOutbox"."payload" IS 'Normalized event as delivered to consumers';
+COMMENT ON COLUMN "zpEventOutbox"."createdAt" IS 'When the event was recorded';
+COMMENT ON TABLE "zpEventOutboxCheckpoints" IS 'Outbox position processed by each dispatcher';
+COMMENT ON COLUMN "zpEventOutboxCheckpoints"."consumer" IS 'Dispatcher name';
+COMMENT ON COLUMN "zpEventOutboxCheckpoints"."sequence" IS 'Last outbox sequence the dispatcher has processed';
+COMMENT ON COLUMN "zpEventOutboxCheckpoints"."updatedAt" IS 'Last checkpoint update timestamp';
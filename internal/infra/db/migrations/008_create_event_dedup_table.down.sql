@@ -0,0 +1,2 @@
+-- Drop event dedup table
+DROP TABLE IF EXISTS "zpEventDedup";
@@ -0,0 +1,18 @@
+-- Create event dedup keys, the inbound events already recorded in the outbox
+CREATE TABLE IF NOT EXISTS "zpEventDedup" (
+    "sessionId" UUID NOT NULL REFERENCES "zpSessions"("id") ON DELETE CASCADE,
+    "dedupKey" VARCHAR(64) NOT NULL,
+    "eventId" VARCHAR(255) NOT NULL,
+    "expiresAt" TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY ("sessionId", "dedupKey")
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS "idx_zp_event_dedup_expires_at" ON "zpEventDedup" ("expiresAt");
+
+-- Add comments for documentation
+COMMENT ON TABLE "zpEventDedup" IS 'Inbound events recorded recently, used to drop duplicates redelivered by WhatsApp';
+COMMENT ON COLUMN "zpEventDedup"."sessionId" IS 'Session that received the event';
+COMMENT ON COLUMN "zpEventDedup"."dedupKey" IS 'SHA-256 of the event kind, chat, sender and message IDs';
+COMMENT ON COLUMN "zpEventDedup"."eventId" IS 'Event recorded for the key';
+COMMENT ON COLUMN "zpEventDedup"."expiresAt" IS 'When the key stops matching duplicates';
@@ -0,0 +1,2 @@
+-- Remove webhook payload templates
+ALTER TABLE "zpWebhooks" DROP COLUMN IF EXISTS "template";
@@ -0,0 +1,5 @@
+-- Add payload templates that reshape the body and headers of deliveries
+ALTER TABLE "zpWebhooks" ADD COLUMN IF NOT EXISTS "template" JSONB;
+
+-- Add comments for documentation
+COMMENT ON COLUMN "zpWebhooks"."template" IS 'Payload template (text/template body, content type, headers); NULL sends the standard JSON event';
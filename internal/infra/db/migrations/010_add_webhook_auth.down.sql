@@ -0,0 +1,2 @@
+-- Remove webhook receiver credentials
+ALTER TABLE "zpWebhooks" DROP COLUMN IF EXISTS "auth";
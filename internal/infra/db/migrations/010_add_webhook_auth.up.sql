@@ -0,0 +1,5 @@
+-- Add receiver credentials (headers, basic, bearer, OAuth2 client credentials, client TLS)
+ALTER TABLE "zpWebhooks" ADD COLUMN IF NOT EXISTS "auth" TEXT;
+
+-- Add comments for documentation
+COMMENT ON COLUMN "zpWebhooks"."auth" IS 'Receiver credentials as JSON encrypted with AES-256-GCM using WEBHOOK_ENCRYPTION_KEY; NULL when the webhook sends none';
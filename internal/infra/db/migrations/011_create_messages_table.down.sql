@@ -0,0 +1,3 @@
+-- Drop messages table
+DROP TRIGGER IF EXISTS update_zp_messages_updated_at ON "zpMessages";
+DROP TABLE IF EXISTS "zpMessages";
@@ -0,0 +1,66 @@
+-- Create messages table, the history of every message received or sent by the sessions
+CREATE TABLE IF NOT EXISTS "zpMessages" (
+    "id" UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    "sessionId" UUID NOT NULL REFERENCES "zpSessions"("id") ON DELETE CASCADE,
+    "messageId" VARCHAR(255) NOT NULL,
+    "chatJid" VARCHAR(255) NOT NULL,
+    "senderJid" VARCHAR(255) NOT NULL,
+    "fromMe" BOOLEAN NOT NULL DEFAULT false,
+    "isGroup" BOOLEAN NOT NULL DEFAULT false,
+    "pushName" VARCHAR(255),
+    "messageType" VARCHAR(50) NOT NULL,
+    "text" TEXT,
+    "caption" TEXT,
+    "mimeType" VARCHAR(255),
+    "fileName" VARCHAR(1024),
+    "fileSize" BIGINT,
+    "mediaUrl" TEXT,
+    "directPath" TEXT,
+    "mediaKey" VARCHAR(255),
+    "fileSha256" VARCHAR(255),
+    "fileEncSha256" VARCHAR(255),
+    "quotedMessageId" VARCHAR(255),
+    "timestamp" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "raw" JSONB,
+    "createdAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    "updatedAt" TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE ("sessionId", "chatJid", "messageId")
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS "idx_zp_messages_session_chat_timestamp" ON "zpMessages" ("sessionId", "chatJid", "timestamp" DESC);
+CREATE INDEX IF NOT EXISTS "idx_zp_messages_session_message_id" ON "zpMessages" ("sessionId", "messageId");
+CREATE INDEX IF NOT EXISTS "idx_zp_messages_timestamp" ON "zpMessages" ("timestamp");
+
+-- Create trigger to automatically update updatedAt
+CREATE TRIGGER update_zp_messages_updated_at
+    BEFORE UPDATE ON "zpMessages"
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Add comments for documentation
+COMMENT ON TABLE "zpMessages" IS 'Messages received and sent by the sessions';
+COMMENT ON COLUMN "zpMessages"."id" IS 'Unique record identifier';
+COMMENT ON COLUMN "zpMessages"."sessionId" IS 'Session that received or sent the message';
+COMMENT ON COLUMN "zpMessages"."messageId" IS 'WhatsApp message ID';
+COMMENT ON COLUMN "zpMessages"."chatJid" IS 'Chat the message belongs to';
+COMMENT ON COLUMN "zpMessages"."senderJid" IS 'Author of the message';
+COMMENT ON COLUMN "zpMessages"."fromMe" IS 'Whether the message was sent by the session account';
+COMMENT ON COLUMN "zpMessages"."isGroup" IS 'Whether the chat is a group';
+COMMENT ON COLUMN "zpMessages"."pushName" IS 'Display name of the sender at the time of the message';
+COMMENT ON COLUMN "zpMessages"."messageType" IS 'Content type, as used by webhook filters (text, image, reaction...)';
+COMMENT ON COLUMN "zpMessages"."text" IS 'Text of text messages';
+COMMENT ON COLUMN "zpMessages"."caption" IS 'Caption of media messages';
+COMMENT ON COLUMN "zpMessages"."mimeType" IS 'MIME type of the media';
+COMMENT ON COLUMN "zpMessages"."fileName" IS 'File name of documents';
+COMMENT ON COLUMN "zpMessages"."fileSize" IS 'Size of the media in bytes';
+COMMENT ON COLUMN "zpMessages"."mediaUrl" IS 'WhatsApp CDN URL of the encrypted media';
+COMMENT ON COLUMN "zpMessages"."directPath" IS 'WhatsApp CDN path of the encrypted media';
+COMMENT ON COLUMN "zpMessages"."mediaKey" IS 'Base64 key that decrypts the media';
+COMMENT ON COLUMN "zpMessages"."fileSha256" IS 'Base64 SHA-256 of the decrypted media';
+COMMENT ON COLUMN "zpMessages"."fileEncSha256" IS 'Base64 SHA-256 of the encrypted media';
+COMMENT ON COLUMN "zpMessages"."quotedMessageId" IS 'ID of the message this one replies to';
+COMMENT ON COLUMN "zpMessages"."timestamp" IS 'When the message was sent, as reported by WhatsApp';
+COMMENT ON COLUMN "zpMessages"."raw" IS 'Message protobuf encoded as JSON';
+COMMENT ON COLUMN "zpMessages"."createdAt" IS 'When the message was stored';
+COMMENT ON COLUMN "zpMessages"."updatedAt" IS 'Last update timestamp';
@@ -0,0 +1,3 @@
+-- Remove message read state
+DROP INDEX IF EXISTS "idx_zp_messages_unread";
+ALTER TABLE "zpMessages" DROP COLUMN IF EXISTS "readAt";
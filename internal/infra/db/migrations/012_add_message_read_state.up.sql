@@ -0,0 +1,9 @@
+-- Add read state to messages, so chats can report how many received messages are unread
+ALTER TABLE "zpMessages" ADD COLUMN IF NOT EXISTS "readAt" TIMESTAMP WITH TIME ZONE;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS "idx_zp_messages_unread" ON "zpMessages" ("sessionId", "chatJid")
+    WHERE "fromMe" = false AND "readAt" IS NULL;
+
+-- Add comments for documentation
+COMMENT ON COLUMN "zpMessages"."readAt" IS 'When the session account read the received message (NULL while unread or for sent messages)';
@@ -0,0 +1,4 @@
+-- Remove full-text search over messages
+DROP INDEX IF EXISTS "idx_zp_messages_sender";
+DROP INDEX IF EXISTS "idx_zp_messages_search_en";
+DROP INDEX IF EXISTS "idx_zp_messages_search_pt";
@@ -0,0 +1,17 @@
+-- Add full-text search over message text, captions and document file names.
+-- Messages are indexed with both the Portuguese and English configurations, so stemming works for either language.
+-- Dots, underscores and dashes of file names are read as spaces, so "pedido_123.pdf" matches "pedido".
+CREATE INDEX IF NOT EXISTS "idx_zp_messages_search_pt" ON "zpMessages" USING GIN (
+    to_tsvector('portuguese', coalesce(text, '') || ' ' || coalesce(caption, '') || ' ' || coalesce(translate("fileName", '._-', '   '), ''))
+);
+
+CREATE INDEX IF NOT EXISTS "idx_zp_messages_search_en" ON "zpMessages" USING GIN (
+    to_tsvector('english', coalesce(text, '') || ' ' || coalesce(caption, '') || ' ' || coalesce(translate("fileName", '._-', '   '), ''))
+);
+
+-- Speed up searches narrowed to a sender
+CREATE INDEX IF NOT EXISTS "idx_zp_messages_sender" ON "zpMessages" ("sessionId", "senderJid", "timestamp" DESC);
+
+-- Add comments for documentation
+COMMENT ON INDEX "idx_zp_messages_search_pt" IS 'Full-text search of text, caption and file name with the Portuguese configuration';
+COMMENT ON INDEX "idx_zp_messages_search_en" IS 'Full-text search of text, caption and file name with the English configuration';
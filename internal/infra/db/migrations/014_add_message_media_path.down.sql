@@ -0,0 +1,2 @@
+-- Remove the location of downloaded media from messages
+ALTER TABLE "zpMessages" DROP COLUMN IF EXISTS "mediaPath";
@@ -0,0 +1,5 @@
+-- Add the location of downloaded media to messages
+ALTER TABLE "zpMessages" ADD COLUMN IF NOT EXISTS "mediaPath" TEXT;
+
+-- Add comments for documentation
+COMMENT ON COLUMN "zpMessages"."mediaPath" IS 'Downloaded and decrypted media file, relative to the media directory (NULL until downloaded)';
@@ -0,0 +1,4 @@
+-- Restore the location of downloaded media
+ALTER TABLE "zpMessages" RENAME COLUMN "mediaStoreKey" TO "mediaPath";
+
+COMMENT ON COLUMN "zpMessages"."mediaPath" IS 'Downloaded and decrypted media file, relative to the media directory (NULL until downloaded)';
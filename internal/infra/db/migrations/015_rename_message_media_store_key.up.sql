@@ -0,0 +1,6 @@
+-- Media is now kept in a media store under content-addressed keys. Paths recorded by earlier
+-- versions stay valid keys of a local store over the same directory.
+ALTER TABLE "zpMessages" RENAME COLUMN "mediaPath" TO "mediaStoreKey";
+
+-- Add comments for documentation
+COMMENT ON COLUMN "zpMessages"."mediaStoreKey" IS 'Key of the media file in the media store, for received media once downloaded and for sent media once uploaded (NULL until stored)';
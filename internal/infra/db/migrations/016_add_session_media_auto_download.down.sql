@@ -0,0 +1,2 @@
+-- Remove the per-session override of MEDIA_AUTO_DOWNLOAD
+ALTER TABLE "zpSessions" DROP COLUMN IF EXISTS "mediaAutoDownload";
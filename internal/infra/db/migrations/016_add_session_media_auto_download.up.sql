@@ -0,0 +1,5 @@
+-- Add a per-session override of MEDIA_AUTO_DOWNLOAD
+ALTER TABLE "zpSessions" ADD COLUMN IF NOT EXISTS "mediaAutoDownload" BOOLEAN;
+
+-- Add comments for documentation
+COMMENT ON COLUMN "zpSessions"."mediaAutoDownload" IS 'Whether received media is downloaded as messages arrive (NULL follows MEDIA_AUTO_DOWNLOAD)';
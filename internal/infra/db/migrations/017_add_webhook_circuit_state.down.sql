@@ -0,0 +1,3 @@
+-- Remove the stored circuit of webhooks
+ALTER TABLE "zpWebhooks" DROP COLUMN IF EXISTS "circuitOpenedAt";
+ALTER TABLE "zpWebhooks" DROP COLUMN IF EXISTS "circuitFailures";
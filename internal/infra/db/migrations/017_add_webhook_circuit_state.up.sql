@@ -0,0 +1,7 @@
+-- Keep the open circuit of webhooks across restarts
+ALTER TABLE "zpWebhooks" ADD COLUMN IF NOT EXISTS "circuitFailures" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "zpWebhooks" ADD COLUMN IF NOT EXISTS "circuitOpenedAt" TIMESTAMP WITH TIME ZONE;
+
+-- Add comments for documentation
+COMMENT ON COLUMN "zpWebhooks"."circuitFailures" IS 'Consecutive failed deliveries while the circuit is open';
+COMMENT ON COLUMN "zpWebhooks"."circuitOpenedAt" IS 'When the circuit opened and deliveries were paused; NULL while deliveries go through';
@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+
+	"zpwoot/internal/app"
+	messageApp "zpwoot/internal/app/message"
+	"zpwoot/internal/domain/message"
+	"zpwoot/internal/domain/session"
+	"zpwoot/internal/infra/http/helpers"
+	"zpwoot/internal/ports"
+	"zpwoot/platform/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ChatHandler serves the chats and message history of sessions
+type ChatHandler struct {
+	messageUC       app.MessageUseCase
+	sessionResolver *helpers.SessionResolver
+	logger          *logger.Logger
+}
+
+// NewChatHandler creates a new chat handler
+func NewChatHandler(messageUC app.MessageUseCase, sessionRepo helpers.SessionRepository, appLogger *logger.Logger) *ChatHandler {
+	return &ChatHandler{
+		messageUC:       messageUC,
+		sessionResolver: helpers.NewSessionResolver(appLogger, sessionRepo),
+		logger:          appLogger,
+	}
+}
+
+// ListChats lists the chats of a session
+// @Summary List chats
+// @Description Lists the chats of a session from the message history, most recently active first, with their latest message and the number of received messages not read yet. Use nextCursor as cursor to fetch the next page. Requires API key authentication.
+// @Tags Chats
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param cursor query string false "Cursor returned as nextCursor by the previous page"
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Success 200 {object} zpwoot_internal_app_message.ListChatsResponse "Chats retrieved successfully"
+// @Failure 400 {object} object "Invalid query parameters"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/chats [get]
+func (h *ChatHandler) ListChats(c *fiber.Ctx) error {
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), c.Params("sessionId"))
+	if err != nil {
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	}
+
+	var req app.ListChatsRequest
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid query parameters"))
+	}
+
+	result, err := h.messageUC.ListChats(c.Context(), sess.ID.String(), &req)
+	if err != nil {
+		return h.historyError(c, err, "Failed to list chats")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Chats retrieved successfully"))
+}
+
+// ListMessages lists the message history of a chat
+// @Summary List chat messages
+// @Description Lists the received and sent messages of a chat, newest first by default. Narrow the history with before and after (RFC3339, exclusive) and types (message types, comma separated or repeated), and use nextCursor as cursor to fetch the next page in the same order. Requires API key authentication.
+// @Tags Chats
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param jid path string true "Chat JID, or phone number for direct chats" example("5511999999999@s.whatsapp.net")
+// @Param cursor query string false "Cursor returned as nextCursor by the previous page"
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param before query string false "Only messages sent before this RFC3339 time" example("2024-01-02T00:00:00Z")
+// @Param after query string false "Only messages sent after this RFC3339 time" example("2024-01-01T00:00:00Z")
+// @Param types query string false "Message types, comma separated" example("text,image")
+// @Param order query string false "desc (newest first) or asc (oldest first)" default(desc)
+// @Success 200 {object} zpwoot_internal_app_message.ListMessagesResponse "Messages retrieved successfully"
+// @Failure 400 {object} object "Invalid query parameters"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/chats/{jid}/messages [get]
+func (h *ChatHandler) ListMessages(c *fiber.Ctx) error {
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), c.Params("sessionId"))
+	if err != nil {
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	}
+
+	chatJID, err := url.PathUnescape(c.Params("jid"))
+	if err != nil || chatJID == "" {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid chat JID"))
+	}
+
+	var req app.ListMessagesRequest
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid query parameters"))
+	}
+
+	result, err := h.messageUC.ListMessages(c.Context(), sess.ID.String(), chatJID, &req)
+	if err != nil {
+		return h.historyError(c, err, "Failed to list messages")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Messages retrieved successfully"))
+}
+
+// SearchMessages searches the message history of a session
+// @Summary Search session messages
+// @Description Full-text search over the text, captions and document file names of the stored messages of a session, matched in Portuguese and English. q accepts web search syntax: "quoted phrases", OR and -excluded words. Results come most relevant first, each with a snippet where matched words are wrapped in <mark> and the rest is HTML escaped. Narrow the search with chat, sender, before and after (RFC3339, exclusive) and types. Requires API key authentication.
+// @Tags Chats
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param q query string true "Search query" example("pedido 12345")
+// @Param chat query string false "Chat JID, or phone number for direct chats" example("5511999999999@s.whatsapp.net")
+// @Param sender query string false "Sender JID or phone number" example("5511999999999@s.whatsapp.net")
+// @Param before query string false "Only messages sent before this RFC3339 time" example("2024-01-02T00:00:00Z")
+// @Param after query string false "Only messages sent after this RFC3339 time" example("2024-01-01T00:00:00Z")
+// @Param types query string false "Message types, comma separated" example("text,document")
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} zpwoot_internal_app_message.SearchMessagesResponse "Messages found"
+// @Failure 400 {object} object "Invalid query parameters"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/messages/search [get]
+func (h *ChatHandler) SearchMessages(c *fiber.Ctx) error {
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), c.Params("sessionId"))
+	if err != nil {
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	}
+
+	return h.search(c, sess.ID.String())
+}
+
+// SearchAllMessages searches the message history of every session
+// @Summary Search messages of all sessions
+// @Description Same search as /sessions/{sessionId}/messages/search across the stored messages of every session, for administrators handling several numbers. Each result carries the sessionId it belongs to; pass sessionId to narrow the search to one session. Requires API key authentication.
+// @Tags Chats
+// @Produce json
+// @Security ApiKeyAuth
+// @Param q query string true "Search query" example("pedido 12345")
+// @Param sessionId query string false "Session ID or name" example("my-session")
+// @Param chat query string false "Chat JID, or phone number for direct chats" example("5511999999999@s.whatsapp.net")
+// @Param sender query string false "Sender JID or phone number" example("5511999999999@s.whatsapp.net")
+// @Param before query string false "Only messages sent before this RFC3339 time" example("2024-01-02T00:00:00Z")
+// @Param after query string false "Only messages sent after this RFC3339 time" example("2024-01-01T00:00:00Z")
+// @Param types query string false "Message types, comma separated" example("text,document")
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param offset query int false "Number of results to skip" default(0)
+// @Success 200 {object} zpwoot_internal_app_message.SearchMessagesResponse "Messages found"
+// @Failure 400 {object} object "Invalid query parameters"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /messages/search [get]
+func (h *ChatHandler) SearchAllMessages(c *fiber.Ctx) error {
+	sessionID := ""
+	if idOrName := c.Query("sessionId"); idOrName != "" {
+		sess, err := h.sessionResolver.ResolveSession(c.Context(), idOrName)
+		if err != nil {
+			return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+		}
+		sessionID = sess.ID.String()
+	}
+
+	return h.search(c, sessionID)
+}
+
+// search runs a message search, across every session when sessionID is empty
+func (h *ChatHandler) search(c *fiber.Ctx, sessionID string) error {
+	var req app.SearchMessagesRequest
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid query parameters"))
+	}
+
+	result, err := h.messageUC.SearchMessages(c.Context(), sessionID, &req)
+	if err != nil {
+		return h.historyError(c, err, "Failed to search messages")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Messages found"))
+}
+
+// GetMessageMedia serves the media of a message
+// @Summary Get message media
+// @Description Streams the image, audio, video, document or sticker of a received or sent message with its content type and file name. The first access downloads and decrypts received media from WhatsApp, which needs the session to be connected; the file is kept in the media store, so later accesses work offline. Sent media is kept when it is sent. WhatsApp keeps media for a limited time, older media missing from the media store answers 410. Requires API key authentication.
+// @Tags Chats
+// @Produce octet-stream
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param messageId path string true "WhatsApp message ID" example("3EB0C767D71D")
+// @Param download query bool false "Serve as attachment instead of inline; media other than images, audio and video is always an attachment" default(false)
+// @Success 200 {file} file "Media file"
+// @Failure 400 {object} object "Session is not connected"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session, message or media not found"
+// @Failure 410 {object} object "Media expired on WhatsApp servers"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/messages/{messageId}/media [get]
+func (h *ChatHandler) GetMessageMedia(c *fiber.Ctx) error {
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), c.Params("sessionId"))
+	if err != nil {
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	}
+
+	reader, media, err := h.messageUC.GetMedia(c.Context(), sess.ID.String(), c.Params("messageId"))
+	if err != nil {
+		return h.mediaError(c, err, "Failed to get message media")
+	}
+
+	return h.sendMedia(c, reader, media)
+}
+
+// GetMessageMediaURL returns a presigned URL of the media of a message
+// @Summary Get message media URL
+// @Description Returns a temporary URL that downloads the media of a received or sent message without the API key, to hand to browsers or other services. With local media storage the URL points at this API under /media; with S3 storage it points at the bucket. The media is downloaded from WhatsApp first when it is not in the media store yet. Requires API key authentication.
+// @Tags Chats
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param messageId path string true "WhatsApp message ID" example("3EB0C767D71D")
+// @Param expires query int false "Validity in seconds (max 604800)" default(3600)
+// @Success 200 {object} zpwoot_internal_app_message.MediaURLResponse "Media URL created successfully"
+// @Failure 400 {object} object "Invalid expiry or session is not connected"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session, message or media not found"
+// @Failure 410 {object} object "Media expired on WhatsApp servers"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/messages/{messageId}/media/url [get]
+func (h *ChatHandler) GetMessageMediaURL(c *fiber.Ctx) error {
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), c.Params("sessionId"))
+	if err != nil {
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	}
+
+	var req app.MediaURLRequest
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid query parameters"))
+	}
+
+	result, err := h.messageUC.GetMediaURL(c.Context(), sess.ID.String(), c.Params("messageId"), &req)
+	if err != nil {
+		return h.mediaError(c, err, "Failed to get message media URL")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Media URL created successfully"))
+}
+
+// ServeSignedMedia serves media through a presigned URL of the local media store
+// @Summary Download media through a presigned URL
+// @Description Serves the media a presigned URL was created for, checking its signature and expiry instead of the API key. Only used with local media storage.
+// @Tags Chats
+// @Produce octet-stream
+// @Param key path string true "Media store key"
+// @Param expires query int true "Expiry as unix time"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file "Media file"
+// @Failure 403 {object} object "Invalid or expired signature"
+// @Failure 404 {object} object "Media not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /media/{key} [get]
+func (h *ChatHandler) ServeSignedMedia(c *fiber.Ctx) error {
+	query, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return c.Status(403).JSON(app.NewErrorResponse("Invalid or expired signature"))
+	}
+
+	reader, media, err := h.messageUC.OpenSignedMedia(c.Context(), c.Params("*"), query)
+	if err != nil {
+		if errors.Is(err, message.ErrInvalidMediaSignature) {
+			return c.Status(403).JSON(app.NewErrorResponse("Invalid or expired signature"))
+		}
+		return h.mediaError(c, err, "Failed to get media")
+	}
+
+	return h.sendMedia(c, reader, media)
+}
+
+// sendMedia streams a media file with its content type and file name.
+// The media comes from chats, so only images, audio and video are shown inline and browsers
+// must not sniff another type; documents, HTML and SVG are always downloaded.
+func (h *ChatHandler) sendMedia(c *fiber.Ctx, reader io.ReadCloser, media *ports.MediaFile) error {
+	disposition := "inline"
+	if c.QueryBool("download") || !isInlineMediaType(media.MimeType) {
+		disposition = "attachment"
+	}
+
+	c.Set(fiber.HeaderContentType, media.MimeType)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	if media.FileName != "" {
+		c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": media.FileName}))
+	} else {
+		c.Set(fiber.HeaderContentDisposition, disposition)
+	}
+	c.Set(fiber.HeaderCacheControl, "private, max-age=86400")
+	return c.SendStream(reader, int(media.Size))
+}
+
+// isInlineMediaType reports whether browsers can show a media type inline without running it
+func isInlineMediaType(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "image/svg"):
+		return false
+	case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "audio/"), strings.HasPrefix(mediaType, "video/"):
+		return true
+	}
+	return false
+}
+
+// mediaError maps media errors to HTTP responses
+func (h *ChatHandler) mediaError(c *fiber.Ctx, err error, failure string) error {
+	switch {
+	case errors.Is(err, message.ErrMessageNotFound):
+		return c.Status(404).JSON(app.NewErrorResponse("Message not found"))
+	case errors.Is(err, message.ErrMessageHasNoMedia):
+		return c.Status(404).JSON(app.NewErrorResponse("Message has no media"))
+	case errors.Is(err, message.ErrMediaObjectNotFound):
+		return c.Status(404).JSON(app.NewErrorResponse("Media not found"))
+	case errors.Is(err, message.ErrMediaExpired):
+		return c.Status(410).JSON(app.NewErrorResponse("Media expired on WhatsApp servers"))
+	case errors.Is(err, session.ErrSessionNotConnected):
+		return c.Status(400).JSON(app.NewErrorResponse("Session is not connected"))
+	case errors.Is(err, messageApp.ErrInvalidMediaURLRequest):
+		return c.Status(400).JSON(app.NewErrorResponse(err.Error()))
+	}
+
+	h.logger.Error(failure + ": " + err.Error())
+	return c.Status(500).JSON(app.NewErrorResponse(failure))
+}
+
+// historyError maps history errors to HTTP responses
+func (h *ChatHandler) historyError(c *fiber.Ctx, err error, message string) error {
+	if errors.Is(err, messageApp.ErrInvalidHistoryQuery) {
+		return c.Status(400).JSON(app.NewErrorResponse(err.Error()))
+	}
+
+	h.logger.Error(message + ": " + err.Error())
+	return c.Status(500).JSON(app.NewErrorResponse(message))
+}
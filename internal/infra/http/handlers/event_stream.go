@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"zpwoot/internal/app"
+	"zpwoot/internal/domain/webhook"
+	"zpwoot/internal/infra/http/helpers"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// streamWriteWait is how long writing a frame to a stream client may take
+	streamWriteWait = 10 * time.Second
+	// streamPongWait is how long a stream client may stay silent before it is considered gone
+	streamPongWait = 60 * time.Second
+	// streamPingPeriod must be shorter than streamPongWait
+	streamPingPeriod = 30 * time.Second
+)
+
+// StreamSessionEvents streams the events of a session over WebSocket
+// @Summary Stream session events over WebSocket
+// @Description Upgrades the connection to WebSocket and streams the events of a session live, as JSON text frames with the same body webhooks receive. Filter event types with the events query parameter, comma separated or repeated. Browsers may pass the API key in the apiKey query parameter, since they cannot set headers on WebSocket requests.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param events query string false "Event types to stream, comma separated (default: all)" example("Message,Receipt")
+// @Param apiKey query string false "API key, for clients that cannot set headers"
+// @Success 101 {string} string "Switching protocols"
+// @Failure 400 {object} object "Invalid event types"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session not found"
+// @Failure 426 {object} object "WebSocket upgrade required"
+// @Router /sessions/{sessionId}/events/ws [get]
+func (h *WebhookHandler) StreamSessionEvents(c *fiber.Ctx) error {
+	if !helpers.IsWebSocketUpgrade(c) {
+		return c.Status(426).JSON(app.NewErrorResponse("WebSocket upgrade required"))
+	}
+
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), c.Params("sessionId"))
+	if err != nil {
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	}
+
+	return h.streamEvents(c, sess.ID.String())
+}
+
+// StreamAllEvents streams the events of every session over WebSocket
+// @Summary Stream events of every session over WebSocket
+// @Description Upgrades the connection to WebSocket and streams the events of every session live, as JSON text frames with the same body webhooks receive. Filter event types with the events query parameter, comma separated or repeated. Browsers may pass the API key in the apiKey query parameter, since they cannot set headers on WebSocket requests.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Param events query string false "Event types to stream, comma separated (default: all)" example("Message,Receipt")
+// @Param apiKey query string false "API key, for clients that cannot set headers"
+// @Success 101 {string} string "Switching protocols"
+// @Failure 400 {object} object "Invalid event types"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 426 {object} object "WebSocket upgrade required"
+// @Router /events/ws [get]
+func (h *WebhookHandler) StreamAllEvents(c *fiber.Ctx) error {
+	if !helpers.IsWebSocketUpgrade(c) {
+		return c.Status(426).JSON(app.NewErrorResponse("WebSocket upgrade required"))
+	}
+
+	return h.streamEvents(c, "")
+}
+
+// streamEvents validates the event filter and upgrades the connection.
+// An empty sessionID streams the events of every session.
+func (h *WebhookHandler) streamEvents(c *fiber.Ctx, sessionID string) error {
+	eventTypes := queryEventTypes(c)
+	if invalidEvents := webhook.ValidateEvents(eventTypes); len(invalidEvents) > 0 {
+		return c.Status(400).JSON(app.NewErrorResponse(fmt.Sprintf("unsupported event types: %v", invalidEvents)))
+	}
+
+	remoteAddr := c.IP()
+
+	return helpers.UpgradeWebSocket(c, func(conn *websocket.Conn) {
+		sub, err := h.webhookUC.SubscribeEvents(context.Background(), sessionID, eventTypes)
+		if err != nil {
+			h.logger.Error("Failed to subscribe to events: " + err.Error())
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "failed to subscribe to events"),
+				time.Now().Add(streamWriteWait))
+			return
+		}
+		defer sub.Close()
+
+		h.pumpEvents(conn, sub)
+
+		h.logger.InfoWithFields("Event stream subscriber disconnected", map[string]interface{}{
+			"session_id":     sessionID,
+			"remote_addr":    remoteAddr,
+			"dropped_events": sub.Dropped(),
+		})
+	})
+}
+
+// pumpEvents writes the events of the subscription to the connection until
+// either the client goes away or the subscription is closed
+func (h *WebhookHandler) pumpEvents(conn *websocket.Conn, sub *webhook.Subscription) {
+	// The client is not expected to send anything; reading only detects closes and pongs
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		conn.SetReadLimit(512)
+		_ = conn.SetReadDeadline(time.Now().Add(streamPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(streamPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(streamPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+					time.Now().Add(streamWriteWait))
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// queryEventTypes reads the events query parameter, which may be comma separated and repeated
+func queryEventTypes(c *fiber.Ctx) []string {
+	var eventTypes []string
+	for _, value := range c.Context().QueryArgs().PeekMulti("events") {
+		for _, eventType := range strings.Split(string(value), ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				eventTypes = append(eventTypes, eventType)
+			}
+		}
+	}
+	return eventTypes
+}
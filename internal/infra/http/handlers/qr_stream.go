@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"time"
+
+	"zpwoot/internal/app"
+	"zpwoot/internal/domain/webhook"
+	"zpwoot/internal/infra/http/helpers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// qrStreamKeepAlive is how often an idle QR stream sends a comment to keep proxies from closing it
+const qrStreamKeepAlive = 15 * time.Second
+
+// qrStreamEvents maps the webhook events of the pairing flow to Server-Sent Event names
+var qrStreamEvents = map[string]string{
+	"QR":          "qr",
+	"QRTimeout":   "qr_timeout",
+	"PairSuccess": "pair_success",
+	"PairError":   "pair_error",
+	"Connected":   "connected",
+	"LoggedOut":   "logged_out",
+}
+
+// qrStreamFinalEvents end the pairing flow, and with it the stream
+var qrStreamFinalEvents = map[string]bool{
+	"QRTimeout": true,
+	"PairError": true,
+	"Connected": true,
+	"LoggedOut": true,
+}
+
+// StreamQRCode streams QR codes and the pairing outcome as Server-Sent Events
+// @Summary Stream QR codes for session pairing
+// @Description Streams the pairing flow of a session as Server-Sent Events: every new QR code (raw content and PNG data URI), pair success or error, and the final connected, logged out or QR timeout state, after which the stream ends. The current QR code, or the connected state, is sent right away. Each event carries the same JSON body webhooks receive. EventSource cannot set headers, so the API key may be passed in the apiKey query parameter.
+// @Tags Sessions
+// @Produce text/event-stream
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param apiKey query string false "API key, for clients that cannot set headers"
+// @Success 200 {string} string "Event stream of qr, qr_timeout, pair_success, pair_error, connected and logged_out events"
+// @Failure 401 {object} app.ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} app.ErrorResponse "Session not found"
+// @Failure 500 {object} app.ErrorResponse "Internal server error"
+// @Router /sessions/{sessionId}/qr/stream [get]
+func (h *SessionHandler) StreamQRCode(c *fiber.Ctx) error {
+	if h.sessionUC == nil || h.webhookUC == nil {
+		return c.Status(500).JSON(app.NewErrorResponse("Session use case not initialized"))
+	}
+
+	sess, fiberErr := h.resolveSession(c)
+	if fiberErr != nil {
+		return c.Status(fiberErr.Code).JSON(app.NewErrorResponse(fiberErr.Message))
+	}
+	sessionID := sess.ID.String()
+
+	eventTypes := make([]string, 0, len(qrStreamEvents))
+	for eventType := range qrStreamEvents {
+		eventTypes = append(eventTypes, eventType)
+	}
+
+	sub, err := h.webhookUC.SubscribeEvents(c.Context(), sessionID, eventTypes)
+	if err != nil {
+		h.logger.Error("Failed to subscribe to QR events: " + err.Error())
+		return c.Status(500).JSON(app.NewErrorResponse("Failed to stream QR code"))
+	}
+
+	// Read the current state again now that we are subscribed, so a change made
+	// between resolving the session and subscribing is not missed
+	sess, err = h.sessionResolver.ResolveSession(c.Context(), sessionID)
+	if err != nil {
+		sub.Close()
+		h.logger.Error("Failed to reload session for QR stream: " + err.Error())
+		return c.Status(500).JSON(app.NewErrorResponse("Failed to stream QR code"))
+	}
+
+	// The current state of the session, sent before waiting for new events
+	var initial *webhook.WebhookEvent
+	if sess.IsConnected {
+		initial = webhook.NewWebhookEvent(sessionID, "Connected", &webhook.ConnectionEventData{Connected: true})
+	} else if sess.QRCode != "" && (sess.QRCodeExpiresAt == nil || sess.QRCodeExpiresAt.After(time.Now())) {
+		data := &webhook.QREventData{Image: sess.QRCode, ExpiresAt: sess.QRCodeExpiresAt}
+		if qr, err := h.sessionUC.GetQRCode(c.Context(), sessionID); err == nil {
+			data.Code = qr.QRCode
+		}
+		initial = webhook.NewWebhookEvent(sessionID, "QR", data)
+	}
+	if initial != nil {
+		initial.SessionName = sess.Name
+	}
+
+	h.logger.InfoWithFields("QR stream opened", map[string]interface{}{
+		"session_id":   sessionID,
+		"is_connected": sess.IsConnected,
+	})
+
+	helpers.SetEventStreamHeaders(c)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		if initial != nil {
+			if err := h.writeQRStreamEvent(w, initial); err != nil || qrStreamFinalEvents[initial.Type] {
+				return
+			}
+		}
+
+		h.pumpQRStream(w, sub)
+
+		h.logger.InfoWithFields("QR stream closed", map[string]interface{}{
+			"session_id": sessionID,
+		})
+	})
+
+	return nil
+}
+
+// pumpQRStream writes pairing events until the flow ends or the client goes away
+func (h *SessionHandler) pumpQRStream(w *bufio.Writer, sub *webhook.Subscription) {
+	ticker := time.NewTicker(qrStreamKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if err := h.writeQRStreamEvent(w, event); err != nil || qrStreamFinalEvents[event.Type] {
+				return
+			}
+		case <-ticker.C:
+			if err := helpers.WriteSSEComment(w, "keep-alive"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeQRStreamEvent writes a webhook event as a Server-Sent Event named after its type
+func (h *SessionHandler) writeQRStreamEvent(w *bufio.Writer, event *webhook.WebhookEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		h.logger.Error("Failed to encode QR stream event: " + err.Error())
+		return nil
+	}
+	return helpers.WriteSSEEvent(w, event.ID, qrStreamEvents[event.Type], data)
+}
@@ -15,13 +15,15 @@ import (
 type SessionHandler struct {
 	logger          *logger.Logger
 	sessionUC       app.SessionUseCase
+	webhookUC       app.WebhookUseCase
 	sessionResolver *helpers.SessionResolver
 }
 
-func NewSessionHandler(appLogger *logger.Logger, sessionUC app.SessionUseCase, sessionRepo helpers.SessionRepository) *SessionHandler {
+func NewSessionHandler(appLogger *logger.Logger, sessionUC app.SessionUseCase, webhookUC app.WebhookUseCase, sessionRepo helpers.SessionRepository) *SessionHandler {
 	return &SessionHandler{
 		logger:          appLogger,
 		sessionUC:       sessionUC,
+		webhookUC:       webhookUC,
 		sessionResolver: helpers.NewSessionResolver(appLogger, sessionRepo),
 	}
 }
@@ -540,3 +542,73 @@ func (h *SessionHandler) GetProxy(c *fiber.Ctx) error {
 	response := app.NewSuccessResponse(result, "Proxy configuration retrieved successfully")
 	return c.JSON(response)
 }
+
+// SetMediaSettings sets whether the session downloads received media as messages arrive
+// POST /sessions/{sessionId}/media/set
+func (h *SessionHandler) SetMediaSettings(c *fiber.Ctx) error {
+	if h.sessionUC == nil {
+		return c.Status(500).JSON(app.NewErrorResponse("Session use case not initialized"))
+	}
+
+	// Resolve session using SessionResolver
+	sess, fiberErr := h.resolveSession(c)
+	if fiberErr != nil {
+		return c.Status(fiberErr.Code).JSON(app.NewErrorResponse(fiberErr.Message))
+	}
+
+	h.logger.InfoWithFields("Setting media settings", map[string]interface{}{
+		"session_id":   sess.ID.String(),
+		"session_name": sess.Name,
+	})
+
+	// Parse request body
+	var req app.SetMediaSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Error("Failed to parse request body: " + err.Error())
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid request body"))
+	}
+
+	// Call use case with resolved session ID
+	err := h.sessionUC.SetMediaSettings(c.Context(), sess.ID.String(), &req)
+	if err != nil {
+		h.logger.Error("Failed to set media settings: " + err.Error())
+		// Check if it's a not found error
+		if err.Error() == "session not found" {
+			return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+		}
+		return c.Status(500).JSON(app.NewErrorResponse("Failed to set media settings"))
+	}
+
+	// Return success response
+	response := app.NewSuccessResponse(nil, "Media settings updated successfully")
+	return c.JSON(response)
+}
+
+// GetMediaSettings gets the media settings of the session
+// GET /sessions/{sessionId}/media/find
+func (h *SessionHandler) GetMediaSettings(c *fiber.Ctx) error {
+	if h.sessionUC == nil {
+		return c.Status(500).JSON(app.NewErrorResponse("Session use case not initialized"))
+	}
+
+	// Resolve session using SessionResolver
+	sess, fiberErr := h.resolveSession(c)
+	if fiberErr != nil {
+		return c.Status(fiberErr.Code).JSON(app.NewErrorResponse(fiberErr.Message))
+	}
+
+	// Call use case with resolved session ID
+	result, err := h.sessionUC.GetMediaSettings(c.Context(), sess.ID.String())
+	if err != nil {
+		h.logger.Error("Failed to get media settings: " + err.Error())
+		// Check if it's a not found error
+		if err.Error() == "session not found" {
+			return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+		}
+		return c.Status(500).JSON(app.NewErrorResponse("Failed to get media settings"))
+	}
+
+	// Return success response
+	response := app.NewSuccessResponse(result, "Media settings retrieved successfully")
+	return c.JSON(response)
+}
@@ -1,32 +1,42 @@
 package handlers
 
 import (
+	"errors"
+
 	"zpwoot/internal/app"
+	webhookApp "zpwoot/internal/app/webhook"
+	"zpwoot/internal/domain/webhook"
+	"zpwoot/internal/infra/http/helpers"
 	"zpwoot/platform/logger"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// errSessionNotFound reports that the session in the path could not be resolved
+var errSessionNotFound = errors.New("session not found")
+
 type WebhookHandler struct {
-	webhookUC app.WebhookUseCase
-	logger    *logger.Logger
+	webhookUC       app.WebhookUseCase
+	sessionResolver *helpers.SessionResolver
+	logger          *logger.Logger
 }
 
-func NewWebhookHandler(webhookUC app.WebhookUseCase, appLogger *logger.Logger) *WebhookHandler {
+func NewWebhookHandler(webhookUC app.WebhookUseCase, sessionRepo helpers.SessionRepository, appLogger *logger.Logger) *WebhookHandler {
 	return &WebhookHandler{
-		webhookUC: webhookUC,
-		logger:    appLogger,
+		webhookUC:       webhookUC,
+		sessionResolver: helpers.NewSessionResolver(appLogger, sessionRepo),
+		logger:          appLogger,
 	}
 }
 
 // SetConfig creates a new webhook configuration
 // @Summary Create webhook configuration
-// @Description Creates a new webhook configuration for a specific session. Webhooks will receive real-time events from Wameow. Requires API key authentication.
+// @Description Creates a new webhook configuration for a specific session. Webhooks will receive real-time events from Wameow. A session can have several webhooks, each with its own URL, events and secret. Requires API key authentication.
 // @Tags Webhooks
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Param id path string true "Session ID" format(uuid) example("123e4567-e89b-12d3-a456-426614174000")
+// @Param sessionId path string true "Session ID or name" example("my-session")
 // @Param request body zpwoot_internal_app_webhook.SetConfigRequest true "Webhook configuration request"
 // @Success 201 {object} zpwoot_internal_app_webhook.WebhookResponse "Webhook created successfully"
 // @Failure 400 {object} object "Invalid request body or parameters"
@@ -34,12 +44,19 @@ func NewWebhookHandler(webhookUC app.WebhookUseCase, appLogger *logger.Logger) *
 // @Failure 404 {object} object "Session not found"
 // @Failure 500 {object} object "Internal server error"
 // @Router /sessions/{sessionId}/webhook/config [post]
+// @Router /sessions/{sessionId}/webhooks [post]
 func (h *WebhookHandler) SetConfig(c *fiber.Ctx) error {
-	sessionID := c.Params("id")
+	sessionIdentifier := c.Params("sessionId")
 	h.logger.InfoWithFields("Creating webhook config", map[string]interface{}{
-		"session_id": sessionID,
+		"session_identifier": sessionIdentifier,
 	})
 
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), sessionIdentifier)
+	if err != nil {
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	}
+	sessionID := sess.ID.String()
+
 	// Parse request body
 	var req app.SetConfigRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -54,8 +71,7 @@ func (h *WebhookHandler) SetConfig(c *fiber.Ctx) error {
 	ctx := c.Context()
 	result, err := h.webhookUC.SetConfig(ctx, &req)
 	if err != nil {
-		h.logger.Error("Failed to create webhook: " + err.Error())
-		return c.Status(500).JSON(app.NewErrorResponse("Failed to create webhook"))
+		return h.webhookError(c, err, "Failed to create webhook")
 	}
 
 	// Return success response
@@ -70,17 +86,24 @@ func (h *WebhookHandler) SetConfig(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Param id path string true "Session ID" format(uuid) example("123e4567-e89b-12d3-a456-426614174000")
+// @Param sessionId path string true "Session ID or name" example("my-session")
 // @Success 200 {object} zpwoot_internal_app_webhook.WebhookResponse "Webhook configuration retrieved successfully"
 // @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
 // @Failure 404 {object} object "Session or webhook configuration not found"
 // @Failure 500 {object} object "Internal server error"
 // @Router /sessions/{sessionId}/webhook/config [get]
 func (h *WebhookHandler) FindConfig(c *fiber.Ctx) error {
-	sessionID := c.Params("id")
+	sessionIdentifier := c.Params("sessionId")
 	h.logger.InfoWithFields("Getting webhook config", map[string]interface{}{
-		"session_id": sessionID,
+		"session_identifier": sessionIdentifier,
 	})
+
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), sessionIdentifier)
+	if err != nil {
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	}
+	sessionID := sess.ID.String()
+
 	// Get webhook configuration for the session
 	ctx := c.Context()
 	webhook, err := h.webhookUC.FindConfig(ctx, sessionID)
@@ -93,3 +116,633 @@ func (h *WebhookHandler) FindConfig(c *fiber.Ctx) error {
 	response := app.NewSuccessResponse(webhook, "Webhook configuration retrieved successfully")
 	return c.JSON(response)
 }
+
+// ListSessionWebhooks lists the webhooks of a session
+// @Summary List session webhooks
+// @Description Lists every webhook of a session. A session can have several independent webhooks, each with its own URL, events, secret and active flag. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param active query bool false "Filter by active flag"
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param offset query int false "Page offset" default(0)
+// @Success 200 {object} zpwoot_internal_app_webhook.ListWebhooksResponse "Webhooks retrieved successfully"
+// @Failure 400 {object} object "Invalid query parameters"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/webhooks [get]
+func (h *WebhookHandler) ListSessionWebhooks(c *fiber.Ctx) error {
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), c.Params("sessionId"))
+	if err != nil {
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	}
+
+	var req app.ListWebhooksRequest
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid query parameters"))
+	}
+	if req.Limit < 0 || req.Limit > 100 || req.Offset < 0 {
+		return c.Status(400).JSON(app.NewErrorResponse("limit must be between 1 and 100 and offset must not be negative"))
+	}
+	sessionID := sess.ID.String()
+	req.SessionID = &sessionID
+	req.Global = false
+
+	result, err := h.webhookUC.ListWebhooks(c.Context(), &req)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to list webhooks")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Webhooks retrieved successfully"))
+}
+
+// GetSessionWebhook returns a webhook of a session
+// @Summary Get session webhook
+// @Description Returns a single webhook of a session. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Success 200 {object} zpwoot_internal_app_webhook.WebhookResponse "Webhook retrieved successfully"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session or webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/webhooks/{webhookId} [get]
+func (h *WebhookHandler) GetSessionWebhook(c *fiber.Ctx) error {
+	result, err := h.sessionWebhook(c)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to get webhook")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Webhook retrieved successfully"))
+}
+
+// UpdateSessionWebhook updates a webhook of a session
+// @Summary Update session webhook
+// @Description Updates the URL, secret, events or active flag of a session webhook. Omitted fields are left unchanged. Requires API key authentication.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Param request body zpwoot_internal_app_webhook.UpdateWebhookRequest true "Fields to update"
+// @Success 200 {object} zpwoot_internal_app_webhook.WebhookResponse "Webhook updated successfully"
+// @Failure 400 {object} object "Invalid request body, URL or events"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session or webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/webhooks/{webhookId} [put]
+func (h *WebhookHandler) UpdateSessionWebhook(c *fiber.Ctx) error {
+	current, err := h.sessionWebhook(c)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to get webhook")
+	}
+
+	var req app.UpdateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid request body"))
+	}
+
+	result, err := h.webhookUC.UpdateWebhook(c.Context(), current.ID, &req)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to update webhook")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Webhook updated successfully"))
+}
+
+// ToggleSessionWebhook enables or disables a webhook of a session
+// @Summary Toggle session webhook
+// @Description Flips the active flag of a session webhook. Inactive webhooks keep their configuration but receive no events. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Success 200 {object} zpwoot_internal_app_webhook.WebhookResponse "Webhook toggled successfully"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session or webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/webhooks/{webhookId}/toggle [patch]
+func (h *WebhookHandler) ToggleSessionWebhook(c *fiber.Ctx) error {
+	current, err := h.sessionWebhook(c)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to get webhook")
+	}
+
+	result, err := h.webhookUC.ToggleWebhook(c.Context(), current.ID)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to toggle webhook")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Webhook toggled successfully"))
+}
+
+// DeleteSessionWebhook deletes a webhook of a session
+// @Summary Delete session webhook
+// @Description Deletes a session webhook and its delivery log. Other webhooks of the session are not affected. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Success 200 {object} object "Webhook deleted successfully"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session or webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/webhooks/{webhookId} [delete]
+func (h *WebhookHandler) DeleteSessionWebhook(c *fiber.Ctx) error {
+	current, err := h.sessionWebhook(c)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to get webhook")
+	}
+
+	if err := h.webhookUC.DeleteWebhook(c.Context(), current.ID); err != nil {
+		return h.webhookError(c, err, "Failed to delete webhook")
+	}
+
+	return c.JSON(app.NewSuccessResponse(nil, "Webhook deleted successfully"))
+}
+
+// sessionWebhook loads the webhook in the path, making sure it belongs to the session in the path
+func (h *WebhookHandler) sessionWebhook(c *fiber.Ctx) (*app.WebhookResponse, error) {
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), c.Params("sessionId"))
+	if err != nil {
+		return nil, errSessionNotFound
+	}
+
+	return h.webhookUC.GetSessionWebhook(c.Context(), sess.ID.String(), c.Params("webhookId"))
+}
+
+// GetSupportedEvents returns the catalogue of event types webhooks can subscribe to
+// @Summary List supported webhook events
+// @Description Returns every event type that can be used in a webhook events list, with its category and description. Use "All" to receive every event. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} zpwoot_internal_app_webhook.WebhookEventsResponse "Supported events retrieved successfully"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/events [get]
+func (h *WebhookHandler) GetSupportedEvents(c *fiber.Ctx) error {
+	result, err := h.webhookUC.GetSupportedWebhookEvents(c.Context())
+	if err != nil {
+		return h.webhookError(c, err, "Failed to get supported events")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Supported events retrieved successfully"))
+}
+
+// GetEventSchemas returns the JSON Schemas of the webhook event bodies
+// @Summary List webhook event schemas
+// @Description Returns the JSON Schema (draft 2020-12) of the body sent for each event type with a typed payload. Payloads carry a schema_version; optional fields may be added within a version, breaking changes bump the major number. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} zpwoot_internal_app_webhook.EventSchemasResponse "Event schemas retrieved successfully"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/schemas [get]
+func (h *WebhookHandler) GetEventSchemas(c *fiber.Ctx) error {
+	result, err := h.webhookUC.GetEventSchemas(c.Context())
+	if err != nil {
+		return h.webhookError(c, err, "Failed to get event schemas")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Event schemas retrieved successfully"))
+}
+
+// GetEventSchema returns the JSON Schema of a single event type
+// @Summary Get webhook event schema
+// @Description Returns the raw JSON Schema (draft 2020-12) of the body sent for an event type, ready to be used by code generators and validators. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param eventType path string true "Event type" example("Message")
+// @Success 200 {object} object "JSON Schema of the event"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Event type has no typed payload"
+// @Router /webhooks/schemas/{eventType} [get]
+func (h *WebhookHandler) GetEventSchema(c *fiber.Ctx) error {
+	schema, err := h.webhookUC.GetEventSchema(c.Context(), c.Params("eventType"))
+	if err != nil {
+		if errors.Is(err, webhook.ErrUnsupportedEventType) {
+			return c.Status(404).JSON(app.NewErrorResponse(err.Error()))
+		}
+		return h.webhookError(c, err, "Failed to get event schema")
+	}
+
+	return c.JSON(schema, "application/schema+json")
+}
+
+// PreviewTemplate renders a sample event with a webhook payload template
+// @Summary Preview webhook payload template
+// @Description Renders a sample event with a payload template and returns the body, content type and headers a webhook would receive, without sending anything. Pass the template to try it before saving, or webhookId to preview the template of a saved webhook. The body is a Go text/template rendered against the typed event, with the json, default, lower, upper, trim and unix functions. Requires API key authentication.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body zpwoot_internal_app_webhook.PreviewTemplateRequest true "Template or webhook ID, and the sample event; eventType defaults to Message"
+// @Success 200 {object} zpwoot_internal_app_webhook.PreviewTemplateResponse "Template rendered successfully"
+// @Failure 400 {object} object "Invalid request body, template, event type or test data"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/templates/preview [post]
+func (h *WebhookHandler) PreviewTemplate(c *fiber.Ctx) error {
+	var req app.PreviewTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid request body"))
+	}
+
+	result, err := h.webhookUC.PreviewTemplate(c.Context(), &req)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to preview template")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Template rendered successfully"))
+}
+
+// ToggleWebhook enables or disables a webhook by ID
+// @Summary Toggle webhook
+// @Description Flips the active flag of a webhook. The webhook configured through GLOBAL_WEBHOOK_URL cannot be toggled through the API. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Success 200 {object} zpwoot_internal_app_webhook.WebhookResponse "Webhook toggled successfully"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 409 {object} object "Webhook is managed by GLOBAL_WEBHOOK_URL"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/{webhookId}/toggle [patch]
+func (h *WebhookHandler) ToggleWebhook(c *fiber.Ctx) error {
+	result, err := h.webhookUC.ToggleWebhook(c.Context(), c.Params("webhookId"))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to toggle webhook")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Webhook toggled successfully"))
+}
+
+// TestWebhook sends a synthetic event to the session webhook
+// @Summary Test webhook
+// @Description Sends a synthetic event of the chosen type to the session webhook and reports the real status code, latency, response body snippet, TLS errors and the headers sent, including the signature. Requires API key authentication.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param request body zpwoot_internal_app_webhook.TestWebhookRequest false "Test event; webhook_id defaults to the session webhook and event_type to Message"
+// @Success 200 {object} zpwoot_internal_app_webhook.TestWebhookResponse "Test event sent; see success and status_code for the receiver outcome"
+// @Failure 400 {object} object "Invalid request body or event type"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session or webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/webhook/test [post]
+func (h *WebhookHandler) TestWebhook(c *fiber.Ctx) error {
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), c.Params("sessionId"))
+	if err != nil {
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	}
+
+	var req app.TestWebhookRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(app.NewErrorResponse("Invalid request body"))
+		}
+	}
+
+	result, err := h.webhookUC.TestWebhook(c.Context(), sess.ID.String(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, webhook.ErrUnsupportedEventType), errors.Is(err, webhook.ErrInvalidTestData):
+			return c.Status(400).JSON(app.NewErrorResponse(err.Error()))
+		case errors.Is(err, webhook.ErrWebhookNotFound):
+			return c.Status(404).JSON(app.NewErrorResponse("Webhook not found"))
+		}
+		h.logger.Error("Failed to test webhook: " + err.Error())
+		return c.Status(500).JSON(app.NewErrorResponse("Failed to test webhook"))
+	}
+
+	message := "Webhook test succeeded"
+	if !result.Success {
+		message = "Webhook test failed"
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, message))
+}
+
+// CreateGlobalWebhook creates a webhook that receives events from every session
+// @Summary Create global webhook
+// @Description Creates a webhook that is not bound to a session and receives events from all sessions. Events carry the originating sessionId and sessionName. Requires API key authentication.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body zpwoot_internal_app_webhook.SetConfigRequest true "Webhook configuration request; sessionId is ignored"
+// @Success 201 {object} zpwoot_internal_app_webhook.SetConfigResponse "Global webhook created successfully"
+// @Failure 400 {object} object "Invalid request body, URL or events"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateGlobalWebhook(c *fiber.Ctx) error {
+	var req app.SetConfigRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid request body"))
+	}
+	req.SessionID = nil
+
+	result, err := h.webhookUC.SetConfig(c.Context(), &req)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to create global webhook")
+	}
+
+	return c.Status(201).JSON(app.NewSuccessResponse(result, "Global webhook created successfully"))
+}
+
+// ListGlobalWebhooks lists webhooks that receive events from every session
+// @Summary List global webhooks
+// @Description Lists the webhooks that are not bound to a session, including the one configured through GLOBAL_WEBHOOK_URL. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param active query bool false "Filter by active flag"
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param offset query int false "Page offset" default(0)
+// @Success 200 {object} zpwoot_internal_app_webhook.ListWebhooksResponse "Global webhooks retrieved successfully"
+// @Failure 400 {object} object "Invalid query parameters"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListGlobalWebhooks(c *fiber.Ctx) error {
+	var req app.ListWebhooksRequest
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid query parameters"))
+	}
+	if req.Limit < 0 || req.Limit > 100 || req.Offset < 0 {
+		return c.Status(400).JSON(app.NewErrorResponse("limit must be between 1 and 100 and offset must not be negative"))
+	}
+	req.SessionID = nil
+	req.Global = true
+
+	result, err := h.webhookUC.ListWebhooks(c.Context(), &req)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to list global webhooks")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Global webhooks retrieved successfully"))
+}
+
+// GetWebhook returns a webhook by ID
+// @Summary Get webhook
+// @Description Returns a webhook configuration by ID, whether it is bound to a session or global. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Success 200 {object} zpwoot_internal_app_webhook.WebhookResponse "Webhook retrieved successfully"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/{webhookId} [get]
+func (h *WebhookHandler) GetWebhook(c *fiber.Ctx) error {
+	result, err := h.webhookUC.GetWebhook(c.Context(), c.Params("webhookId"))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to get webhook")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Webhook retrieved successfully"))
+}
+
+// UpdateWebhook updates a webhook by ID
+// @Summary Update webhook
+// @Description Updates the URL, secret, events or active flag of a webhook. The webhook configured through GLOBAL_WEBHOOK_URL cannot be changed through the API. Requires API key authentication.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Param request body zpwoot_internal_app_webhook.UpdateWebhookRequest true "Fields to update"
+// @Success 200 {object} zpwoot_internal_app_webhook.WebhookResponse "Webhook updated successfully"
+// @Failure 400 {object} object "Invalid request body, URL or events"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 409 {object} object "Webhook is managed by GLOBAL_WEBHOOK_URL"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/{webhookId} [put]
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	var req app.UpdateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid request body"))
+	}
+
+	result, err := h.webhookUC.UpdateWebhook(c.Context(), c.Params("webhookId"), &req)
+	if err != nil {
+		return h.webhookError(c, err, "Failed to update webhook")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Webhook updated successfully"))
+}
+
+// DeleteWebhook deletes a webhook by ID
+// @Summary Delete webhook
+// @Description Deletes a webhook and its delivery log. The webhook configured through GLOBAL_WEBHOOK_URL cannot be deleted through the API. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param webhookId path string true "Webhook ID" format(uuid)
+// @Success 200 {object} object "Webhook deleted successfully"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Webhook not found"
+// @Failure 409 {object} object "Webhook is managed by GLOBAL_WEBHOOK_URL"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/{webhookId} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	if err := h.webhookUC.DeleteWebhook(c.Context(), c.Params("webhookId")); err != nil {
+		return h.webhookError(c, err, "Failed to delete webhook")
+	}
+
+	return c.JSON(app.NewSuccessResponse(nil, "Webhook deleted successfully"))
+}
+
+// ListDeadLetters lists webhook deliveries that exhausted their retries
+// @Summary List dead-lettered webhook deliveries
+// @Description Lists webhook deliveries that exhausted their retries, optionally filtered by webhook, session and creation time. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param webhookId query string false "Webhook ID"
+// @Param sessionId query string false "Session ID"
+// @Param since query string false "Only deliveries created at or after this RFC3339 time" example("2024-01-01T10:00:00Z")
+// @Param until query string false "Only deliveries created at or before this RFC3339 time"
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param offset query int false "Page offset" default(0)
+// @Success 200 {object} zpwoot_internal_app_webhook.ListDeliveriesResponse "Dead-lettered deliveries retrieved successfully"
+// @Failure 400 {object} object "Invalid filter"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/deliveries/dead-letters [get]
+func (h *WebhookHandler) ListDeadLetters(c *fiber.Ctx) error {
+	var req app.ListDeliveriesRequest
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid query parameters"))
+	}
+
+	return h.listDeadLetters(c, &req)
+}
+
+// ListSessionDeadLetters lists dead-lettered webhook deliveries for a session
+// @Summary List dead-lettered webhook deliveries for a session
+// @Description Lists webhook deliveries of a session that exhausted their retries. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param sessionId path string true "Session ID or name" example("my-session")
+// @Param webhookId query string false "Webhook ID"
+// @Param since query string false "Only deliveries created at or after this RFC3339 time" example("2024-01-01T10:00:00Z")
+// @Param until query string false "Only deliveries created at or before this RFC3339 time"
+// @Param limit query int false "Page size (max 100)" default(20)
+// @Param offset query int false "Page offset" default(0)
+// @Success 200 {object} zpwoot_internal_app_webhook.ListDeliveriesResponse "Dead-lettered deliveries retrieved successfully"
+// @Failure 400 {object} object "Invalid filter"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Session not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /sessions/{sessionId}/webhook/dead-letters [get]
+func (h *WebhookHandler) ListSessionDeadLetters(c *fiber.Ctx) error {
+	sess, err := h.sessionResolver.ResolveSession(c.Context(), c.Params("sessionId"))
+	if err != nil {
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	}
+
+	var req app.ListDeliveriesRequest
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid query parameters"))
+	}
+	req.SessionID = sess.ID.String()
+
+	return h.listDeadLetters(c, &req)
+}
+
+// listDeadLetters runs a dead-letter query and writes the response
+func (h *WebhookHandler) listDeadLetters(c *fiber.Ctx, req *app.ListDeliveriesRequest) error {
+	if req.Limit < 0 || req.Limit > 100 || req.Offset < 0 {
+		return c.Status(400).JSON(app.NewErrorResponse("limit must be between 1 and 100 and offset must not be negative"))
+	}
+
+	result, err := h.webhookUC.ListDeadLetters(c.Context(), req)
+	if err != nil {
+		if errors.Is(err, webhookApp.ErrInvalidDeliveryFilter) {
+			return c.Status(400).JSON(app.NewErrorResponse(err.Error()))
+		}
+		h.logger.Error("Failed to list dead-lettered deliveries: " + err.Error())
+		return c.Status(500).JSON(app.NewErrorResponse("Failed to list dead-lettered deliveries"))
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Dead-lettered deliveries retrieved successfully"))
+}
+
+// GetDelivery returns a webhook delivery with its request payload and last response
+// @Summary Get webhook delivery
+// @Description Returns a recorded webhook delivery including the payload sent and the last response received. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param deliveryId path string true "Delivery ID" format(uuid)
+// @Success 200 {object} zpwoot_internal_app_webhook.DeliveryResponse "Delivery retrieved successfully"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Delivery not found"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/deliveries/{deliveryId} [get]
+func (h *WebhookHandler) GetDelivery(c *fiber.Ctx) error {
+	result, err := h.webhookUC.GetDelivery(c.Context(), c.Params("deliveryId"))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to get delivery")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Delivery retrieved successfully"))
+}
+
+// ReplayDelivery sends a recorded webhook delivery again
+// @Summary Replay webhook delivery
+// @Description Sends a recorded webhook delivery again right away and returns the updated delivery. Requires API key authentication.
+// @Tags Webhooks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param deliveryId path string true "Delivery ID" format(uuid)
+// @Success 200 {object} zpwoot_internal_app_webhook.DeliveryResponse "Delivery replayed"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} object "Delivery or webhook not found"
+// @Failure 409 {object} object "Webhook is inactive"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/deliveries/{deliveryId}/replay [post]
+func (h *WebhookHandler) ReplayDelivery(c *fiber.Ctx) error {
+	result, err := h.webhookUC.ReplayDelivery(c.Context(), c.Params("deliveryId"))
+	if err != nil {
+		return h.webhookError(c, err, "Failed to replay delivery")
+	}
+
+	return c.JSON(app.NewSuccessResponse(result, "Delivery replayed"))
+}
+
+// ReplayDeadLetters requeues dead-lettered webhook deliveries in bulk
+// @Summary Replay dead-lettered webhook deliveries
+// @Description Requeues dead-lettered deliveries of a webhook or session, optionally limited to a time range, for an immediate retry. Requires API key authentication.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body zpwoot_internal_app_webhook.ReplayDeadLettersRequest true "Deliveries to replay"
+// @Success 202 {object} zpwoot_internal_app_webhook.ReplayDeadLettersResponse "Deliveries requeued"
+// @Failure 400 {object} object "Invalid request"
+// @Failure 401 {object} object "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} object "Internal server error"
+// @Router /webhooks/deliveries/dead-letters/replay [post]
+func (h *WebhookHandler) ReplayDeadLetters(c *fiber.Ctx) error {
+	var req app.ReplayDeadLettersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(app.NewErrorResponse("Invalid request body"))
+	}
+
+	result, err := h.webhookUC.ReplayDeadLetters(c.Context(), &req)
+	if err != nil {
+		if errors.Is(err, webhookApp.ErrInvalidDeliveryFilter) {
+			return c.Status(400).JSON(app.NewErrorResponse(err.Error()))
+		}
+		h.logger.Error("Failed to replay dead-lettered deliveries: " + err.Error())
+		return c.Status(500).JSON(app.NewErrorResponse("Failed to replay dead-lettered deliveries"))
+	}
+
+	return c.Status(202).JSON(app.NewSuccessResponse(result, "Dead-lettered deliveries requeued"))
+}
+
+// webhookError maps webhook and delivery errors to HTTP responses
+func (h *WebhookHandler) webhookError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, errSessionNotFound):
+		return c.Status(404).JSON(app.NewErrorResponse("Session not found"))
+	case errors.Is(err, webhook.ErrDeliveryNotFound):
+		return c.Status(404).JSON(app.NewErrorResponse("Delivery not found"))
+	case errors.Is(err, webhook.ErrWebhookNotFound):
+		return c.Status(404).JSON(app.NewErrorResponse("Webhook not found"))
+	case errors.Is(err, webhook.ErrInvalidWebhookURL),
+		errors.Is(err, webhook.ErrInvalidWebhookEvents),
+		errors.Is(err, webhook.ErrInvalidWebhookFilters),
+		errors.Is(err, webhook.ErrInvalidWebhookTemplate),
+		errors.Is(err, webhook.ErrInvalidWebhookAuth),
+		errors.Is(err, webhook.ErrInvalidTestData),
+		errors.Is(err, webhook.ErrUnsupportedEventType):
+		return c.Status(400).JSON(app.NewErrorResponse(err.Error()))
+	case errors.Is(err, webhook.ErrWebhookManagedByEnv),
+		errors.Is(err, webhook.ErrWebhookInactive):
+		return c.Status(409).JSON(app.NewErrorResponse(err.Error()))
+	}
+
+	h.logger.Error(message + ": " + err.Error())
+	return c.Status(500).JSON(app.NewErrorResponse(message))
+}
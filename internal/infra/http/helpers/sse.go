@@ -0,0 +1,45 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IsEventStreamRequest reports whether the request asks for a Server-Sent Events stream
+func IsEventStreamRequest(c *fiber.Ctx) bool {
+	return strings.Contains(c.Get(fiber.HeaderAccept), "text/event-stream")
+}
+
+// SetEventStreamHeaders sets the response headers of a Server-Sent Events stream
+func SetEventStreamHeaders(c *fiber.Ctx) {
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Keep reverse proxies such as nginx from buffering the stream
+	c.Set("X-Accel-Buffering", "no")
+}
+
+// WriteSSEEvent writes a Server-Sent Event and flushes it to the client.
+// An error means the client went away.
+func WriteSSEEvent(w *bufio.Writer, id, event string, data []byte) error {
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	if event != "" {
+		fmt.Fprintf(w, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	w.WriteString("\n")
+	return w.Flush()
+}
+
+// WriteSSEComment writes a comment line, which clients ignore, to keep the connection alive
+func WriteSSEComment(w *bufio.Writer, comment string) error {
+	fmt.Fprintf(w, ": %s\n\n", comment)
+	return w.Flush()
+}
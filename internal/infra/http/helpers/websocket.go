@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gorilla/websocket"
+)
+
+// ErrNotWebSocketUpgrade reports a request that did not ask to switch to the WebSocket protocol
+var ErrNotWebSocketUpgrade = errors.New("request is not a websocket upgrade")
+
+// webSocketUpgrader accepts connections from any origin: clients are authenticated
+// by API key, not by browser origin
+var webSocketUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// IsWebSocketUpgrade reports whether the request asks to switch to the WebSocket protocol
+func IsWebSocketUpgrade(c *fiber.Ctx) bool {
+	return strings.EqualFold(c.Get(fiber.HeaderUpgrade), "websocket") &&
+		strings.Contains(strings.ToLower(c.Get(fiber.HeaderConnection)), "upgrade")
+}
+
+// UpgradeWebSocket takes over the connection of the request and runs handle with the
+// resulting WebSocket once the handler returns. Fiber runs on fasthttp, which gorilla/websocket
+// does not support directly, so the request is rebuilt as a net/http request and the
+// hijacked connection is handed to the upgrader.
+//
+// The fiber context must not be used inside handle: it is recycled when the handler returns.
+func UpgradeWebSocket(c *fiber.Ctx, handle func(conn *websocket.Conn)) error {
+	if !IsWebSocketUpgrade(c) {
+		return ErrNotWebSocketUpgrade
+	}
+
+	req := &http.Request{
+		Method:     c.Method(),
+		URL:        &url.URL{Path: c.Path(), RawQuery: string(c.Request().URI().QueryString())},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Host:       c.Hostname(),
+		RemoteAddr: c.Context().RemoteAddr().String(),
+	}
+	for key, values := range c.GetReqHeaders() {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	c.Context().HijackSetNoResponse(true)
+	c.Context().Hijack(func(netConn net.Conn) {
+		writer := &hijackResponseWriter{conn: netConn, header: http.Header{}}
+
+		// The upgrader writes its own error response when the handshake is invalid
+		conn, err := webSocketUpgrader.Upgrade(writer, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		handle(conn)
+	})
+
+	return nil
+}
+
+// hijackResponseWriter exposes a hijacked fasthttp connection as the http.ResponseWriter
+// and http.Hijacker gorilla/websocket expects
+type hijackResponseWriter struct {
+	conn   net.Conn
+	header http.Header
+}
+
+func (w *hijackResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *hijackResponseWriter) Write(b []byte) (int, error) {
+	return w.conn.Write(b)
+}
+
+// WriteHeader writes the status line and headers of a rejected handshake.
+// The connection is closed once the response is written.
+func (w *hijackResponseWriter) WriteHeader(statusCode int) {
+	w.header.Set(fiber.HeaderConnection, "close")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "HTTP/1.1 %d %s\r\n", statusCode, http.StatusText(statusCode))
+	_ = w.header.Write(&b)
+	b.WriteString("\r\n")
+
+	_, _ = w.conn.Write([]byte(b.String()))
+}
+
+func (w *hijackResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.conn, bufio.NewReadWriter(bufio.NewReader(w.conn), bufio.NewWriter(w.conn)), nil
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"zpwoot/internal/infra/http/helpers"
 	"zpwoot/platform/config"
 	"zpwoot/platform/logger"
 )
@@ -11,9 +12,9 @@ import (
 // APIKeyAuth creates a middleware that validates API key authentication
 func APIKeyAuth(cfg *config.Config, logger *logger.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Skip authentication for health check and swagger docs
+		// Skip authentication for health check, swagger docs and presigned media URLs, which carry their own signature
 		path := c.Path()
-		if path == "/health" || strings.HasPrefix(path, "/swagger") {
+		if path == "/health" || strings.HasPrefix(path, "/swagger") || strings.HasPrefix(path, "/media/") {
 			return c.Next()
 		}
 
@@ -23,6 +24,10 @@ func APIKeyAuth(cfg *config.Config, logger *logger.Logger) fiber.Handler {
 			// Fallback to X-API-Key header for compatibility
 			apiKey = c.Get("X-API-Key")
 		}
+		if apiKey == "" && (helpers.IsWebSocketUpgrade(c) || helpers.IsEventStreamRequest(c)) {
+			// Browsers cannot set headers on WebSocket and EventSource requests, so they pass the key in the query
+			apiKey = c.Query("apiKey")
+		}
 
 		// Validate API key
 		if apiKey == "" {
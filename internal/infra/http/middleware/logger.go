@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"io"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -122,6 +123,29 @@ func (w *httpLogWriter) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
+// sensitiveQueryParams are the query parameters carrying credentials: the API key of WebSocket
+// and event stream requests and the signature of presigned media URLs
+var sensitiveQueryParams = map[string]bool{
+	"apikey":    true,
+	"signature": true,
+}
+
+// redactQuery masks the values of credentials in a raw query string, keeping the rest as sent
+func redactQuery(query string) string {
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		rawName, _, found := strings.Cut(param, "=")
+		name, err := url.QueryUnescape(rawName)
+		if err != nil {
+			name = rawName
+		}
+		if found && sensitiveQueryParams[strings.ToLower(name)] {
+			params[i] = rawName + "=xxxxx"
+		}
+	}
+	return strings.Join(params, "&")
+}
+
 // logHTTPRequest logs HTTP request using structured logging
 func logHTTPRequest(logger *logger.Logger, c *fiber.Ctx, data *fiberLogger.Data) {
 	// Determine log level based on status code
@@ -142,7 +166,7 @@ func logHTTPRequest(logger *logger.Logger, c *fiber.Ctx, data *fiberLogger.Data)
 
 	// Add query parameters if present
 	if c.Request().URI().QueryString() != nil {
-		fields["query"] = string(c.Request().URI().QueryString())
+		fields["query"] = redactQuery(string(c.Request().URI().QueryString()))
 	}
 
 	// Add request ID if present
@@ -207,7 +231,7 @@ func HTTPLogger(logger *logger.Logger) fiber.Handler {
 
 		// Add query parameters if present
 		if queryString := string(c.Request().URI().QueryString()); queryString != "" {
-			fields["query"] = queryString
+			fields["query"] = redactQuery(queryString)
 		}
 
 		// Add request headers if needed (be careful with sensitive data)
@@ -0,0 +1,25 @@
+package middleware
+
+import "testing"
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "events=Message", want: "events=Message"},
+		{query: "apiKey=secret-key", want: "apiKey=xxxxx"},
+		{query: "events=Message&apiKey=secret-key&limit=10", want: "events=Message&apiKey=xxxxx&limit=10"},
+		{query: "expires=1700000000&signature=abc%2Bdef&type=image%2Fpng", want: "expires=1700000000&signature=xxxxx&type=image%2Fpng"},
+		{query: "apikey=secret-key", want: "apikey=xxxxx"},
+		{query: "api%4Bey=secret-key", want: "api%4Bey=xxxxx"},
+		{query: "apiKey", want: "apiKey"},
+		{query: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := redactQuery(tt.query); got != tt.want {
+			t.Errorf("redactQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
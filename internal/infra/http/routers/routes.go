@@ -69,7 +69,7 @@ func SetupRoutes(app *fiber.App, database *db.DB, logger *logger.Logger, WameowM
 // setupSessionRoutes configures session management routes
 func setupSessionRoutes(app *fiber.App, appLogger *logger.Logger, WameowManager *wameow.Manager, container *app.Container) {
 	// Initialize session handler with use case and repository from container
-	sessionHandler := handlers.NewSessionHandler(appLogger, container.GetSessionUseCase(), container.GetSessionRepository())
+	sessionHandler := handlers.NewSessionHandler(appLogger, container.GetSessionUseCase(), container.WebhookUseCase, container.GetSessionRepository())
 
 	// Log Wameow manager availability
 	if WameowManager != nil {
@@ -81,23 +81,39 @@ func setupSessionRoutes(app *fiber.App, appLogger *logger.Logger, WameowManager
 	sessions := app.Group("/sessions")
 
 	// Session management routes (supports both UUID and session names)
-	sessions.Post("/create", sessionHandler.CreateSession)              // POST /sessions/create
-	sessions.Get("/list", sessionHandler.ListSessions)                  // GET /sessions/list
-	sessions.Get("/:sessionId/info", sessionHandler.GetSessionInfo)     // GET /sessions/:sessionId/info
-	sessions.Delete("/:sessionId/delete", sessionHandler.DeleteSession) // DELETE /sessions/:sessionId/delete
-	sessions.Post("/:sessionId/connect", sessionHandler.ConnectSession) // POST /sessions/:sessionId/connect
-	sessions.Post("/:sessionId/logout", sessionHandler.LogoutSession)   // POST /sessions/:sessionId/logout
-	sessions.Get("/:sessionId/qr", sessionHandler.GetQRCode)            // GET /sessions/:sessionId/qr
-	sessions.Post("/:sessionId/pair", sessionHandler.PairPhone)         // POST /sessions/:sessionId/pair
-	sessions.Post("/:sessionId/proxy/set", sessionHandler.SetProxy)     // POST /sessions/:sessionId/proxy/set
-	sessions.Get("/:sessionId/proxy/find", sessionHandler.GetProxy)     // GET /sessions/:sessionId/proxy/find
+	sessions.Post("/create", sessionHandler.CreateSession)                  // POST /sessions/create
+	sessions.Get("/list", sessionHandler.ListSessions)                      // GET /sessions/list
+	sessions.Get("/:sessionId/info", sessionHandler.GetSessionInfo)         // GET /sessions/:sessionId/info
+	sessions.Delete("/:sessionId/delete", sessionHandler.DeleteSession)     // DELETE /sessions/:sessionId/delete
+	sessions.Post("/:sessionId/connect", sessionHandler.ConnectSession)     // POST /sessions/:sessionId/connect
+	sessions.Post("/:sessionId/logout", sessionHandler.LogoutSession)       // POST /sessions/:sessionId/logout
+	sessions.Get("/:sessionId/qr", sessionHandler.GetQRCode)                // GET /sessions/:sessionId/qr
+	sessions.Get("/:sessionId/qr/stream", sessionHandler.StreamQRCode)      // GET /sessions/:sessionId/qr/stream (Server-Sent Events)
+	sessions.Post("/:sessionId/pair", sessionHandler.PairPhone)             // POST /sessions/:sessionId/pair
+	sessions.Post("/:sessionId/proxy/set", sessionHandler.SetProxy)         // POST /sessions/:sessionId/proxy/set
+	sessions.Get("/:sessionId/proxy/find", sessionHandler.GetProxy)         // GET /sessions/:sessionId/proxy/find
+	sessions.Post("/:sessionId/media/set", sessionHandler.SetMediaSettings) // POST /sessions/:sessionId/media/set
+	sessions.Get("/:sessionId/media/find", sessionHandler.GetMediaSettings) // GET /sessions/:sessionId/media/find
 
 	// Initialize webhook handler for session-specific routes
-	webhookHandler := handlers.NewWebhookHandler(container.WebhookUseCase, appLogger)
+	webhookHandler := handlers.NewWebhookHandler(container.WebhookUseCase, container.GetSessionRepository(), appLogger)
 
 	// Session-specific webhook configuration (supports both UUID and session names)
-	sessions.Post("/:sessionId/webhook/set", webhookHandler.SetConfig)  // POST /sessions/:sessionId/webhook/set
-	sessions.Get("/:sessionId/webhook/find", webhookHandler.FindConfig) // GET /sessions/:sessionId/webhook/find
+	sessions.Post("/:sessionId/webhook/set", webhookHandler.SetConfig)                      // POST /sessions/:sessionId/webhook/set
+	sessions.Get("/:sessionId/webhook/find", webhookHandler.FindConfig)                     // GET /sessions/:sessionId/webhook/find
+	sessions.Get("/:sessionId/webhook/dead-letters", webhookHandler.ListSessionDeadLetters) // GET /sessions/:sessionId/webhook/dead-letters
+	sessions.Post("/:sessionId/webhook/test", webhookHandler.TestWebhook)                   // POST /sessions/:sessionId/webhook/test
+
+	// Multiple independent webhooks per session
+	sessions.Post("/:sessionId/webhooks", webhookHandler.SetConfig)                               // POST /sessions/:sessionId/webhooks
+	sessions.Get("/:sessionId/webhooks", webhookHandler.ListSessionWebhooks)                      // GET /sessions/:sessionId/webhooks
+	sessions.Get("/:sessionId/webhooks/:webhookId", webhookHandler.GetSessionWebhook)             // GET /sessions/:sessionId/webhooks/:webhookId
+	sessions.Put("/:sessionId/webhooks/:webhookId", webhookHandler.UpdateSessionWebhook)          // PUT /sessions/:sessionId/webhooks/:webhookId
+	sessions.Patch("/:sessionId/webhooks/:webhookId/toggle", webhookHandler.ToggleSessionWebhook) // PATCH /sessions/:sessionId/webhooks/:webhookId/toggle
+	sessions.Delete("/:sessionId/webhooks/:webhookId", webhookHandler.DeleteSessionWebhook)       // DELETE /sessions/:sessionId/webhooks/:webhookId
+
+	// Live event stream over WebSocket
+	sessions.Get("/:sessionId/events/ws", webhookHandler.StreamSessionEvents) // GET /sessions/:sessionId/events/ws
 
 	// Session-specific Chatwoot configuration (simplified to 2 endpoints)
 	chatwootHandler := handlers.NewChatwootHandler(container.GetChatwootUseCase(), appLogger)
@@ -123,6 +139,14 @@ func setupSessionRoutes(app *fiber.App, appLogger *logger.Logger, WameowManager
 	sessions.Post("/:sessionId/messages/edit", messageHandler.EditMessage)              // POST /sessions/:sessionId/messages/edit
 	sessions.Post("/:sessionId/messages/delete", messageHandler.DeleteMessage)          // POST /sessions/:sessionId/messages/delete
 
+	// Chats and message history
+	chatHandler := handlers.NewChatHandler(container.GetMessageUseCase(), container.GetSessionRepository(), appLogger)
+	sessions.Get("/:sessionId/chats", chatHandler.ListChats)                                  // GET /sessions/:sessionId/chats
+	sessions.Get("/:sessionId/chats/:jid/messages", chatHandler.ListMessages)                 // GET /sessions/:sessionId/chats/:jid/messages
+	sessions.Get("/:sessionId/messages/search", chatHandler.SearchMessages)                   // GET /sessions/:sessionId/messages/search
+	sessions.Get("/:sessionId/messages/:messageId/media", chatHandler.GetMessageMedia)        // GET /sessions/:sessionId/messages/:messageId/media
+	sessions.Get("/:sessionId/messages/:messageId/media/url", chatHandler.GetMessageMediaURL) // GET /sessions/:sessionId/messages/:messageId/media/url
+
 }
 
 // setupSessionSpecificRoutes configures routes grouped by session ID
@@ -131,8 +155,37 @@ func setupSessionSpecificRoutes(app *fiber.App, database *db.DB, appLogger *logg
 	// Currently all required routes are in setupSessionRoutes
 }
 
-// setupGlobalRoutes configures global routes (currently none needed)
+// setupGlobalRoutes configures routes that are not scoped to a single session
 func setupGlobalRoutes(app *fiber.App, database *db.DB, appLogger *logger.Logger, WameowManager *wameow.Manager, container *app.Container) {
-	// All configuration routes are now session-specific
-	// This function is kept for future global routes if needed
+	webhookHandler := handlers.NewWebhookHandler(container.WebhookUseCase, container.GetSessionRepository(), appLogger)
+
+	webhooks := app.Group("/webhooks")
+
+	// Global webhooks receive events from every session
+	webhooks.Post("/", webhookHandler.CreateGlobalWebhook)              // POST /webhooks
+	webhooks.Get("/", webhookHandler.ListGlobalWebhooks)                // GET /webhooks
+	webhooks.Get("/events", webhookHandler.GetSupportedEvents)          // GET /webhooks/events
+	webhooks.Get("/schemas", webhookHandler.GetEventSchemas)            // GET /webhooks/schemas
+	webhooks.Get("/schemas/:eventType", webhookHandler.GetEventSchema)  // GET /webhooks/schemas/:eventType
+	webhooks.Post("/templates/preview", webhookHandler.PreviewTemplate) // POST /webhooks/templates/preview
+	webhooks.Get("/:webhookId", webhookHandler.GetWebhook)              // GET /webhooks/:webhookId
+	webhooks.Put("/:webhookId", webhookHandler.UpdateWebhook)           // PUT /webhooks/:webhookId
+	webhooks.Delete("/:webhookId", webhookHandler.DeleteWebhook)        // DELETE /webhooks/:webhookId
+	webhooks.Patch("/:webhookId/toggle", webhookHandler.ToggleWebhook)  // PATCH /webhooks/:webhookId/toggle
+
+	// Delivery log, dead letters and replay
+	webhooks.Get("/deliveries/dead-letters", webhookHandler.ListDeadLetters)           // GET /webhooks/deliveries/dead-letters
+	webhooks.Post("/deliveries/dead-letters/replay", webhookHandler.ReplayDeadLetters) // POST /webhooks/deliveries/dead-letters/replay
+	webhooks.Get("/deliveries/:deliveryId", webhookHandler.GetDelivery)                // GET /webhooks/deliveries/:deliveryId
+	webhooks.Post("/deliveries/:deliveryId/replay", webhookHandler.ReplayDelivery)     // POST /webhooks/deliveries/:deliveryId/replay
+
+	// Live event stream of every session over WebSocket
+	app.Get("/events/ws", webhookHandler.StreamAllEvents) // GET /events/ws
+
+	// Message search across every session
+	chatHandler := handlers.NewChatHandler(container.GetMessageUseCase(), container.GetSessionRepository(), appLogger)
+	app.Get("/messages/search", chatHandler.SearchAllMessages) // GET /messages/search
+
+	// Presigned URLs of the local media store, authenticated by their signature
+	app.Get("/media/*", chatHandler.ServeSignedMedia) // GET /media/:key
 }
@@ -0,0 +1,268 @@
+package mediastore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"zpwoot/internal/domain/message"
+	"zpwoot/internal/ports"
+)
+
+// LocalConfig configures a media store on local disk
+type LocalConfig struct {
+	// Dir is the directory media files are written to
+	Dir string
+	// BaseURL is the public URL of this API; presigned URLs point at BaseURL/media/<key>
+	BaseURL string
+	// SigningKey signs presigned URLs
+	SigningKey string
+}
+
+// LocalStore keeps media files in a local directory. Its presigned URLs are served by the
+// API itself, which checks their signature instead of the API key.
+type LocalStore struct {
+	dir        string
+	baseURL    string
+	signingKey []byte
+}
+
+// NewLocalStore creates a media store on local disk, creating its directory if needed
+func NewLocalStore(config *LocalConfig) (*LocalStore, error) {
+	if config.SigningKey == "" {
+		return nil, fmt.Errorf("a signing key is required for presigned media URLs")
+	}
+
+	dir, err := filepath.Abs(config.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve media directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create media directory: %w", err)
+	}
+
+	return &LocalStore{
+		dir:        dir,
+		baseURL:    strings.TrimRight(config.BaseURL, "/"),
+		signingKey: []byte(config.SigningKey),
+	}, nil
+}
+
+// Put stores content under its content-addressed key. Content already kept is not
+// written again, only its modification time is refreshed.
+func (s *LocalStore) Put(ctx context.Context, data []byte, contentType string) (*ports.MediaObject, error) {
+	key := contentKey(data)
+	path := s.path(key)
+
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err == nil {
+		return &ports.MediaObject{Key: key, Size: int64(len(data)), ContentType: contentType, ModifiedAt: now.Unix()}, nil
+	}
+
+	if err := writeFileAtomic(path, data); err != nil {
+		return nil, err
+	}
+
+	return &ports.MediaObject{Key: key, Size: int64(len(data)), ContentType: contentType, ModifiedAt: now.Unix()}, nil
+}
+
+// Open returns the content stored under a key
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, *ports.MediaObject, error) {
+	if err := validateKey(key); err != nil {
+		return nil, nil, message.ErrMediaObjectNotFound
+	}
+
+	file, err := os.Open(s.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, message.ErrMediaObjectNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to open media file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to stat media file: %w", err)
+	}
+
+	return file, s.object(key, info), nil
+}
+
+// Stat describes the content stored under a key
+func (s *LocalStore) Stat(ctx context.Context, key string) (*ports.MediaObject, error) {
+	if err := validateKey(key); err != nil {
+		return nil, message.ErrMediaObjectNotFound
+	}
+
+	info, err := os.Stat(s.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, message.ErrMediaObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to stat media file: %w", err)
+	}
+
+	return s.object(key, info), nil
+}
+
+// Delete removes the content stored under a key
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete media file: %w", err)
+	}
+	return nil
+}
+
+// DeleteOlderThan removes the content-addressed files last stored before the given time.
+// Files outside sha256/, such as those kept by earlier versions under their own paths, are left alone.
+func (s *LocalStore) DeleteOlderThan(ctx context.Context, before time.Time) ([]string, error) {
+	var deleted []string
+	err := filepath.WalkDir(s.path(strings.TrimSuffix(keyPrefix, "/")), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !info.ModTime().Before(before) {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if rel, err := filepath.Rel(s.dir, path); err == nil {
+			deleted = append(deleted, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return deleted, fmt.Errorf("failed to delete old media files: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// PresignGet returns a URL of this API that serves the content under a key until it expires
+func (s *LocalStore) PresignGet(ctx context.Context, key string, expires time.Duration, contentType, fileName string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if err := validateExpiry(expires); err != nil {
+		return "", err
+	}
+
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expiresAt)
+	if contentType != "" {
+		query.Set("type", contentType)
+	}
+	if fileName != "" {
+		query.Set("name", fileName)
+	}
+	query.Set("signature", s.sign(key, expiresAt, contentType, fileName))
+
+	return s.baseURL + "/media/" + key + "?" + query.Encode(), nil
+}
+
+// VerifyPresigned checks the query of a presigned URL for a key and returns the content type
+// and file name it was signed with
+func (s *LocalStore) VerifyPresigned(key string, query url.Values) (string, string, error) {
+	if err := validateKey(key); err != nil {
+		return "", "", message.ErrInvalidMediaSignature
+	}
+
+	expiresAt := query.Get("expires")
+	expires, err := strconv.ParseInt(expiresAt, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", "", message.ErrInvalidMediaSignature
+	}
+
+	contentType, fileName := query.Get("type"), query.Get("name")
+	expected := s.sign(key, expiresAt, contentType, fileName)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return "", "", message.ErrInvalidMediaSignature
+	}
+
+	return contentType, fileName, nil
+}
+
+// sign returns the signature of a presigned URL
+func (s *LocalStore) sign(key, expiresAt, contentType, fileName string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + expiresAt + "\n" + contentType + "\n" + fileName))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// path returns the file of a key
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+// object describes the file of a key
+func (s *LocalStore) object(key string, info fs.FileInfo) *ports.MediaObject {
+	return &ports.MediaObject{
+		Key:        key,
+		Size:       info.Size(),
+		ModifiedAt: info.ModTime().Unix(),
+	}
+}
+
+// writeFileAtomic writes a file through a temporary file, so a concurrent reader never sees a partial file
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create media directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create media file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write media file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write media file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save media file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+package mediastore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"zpwoot/internal/domain/message"
+)
+
+func newTestLocalStore(t *testing.T) *LocalStore {
+	t.Helper()
+
+	store, err := NewLocalStore(&LocalConfig{
+		Dir:        t.TempDir(),
+		BaseURL:    "https://api.example.com/",
+		SigningKey: "test-signing-key",
+	})
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	return store
+}
+
+func TestNewLocalStoreRequiresSigningKey(t *testing.T) {
+	if _, err := NewLocalStore(&LocalConfig{Dir: t.TempDir()}); err == nil {
+		t.Fatal("NewLocalStore without signing key = nil, want error")
+	}
+}
+
+func TestLocalStorePutStatOpen(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+	data := []byte("a photo a customer sent")
+
+	object, err := store.Put(ctx, data, "image/jpeg")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if object.Key != contentKey(data) || object.Size != int64(len(data)) || object.ContentType != "image/jpeg" {
+		t.Fatalf("Put = %+v", object)
+	}
+
+	stat, err := store.Stat(ctx, object.Key)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if stat.Key != object.Key || stat.Size != int64(len(data)) {
+		t.Fatalf("Stat = %+v", stat)
+	}
+
+	reader, opened, err := store.Open(ctx, object.Key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(data) || opened.Size != int64(len(data)) {
+		t.Fatalf("Open = %q, %+v", content, opened)
+	}
+}
+
+func TestLocalStorePutRefreshesExistingContent(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+	data := []byte("sent twice")
+
+	object, err := store.Put(ctx, data, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(store.path(object.Key), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Put(ctx, data, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := store.Stat(ctx, object.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(time.Unix(stat.ModifiedAt, 0)) > time.Minute {
+		t.Fatalf("Put did not refresh the modification time: %s", time.Unix(stat.ModifiedAt, 0))
+	}
+}
+
+func TestLocalStoreMissingAndInvalidKeys(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+
+	// A file next to the store must not be reachable through a key
+	outside := filepath.Join(filepath.Dir(store.dir), "outside.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{contentKey([]byte("never stored")), "../outside.txt", "/etc/passwd", ""} {
+		if _, err := store.Stat(ctx, key); !errors.Is(err, message.ErrMediaObjectNotFound) {
+			t.Errorf("Stat(%q) = %v, want ErrMediaObjectNotFound", key, err)
+		}
+		if _, _, err := store.Open(ctx, key); !errors.Is(err, message.ErrMediaObjectNotFound) {
+			t.Errorf("Open(%q) = %v, want ErrMediaObjectNotFound", key, err)
+		}
+	}
+
+	if err := store.Delete(ctx, "../outside.txt"); err == nil {
+		t.Error("Delete outside the store = nil, want error")
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("file outside the store was removed: %v", err)
+	}
+}
+
+func TestLocalStoreDelete(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+
+	object, err := store.Put(ctx, []byte("to delete"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, object.Key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Stat(ctx, object.Key); !errors.Is(err, message.ErrMediaObjectNotFound) {
+		t.Fatalf("Stat after Delete = %v, want ErrMediaObjectNotFound", err)
+	}
+
+	// Deleting content that is already gone is not an error
+	if err := store.Delete(ctx, object.Key); err != nil {
+		t.Fatalf("second Delete: %v", err)
+	}
+}
+
+func TestLocalStoreDeleteOlderThan(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+
+	oldObject, err := store.Put(ctx, []byte("old"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newObject, err := store.Put(ctx, []byte("new"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A file kept by an earlier version under its own path
+	legacy := filepath.Join(store.dir, "session", "message.jpg")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte("legacy"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	for _, path := range []string{store.path(oldObject.Key), legacy} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := store.DeleteOlderThan(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteOlderThan: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != oldObject.Key {
+		t.Fatalf("DeleteOlderThan = %v, want [%s]", deleted, oldObject.Key)
+	}
+
+	if _, err := store.Stat(ctx, oldObject.Key); !errors.Is(err, message.ErrMediaObjectNotFound) {
+		t.Errorf("old content still kept: %v", err)
+	}
+	if _, err := store.Stat(ctx, newObject.Key); err != nil {
+		t.Errorf("new content removed: %v", err)
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		t.Errorf("file outside sha256/ removed: %v", err)
+	}
+}
+
+func TestLocalStoreDeleteOlderThanEmptyStore(t *testing.T) {
+	store := newTestLocalStore(t)
+
+	deleted, err := store.DeleteOlderThan(context.Background(), time.Now())
+	if err != nil || len(deleted) != 0 {
+		t.Fatalf("DeleteOlderThan on an empty store = %v, %v", deleted, err)
+	}
+}
+
+func TestLocalStorePresign(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+	key := contentKey([]byte("presigned"))
+
+	presigned, err := store.PresignGet(ctx, key, time.Hour, "image/png", "photo.png")
+	if err != nil {
+		t.Fatalf("PresignGet: %v", err)
+	}
+
+	parsed, err := url.Parse(presigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(presigned, "https://api.example.com/media/"+key+"?") {
+		t.Fatalf("PresignGet = %q, want a URL of the API", presigned)
+	}
+
+	contentType, fileName, err := store.VerifyPresigned(key, parsed.Query())
+	if err != nil {
+		t.Fatalf("VerifyPresigned: %v", err)
+	}
+	if contentType != "image/png" || fileName != "photo.png" {
+		t.Fatalf("VerifyPresigned = %q, %q", contentType, fileName)
+	}
+
+	tampered := []struct {
+		name   string
+		key    string
+		modify func(url.Values)
+	}{
+		{name: "other key", key: contentKey([]byte("other")), modify: func(url.Values) {}},
+		{name: "changed type", key: key, modify: func(q url.Values) { q.Set("type", "text/html") }},
+		{name: "changed name", key: key, modify: func(q url.Values) { q.Set("name", "evil.html") }},
+		{name: "extended expiry", key: key, modify: func(q url.Values) { q.Set("expires", strconv.FormatInt(time.Now().Add(48*time.Hour).Unix(), 10)) }},
+		{name: "bad signature", key: key, modify: func(q url.Values) { q.Set("signature", "AAAA") }},
+		{name: "missing signature", key: key, modify: func(q url.Values) { q.Del("signature") }},
+		{name: "invalid key", key: "../" + key, modify: func(url.Values) {}},
+	}
+	for _, tt := range tampered {
+		query := parsed.Query()
+		tt.modify(query)
+		if _, _, err := store.VerifyPresigned(tt.key, query); !errors.Is(err, message.ErrInvalidMediaSignature) {
+			t.Errorf("%s: VerifyPresigned = %v, want ErrInvalidMediaSignature", tt.name, err)
+		}
+	}
+}
+
+func TestLocalStorePresignExpired(t *testing.T) {
+	store := newTestLocalStore(t)
+	key := contentKey([]byte("expired"))
+
+	// A URL signed correctly but whose expiry has passed
+	expiresAt := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expiresAt)
+	query.Set("signature", store.sign(key, expiresAt, "", ""))
+
+	if _, _, err := store.VerifyPresigned(key, query); !errors.Is(err, message.ErrInvalidMediaSignature) {
+		t.Fatalf("VerifyPresigned of an expired URL = %v, want ErrInvalidMediaSignature", err)
+	}
+}
+
+func TestLocalStorePresignRejectsInvalidRequests(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+	key := contentKey([]byte("presigned"))
+
+	if _, err := store.PresignGet(ctx, "../"+key, time.Hour, "", ""); err == nil {
+		t.Error("PresignGet with an invalid key = nil, want error")
+	}
+	for _, expires := range []time.Duration{0, 8 * 24 * time.Hour} {
+		if _, err := store.PresignGet(ctx, key, expires, "", ""); err == nil {
+			t.Errorf("PresignGet expiring in %s = nil, want error", expires)
+		}
+	}
+}
+
+func TestLocalStoreWritesAtomically(t *testing.T) {
+	store := newTestLocalStore(t)
+
+	if _, err := store.Put(context.Background(), []byte("complete"), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// No temporary upload file is left behind
+	var files []string
+	filepath.WalkDir(store.dir, func(path string, entry os.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			files = append(files, filepath.Base(path))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	if len(files) != 1 || strings.HasPrefix(files[0], ".upload-") {
+		t.Fatalf("store files = %v, want only the stored content", files)
+	}
+}
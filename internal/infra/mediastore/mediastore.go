@@ -0,0 +1,62 @@
+// Package mediastore implements media stores that keep the media of messages on local
+// disk or in an S3-compatible bucket, under content-addressed keys.
+package mediastore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// keyPrefix starts every content-addressed key, naming the hash it is derived from
+	keyPrefix = "sha256/"
+	// maxPresignExpiry is the longest validity of a presigned URL, the limit of S3 signatures
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// contentKey returns the content-addressed key of media content. The first byte of the hash
+// names a subdirectory, so no directory of a local store grows too large.
+func contentKey(data []byte) string {
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	return keyPrefix + digest[:2] + "/" + digest
+}
+
+// validateKey rejects keys that would address a file outside the store
+func validateKey(key string) error {
+	if key == "" || path.Clean(key) != key || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return fmt.Errorf("invalid media key %q", key)
+	}
+	return nil
+}
+
+// validateExpiry checks the validity requested for a presigned URL
+func validateExpiry(expires time.Duration) error {
+	if expires <= 0 || expires > maxPresignExpiry {
+		return fmt.Errorf("presigned URL expiry must be between 1s and %s", maxPresignExpiry)
+	}
+	return nil
+}
+
+// contentDisposition returns the Content-Disposition of a download with the given file name
+func contentDisposition(fileName string) string {
+	if fileName == "" {
+		return "inline"
+	}
+	return mime.FormatMediaType("inline", map[string]string{"filename": fileName})
+}
+
+// trimSlashes removes the leading and trailing slashes of a key prefix and ends it with one
+func trimSlashes(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
@@ -0,0 +1,94 @@
+package mediastore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+func TestContentKey(t *testing.T) {
+	data := []byte("hello media")
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	key := contentKey(data)
+	if want := "sha256/" + digest[:2] + "/" + digest; key != want {
+		t.Fatalf("contentKey = %q, want %q", key, want)
+	}
+	if err := validateKey(key); err != nil {
+		t.Fatalf("validateKey(contentKey) = %v", err)
+	}
+	if contentKey([]byte("other media")) == key {
+		t.Fatal("different content got the same key")
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{key: "sha256/ab/abcdef", valid: true},
+		{key: "session/message.jpg", valid: true},
+		{key: "", valid: false},
+		{key: "../etc/passwd", valid: false},
+		{key: "sha256/../../etc/passwd", valid: false},
+		{key: "sha256/ab/../../../secret", valid: false},
+		{key: "/etc/passwd", valid: false},
+		{key: "sha256//ab", valid: false},
+		{key: "sha256/ab/", valid: false},
+		{key: "./sha256/ab", valid: false},
+		{key: "..", valid: false},
+	}
+
+	for _, tt := range tests {
+		err := validateKey(tt.key)
+		if tt.valid && err != nil {
+			t.Errorf("validateKey(%q) = %v, want nil", tt.key, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("validateKey(%q) = nil, want error", tt.key)
+		}
+	}
+}
+
+func TestValidateExpiry(t *testing.T) {
+	for _, expires := range []time.Duration{time.Second, time.Hour, maxPresignExpiry} {
+		if err := validateExpiry(expires); err != nil {
+			t.Errorf("validateExpiry(%s) = %v, want nil", expires, err)
+		}
+	}
+	for _, expires := range []time.Duration{0, -time.Second, maxPresignExpiry + time.Second} {
+		if err := validateExpiry(expires); err == nil {
+			t.Errorf("validateExpiry(%s) = nil, want error", expires)
+		}
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	if got := contentDisposition(""); got != "inline" {
+		t.Errorf("contentDisposition(\"\") = %q", got)
+	}
+	if got := contentDisposition("photo.jpg"); got != "inline; filename=photo.jpg" {
+		t.Errorf("contentDisposition(photo.jpg) = %q", got)
+	}
+	if got := contentDisposition("relatório final.pdf"); got != "inline; filename*=utf-8''relat%C3%B3rio%20final.pdf" {
+		t.Errorf("contentDisposition with non-ASCII name = %q", got)
+	}
+}
+
+func TestTrimSlashes(t *testing.T) {
+	tests := map[string]string{
+		"":             "",
+		"/":            "",
+		"media":        "media/",
+		"/media/":      "media/",
+		"zpwoot/media": "zpwoot/media/",
+	}
+	for prefix, want := range tests {
+		if got := trimSlashes(prefix); got != want {
+			t.Errorf("trimSlashes(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+}
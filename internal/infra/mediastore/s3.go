@@ -0,0 +1,260 @@
+package mediastore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"zpwoot/internal/domain/message"
+	"zpwoot/internal/ports"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config configures a media store in an S3-compatible bucket
+type S3Config struct {
+	// Endpoint is the host of the S3 API, optionally with an http:// or https:// scheme
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// Prefix is prepended to every object key, so the bucket can be shared
+	Prefix string
+	// UseSSL connects over HTTPS when the endpoint has no scheme
+	UseSSL bool
+	// PathStyle addresses the bucket as endpoint/bucket, as MinIO and most S3-compatible servers expect
+	PathStyle bool
+}
+
+// S3Store keeps media files in an S3-compatible bucket. Its presigned URLs are signed by
+// the bucket credentials and served by the bucket itself.
+type S3Store struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Store connects to an S3-compatible bucket, creating it if it does not exist
+func NewS3Store(ctx context.Context, config *S3Config) (*S3Store, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("an endpoint and a bucket are required for S3 media storage")
+	}
+
+	endpoint, secure, err := parseEndpoint(config.Endpoint, config.UseSSL)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := minio.BucketLookupAuto
+	if config.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure:       secure,
+		Region:       config.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, config.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check media bucket: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, config.Bucket, minio.MakeBucketOptions{Region: config.Region}); err != nil {
+			return nil, fmt.Errorf("failed to create media bucket: %w", err)
+		}
+	}
+
+	return &S3Store{
+		client: client,
+		bucket: config.Bucket,
+		prefix: trimSlashes(config.Prefix),
+	}, nil
+}
+
+// Put stores content under its content-addressed key. Content already kept is not
+// uploaded again, the object is copied onto itself to refresh its modification time.
+func (s *S3Store) Put(ctx context.Context, data []byte, contentType string) (*ports.MediaObject, error) {
+	key := contentKey(data)
+	object := s.prefix + key
+
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	if _, err := s.client.StatObject(ctx, s.bucket, object, minio.StatObjectOptions{}); err == nil {
+		_, err := s.client.CopyObject(ctx,
+			minio.CopyDestOptions{Bucket: s.bucket, Object: object, ReplaceMetadata: true, ContentType: contentType},
+			minio.CopySrcOptions{Bucket: s.bucket, Object: object},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to refresh media object: %w", err)
+		}
+	} else if !isNotFound(err) {
+		return nil, fmt.Errorf("failed to stat media object: %w", err)
+	} else {
+		_, err := s.client.PutObject(ctx, s.bucket, object, bytes.NewReader(data), int64(len(data)),
+			minio.PutObjectOptions{ContentType: contentType})
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload media object: %w", err)
+		}
+	}
+
+	return &ports.MediaObject{
+		Key:         key,
+		Size:        int64(len(data)),
+		ContentType: contentType,
+		ModifiedAt:  time.Now().Unix(),
+	}, nil
+}
+
+// Open returns the content stored under a key
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, *ports.MediaObject, error) {
+	if err := validateKey(key); err != nil {
+		return nil, nil, message.ErrMediaObjectNotFound
+	}
+
+	object, err := s.client.GetObject(ctx, s.bucket, s.prefix+key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open media object: %w", err)
+	}
+
+	// GetObject is lazy, Stat makes the request and reports a missing object
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		if isNotFound(err) {
+			return nil, nil, message.ErrMediaObjectNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to open media object: %w", err)
+	}
+
+	return object, s.object(key, info), nil
+}
+
+// Stat describes the content stored under a key
+func (s *S3Store) Stat(ctx context.Context, key string) (*ports.MediaObject, error) {
+	if err := validateKey(key); err != nil {
+		return nil, message.ErrMediaObjectNotFound
+	}
+
+	info, err := s.client.StatObject(ctx, s.bucket, s.prefix+key, minio.StatObjectOptions{})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, message.ErrMediaObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to stat media object: %w", err)
+	}
+
+	return s.object(key, info), nil
+}
+
+// Delete removes the content stored under a key
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	if err := s.client.RemoveObject(ctx, s.bucket, s.prefix+key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete media object: %w", err)
+	}
+	return nil
+}
+
+// DeleteOlderThan removes the content-addressed objects last stored before the given time
+func (s *S3Store) DeleteOlderThan(ctx context.Context, before time.Time) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var deleted []string
+	objects := s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    s.prefix + keyPrefix,
+		Recursive: true,
+	})
+	for object := range objects {
+		if object.Err != nil {
+			return deleted, fmt.Errorf("failed to list media objects: %w", object.Err)
+		}
+		if !object.LastModified.Before(before) {
+			continue
+		}
+
+		if err := s.client.RemoveObject(ctx, s.bucket, object.Key, minio.RemoveObjectOptions{}); err != nil {
+			return deleted, fmt.Errorf("failed to delete media object: %w", err)
+		}
+		deleted = append(deleted, strings.TrimPrefix(object.Key, s.prefix))
+	}
+
+	return deleted, nil
+}
+
+// PresignGet returns a URL of the bucket that serves the content under a key until it expires
+func (s *S3Store) PresignGet(ctx context.Context, key string, expires time.Duration, contentType, fileName string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if err := validateExpiry(expires); err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	if contentType != "" {
+		params.Set("response-content-type", contentType)
+	}
+	params.Set("response-content-disposition", contentDisposition(fileName))
+
+	presigned, err := s.client.PresignedGetObject(ctx, s.bucket, s.prefix+key, expires, params)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign media URL: %w", err)
+	}
+
+	return presigned.String(), nil
+}
+
+// object describes a bucket object under a key
+func (s *S3Store) object(key string, info minio.ObjectInfo) *ports.MediaObject {
+	return &ports.MediaObject{
+		Key:         key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ModifiedAt:  info.LastModified.Unix(),
+	}
+}
+
+// parseEndpoint splits an endpoint into the host minio expects and whether it uses HTTPS
+func parseEndpoint(endpoint string, useSSL bool) (string, bool, error) {
+	if !strings.Contains(endpoint, "://") {
+		return strings.TrimRight(endpoint, "/"), useSSL, nil
+	}
+
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return "", false, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		return parsed.Host, true, nil
+	case "http":
+		return parsed.Host, false, nil
+	default:
+		return "", false, fmt.Errorf("invalid S3 endpoint scheme %q", parsed.Scheme)
+	}
+}
+
+// isNotFound reports whether an S3 error is about a missing object
+func isNotFound(err error) bool {
+	code := minio.ToErrorResponse(err).Code
+	return code == "NoSuchKey" || code == "NotFound"
+}
@@ -0,0 +1,473 @@
+package mediastore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"zpwoot/internal/domain/message"
+)
+
+func newTestS3Store(t *testing.T, prefix string) (*S3Store, *fakeS3) {
+	t.Helper()
+
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Store(context.Background(), &S3Config{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "media",
+		AccessKey: "access",
+		SecretKey: "secret",
+		Prefix:    prefix,
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Store: %v", err)
+	}
+	return store, fake
+}
+
+func TestNewS3StoreCreatesBucket(t *testing.T) {
+	_, fake := newTestS3Store(t, "")
+
+	if !fake.hasBucket("media") {
+		t.Fatal("bucket was not created")
+	}
+}
+
+func TestNewS3StoreRequiresEndpointAndBucket(t *testing.T) {
+	if _, err := NewS3Store(context.Background(), &S3Config{Bucket: "media"}); err == nil {
+		t.Error("NewS3Store without endpoint = nil, want error")
+	}
+	if _, err := NewS3Store(context.Background(), &S3Config{Endpoint: "localhost:9000"}); err == nil {
+		t.Error("NewS3Store without bucket = nil, want error")
+	}
+}
+
+func TestS3StorePutStatOpen(t *testing.T) {
+	store, fake := newTestS3Store(t, "zpwoot")
+	ctx := context.Background()
+	data := []byte("a voice note a customer sent")
+
+	object, err := store.Put(ctx, data, "audio/ogg")
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if object.Key != contentKey(data) || object.Size != int64(len(data)) || object.ContentType != "audio/ogg" {
+		t.Fatalf("Put = %+v", object)
+	}
+
+	// The object lives under the prefix, the key does not include it
+	if stored, ok := fake.object("media", "zpwoot/"+object.Key); !ok || string(stored.data) != string(data) {
+		t.Fatalf("bucket object = %q, %v", stored.data, ok)
+	}
+
+	stat, err := store.Stat(ctx, object.Key)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if stat.Key != object.Key || stat.Size != int64(len(data)) || stat.ContentType != "audio/ogg" {
+		t.Fatalf("Stat = %+v", stat)
+	}
+
+	reader, opened, err := store.Open(ctx, object.Key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != string(data) || opened.ContentType != "audio/ogg" {
+		t.Fatalf("Open = %q, %+v", content, opened)
+	}
+}
+
+func TestS3StorePutRefreshesExistingContent(t *testing.T) {
+	store, fake := newTestS3Store(t, "")
+	ctx := context.Background()
+	data := []byte("sent twice")
+
+	object, err := store.Put(ctx, data, "image/jpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.age("media", object.Key, 48*time.Hour)
+
+	if _, err := store.Put(ctx, data, "image/jpeg"); err != nil {
+		t.Fatalf("second Put: %v", err)
+	}
+
+	if uploads := fake.uploadCount(); uploads != 1 {
+		t.Errorf("content was uploaded %d times, want once", uploads)
+	}
+	stat, err := store.Stat(ctx, object.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(time.Unix(stat.ModifiedAt, 0)) > time.Minute {
+		t.Fatalf("Put did not refresh the modification time: %s", time.Unix(stat.ModifiedAt, 0))
+	}
+}
+
+func TestS3StoreMissingAndInvalidKeys(t *testing.T) {
+	store, _ := newTestS3Store(t, "")
+	ctx := context.Background()
+
+	for _, key := range []string{contentKey([]byte("never stored")), "../other-bucket/key", ""} {
+		if _, err := store.Stat(ctx, key); !errors.Is(err, message.ErrMediaObjectNotFound) {
+			t.Errorf("Stat(%q) = %v, want ErrMediaObjectNotFound", key, err)
+		}
+		if _, _, err := store.Open(ctx, key); !errors.Is(err, message.ErrMediaObjectNotFound) {
+			t.Errorf("Open(%q) = %v, want ErrMediaObjectNotFound", key, err)
+		}
+	}
+	if err := store.Delete(ctx, "../other-bucket/key"); err == nil {
+		t.Error("Delete with an invalid key = nil, want error")
+	}
+}
+
+func TestS3StoreDelete(t *testing.T) {
+	store, _ := newTestS3Store(t, "")
+	ctx := context.Background()
+
+	object, err := store.Put(ctx, []byte("to delete"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, object.Key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Stat(ctx, object.Key); !errors.Is(err, message.ErrMediaObjectNotFound) {
+		t.Fatalf("Stat after Delete = %v, want ErrMediaObjectNotFound", err)
+	}
+}
+
+func TestS3StoreDeleteOlderThan(t *testing.T) {
+	store, fake := newTestS3Store(t, "zpwoot")
+	ctx := context.Background()
+
+	oldObject, err := store.Put(ctx, []byte("old"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newObject, err := store.Put(ctx, []byte("new"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.age("media", "zpwoot/"+oldObject.Key, 48*time.Hour)
+
+	// Old objects outside the content-addressed keys of the store are left alone
+	fake.put("media", "zpwoot/session/message.jpg", []byte("legacy"), 48*time.Hour)
+	fake.put("media", "other-app/"+oldObject.Key, []byte("old"), 48*time.Hour)
+
+	deleted, err := store.DeleteOlderThan(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("DeleteOlderThan: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != oldObject.Key {
+		t.Fatalf("DeleteOlderThan = %v, want [%s]", deleted, oldObject.Key)
+	}
+
+	if _, err := store.Stat(ctx, oldObject.Key); !errors.Is(err, message.ErrMediaObjectNotFound) {
+		t.Errorf("old content still kept: %v", err)
+	}
+	if _, err := store.Stat(ctx, newObject.Key); err != nil {
+		t.Errorf("new content removed: %v", err)
+	}
+	for _, key := range []string{"zpwoot/session/message.jpg", "other-app/" + oldObject.Key} {
+		if _, ok := fake.object("media", key); !ok {
+			t.Errorf("object %q outside the retention scope was removed", key)
+		}
+	}
+}
+
+func TestS3StorePresign(t *testing.T) {
+	store, _ := newTestS3Store(t, "zpwoot")
+	ctx := context.Background()
+	key := contentKey([]byte("presigned"))
+
+	presigned, err := store.PresignGet(ctx, key, time.Hour, "image/png", "photo.png")
+	if err != nil {
+		t.Fatalf("PresignGet: %v", err)
+	}
+
+	parsed, err := url.Parse(presigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Path != "/media/zpwoot/"+key {
+		t.Errorf("presigned path = %q", parsed.Path)
+	}
+	query := parsed.Query()
+	if query.Get("response-content-type") != "image/png" {
+		t.Errorf("response-content-type = %q", query.Get("response-content-type"))
+	}
+	if query.Get("response-content-disposition") != "inline; filename=photo.png" {
+		t.Errorf("response-content-disposition = %q", query.Get("response-content-disposition"))
+	}
+	if query.Get("X-Amz-Expires") != "3600" || query.Get("X-Amz-Signature") == "" {
+		t.Errorf("presigned URL is not signed for an hour: %s", presigned)
+	}
+
+	if _, err := store.PresignGet(ctx, "../"+key, time.Hour, "", ""); err == nil {
+		t.Error("PresignGet with an invalid key = nil, want error")
+	}
+	if _, err := store.PresignGet(ctx, key, 8*24*time.Hour, "", ""); err == nil {
+		t.Error("PresignGet past the S3 signature limit = nil, want error")
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		useSSL   bool
+		host     string
+		secure   bool
+		wantErr  bool
+	}{
+		{endpoint: "minio:9000", host: "minio:9000"},
+		{endpoint: "s3.amazonaws.com", useSSL: true, host: "s3.amazonaws.com", secure: true},
+		{endpoint: "http://minio:9000/", useSSL: true, host: "minio:9000"},
+		{endpoint: "https://storage.example.com", host: "storage.example.com", secure: true},
+		{endpoint: "ftp://storage.example.com", wantErr: true},
+		{endpoint: "https://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		host, secure, err := parseEndpoint(tt.endpoint, tt.useSSL)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseEndpoint(%q) = nil error, want error", tt.endpoint)
+			}
+			continue
+		}
+		if err != nil || host != tt.host || secure != tt.secure {
+			t.Errorf("parseEndpoint(%q, %v) = %q, %v, %v; want %q, %v", tt.endpoint, tt.useSSL, host, secure, err, tt.host, tt.secure)
+		}
+	}
+}
+
+// fakeS3Object is an object kept by the fake S3 server
+type fakeS3Object struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+// fakeS3 is an in-memory stand-in for an S3-compatible server with path-style addressing.
+// It serves the calls the store makes and does not check signatures.
+type fakeS3 struct {
+	mutex   sync.Mutex
+	buckets map[string]map[string]*fakeS3Object
+	uploads int
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{buckets: make(map[string]map[string]*fakeS3Object)}
+}
+
+func (f *fakeS3) hasBucket(bucket string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	_, ok := f.buckets[bucket]
+	return ok
+}
+
+func (f *fakeS3) object(bucket, key string) (fakeS3Object, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	object, ok := f.buckets[bucket][key]
+	if !ok {
+		return fakeS3Object{}, false
+	}
+	return *object, true
+}
+
+func (f *fakeS3) put(bucket, key string, data []byte, age time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.buckets[bucket][key] = &fakeS3Object{data: data, modTime: time.Now().Add(-age)}
+}
+
+// age makes an object look like it was last stored the given duration ago
+func (f *fakeS3) age(bucket, key string, age time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.buckets[bucket][key].modTime = time.Now().Add(-age)
+}
+
+func (f *fakeS3) uploadCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.uploads
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	objects, exists := f.buckets[bucket]
+
+	if key == "" {
+		switch {
+		case r.Method == http.MethodHead:
+			if !exists {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodPut:
+			f.buckets[bucket] = make(map[string]*fakeS3Object)
+		case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+			f.list(w, objects, r.URL.Query().Get("prefix"))
+		default:
+			http.Error(w, "unsupported bucket request", http.StatusNotImplemented)
+		}
+		return
+	}
+
+	if !exists {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if source := r.Header.Get("X-Amz-Copy-Source"); source != "" {
+			source, _ = url.PathUnescape(source)
+			_, sourceKey, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+			original, ok := objects[sourceKey]
+			if !ok {
+				writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+				return
+			}
+			copied := &fakeS3Object{data: original.data, contentType: r.Header.Get("Content-Type"), modTime: time.Now()}
+			objects[key] = copied
+			fmt.Fprintf(w, `<CopyObjectResult><LastModified>%s</LastModified><ETag>"etag"</ETag></CopyObjectResult>`,
+				copied.modTime.UTC().Format(time.RFC3339))
+			return
+		}
+
+		data, err := readS3Body(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		objects[key] = &fakeS3Object{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now()}
+		f.uploads++
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodHead, http.MethodGet:
+		object, ok := objects[key]
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+			} else {
+				writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			}
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.data)))
+		w.Header().Set("Last-Modified", object.modTime.UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"etag"`)
+		if r.Method == http.MethodGet {
+			w.Write(object.data)
+		}
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unsupported object request", http.StatusNotImplemented)
+	}
+}
+
+// list answers a ListObjectsV2 request in a single page
+func (f *fakeS3) list(w http.ResponseWriter, objects map[string]*fakeS3Object, prefix string) {
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Prefix      string
+		KeyCount    int
+		IsTruncated bool
+		Contents    []content
+	}{Prefix: prefix}
+
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		result.Contents = append(result.Contents, content{
+			Key:          key,
+			LastModified: objects[key].modTime.UTC().Format(time.RFC3339),
+			ETag:         `"etag"`,
+			Size:         len(objects[key].data),
+		})
+	}
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+// readS3Body reads an upload, decoding the aws-chunked encoding of streaming signatures
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chunk size %q", sizeHex)
+		}
+		if size == 0 {
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, reader, size); err != nil {
+			return nil, err
+		}
+		if _, err := reader.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<Error><Code>%s</Code><Message>%s</Message></Error>`, code, code)
+}
@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"zpwoot/internal/ports"
+	"zpwoot/platform/logger"
+)
+
+// eventOutboxRepository implements the EventOutboxRepository interface
+type eventOutboxRepository struct {
+	db     *sqlx.DB
+	logger *logger.Logger
+}
+
+// NewEventOutboxRepository creates a new event outbox repository
+func NewEventOutboxRepository(db *sqlx.DB, logger *logger.Logger) ports.EventOutboxRepository {
+	return &eventOutboxRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// outboxEventModel represents the database model for outbox events
+type outboxEventModel struct {
+	Sequence  int64          `db:"sequence"`
+	EventID   string         `db:"eventId"`
+	SessionID sql.NullString `db:"sessionId"`
+	EventType string         `db:"eventType"`
+	Payload   string         `db:"payload"`
+	CreatedAt time.Time      `db:"createdAt"`
+}
+
+// Append records an event, joining the transaction carried by ctx if there is one.
+// Recording the same event twice keeps the first copy.
+//
+// createdAt is taken from the database clock when the row is inserted, right after its
+// sequence: clock_timestamp() rather than NOW(), which is when the transaction started.
+// The gap timeout of the dispatcher relies on it to bound when a sequence can still appear.
+func (r *eventOutboxRepository) Append(ctx context.Context, event *ports.OutboxEvent) error {
+	model := &outboxEventModel{
+		EventID:   event.EventID,
+		SessionID: sql.NullString{String: event.SessionID, Valid: event.SessionID != ""},
+		EventType: event.EventType,
+		Payload:   event.Payload,
+	}
+
+	query := `
+		INSERT INTO "zpEventOutbox" ("eventId", "sessionId", "eventType", payload, "createdAt")
+		VALUES (:eventId, :sessionId, :eventType, :payload, clock_timestamp())
+		ON CONFLICT ("eventId") DO NOTHING
+	`
+
+	_, err := sqlx.NamedExecContext(ctx, executor(ctx, r.db), query, model)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to append event to outbox", map[string]interface{}{
+			"event_id":   event.EventID,
+			"event_type": event.EventType,
+			"session_id": event.SessionID,
+			"error":      err.Error(),
+		})
+		return fmt.Errorf("failed to append event to outbox: %w", err)
+	}
+
+	return nil
+}
+
+// ListAfter retrieves events recorded after the given sequence, in sequence order
+func (r *eventOutboxRepository) ListAfter(ctx context.Context, sequence int64, limit int) ([]*ports.OutboxEvent, error) {
+	query := `
+		SELECT * FROM "zpEventOutbox"
+		WHERE sequence > $1
+		ORDER BY sequence ASC
+		LIMIT $2
+	`
+
+	var models []outboxEventModel
+	err := r.db.SelectContext(ctx, &models, query, sequence, limit)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to list outbox events", map[string]interface{}{
+			"after_sequence": sequence,
+			"error":          err.Error(),
+		})
+		return nil, fmt.Errorf("failed to list outbox events: %w", err)
+	}
+
+	events := make([]*ports.OutboxEvent, len(models))
+	for i := range models {
+		events[i] = r.fromModel(&models[i])
+	}
+	return events, nil
+}
+
+// ListSequences retrieves the events recorded under the given sequences, in sequence order
+func (r *eventOutboxRepository) ListSequences(ctx context.Context, sequences []int64) ([]*ports.OutboxEvent, error) {
+	query := `
+		SELECT * FROM "zpEventOutbox"
+		WHERE sequence = ANY($1)
+		ORDER BY sequence ASC
+	`
+
+	var models []outboxEventModel
+	err := r.db.SelectContext(ctx, &models, query, pq.Array(sequences))
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to list outbox events by sequence", map[string]interface{}{
+			"sequences": len(sequences),
+			"error":     err.Error(),
+		})
+		return nil, fmt.Errorf("failed to list outbox events by sequence: %w", err)
+	}
+
+	events := make([]*ports.OutboxEvent, len(models))
+	for i := range models {
+		events[i] = r.fromModel(&models[i])
+	}
+	return events, nil
+}
+
+// LatestSequence returns the sequence of the last recorded event, 0 when the outbox is empty
+func (r *eventOutboxRepository) LatestSequence(ctx context.Context) (int64, error) {
+	var sequence int64
+	query := `SELECT COALESCE(MAX(sequence), 0) FROM "zpEventOutbox"`
+
+	if err := r.db.GetContext(ctx, &sequence, query); err != nil {
+		return 0, fmt.Errorf("failed to get latest outbox sequence: %w", err)
+	}
+
+	return sequence, nil
+}
+
+// GetCheckpoint returns the last sequence processed by a consumer
+func (r *eventOutboxRepository) GetCheckpoint(ctx context.Context, consumer string) (int64, bool, error) {
+	var sequence int64
+	query := `SELECT sequence FROM "zpEventOutboxCheckpoints" WHERE consumer = $1`
+
+	err := r.db.GetContext(ctx, &sequence, query, consumer)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to get outbox checkpoint: %w", err)
+	}
+
+	return sequence, true, nil
+}
+
+// SaveCheckpoint stores the last sequence processed by a consumer
+func (r *eventOutboxRepository) SaveCheckpoint(ctx context.Context, consumer string, sequence int64) error {
+	query := `
+		INSERT INTO "zpEventOutboxCheckpoints" (consumer, sequence, "updatedAt")
+		VALUES ($1, $2, $3)
+		ON CONFLICT (consumer) DO UPDATE SET sequence = EXCLUDED.sequence, "updatedAt" = EXCLUDED."updatedAt"
+	`
+
+	_, err := r.db.ExecContext(ctx, query, consumer, sequence, time.Now())
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to save outbox checkpoint", map[string]interface{}{
+			"consumer": consumer,
+			"sequence": sequence,
+			"error":    err.Error(),
+		})
+		return fmt.Errorf("failed to save outbox checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteProcessed removes events up to the given sequence that were recorded before olderThan
+func (r *eventOutboxRepository) DeleteProcessed(ctx context.Context, upToSequence, olderThan int64) (int64, error) {
+	query := `DELETE FROM "zpEventOutbox" WHERE sequence <= $1 AND "createdAt" < $2`
+
+	result, err := r.db.ExecContext(ctx, query, upToSequence, time.Unix(olderThan, 0))
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to delete processed outbox events", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return 0, fmt.Errorf("failed to delete processed outbox events: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// ClaimDedupKey records the dedup key of an inbound event until expiresAt, joining the transaction
+// carried by ctx if there is one. An expired key is taken over by the new event.
+func (r *eventOutboxRepository) ClaimDedupKey(ctx context.Context, sessionID, key, eventID string, expiresAt int64) (bool, error) {
+	query := `
+		INSERT INTO "zpEventDedup" ("sessionId", "dedupKey", "eventId", "expiresAt")
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("sessionId", "dedupKey") DO UPDATE
+		SET "eventId" = EXCLUDED."eventId", "expiresAt" = EXCLUDED."expiresAt"
+		WHERE "zpEventDedup"."expiresAt" <= NOW()
+	`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, sessionID, key, eventID, time.Unix(expiresAt, 0))
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to claim event dedup key", map[string]interface{}{
+			"session_id": sessionID,
+			"event_id":   eventID,
+			"error":      err.Error(),
+		})
+		return false, fmt.Errorf("failed to claim event dedup key: %w", err)
+	}
+
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return claimed > 0, nil
+}
+
+// DeleteExpiredDedupKeys removes dedup keys that expired
+func (r *eventOutboxRepository) DeleteExpiredDedupKeys(ctx context.Context) (int64, error) {
+	query := `DELETE FROM "zpEventDedup" WHERE "expiresAt" <= NOW()`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to delete expired event dedup keys", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return 0, fmt.Errorf("failed to delete expired event dedup keys: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// fromModel converts a database model to an outbox event
+func (r *eventOutboxRepository) fromModel(model *outboxEventModel) *ports.OutboxEvent {
+	return &ports.OutboxEvent{
+		Sequence:  model.Sequence,
+		EventID:   model.EventID,
+		SessionID: model.SessionID.String,
+		EventType: model.EventType,
+		Payload:   model.Payload,
+		CreatedAt: model.CreatedAt.Unix(),
+	}
+}
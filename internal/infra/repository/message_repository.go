@@ -0,0 +1,577 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"zpwoot/internal/domain/message"
+	"zpwoot/internal/domain/webhook"
+	"zpwoot/internal/ports"
+	"zpwoot/platform/logger"
+)
+
+// messageRepository implements the MessageRepository interface
+type messageRepository struct {
+	db     *sqlx.DB
+	logger *logger.Logger
+}
+
+// NewMessageRepository creates a new message repository
+func NewMessageRepository(db *sqlx.DB, logger *logger.Logger) ports.MessageRepository {
+	return &messageRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// messageModel represents the database model for messages
+type messageModel struct {
+	ID              string         `db:"id"`
+	SessionID       string         `db:"sessionId"`
+	MessageID       string         `db:"messageId"`
+	ChatJID         string         `db:"chatJid"`
+	SenderJID       string         `db:"senderJid"`
+	FromMe          bool           `db:"fromMe"`
+	IsGroup         bool           `db:"isGroup"`
+	PushName        sql.NullString `db:"pushName"`
+	MessageType     string         `db:"messageType"`
+	Text            sql.NullString `db:"text"`
+	Caption         sql.NullString `db:"caption"`
+	MimeType        sql.NullString `db:"mimeType"`
+	FileName        sql.NullString `db:"fileName"`
+	FileSize        sql.NullInt64  `db:"fileSize"`
+	MediaURL        sql.NullString `db:"mediaUrl"`
+	DirectPath      sql.NullString `db:"directPath"`
+	MediaKey        sql.NullString `db:"mediaKey"`
+	FileSHA256      sql.NullString `db:"fileSha256"`
+	FileEncSHA256   sql.NullString `db:"fileEncSha256"`
+	QuotedMessageID sql.NullString `db:"quotedMessageId"`
+	Timestamp       time.Time      `db:"timestamp"`
+	Raw             sql.NullString `db:"raw"`
+	ReadAt          sql.NullTime   `db:"readAt"`
+	MediaStoreKey   sql.NullString `db:"mediaStoreKey"`
+	CreatedAt       time.Time      `db:"createdAt"`
+	UpdatedAt       time.Time      `db:"updatedAt"`
+}
+
+// Save stores a message, joining the transaction carried by ctx if there is one.
+// Saving a message already stored updates its content, which changes when WhatsApp
+// redelivers it with more details, and keeps its ID and creation time.
+func (r *messageRepository) Save(ctx context.Context, msg *ports.StoredMessage) error {
+	if msg.ID == "" {
+		msg.ID = uuid.New().String()
+	}
+
+	now := time.Now().Unix()
+	if msg.CreatedAt == 0 {
+		msg.CreatedAt = now
+	}
+	msg.UpdatedAt = now
+	if msg.Timestamp == 0 {
+		msg.Timestamp = now
+	}
+
+	model := r.toModel(msg)
+
+	query := `
+		INSERT INTO "zpMessages" (
+			id, "sessionId", "messageId", "chatJid", "senderJid", "fromMe", "isGroup", "pushName",
+			"messageType", text, caption, "mimeType", "fileName", "fileSize", "mediaUrl", "directPath",
+			"mediaKey", "fileSha256", "fileEncSha256", "quotedMessageId", "timestamp", raw, "mediaStoreKey", "createdAt", "updatedAt"
+		) VALUES (
+			:id, :sessionId, :messageId, :chatJid, :senderJid, :fromMe, :isGroup, :pushName,
+			:messageType, :text, :caption, :mimeType, :fileName, :fileSize, :mediaUrl, :directPath,
+			:mediaKey, :fileSha256, :fileEncSha256, :quotedMessageId, :timestamp, :raw, :mediaStoreKey, :createdAt, :updatedAt
+		)
+		ON CONFLICT ("sessionId", "chatJid", "messageId") DO UPDATE SET
+			"senderJid" = EXCLUDED."senderJid",
+			"pushName" = COALESCE(EXCLUDED."pushName", "zpMessages"."pushName"),
+			"messageType" = EXCLUDED."messageType",
+			text = EXCLUDED.text,
+			caption = EXCLUDED.caption,
+			"mimeType" = EXCLUDED."mimeType",
+			"fileName" = EXCLUDED."fileName",
+			"fileSize" = EXCLUDED."fileSize",
+			"mediaUrl" = EXCLUDED."mediaUrl",
+			"directPath" = EXCLUDED."directPath",
+			"mediaKey" = EXCLUDED."mediaKey",
+			"fileSha256" = EXCLUDED."fileSha256",
+			"fileEncSha256" = EXCLUDED."fileEncSha256",
+			"quotedMessageId" = EXCLUDED."quotedMessageId",
+			raw = COALESCE(EXCLUDED.raw, "zpMessages".raw),
+			"mediaStoreKey" = COALESCE(EXCLUDED."mediaStoreKey", "zpMessages"."mediaStoreKey"),
+			"updatedAt" = EXCLUDED."updatedAt"
+	`
+
+	_, err := sqlx.NamedExecContext(ctx, executor(ctx, r.db), query, model)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to save message", map[string]interface{}{
+			"session_id": msg.SessionID,
+			"chat_jid":   msg.ChatJID,
+			"message_id": msg.MessageID,
+			"error":      err.Error(),
+		})
+		return fmt.Errorf("failed to save message: %w", err)
+	}
+
+	return nil
+}
+
+// nonContentMessageTypes are message types that neither make a chat unread nor show as its latest
+// message, as they change or react to earlier messages instead of saying something new
+var nonContentMessageTypes = []string{
+	webhook.MessageTypeReaction,
+	webhook.MessageTypeEdit,
+	webhook.MessageTypeRevoke,
+	webhook.MessageTypeProtocol,
+	webhook.MessageTypePollVote,
+}
+
+// GetByMessageID retrieves a message of a session by its WhatsApp ID. Message IDs are
+// generated by the sending device, so on the rare clash the latest message wins.
+func (r *messageRepository) GetByMessageID(ctx context.Context, sessionID, messageID string) (*ports.StoredMessage, error) {
+	query := `
+		SELECT * FROM "zpMessages"
+		WHERE "sessionId" = $1 AND "messageId" = $2
+		ORDER BY "timestamp" DESC
+		LIMIT 1
+	`
+
+	var model messageModel
+	err := r.db.GetContext(ctx, &model, query, sessionID, messageID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, message.ErrMessageNotFound
+		}
+		r.logger.ErrorWithFields("Failed to get message", map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": messageID,
+			"error":      err.Error(),
+		})
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	return r.fromModel(&model), nil
+}
+
+// ListMessages retrieves the messages of a chat matching the filter, newest first unless Ascending is set
+func (r *messageRepository) ListMessages(ctx context.Context, filter *ports.MessageFilter) ([]*ports.StoredMessage, error) {
+	whereClause := `WHERE "sessionId" = $1 AND "chatJid" = $2`
+	args := []interface{}{filter.SessionID, filter.ChatJID}
+	argIndex := 3
+
+	if len(filter.Types) > 0 {
+		var placeholders []string
+		placeholders, argIndex = r.placeholders(argIndex, len(filter.Types))
+		whereClause += fmt.Sprintf(` AND "messageType" IN (%s)`, strings.Join(placeholders, ", "))
+		for _, messageType := range filter.Types {
+			args = append(args, messageType)
+		}
+	}
+
+	if filter.Before > 0 {
+		whereClause += fmt.Sprintf(` AND "timestamp" < $%d`, argIndex)
+		args = append(args, time.Unix(filter.Before, 0))
+		argIndex++
+	}
+
+	if filter.After > 0 {
+		whereClause += fmt.Sprintf(` AND "timestamp" > $%d`, argIndex)
+		args = append(args, time.Unix(filter.After, 0))
+		argIndex++
+	}
+
+	direction, comparison := "DESC", "<"
+	if filter.Ascending {
+		direction, comparison = "ASC", ">"
+	}
+
+	// Messages sent in the same second are ordered by record ID, so the cursor is unambiguous
+	if filter.Cursor != nil {
+		whereClause += fmt.Sprintf(` AND ("timestamp", id) %s ($%d, $%d)`, comparison, argIndex, argIndex+1)
+		args = append(args, time.Unix(filter.Cursor.Timestamp, 0), filter.Cursor.Key)
+		argIndex += 2
+	}
+
+	query := fmt.Sprintf(`
+		SELECT * FROM "zpMessages" %s
+		ORDER BY "timestamp" %s, id %s
+		LIMIT $%d
+	`, whereClause, direction, direction, argIndex)
+
+	args = append(args, filter.Limit)
+
+	var models []messageModel
+	err := r.db.SelectContext(ctx, &models, query, args...)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to list messages", map[string]interface{}{
+			"session_id": filter.SessionID,
+			"chat_jid":   filter.ChatJID,
+			"error":      err.Error(),
+		})
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+
+	messages := make([]*ports.StoredMessage, len(models))
+	for i := range models {
+		messages[i] = r.fromModel(&models[i])
+	}
+	return messages, nil
+}
+
+// chatSummaryModel represents the latest message of a chat with its unread count
+type chatSummaryModel struct {
+	messageModel
+	UnreadCount int `db:"unreadCount"`
+}
+
+// ListChats retrieves the chats of a session with their latest message and unread count, most recently active first.
+// Reactions, edits and other messages that only change earlier ones are not counted as the latest message.
+func (r *messageRepository) ListChats(ctx context.Context, filter *ports.ChatFilter) ([]*ports.ChatSummary, error) {
+	placeholders, argIndex := r.placeholders(2, len(nonContentMessageTypes))
+	excluded := strings.Join(placeholders, ", ")
+
+	args := []interface{}{filter.SessionID}
+	for _, messageType := range nonContentMessageTypes {
+		args = append(args, messageType)
+	}
+
+	cursorClause := ""
+	if filter.Cursor != nil {
+		cursorClause = fmt.Sprintf(`WHERE (last."timestamp", last."chatJid") < ($%d, $%d)`, argIndex, argIndex+1)
+		args = append(args, time.Unix(filter.Cursor.Timestamp, 0), filter.Cursor.Key)
+		argIndex += 2
+	}
+
+	query := fmt.Sprintf(`
+		SELECT last.*, (
+			SELECT COUNT(*) FROM "zpMessages" unread
+			WHERE unread."sessionId" = last."sessionId" AND unread."chatJid" = last."chatJid"
+			  AND unread."fromMe" = false AND unread."readAt" IS NULL
+			  AND unread."messageType" NOT IN (%s)
+		) AS "unreadCount"
+		FROM (
+			SELECT DISTINCT ON ("chatJid") * FROM "zpMessages"
+			WHERE "sessionId" = $1 AND "messageType" NOT IN (%s)
+			ORDER BY "chatJid", "timestamp" DESC, id DESC
+		) last
+		%s
+		ORDER BY last."timestamp" DESC, last."chatJid" DESC
+		LIMIT $%d
+	`, excluded, excluded, cursorClause, argIndex)
+
+	args = append(args, filter.Limit)
+
+	var models []chatSummaryModel
+	err := r.db.SelectContext(ctx, &models, query, args...)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to list chats", map[string]interface{}{
+			"session_id": filter.SessionID,
+			"error":      err.Error(),
+		})
+		return nil, fmt.Errorf("failed to list chats: %w", err)
+	}
+
+	chats := make([]*ports.ChatSummary, len(models))
+	for i := range models {
+		last := r.fromModel(&models[i].messageModel)
+		chats[i] = &ports.ChatSummary{
+			ChatJID:     last.ChatJID,
+			IsGroup:     last.IsGroup,
+			LastMessage: last,
+			UnreadCount: models[i].UnreadCount,
+		}
+	}
+	return chats, nil
+}
+
+// messageSearchDocument is the searchable content of a message. It must stay identical to the
+// expression of the full-text indexes created by migration 013, or searches will not use them.
+const messageSearchDocument = `coalesce(text, '') || ' ' || coalesce(caption, '') || ' ' || coalesce(translate("fileName", '._-', '   '), '')`
+
+// messageSearchHeadline configures the snippets of search results
+var messageSearchHeadline = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxFragments=2, MaxWords=20, MinWords=8",
+	ports.SearchHighlightStart, ports.SearchHighlightStop)
+
+// messageSearchModel represents a message matching a search with its snippet and rank
+type messageSearchModel struct {
+	messageModel
+	Snippet string  `db:"snippet"`
+	Rank    float64 `db:"rank"`
+}
+
+// Search retrieves the messages matching a full-text query, most relevant first and newest first among equals.
+// The query is matched with both the Portuguese and English configurations, and the snippet is
+// highlighted with the configuration that matched.
+func (r *messageRepository) Search(ctx context.Context, search *ports.MessageSearch) ([]*ports.MessageSearchResult, error) {
+	ptVector := fmt.Sprintf(`to_tsvector('portuguese', %s)`, messageSearchDocument)
+	enVector := fmt.Sprintf(`to_tsvector('english', %s)`, messageSearchDocument)
+	ptQuery := `websearch_to_tsquery('portuguese', $1)`
+	enQuery := `websearch_to_tsquery('english', $1)`
+
+	whereClause := fmt.Sprintf(`WHERE (%s @@ %s OR %s @@ %s)`, ptVector, ptQuery, enVector, enQuery)
+	args := []interface{}{search.Query, messageSearchHeadline}
+	argIndex := 3
+
+	if search.SessionID != "" {
+		whereClause += fmt.Sprintf(` AND "sessionId" = $%d`, argIndex)
+		args = append(args, search.SessionID)
+		argIndex++
+	}
+
+	if search.ChatJID != "" {
+		whereClause += fmt.Sprintf(` AND "chatJid" = $%d`, argIndex)
+		args = append(args, search.ChatJID)
+		argIndex++
+	}
+
+	if search.SenderJID != "" {
+		whereClause += fmt.Sprintf(` AND "senderJid" = $%d`, argIndex)
+		args = append(args, search.SenderJID)
+		argIndex++
+	}
+
+	if len(search.Types) > 0 {
+		var placeholders []string
+		placeholders, argIndex = r.placeholders(argIndex, len(search.Types))
+		whereClause += fmt.Sprintf(` AND "messageType" IN (%s)`, strings.Join(placeholders, ", "))
+		for _, messageType := range search.Types {
+			args = append(args, messageType)
+		}
+	}
+
+	if search.Before > 0 {
+		whereClause += fmt.Sprintf(` AND "timestamp" < $%d`, argIndex)
+		args = append(args, time.Unix(search.Before, 0))
+		argIndex++
+	}
+
+	if search.After > 0 {
+		whereClause += fmt.Sprintf(` AND "timestamp" > $%d`, argIndex)
+		args = append(args, time.Unix(search.After, 0))
+		argIndex++
+	}
+
+	query := fmt.Sprintf(`
+		SELECT *,
+			CASE WHEN %[1]s @@ %[3]s
+				THEN ts_headline('portuguese', %[5]s, %[3]s, $2)
+				ELSE ts_headline('english', %[5]s, %[4]s, $2)
+			END AS snippet,
+			GREATEST(ts_rank(%[1]s, %[3]s), ts_rank(%[2]s, %[4]s)) AS rank
+		FROM "zpMessages"
+		%[6]s
+		ORDER BY rank DESC, "timestamp" DESC, id DESC
+		LIMIT $%[7]d OFFSET $%[8]d
+	`, ptVector, enVector, ptQuery, enQuery, messageSearchDocument, whereClause, argIndex, argIndex+1)
+
+	args = append(args, search.Limit, search.Offset)
+
+	var models []messageSearchModel
+	err := r.db.SelectContext(ctx, &models, query, args...)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to search messages", map[string]interface{}{
+			"session_id": search.SessionID,
+			"error":      err.Error(),
+		})
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	results := make([]*ports.MessageSearchResult, len(models))
+	for i := range models {
+		results[i] = &ports.MessageSearchResult{
+			Message: r.fromModel(&models[i].messageModel),
+			Snippet: models[i].Snippet,
+			Rank:    models[i].Rank,
+		}
+	}
+	return results, nil
+}
+
+// SetMediaStoreKey records the media store key the media of a message is kept under
+func (r *messageRepository) SetMediaStoreKey(ctx context.Context, id, key string) error {
+	query := `UPDATE "zpMessages" SET "mediaStoreKey" = $2 WHERE id = $1`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id, toNullString(key))
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to set message media store key", map[string]interface{}{
+			"id":    id,
+			"error": err.Error(),
+		})
+		return fmt.Errorf("failed to set message media store key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return message.ErrMessageNotFound
+	}
+
+	return nil
+}
+
+// ClearSentMediaStoreKeys forgets the media store keys of sent messages whose media was removed
+func (r *messageRepository) ClearSentMediaStoreKeys(ctx context.Context, keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	query := `UPDATE "zpMessages" SET "mediaStoreKey" = NULL WHERE "fromMe" = true AND "mediaStoreKey" = ANY($1)`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, pq.Array(keys))
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to clear media store keys of sent messages", map[string]interface{}{
+			"keys":  len(keys),
+			"error": err.Error(),
+		})
+		return 0, fmt.Errorf("failed to clear media store keys of sent messages: %w", err)
+	}
+
+	cleared, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return cleared, nil
+}
+
+// MarkMessagesRead marks received messages of a chat as read at the given time,
+// joining the transaction carried by ctx if there is one
+func (r *messageRepository) MarkMessagesRead(ctx context.Context, sessionID, chatJID string, messageIDs []string, readAt int64) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+
+	placeholders, argIndex := r.placeholders(3, len(messageIDs))
+	query := fmt.Sprintf(`
+		UPDATE "zpMessages" SET "readAt" = $%d
+		WHERE "sessionId" = $1 AND "chatJid" = $2 AND "messageId" IN (%s)
+		  AND "fromMe" = false AND "readAt" IS NULL
+	`, argIndex, strings.Join(placeholders, ", "))
+
+	args := []interface{}{sessionID, chatJID}
+	for _, messageID := range messageIDs {
+		args = append(args, messageID)
+	}
+	args = append(args, time.Unix(readAt, 0))
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		r.logger.ErrorWithFields("Failed to mark messages as read", map[string]interface{}{
+			"session_id": sessionID,
+			"chat_jid":   chatJID,
+			"error":      err.Error(),
+		})
+		return fmt.Errorf("failed to mark messages as read: %w", err)
+	}
+
+	return nil
+}
+
+// MarkChatRead marks the received messages of a chat sent up to the given time as read
+func (r *messageRepository) MarkChatRead(ctx context.Context, sessionID, chatJID string, upTo int64) error {
+	query := `
+		UPDATE "zpMessages" SET "readAt" = $3
+		WHERE "sessionId" = $1 AND "chatJid" = $2 AND "timestamp" <= $3
+		  AND "fromMe" = false AND "readAt" IS NULL
+	`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, sessionID, chatJID, time.Unix(upTo, 0)); err != nil {
+		r.logger.ErrorWithFields("Failed to mark chat as read", map[string]interface{}{
+			"session_id": sessionID,
+			"chat_jid":   chatJID,
+			"error":      err.Error(),
+		})
+		return fmt.Errorf("failed to mark chat as read: %w", err)
+	}
+
+	return nil
+}
+
+// placeholders returns n positional parameters starting at $start, and the index after them
+func (r *messageRepository) placeholders(start, n int) ([]string, int) {
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", start+i)
+	}
+	return placeholders, start + n
+}
+
+// toModel converts a stored message to its database model
+func (r *messageRepository) toModel(msg *ports.StoredMessage) *messageModel {
+	return &messageModel{
+		ID:              msg.ID,
+		SessionID:       msg.SessionID,
+		MessageID:       msg.MessageID,
+		ChatJID:         msg.ChatJID,
+		SenderJID:       msg.SenderJID,
+		FromMe:          msg.FromMe,
+		IsGroup:         msg.IsGroup,
+		PushName:        toNullString(msg.PushName),
+		MessageType:     msg.MessageType,
+		Text:            toNullString(msg.Text),
+		Caption:         toNullString(msg.Caption),
+		MimeType:        toNullString(msg.MimeType),
+		FileName:        toNullString(msg.FileName),
+		FileSize:        sql.NullInt64{Int64: msg.FileSize, Valid: msg.FileSize > 0},
+		MediaURL:        toNullString(msg.MediaURL),
+		DirectPath:      toNullString(msg.DirectPath),
+		MediaKey:        toNullString(msg.MediaKey),
+		FileSHA256:      toNullString(msg.FileSHA256),
+		FileEncSHA256:   toNullString(msg.FileEncSHA256),
+		QuotedMessageID: toNullString(msg.QuotedMessageID),
+		Timestamp:       time.Unix(msg.Timestamp, 0),
+		Raw:             toNullString(msg.Raw),
+		MediaStoreKey:   toNullString(msg.MediaStoreKey),
+		CreatedAt:       time.Unix(msg.CreatedAt, 0),
+		UpdatedAt:       time.Unix(msg.UpdatedAt, 0),
+	}
+}
+
+// unixOrZero converts an optional time to a unix timestamp, 0 when unset
+func unixOrZero(t sql.NullTime) int64 {
+	if !t.Valid {
+		return 0
+	}
+	return t.Time.Unix()
+}
+
+// fromModel converts a database model to a stored message
+func (r *messageRepository) fromModel(model *messageModel) *ports.StoredMessage {
+	return &ports.StoredMessage{
+		ID:              model.ID,
+		SessionID:       model.SessionID,
+		MessageID:       model.MessageID,
+		ChatJID:         model.ChatJID,
+		SenderJID:       model.SenderJID,
+		FromMe:          model.FromMe,
+		IsGroup:         model.IsGroup,
+		PushName:        model.PushName.String,
+		MessageType:     model.MessageType,
+		Text:            model.Text.String,
+		Caption:         model.Caption.String,
+		MimeType:        model.MimeType.String,
+		FileName:        model.FileName.String,
+		FileSize:        model.FileSize.Int64,
+		MediaURL:        model.MediaURL.String,
+		DirectPath:      model.DirectPath.String,
+		MediaKey:        model.MediaKey.String,
+		FileSHA256:      model.FileSHA256.String,
+		FileEncSHA256:   model.FileEncSHA256.String,
+		QuotedMessageID: model.QuotedMessageID.String,
+		Timestamp:       model.Timestamp.Unix(),
+		Raw:             model.Raw.String,
+		ReadAt:          unixOrZero(model.ReadAt),
+		MediaStoreKey:   model.MediaStoreKey.String,
+		CreatedAt:       model.CreatedAt.Unix(),
+		UpdatedAt:       model.UpdatedAt.Unix(),
+	}
+}
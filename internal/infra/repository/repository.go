@@ -5,21 +5,30 @@ import (
 
 	"zpwoot/internal/ports"
 	"zpwoot/platform/logger"
+	"zpwoot/platform/secrets"
 )
 
 // Repositories holds all repository implementations
 type Repositories struct {
-	Session  ports.SessionRepository
-	Webhook  ports.WebhookRepository
-	Chatwoot ports.ChatwootRepository
+	Session         ports.SessionRepository
+	Webhook         ports.WebhookRepository
+	WebhookDelivery ports.WebhookDeliveryRepository
+	Chatwoot        ports.ChatwootRepository
+	EventOutbox     ports.EventOutboxRepository
+	Message         ports.MessageRepository
+	Transactor      ports.Transactor
 }
 
-// NewRepositories creates all repository implementations
-func NewRepositories(db *sqlx.DB, logger *logger.Logger) *Repositories {
+// NewRepositories creates all repository implementations. The cipher encrypts the webhook credentials.
+func NewRepositories(db *sqlx.DB, cipher *secrets.Cipher, logger *logger.Logger) *Repositories {
 	return &Repositories{
-		Session:  NewSessionRepository(db, logger),
-		Webhook:  NewWebhookRepository(db, logger),
-		Chatwoot: NewChatwootRepository(db, logger),
+		Session:         NewSessionRepository(db, logger),
+		Webhook:         NewWebhookRepository(db, cipher, logger),
+		WebhookDelivery: NewWebhookDeliveryRepository(db, logger),
+		Chatwoot:        NewChatwootRepository(db, logger),
+		EventOutbox:     NewEventOutboxRepository(db, logger),
+		Message:         NewMessageRepository(db, logger),
+		Transactor:      NewTransactor(db, logger),
 	}
 }
 
@@ -33,7 +42,27 @@ func (r *Repositories) GetWebhookRepository() ports.WebhookRepository {
 	return r.Webhook
 }
 
+// GetWebhookDeliveryRepository returns the webhook delivery repository
+func (r *Repositories) GetWebhookDeliveryRepository() ports.WebhookDeliveryRepository {
+	return r.WebhookDelivery
+}
+
 // GetChatwootRepository returns the chatwoot repository
 func (r *Repositories) GetChatwootRepository() ports.ChatwootRepository {
 	return r.Chatwoot
 }
+
+// GetEventOutboxRepository returns the event outbox repository
+func (r *Repositories) GetEventOutboxRepository() ports.EventOutboxRepository {
+	return r.EventOutbox
+}
+
+// GetMessageRepository returns the message repository
+func (r *Repositories) GetMessageRepository() ports.MessageRepository {
+	return r.Message
+}
+
+// GetTransactor returns the transactor shared by the repositories
+func (r *Repositories) GetTransactor() ports.Transactor {
+	return r.Transactor
+}
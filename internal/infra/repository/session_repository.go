@@ -33,18 +33,19 @@ func NewSessionRepository(db *sqlx.DB, logger *logger.Logger) ports.SessionRepos
 
 // sessionModel represents the database model for sessions
 type sessionModel struct {
-	ID               string         `db:"id"`
-	Name             string         `db:"name"`
-	DeviceJid        sql.NullString `db:"deviceJid"`
-	IsConnected      bool           `db:"isConnected"`
-	ConnectionError  sql.NullString `db:"connectionError"`
-	QRCode           sql.NullString `db:"qrCode"`
-	QRCodeExpiresAt  sql.NullTime   `db:"qrCodeExpiresAt"`
-	ProxyConfig      sql.NullString `db:"proxyConfig"` // JSON
-	CreatedAt        time.Time      `db:"createdAt"`
-	UpdatedAt        time.Time      `db:"updatedAt"`
-	ConnectedAt      sql.NullTime   `db:"connectedAt"`
-	LastSeen         sql.NullTime   `db:"lastSeen"`
+	ID                string         `db:"id"`
+	Name              string         `db:"name"`
+	DeviceJid         sql.NullString `db:"deviceJid"`
+	IsConnected       bool           `db:"isConnected"`
+	ConnectionError   sql.NullString `db:"connectionError"`
+	QRCode            sql.NullString `db:"qrCode"`
+	QRCodeExpiresAt   sql.NullTime   `db:"qrCodeExpiresAt"`
+	ProxyConfig       sql.NullString `db:"proxyConfig"` // JSON
+	MediaAutoDownload sql.NullBool   `db:"mediaAutoDownload"`
+	CreatedAt         time.Time      `db:"createdAt"`
+	UpdatedAt         time.Time      `db:"updatedAt"`
+	ConnectedAt       sql.NullTime   `db:"connectedAt"`
+	LastSeen          sql.NullTime   `db:"lastSeen"`
 }
 
 // Create creates a new session
@@ -57,8 +58,8 @@ func (r *sessionRepository) Create(ctx context.Context, sess *session.Session) e
 	model := r.toModel(sess)
 
 	query := `
-		INSERT INTO "zpSessions" (id, name, "deviceJid", "isConnected", "connectionError", "qrCode", "qrCodeExpiresAt", "proxyConfig", "createdAt", "updatedAt", "connectedAt", "lastSeen")
-		VALUES (:id, :name, :deviceJid, :isConnected, :connectionError, :qrCode, :qrCodeExpiresAt, :proxyConfig, :createdAt, :updatedAt, :connectedAt, :lastSeen)
+		INSERT INTO "zpSessions" (id, name, "deviceJid", "isConnected", "connectionError", "qrCode", "qrCodeExpiresAt", "proxyConfig", "mediaAutoDownload", "createdAt", "updatedAt", "connectedAt", "lastSeen")
+		VALUES (:id, :name, :deviceJid, :isConnected, :connectionError, :qrCode, :qrCodeExpiresAt, :proxyConfig, :mediaAutoDownload, :createdAt, :updatedAt, :connectedAt, :lastSeen)
 	`
 
 	_, err := r.db.NamedExecContext(ctx, query, model)
@@ -261,7 +262,7 @@ func (r *sessionRepository) Update(ctx context.Context, sess *session.Session) e
 		WHERE id = :id
 	`
 
-	result, err := r.db.NamedExecContext(ctx, query, model)
+	result, err := sqlx.NamedExecContext(ctx, executor(ctx, r.db), query, model)
 	if err != nil {
 		r.logger.ErrorWithFields("Failed to update session", map[string]interface{}{
 			"session_id": sess.ID.String(),
@@ -311,7 +312,8 @@ func (r *sessionRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-// UpdateConnectionStatus updates only the connection status of a session
+// UpdateConnectionStatus updates only the connection status of a session.
+// Connecting also stamps connectedAt and lastSeen and clears the connection error and QR code.
 func (r *sessionRepository) UpdateConnectionStatus(ctx context.Context, id string, isConnected bool) error {
 	r.logger.InfoWithFields("Updating session connection status", map[string]interface{}{
 		"session_id":   id,
@@ -319,8 +321,16 @@ func (r *sessionRepository) UpdateConnectionStatus(ctx context.Context, id strin
 	})
 
 	query := `UPDATE "zpSessions" SET "isConnected" = $1, "updatedAt" = $2 WHERE id = $3`
+	if isConnected {
+		query = `
+			UPDATE "zpSessions"
+			SET "isConnected" = $1, "updatedAt" = $2, "connectedAt" = $2, "lastSeen" = $2,
+			    "connectionError" = NULL, "qrCode" = NULL, "qrCodeExpiresAt" = NULL
+			WHERE id = $3
+		`
+	}
 
-	result, err := r.db.ExecContext(ctx, query, isConnected, time.Now(), id)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, isConnected, time.Now(), id)
 	if err != nil {
 		r.logger.ErrorWithFields("Failed to update session connection status", map[string]interface{}{
 			"session_id": id,
@@ -350,7 +360,7 @@ func (r *sessionRepository) UpdateLastSeen(ctx context.Context, id string) error
 	query := `UPDATE "zpSessions" SET "lastSeen" = $1, "updatedAt" = $2 WHERE id = $3`
 
 	now := time.Now()
-	result, err := r.db.ExecContext(ctx, query, now, now, id)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, now, now, id)
 	if err != nil {
 		return fmt.Errorf("failed to update last seen: %w", err)
 	}
@@ -367,6 +377,102 @@ func (r *sessionRepository) UpdateLastSeen(ctx context.Context, id string) error
 	return nil
 }
 
+// UpdateDeviceJid updates only the device JID of a session
+func (r *sessionRepository) UpdateDeviceJid(ctx context.Context, id, deviceJid string) error {
+	query := `UPDATE "zpSessions" SET "deviceJid" = $1, "updatedAt" = $2 WHERE id = $3`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, toNullString(deviceJid), time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update device JID: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return session.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// UpdateQRCode updates only the QR code of a session and when it expires.
+// An empty QR code clears both.
+func (r *sessionRepository) UpdateQRCode(ctx context.Context, id, qrCode string, expiresAt *time.Time) error {
+	query := `UPDATE "zpSessions" SET "qrCode" = $1, "qrCodeExpiresAt" = $2, "updatedAt" = $3 WHERE id = $4`
+
+	var expires sql.NullTime
+	if qrCode != "" && expiresAt != nil {
+		expires = sql.NullTime{Time: *expiresAt, Valid: true}
+	}
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, toNullString(qrCode), expires, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update QR code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return session.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// SetConnectionError marks a session as disconnected and records why
+func (r *sessionRepository) SetConnectionError(ctx context.Context, id, errorMsg string) error {
+	query := `UPDATE "zpSessions" SET "isConnected" = false, "connectionError" = $1, "updatedAt" = $2 WHERE id = $3`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, errorMsg, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to set connection error: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return session.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// UpdateMediaAutoDownload updates only the automatic media download setting of a session.
+// Nil clears it, so the session follows the server setting.
+func (r *sessionRepository) UpdateMediaAutoDownload(ctx context.Context, id string, enabled *bool) error {
+	query := `UPDATE "zpSessions" SET "mediaAutoDownload" = $1, "updatedAt" = $2 WHERE id = $3`
+
+	var setting sql.NullBool
+	if enabled != nil {
+		setting = sql.NullBool{Bool: *enabled, Valid: true}
+	}
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, setting, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update media auto download: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return session.ErrSessionNotFound
+	}
+
+	return nil
+}
+
 // GetActiveSessions retrieves all active sessions
 func (r *sessionRepository) GetActiveSessions(ctx context.Context) ([]*session.Session, error) {
 	r.logger.Info("Getting active sessions")
@@ -434,6 +540,10 @@ func (r *sessionRepository) toModel(sess *session.Session) *sessionModel {
 		}
 	}
 
+	if sess.MediaAutoDownload != nil {
+		model.MediaAutoDownload = sql.NullBool{Bool: *sess.MediaAutoDownload, Valid: true}
+	}
+
 	if sess.ConnectionError != nil && *sess.ConnectionError != "" {
 		model.ConnectionError = sql.NullString{String: *sess.ConnectionError, Valid: true}
 	}
@@ -495,6 +605,10 @@ func (r *sessionRepository) fromModel(model *sessionModel) (*session.Session, er
 		}
 	}
 
+	if model.MediaAutoDownload.Valid {
+		sess.MediaAutoDownload = &model.MediaAutoDownload.Bool
+	}
+
 	if model.LastSeen.Valid {
 		sess.LastSeen = &model.LastSeen.Time
 	}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"zpwoot/internal/ports"
+	"zpwoot/platform/logger"
+)
+
+// txContextKey is the context key of the transaction started by WithTransaction
+type txContextKey struct{}
+
+// txState is the transaction carried by a context and the callbacks waiting for its commit
+type txState struct {
+	tx          *sqlx.Tx
+	afterCommit []func()
+}
+
+// transactor implements the Transactor interface
+type transactor struct {
+	db     *sqlx.DB
+	logger *logger.Logger
+}
+
+// NewTransactor creates a new transactor
+func NewTransactor(db *sqlx.DB, logger *logger.Logger) ports.Transactor {
+	return &transactor{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTransaction runs fn in a transaction. A call made inside another transaction joins it.
+func (t *transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txContextKey{}).(*txState); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rolls back when fn fails or panics, and is a no-op after a commit
+	defer tx.Rollback()
+
+	state := &txState{tx: tx}
+	if err := fn(context.WithValue(ctx, txContextKey{}, state)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.logger.ErrorWithFields("Failed to commit transaction", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, callback := range state.afterCommit {
+		callback()
+	}
+
+	return nil
+}
+
+// AfterCommit runs fn once the transaction carried by ctx commits, or right away outside a transaction.
+// Nothing runs when the transaction is rolled back.
+func (t *transactor) AfterCommit(ctx context.Context, fn func()) {
+	if state, ok := ctx.Value(txContextKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+// executor returns the transaction carried by ctx, or db when the call is not part of one
+func executor(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if state, ok := ctx.Value(txContextKey{}).(*txState); ok {
+		return state.tx
+	}
+	return db
+}
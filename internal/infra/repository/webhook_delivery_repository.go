@@ -0,0 +1,513 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"zpwoot/internal/domain/webhook"
+	"zpwoot/internal/ports"
+	"zpwoot/platform/logger"
+)
+
+// webhookDeliveryRepository implements the WebhookDeliveryRepository interface
+type webhookDeliveryRepository struct {
+	db     *sqlx.DB
+	logger *logger.Logger
+}
+
+// NewWebhookDeliveryRepository creates a new webhook delivery repository
+func NewWebhookDeliveryRepository(db *sqlx.DB, logger *logger.Logger) ports.WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// webhookDeliveryModel represents the database model for webhook deliveries
+type webhookDeliveryModel struct {
+	ID               string         `db:"id"`
+	WebhookID        string         `db:"webhookId"`
+	SessionID        sql.NullString `db:"sessionId"`
+	EventID          string         `db:"eventId"`
+	EventType        string         `db:"eventType"`
+	URL              string         `db:"url"`
+	Payload          string         `db:"payload"`
+	StatusCode       int            `db:"statusCode"`
+	ResponseBody     sql.NullString `db:"responseBody"`
+	Latency          int64          `db:"latency"`
+	Success          bool           `db:"success"`
+	Error            sql.NullString `db:"error"`
+	Attempts         int            `db:"attempts"`
+	NextRetryAt      sql.NullTime   `db:"nextRetryAt"`
+	Status           string         `db:"status"`
+	DeadLetterReason sql.NullString `db:"deadLetterReason"`
+	CreatedAt        time.Time      `db:"createdAt"`
+	UpdatedAt        time.Time      `db:"updatedAt"`
+}
+
+// Create creates a new webhook delivery record
+func (r *webhookDeliveryRepository) Create(ctx context.Context, delivery *ports.WebhookDelivery) error {
+	if delivery.ID == "" {
+		delivery.ID = uuid.New().String()
+	}
+	if delivery.Attempts == 0 {
+		delivery.Attempts = 1
+	}
+	if delivery.Status == "" {
+		delivery.Status = deliveryStatusFor(delivery)
+	}
+
+	now := time.Now().Unix()
+	if delivery.CreatedAt == 0 {
+		delivery.CreatedAt = now
+	}
+	delivery.UpdatedAt = now
+
+	model := r.toModel(delivery)
+
+	query := `
+		INSERT INTO "zpWebhookDeliveries" (
+			id, "webhookId", "sessionId", "eventId", "eventType", url, payload,
+			"statusCode", "responseBody", latency, success, error, attempts,
+			"nextRetryAt", status, "deadLetterReason", "createdAt", "updatedAt"
+		) VALUES (
+			:id, :webhookId, :sessionId, :eventId, :eventType, :url, :payload,
+			:statusCode, :responseBody, :latency, :success, :error, :attempts,
+			:nextRetryAt, :status, :deadLetterReason, :createdAt, :updatedAt
+		)
+	`
+
+	_, err := r.db.NamedExecContext(ctx, query, model)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to create webhook delivery", map[string]interface{}{
+			"delivery_id": delivery.ID,
+			"webhook_id":  delivery.WebhookID,
+			"event_id":    delivery.EventID,
+			"error":       err.Error(),
+		})
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a single delivery
+func (r *webhookDeliveryRepository) GetByID(ctx context.Context, deliveryID string) (*ports.WebhookDelivery, error) {
+	var model webhookDeliveryModel
+	query := `SELECT * FROM "zpWebhookDeliveries" WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &model, query, deliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, webhook.ErrDeliveryNotFound
+		}
+		r.logger.ErrorWithFields("Failed to get webhook delivery", map[string]interface{}{
+			"delivery_id": deliveryID,
+			"error":       err.Error(),
+		})
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return r.fromModel(&model), nil
+}
+
+// GetByWebhookID retrieves deliveries for a specific webhook
+func (r *webhookDeliveryRepository) GetByWebhookID(ctx context.Context, webhookID string, limit, offset int) ([]*ports.WebhookDelivery, error) {
+	query := `
+		SELECT * FROM "zpWebhookDeliveries"
+		WHERE "webhookId" = $1
+		ORDER BY "createdAt" DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	var models []webhookDeliveryModel
+	err := r.db.SelectContext(ctx, &models, query, webhookID, limit, offset)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to get webhook deliveries", map[string]interface{}{
+			"webhook_id": webhookID,
+			"error":      err.Error(),
+		})
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return r.fromModels(models), nil
+}
+
+// GetByEventID retrieves deliveries for a specific event
+func (r *webhookDeliveryRepository) GetByEventID(ctx context.Context, eventID string) ([]*ports.WebhookDelivery, error) {
+	query := `SELECT * FROM "zpWebhookDeliveries" WHERE "eventId" = $1 ORDER BY "createdAt" ASC`
+
+	var models []webhookDeliveryModel
+	err := r.db.SelectContext(ctx, &models, query, eventID)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to get webhook deliveries by event", map[string]interface{}{
+			"event_id": eventID,
+			"error":    err.Error(),
+		})
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return r.fromModels(models), nil
+}
+
+// GetFailedDeliveries retrieves failed deliveries whose next retry is due
+func (r *webhookDeliveryRepository) GetFailedDeliveries(ctx context.Context, limit int) ([]*ports.WebhookDelivery, error) {
+	query := `
+		SELECT * FROM "zpWebhookDeliveries"
+		WHERE status = 'retrying' AND "nextRetryAt" IS NOT NULL AND "nextRetryAt" <= $1
+		ORDER BY "nextRetryAt" ASC
+		LIMIT $2
+	`
+
+	var models []webhookDeliveryModel
+	err := r.db.SelectContext(ctx, &models, query, time.Now(), limit)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to get failed webhook deliveries", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, fmt.Errorf("failed to get failed webhook deliveries: %w", err)
+	}
+
+	return r.fromModels(models), nil
+}
+
+// UpdateDeliveryStatus records the outcome of a new attempt and increments the attempt count.
+// A successful attempt clears any scheduled retry; a failed one keeps it until ScheduleRetry runs.
+func (r *webhookDeliveryRepository) UpdateDeliveryStatus(ctx context.Context, deliveryID string, success bool, statusCode int, responseBody, errorMsg string) error {
+	query := `
+		UPDATE "zpWebhookDeliveries"
+		SET success = $1, "statusCode" = $2, "responseBody" = $3, error = $4,
+		    attempts = attempts + 1,
+		    "nextRetryAt" = CASE WHEN $1 THEN NULL ELSE "nextRetryAt" END,
+		    status = CASE WHEN $1 THEN 'delivered' ELSE status END,
+		    "deadLetterReason" = CASE WHEN $1 THEN NULL ELSE "deadLetterReason" END,
+		    "updatedAt" = $5
+		WHERE id = $6
+	`
+
+	result, err := r.db.ExecContext(ctx, query, success, statusCode, toNullString(responseBody), toNullString(errorMsg), time.Now(), deliveryID)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to update webhook delivery status", map[string]interface{}{
+			"delivery_id": deliveryID,
+			"error":       err.Error(),
+		})
+		return fmt.Errorf("failed to update webhook delivery status: %w", err)
+	}
+
+	return r.checkDeliveryAffected(result)
+}
+
+// ScheduleRetry sets when the delivery should be retried next, 0 clears the schedule
+func (r *webhookDeliveryRepository) ScheduleRetry(ctx context.Context, deliveryID string, nextRetryAt int64) error {
+	var next sql.NullTime
+	if nextRetryAt > 0 {
+		next = sql.NullTime{Time: time.Unix(nextRetryAt, 0), Valid: true}
+	}
+
+	query := `
+		UPDATE "zpWebhookDeliveries"
+		SET "nextRetryAt" = $1,
+		    status = CASE WHEN $1::timestamptz IS NULL THEN status ELSE 'retrying' END,
+		    "updatedAt" = $2
+		WHERE id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, next, time.Now(), deliveryID)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to schedule webhook delivery retry", map[string]interface{}{
+			"delivery_id": deliveryID,
+			"error":       err.Error(),
+		})
+		return fmt.Errorf("failed to schedule webhook delivery retry: %w", err)
+	}
+
+	return r.checkDeliveryAffected(result)
+}
+
+// MarkDeadLetter stops retrying a delivery and moves it to the dead-letter state
+func (r *webhookDeliveryRepository) MarkDeadLetter(ctx context.Context, deliveryID, reason string) error {
+	query := `
+		UPDATE "zpWebhookDeliveries"
+		SET status = 'dead_letter', "nextRetryAt" = NULL, "deadLetterReason" = $1, "updatedAt" = $2
+		WHERE id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, toNullString(reason), time.Now(), deliveryID)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to dead-letter webhook delivery", map[string]interface{}{
+			"delivery_id": deliveryID,
+			"error":       err.Error(),
+		})
+		return fmt.Errorf("failed to dead-letter webhook delivery: %w", err)
+	}
+
+	return r.checkDeliveryAffected(result)
+}
+
+// ListDeadLetters retrieves dead-lettered deliveries matching the filter
+func (r *webhookDeliveryRepository) ListDeadLetters(ctx context.Context, filter *ports.DeliveryFilter) ([]*ports.WebhookDelivery, int, error) {
+	whereClause, args := r.deadLetterWhere(filter)
+	argIndex := len(args) + 1
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM "zpWebhookDeliveries" %s`, whereClause)
+	var total int
+	err := r.db.GetContext(ctx, &total, countQuery, args...)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to count dead-lettered webhook deliveries", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, 0, fmt.Errorf("failed to count dead-lettered deliveries: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT * FROM "zpWebhookDeliveries" %s
+		ORDER BY "createdAt" DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, argIndex, argIndex+1)
+
+	args = append(args, filter.Limit, filter.Offset)
+
+	var models []webhookDeliveryModel
+	err = r.db.SelectContext(ctx, &models, query, args...)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to list dead-lettered webhook deliveries", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, 0, fmt.Errorf("failed to list dead-lettered deliveries: %w", err)
+	}
+
+	return r.fromModels(models), total, nil
+}
+
+// RequeueDeadLetters schedules dead-lettered deliveries matching the filter for an immediate retry
+func (r *webhookDeliveryRepository) RequeueDeadLetters(ctx context.Context, filter *ports.DeliveryFilter) (int, error) {
+	whereClause, args := r.deadLetterWhere(filter)
+	argIndex := len(args) + 1
+
+	query := fmt.Sprintf(`
+		UPDATE "zpWebhookDeliveries"
+		SET status = 'retrying', "nextRetryAt" = $%d, "deadLetterReason" = NULL, "updatedAt" = $%d
+		%s
+	`, argIndex, argIndex, whereClause)
+
+	args = append(args, time.Now())
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to requeue dead-lettered webhook deliveries", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return 0, fmt.Errorf("failed to requeue dead-lettered deliveries: %w", err)
+	}
+
+	requeued, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(requeued), nil
+}
+
+// DeleteOldDeliveries removes old delivery records
+func (r *webhookDeliveryRepository) DeleteOldDeliveries(ctx context.Context, olderThan int64) error {
+	query := `
+		DELETE FROM "zpWebhookDeliveries"
+		WHERE "createdAt" < $1 AND status IN ('delivered', 'dead_letter')
+	`
+
+	result, err := r.db.ExecContext(ctx, query, time.Unix(olderThan, 0))
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to delete old webhook deliveries", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return fmt.Errorf("failed to delete old webhook deliveries: %w", err)
+	}
+
+	if deleted, err := result.RowsAffected(); err == nil && deleted > 0 {
+		r.logger.InfoWithFields("Old webhook deliveries deleted", map[string]interface{}{
+			"deleted": deleted,
+		})
+	}
+
+	return nil
+}
+
+// GetDeliveryStats retrieves delivery statistics
+func (r *webhookDeliveryRepository) GetDeliveryStats(ctx context.Context, webhookID string, from, to int64) (*ports.DeliveryStats, error) {
+	query := `
+		SELECT
+			COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE success = true) AS success_count,
+			COALESCE(AVG(latency), 0) AS average_latency
+		FROM "zpWebhookDeliveries"
+		WHERE "webhookId" = $1 AND "createdAt" >= $2 AND "createdAt" <= $3
+	`
+
+	var row struct {
+		Total          int64   `db:"total"`
+		SuccessCount   int64   `db:"success_count"`
+		AverageLatency float64 `db:"average_latency"`
+	}
+
+	err := r.db.GetContext(ctx, &row, query, webhookID, time.Unix(from, 0), time.Unix(to, 0))
+	if err != nil {
+		r.logger.ErrorWithFields("Failed to get webhook delivery stats", map[string]interface{}{
+			"webhook_id": webhookID,
+			"error":      err.Error(),
+		})
+		return nil, fmt.Errorf("failed to get webhook delivery stats: %w", err)
+	}
+
+	stats := &ports.DeliveryStats{
+		WebhookID:       webhookID,
+		TotalDeliveries: row.Total,
+		SuccessCount:    row.SuccessCount,
+		FailureCount:    row.Total - row.SuccessCount,
+		AverageLatency:  row.AverageLatency,
+		From:            from,
+		To:              to,
+	}
+
+	if row.Total > 0 {
+		stats.SuccessRate = float64(row.SuccessCount) / float64(row.Total) * 100
+	}
+
+	return stats, nil
+}
+
+// Helper methods
+
+// deadLetterWhere builds the WHERE clause selecting dead-lettered deliveries for a filter
+func (r *webhookDeliveryRepository) deadLetterWhere(filter *ports.DeliveryFilter) (string, []interface{}) {
+	whereClause := "WHERE status = 'dead_letter'"
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.WebhookID != "" {
+		whereClause += fmt.Sprintf(` AND "webhookId" = $%d`, argIndex)
+		args = append(args, filter.WebhookID)
+		argIndex++
+	}
+
+	if filter.SessionID != "" {
+		whereClause += fmt.Sprintf(` AND "sessionId" = $%d`, argIndex)
+		args = append(args, filter.SessionID)
+		argIndex++
+	}
+
+	if filter.Since > 0 {
+		whereClause += fmt.Sprintf(` AND "createdAt" >= $%d`, argIndex)
+		args = append(args, time.Unix(filter.Since, 0))
+		argIndex++
+	}
+
+	if filter.Until > 0 {
+		whereClause += fmt.Sprintf(` AND "createdAt" <= $%d`, argIndex)
+		args = append(args, time.Unix(filter.Until, 0))
+	}
+
+	return whereClause, args
+}
+
+// deliveryStatusFor derives the status of a new delivery record
+func deliveryStatusFor(delivery *ports.WebhookDelivery) string {
+	switch {
+	case delivery.Success:
+		return webhook.DeliveryStatusDelivered
+	case delivery.NextRetryAt > 0:
+		return webhook.DeliveryStatusRetrying
+	default:
+		return webhook.DeliveryStatusDeadLetter
+	}
+}
+
+// checkDeliveryAffected returns an error when an update did not match any delivery
+func (r *webhookDeliveryRepository) checkDeliveryAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return webhook.ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+// toModel converts a delivery record to database model
+func (r *webhookDeliveryRepository) toModel(delivery *ports.WebhookDelivery) *webhookDeliveryModel {
+	model := &webhookDeliveryModel{
+		ID:               delivery.ID,
+		WebhookID:        delivery.WebhookID,
+		SessionID:        toNullString(delivery.SessionID),
+		EventID:          delivery.EventID,
+		EventType:        delivery.EventType,
+		URL:              delivery.URL,
+		Payload:          delivery.Payload,
+		StatusCode:       delivery.StatusCode,
+		ResponseBody:     toNullString(delivery.ResponseBody),
+		Latency:          delivery.Latency,
+		Success:          delivery.Success,
+		Error:            toNullString(delivery.Error),
+		Attempts:         delivery.Attempts,
+		Status:           delivery.Status,
+		DeadLetterReason: toNullString(delivery.DeadLetterReason),
+		CreatedAt:        time.Unix(delivery.CreatedAt, 0),
+		UpdatedAt:        time.Unix(delivery.UpdatedAt, 0),
+	}
+
+	if delivery.NextRetryAt > 0 {
+		model.NextRetryAt = sql.NullTime{Time: time.Unix(delivery.NextRetryAt, 0), Valid: true}
+	}
+
+	return model
+}
+
+// fromModel converts database model to a delivery record
+func (r *webhookDeliveryRepository) fromModel(model *webhookDeliveryModel) *ports.WebhookDelivery {
+	delivery := &ports.WebhookDelivery{
+		ID:               model.ID,
+		WebhookID:        model.WebhookID,
+		SessionID:        model.SessionID.String,
+		EventID:          model.EventID,
+		EventType:        model.EventType,
+		URL:              model.URL,
+		Payload:          model.Payload,
+		StatusCode:       model.StatusCode,
+		ResponseBody:     model.ResponseBody.String,
+		Latency:          model.Latency,
+		Success:          model.Success,
+		Error:            model.Error.String,
+		Attempts:         model.Attempts,
+		Status:           model.Status,
+		DeadLetterReason: model.DeadLetterReason.String,
+		CreatedAt:        model.CreatedAt.Unix(),
+		UpdatedAt:        model.UpdatedAt.Unix(),
+	}
+
+	if model.NextRetryAt.Valid {
+		delivery.NextRetryAt = model.NextRetryAt.Time.Unix()
+	}
+
+	return delivery
+}
+
+// fromModels converts a slice of database models to delivery records
+func (r *webhookDeliveryRepository) fromModels(models []webhookDeliveryModel) []*ports.WebhookDelivery {
+	deliveries := make([]*ports.WebhookDelivery, 0, len(models))
+	for i := range models {
+		deliveries = append(deliveries, r.fromModel(&models[i]))
+	}
+	return deliveries
+}
+
+// toNullString converts an optional string to sql.NullString
+func toNullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -13,18 +14,21 @@ import (
 	"zpwoot/internal/domain/webhook"
 	"zpwoot/internal/ports"
 	"zpwoot/platform/logger"
+	"zpwoot/platform/secrets"
 )
 
 // webhookRepository implements the WebhookRepository interface
 type webhookRepository struct {
 	db     *sqlx.DB
+	cipher *secrets.Cipher // encrypts the webhook auth
 	logger *logger.Logger
 }
 
 // NewWebhookRepository creates a new webhook repository
-func NewWebhookRepository(db *sqlx.DB, logger *logger.Logger) ports.WebhookRepository {
+func NewWebhookRepository(db *sqlx.DB, cipher *secrets.Cipher, logger *logger.Logger) ports.WebhookRepository {
 	return &webhookRepository{
 		db:     db,
+		cipher: cipher,
 		logger: logger,
 	}
 }
@@ -35,10 +39,16 @@ type webhookModel struct {
 	SessionID sql.NullString `db:"sessionId"`
 	URL       string         `db:"url"`
 	Secret    sql.NullString `db:"secret"`
-	Events    string         `db:"events"` // JSONB field
+	Events    string         `db:"events"`   // JSONB field
+	Filters   sql.NullString `db:"filters"`  // JSONB field, NULL when no filters are set
+	Template  sql.NullString `db:"template"` // JSONB field, NULL when the standard body is sent
+	Auth      sql.NullString `db:"auth"`     // encrypted JSON, NULL when no credentials are sent
 	Active    bool           `db:"active"`
 	CreatedAt time.Time      `db:"createdAt"`
 	UpdatedAt time.Time      `db:"updatedAt"`
+
+	CircuitFailures int          `db:"circuitFailures"`
+	CircuitOpenedAt sql.NullTime `db:"circuitOpenedAt"` // NULL while the circuit is closed
 }
 
 // Create creates a new webhook configuration
@@ -49,14 +59,17 @@ func (r *webhookRepository) Create(ctx context.Context, wh *webhook.WebhookConfi
 		"session_id": wh.SessionID,
 	})
 
-	model := r.toModel(wh)
+	model, err := r.toModel(wh)
+	if err != nil {
+		return err
+	}
 
 	query := `
-		INSERT INTO "zpWebhooks" (id, "sessionId", url, secret, events, active, "createdAt", "updatedAt")
-		VALUES (:id, :sessionId, :url, :secret, :events, :active, :createdAt, :updatedAt)
+		INSERT INTO "zpWebhooks" (id, "sessionId", url, secret, events, filters, template, auth, active, "createdAt", "updatedAt")
+		VALUES (:id, :sessionId, :url, :secret, :events, :filters, :template, :auth, :active, :createdAt, :updatedAt)
 	`
 
-	_, err := r.db.NamedExecContext(ctx, query, model)
+	_, err = r.db.NamedExecContext(ctx, query, model)
 	if err != nil {
 		r.logger.ErrorWithFields("Failed to create webhook", map[string]interface{}{
 			"webhook_id": wh.ID.String(),
@@ -186,6 +199,10 @@ func (r *webhookRepository) List(ctx context.Context, req *webhook.ListWebhooksR
 		argIndex++
 	}
 
+	if req.Global {
+		whereClause += " AND \"sessionId\" IS NULL"
+	}
+
 	if req.Active != nil {
 		whereClause += fmt.Sprintf(" AND active = $%d", argIndex)
 		args = append(args, *req.Active)
@@ -244,13 +261,16 @@ func (r *webhookRepository) Update(ctx context.Context, wh *webhook.WebhookConfi
 		"webhook_id": wh.ID.String(),
 	})
 
-	model := r.toModel(wh)
+	model, err := r.toModel(wh)
+	if err != nil {
+		return err
+	}
 	model.UpdatedAt = time.Now()
 
 	query := `
 		UPDATE "zpWebhooks"
 		SET "sessionId" = :sessionId, url = :url, secret = :secret,
-		    events = :events, active = :active, "updatedAt" = :updatedAt
+		    events = :events, filters = :filters, template = :template, auth = :auth, active = :active, "updatedAt" = :updatedAt
 		WHERE id = :id
 	`
 
@@ -334,6 +354,18 @@ func (r *webhookRepository) UpdateStatus(ctx context.Context, id string, active
 	return nil
 }
 
+// SaveCircuit stores the open circuit of a webhook, a nil openedAt clears it.
+// It leaves updatedAt alone, the configuration did not change.
+func (r *webhookRepository) SaveCircuit(ctx context.Context, id string, failures int, openedAt *time.Time) error {
+	query := `UPDATE "zpWebhooks" SET "circuitFailures" = $1, "circuitOpenedAt" = $2 WHERE id = $3`
+
+	if _, err := r.db.ExecContext(ctx, query, failures, openedAt, id); err != nil {
+		return fmt.Errorf("failed to save webhook circuit: %w", err)
+	}
+
+	return nil
+}
+
 // GetActiveWebhooks retrieves all active webhooks
 func (r *webhookRepository) GetActiveWebhooks(ctx context.Context) ([]*webhook.WebhookConfig, error) {
 	r.logger.Info("Getting active webhooks")
@@ -435,7 +467,7 @@ func (r *webhookRepository) UpdateWebhookStats(ctx context.Context, webhookID st
 // Helper methods
 
 // toModel converts domain entity to database model
-func (r *webhookRepository) toModel(wh *webhook.WebhookConfig) *webhookModel {
+func (r *webhookRepository) toModel(wh *webhook.WebhookConfig) (*webhookModel, error) {
 	model := &webhookModel{
 		ID:        wh.ID.String(),
 		URL:       wh.URL,
@@ -462,7 +494,39 @@ func (r *webhookRepository) toModel(wh *webhook.WebhookConfig) *webhookModel {
 		model.Events = "[]"
 	}
 
-	return model
+	if !wh.Filters.IsEmpty() {
+		filtersJSON, err := json.Marshal(wh.Filters)
+		if err == nil {
+			model.Filters = sql.NullString{String: string(filtersJSON), Valid: true}
+		}
+	}
+
+	if !wh.Template.IsEmpty() {
+		templateJSON, err := json.Marshal(wh.Template)
+		if err == nil {
+			model.Template = sql.NullString{String: string(templateJSON), Valid: true}
+		}
+	}
+
+	if !wh.Auth.IsEmpty() {
+		authJSON, err := json.Marshal(wh.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode webhook auth: %w", err)
+		}
+		encrypted, err := r.cipher.Encrypt(authJSON)
+		if errors.Is(err, secrets.ErrNoKey) {
+			return nil, fmt.Errorf("%w: set WEBHOOK_ENCRYPTION_KEY to store webhook credentials", webhook.ErrInvalidWebhookAuth)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt webhook auth: %w", err)
+		}
+		model.Auth = sql.NullString{String: encrypted, Valid: true}
+	} else if wh.EncryptedAuth != "" {
+		// The stored credentials could not be decrypted and were not replaced, keep them
+		model.Auth = sql.NullString{String: wh.EncryptedAuth, Valid: true}
+	}
+
+	return model, nil
 }
 
 // fromModel converts database model to domain entity
@@ -484,6 +548,11 @@ func (r *webhookRepository) fromModel(model *webhookModel) (*webhook.WebhookConf
 		wh.SessionID = &model.SessionID.String
 	}
 
+	if model.CircuitOpenedAt.Valid {
+		wh.CircuitOpenedAt = &model.CircuitOpenedAt.Time
+		wh.CircuitFailures = model.CircuitFailures
+	}
+
 	if model.Secret.Valid {
 		wh.Secret = model.Secret.String
 	}
@@ -501,5 +570,49 @@ func (r *webhookRepository) fromModel(model *webhookModel) (*webhook.WebhookConf
 		wh.Events = []string{}
 	}
 
+	if model.Filters.Valid {
+		var filters webhook.WebhookFilters
+		if err := json.Unmarshal([]byte(model.Filters.String), &filters); err != nil {
+			return nil, fmt.Errorf("invalid webhook filters: %w", err)
+		}
+		wh.Filters = &filters
+	}
+
+	if model.Template.Valid {
+		var tmpl webhook.WebhookTemplate
+		if err := json.Unmarshal([]byte(model.Template.String), &tmpl); err != nil {
+			return nil, fmt.Errorf("invalid webhook template: %w", err)
+		}
+		wh.Template = &tmpl
+	}
+
+	if model.Auth.Valid {
+		auth, err := r.decryptAuth(model.Auth.String)
+		if err != nil {
+			// Keep the webhook readable so the auth can be set again; deliveries fail until then
+			r.logger.ErrorWithFields("Failed to decrypt webhook auth", map[string]interface{}{
+				"webhook_id": model.ID,
+				"error":      err.Error(),
+			})
+			wh.AuthError = err.Error()
+			wh.EncryptedAuth = model.Auth.String
+		}
+		wh.Auth = auth
+	}
+
 	return wh, nil
 }
+
+// decryptAuth decrypts and decodes the stored webhook auth
+func (r *webhookRepository) decryptAuth(encrypted string) (*webhook.WebhookAuth, error) {
+	authJSON, err := r.cipher.Decrypt(encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt webhook auth, check WEBHOOK_ENCRYPTION_KEY: %w", err)
+	}
+
+	var auth webhook.WebhookAuth
+	if err := json.Unmarshal(authJSON, &auth); err != nil {
+		return nil, fmt.Errorf("invalid webhook auth: %w", err)
+	}
+	return &auth, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"zpwoot/internal/domain/webhook"
+	"zpwoot/platform/logger"
+	"zpwoot/platform/secrets"
+)
+
+func newTestWebhookRepository(t *testing.T, key string) *webhookRepository {
+	t.Helper()
+
+	cipher, err := secrets.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &webhookRepository{cipher: cipher, logger: logger.New("error")}
+}
+
+func newTestWebhook() *webhook.WebhookConfig {
+	return &webhook.WebhookConfig{
+		ID:        uuid.New(),
+		URL:       "https://receiver.example.com/hook",
+		Events:    []string{"Message"},
+		Auth:      &webhook.WebhookAuth{Type: "bearer", Token: "receiver-token"},
+		Active:    true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func TestWebhookAuthRoundTrip(t *testing.T) {
+	repo := newTestWebhookRepository(t, "current-key")
+
+	model, err := repo.toModel(newTestWebhook())
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+	if !model.Auth.Valid || model.Auth.String == "" {
+		t.Fatal("auth was not stored")
+	}
+
+	wh, err := repo.fromModel(model)
+	if err != nil {
+		t.Fatalf("fromModel: %v", err)
+	}
+	if wh.AuthError != "" || wh.Auth == nil || wh.Auth.Token != "receiver-token" {
+		t.Fatalf("fromModel auth = %+v, error %q", wh.Auth, wh.AuthError)
+	}
+}
+
+func TestWebhookAuthIsKeptWhenItCannotBeDecrypted(t *testing.T) {
+	stored, err := newTestWebhookRepository(t, "old-key").toModel(newTestWebhook())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// WEBHOOK_ENCRYPTION_KEY changed, the stored credentials can no longer be read
+	repo := newTestWebhookRepository(t, "new-key")
+	wh, err := repo.fromModel(stored)
+	if err != nil {
+		t.Fatalf("fromModel: %v", err)
+	}
+	if wh.AuthError == "" || wh.Auth != nil {
+		t.Fatalf("fromModel auth = %+v, error %q; want an auth error", wh.Auth, wh.AuthError)
+	}
+
+	// An update that does not touch the auth writes the ciphertext back unchanged
+	active := false
+	wh.Update(&webhook.UpdateWebhookRequest{Active: &active})
+	model, err := repo.toModel(wh)
+	if err != nil {
+		t.Fatalf("toModel: %v", err)
+	}
+	if model.Auth != stored.Auth {
+		t.Fatalf("stored auth = %+v, want %+v", model.Auth, stored.Auth)
+	}
+
+	// The auth error is still reported on the next read
+	reread, err := repo.fromModel(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reread.AuthError == "" {
+		t.Fatal("auth error disappeared after saving the webhook")
+	}
+
+	// The original key still decrypts the credentials
+	restored, err := newTestWebhookRepository(t, "old-key").fromModel(model)
+	if err != nil || restored.Auth == nil || restored.Auth.Token != "receiver-token" {
+		t.Fatalf("credentials were not preserved: %+v, %v", restored, err)
+	}
+}
+
+func TestWebhookAuthIsReplacedOrRemovedOnRequest(t *testing.T) {
+	stored, err := newTestWebhookRepository(t, "old-key").toModel(newTestWebhook())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newTestWebhookRepository(t, "new-key")
+
+	tests := []struct {
+		name     string
+		auth     *webhook.WebhookAuth
+		wantAuth bool
+	}{
+		{name: "new credentials", auth: &webhook.WebhookAuth{Type: "bearer", Token: "new-token"}, wantAuth: true},
+		{name: "empty auth removes the credentials", auth: &webhook.WebhookAuth{}, wantAuth: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wh, err := repo.fromModel(stored)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wh.Update(&webhook.UpdateWebhookRequest{Auth: tt.auth})
+
+			model, err := repo.toModel(wh)
+			if err != nil {
+				t.Fatalf("toModel: %v", err)
+			}
+			if model.Auth.Valid != tt.wantAuth || model.Auth.String == stored.Auth.String {
+				t.Fatalf("stored auth = %+v", model.Auth)
+			}
+
+			reread, err := repo.fromModel(model)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reread.AuthError != "" {
+				t.Fatalf("auth error after replacing the credentials: %s", reread.AuthError)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+// Package sink implements webhook sinks that publish events to message brokers
+// instead of HTTP endpoints.
+package sink
+
+import (
+	"net/url"
+	"time"
+)
+
+const (
+	// dialTimeout bounds how long connecting to a broker may take
+	dialTimeout = 10 * time.Second
+	// reconnectInterval is the minimum time between attempts to reach a broker that is down
+	reconnectInterval = 5 * time.Second
+)
+
+// brokerURL returns the target URL without the query parameters read by the sink,
+// which the broker client does not know about
+func brokerURL(target *url.URL, params []string) string {
+	broker := *target
+	query := broker.Query()
+	for _, param := range params {
+		query.Del(param)
+	}
+	broker.RawQuery = query.Encode()
+	return broker.String()
+}
+
+// redactedURL returns the URL with its password masked, for logging
+func redactedURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Redacted()
+}
@@ -37,6 +37,15 @@ type WameowClient struct {
 	ctx           context.Context
 	cancel        context.CancelFunc
 	qrStopChannel chan bool
+
+	// qrEventHandler receives the codes and outcome reported by the QR channel
+	qrEventHandler func(evt *QRChannelEvent)
+
+	// sentMessageHandler receives every message sent successfully through the client
+	sentMessageHandler func(to types.JID, resp whatsmeow.SendResponse, msg *waE2E.Message, media []byte)
+
+	// mediaAutoDownload overrides the automatic media download of the server for the session, nil follows it
+	mediaAutoDownload *bool
 }
 
 // NewWameowClient creates a new WameowClient
@@ -50,8 +59,10 @@ func NewWameowClient(
 	ctx := context.Background()
 	sess, err := sessionRepo.GetByID(ctx, sessionID)
 	var deviceJid string
+	var mediaAutoDownload *bool
 	if err == nil && sess != nil {
 		deviceJid = sess.DeviceJid
+		mediaAutoDownload = sess.MediaAutoDownload
 		logger.InfoWithFields("Found existing session", map[string]interface{}{
 			"session_id": sessionID,
 			"device_jid": deviceJid,
@@ -90,6 +101,8 @@ func NewWameowClient(
 		ctx:           ctx,
 		cancel:        cancel,
 		qrStopChannel: make(chan bool, 1),
+
+		mediaAutoDownload: mediaAutoDownload,
 	}
 
 	return wameowClient, nil
@@ -176,6 +189,72 @@ func (c *WameowClient) GetQRCode() (string, error) {
 	return c.qrCode, nil
 }
 
+// SetQRChannelHandler sets the function that receives each QR code rotation and the
+// pairing outcome reported by the QR channel. whatsmeow only emits events.QR once per
+// batch of codes, so this is the only way to follow every code shown to the user.
+func (c *WameowClient) SetQRChannelHandler(handler func(evt *QRChannelEvent)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.qrEventHandler = handler
+}
+
+// SetSentMessageHandler sets the function that receives every message sent successfully
+// through the client, with the response of the server and the uploaded media, if any
+func (c *WameowClient) SetSentMessageHandler(handler func(to types.JID, resp whatsmeow.SendResponse, msg *waE2E.Message, media []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sentMessageHandler = handler
+}
+
+// SetMediaAutoDownload sets whether the session downloads received media as messages arrive,
+// nil follows the server setting
+func (c *WameowClient) SetMediaAutoDownload(enabled *bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mediaAutoDownload = enabled
+}
+
+// getMediaAutoDownload returns the automatic media download setting of the session, nil when it follows the server
+func (c *WameowClient) getMediaAutoDownload() *bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.mediaAutoDownload
+}
+
+// sendMessage sends a message and passes it to the sent message handler once the server accepted it
+func (c *WameowClient) sendMessage(ctx context.Context, to types.JID, msg *waE2E.Message) (whatsmeow.SendResponse, error) {
+	return c.sendMediaMessage(ctx, to, msg, nil)
+}
+
+// sendMediaMessage sends a message with uploaded media, passing the unencrypted media
+// to the sent message handler so it can be kept
+func (c *WameowClient) sendMediaMessage(ctx context.Context, to types.JID, msg *waE2E.Message, media []byte) (whatsmeow.SendResponse, error) {
+	resp, err := c.client.SendMessage(ctx, to, msg)
+	if err != nil {
+		return resp, err
+	}
+
+	c.mu.RLock()
+	handler := c.sentMessageHandler
+	c.mu.RUnlock()
+
+	if handler != nil {
+		handler(to, resp, msg, media)
+	}
+	return resp, nil
+}
+
+// notifyQRChannelEvent passes a QR channel item to the registered handler, if any
+func (c *WameowClient) notifyQRChannelEvent(item whatsmeow.QRChannelItem) {
+	c.mu.RLock()
+	handler := c.qrEventHandler
+	c.mu.RUnlock()
+
+	if handler != nil {
+		handler(&QRChannelEvent{QRChannelItem: item, ReceivedAt: time.Now()})
+	}
+}
+
 // GetClient returns the underlying whatsmeow client
 func (c *WameowClient) GetClient() *whatsmeow.Client {
 	return c.client
@@ -349,6 +428,7 @@ func (c *WameowClient) handleQRLoop(qrChan <-chan whatsmeow.QRChannelItem) {
 					"session_id": c.sessionID,
 				})
 				c.setStatus("connecting")
+				c.notifyQRChannelEvent(evt)
 
 			case "success":
 				c.logger.InfoWithFields("QR code scanned successfully", map[string]interface{}{
@@ -371,6 +451,7 @@ func (c *WameowClient) handleQRLoop(qrChan <-chan whatsmeow.QRChannelItem) {
 				c.qrCodeBase64 = ""
 				c.mu.Unlock()
 				c.setStatus("disconnected")
+				c.notifyQRChannelEvent(evt)
 				return
 
 			default:
@@ -451,7 +532,7 @@ func (c *WameowClient) SendTextMessage(ctx context.Context, to, body string) (*w
 		"body_len":   len(body),
 	})
 
-	resp, err := c.client.SendMessage(ctx, jid, message)
+	resp, err := c.sendMessage(ctx, jid, message)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to send text message", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -515,7 +596,7 @@ func (c *WameowClient) SendImageMessage(ctx context.Context, to, filePath, capti
 		"caption":    caption,
 	})
 
-	resp, err := c.client.SendMessage(ctx, jid, message)
+	resp, err := c.sendMediaMessage(ctx, jid, message, data)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to send image message", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -577,7 +658,7 @@ func (c *WameowClient) SendAudioMessage(ctx context.Context, to, filePath string
 		"file_size":  len(data),
 	})
 
-	resp, err := c.client.SendMessage(ctx, jid, message)
+	resp, err := c.sendMediaMessage(ctx, jid, message, data)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to send audio message", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -641,7 +722,7 @@ func (c *WameowClient) SendVideoMessage(ctx context.Context, to, filePath, capti
 		"caption":    caption,
 	})
 
-	resp, err := c.client.SendMessage(ctx, jid, message)
+	resp, err := c.sendMediaMessage(ctx, jid, message, data)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to send video message", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -706,7 +787,7 @@ func (c *WameowClient) SendDocumentMessage(ctx context.Context, to, filePath, fi
 		"filename":   filename,
 	})
 
-	resp, err := c.client.SendMessage(ctx, jid, message)
+	resp, err := c.sendMediaMessage(ctx, jid, message, data)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to send document message", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -753,7 +834,7 @@ func (c *WameowClient) SendLocationMessage(ctx context.Context, to string, latit
 		"address":    address,
 	})
 
-	resp, err := c.client.SendMessage(ctx, jid, message)
+	resp, err := c.sendMessage(ctx, jid, message)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to send location message", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -801,7 +882,7 @@ func (c *WameowClient) SendContactMessage(ctx context.Context, to, contactName,
 		"contact_phone": contactPhone,
 	})
 
-	resp, err := c.client.SendMessage(ctx, jid, message)
+	resp, err := c.sendMessage(ctx, jid, message)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to send contact message", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -887,7 +968,7 @@ func (c *WameowClient) SendStickerMessage(ctx context.Context, to, filePath stri
 		"file_size":  len(data),
 	})
 
-	resp, err := c.client.SendMessage(ctx, jid, message)
+	resp, err := c.sendMediaMessage(ctx, jid, message, data)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to send sticker message", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -938,7 +1019,7 @@ func (c *WameowClient) SendButtonMessage(ctx context.Context, to, body string, b
 		"body_length":   len(body),
 	})
 
-	resp, err := c.client.SendMessage(ctx, jid, message)
+	resp, err := c.sendMessage(ctx, jid, message)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to send button message", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -1011,7 +1092,7 @@ func (c *WameowClient) SendListMessage(ctx context.Context, to, body, buttonText
 		"body_length":    len(body),
 	})
 
-	resp, err := c.client.SendMessage(ctx, jid, message)
+	resp, err := c.sendMessage(ctx, jid, message)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to send list message", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -1056,7 +1137,7 @@ func (c *WameowClient) SendReaction(ctx context.Context, to, messageID, reaction
 	// Parameters: chat JID, sender JID, message ID, reaction emoji
 	message := c.client.BuildReaction(jid, jid, types.MessageID(messageID), reaction)
 
-	_, err = c.client.SendMessage(ctx, jid, message)
+	_, err = c.sendMessage(ctx, jid, message)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to send reaction", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -1160,7 +1241,7 @@ func (c *WameowClient) EditMessage(ctx context.Context, to, messageID, newText s
 
 	// Note: Message editing in WhatsApp is complex and may require the original message key
 	// This is a simplified implementation
-	_, err = c.client.SendMessage(ctx, jid, editMessage)
+	_, err = c.sendMessage(ctx, jid, editMessage)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to edit message", map[string]interface{}{
 			"session_id": c.sessionID,
@@ -1205,7 +1286,7 @@ func (c *WameowClient) DeleteMessage(ctx context.Context, to, messageID string,
 	// Build revoke message using WhatsMeow's BuildRevoke
 	message := c.client.BuildRevoke(jid, jid, messageID)
 
-	_, err = c.client.SendMessage(ctx, jid, message)
+	_, err = c.sendMessage(ctx, jid, message)
 	if err != nil {
 		c.logger.ErrorWithFields("Failed to delete message", map[string]interface{}{
 			"session_id": c.sessionID,
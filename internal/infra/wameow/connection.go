@@ -189,6 +189,19 @@ func NewSessionManager(sessionRepo ports.SessionRepository, logger *logger.Logge
 
 // UpdateConnectionStatus updates session connection status
 func (s *SessionManager) UpdateConnectionStatus(sessionID string, isConnected bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.updateConnectionStatus(ctx, sessionID, isConnected); err != nil {
+		s.logger.ErrorWithFields("Failed to update session connection status", map[string]interface{}{
+			"session_id": sessionID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// updateConnectionStatus updates session connection status, joining the transaction carried by ctx if there is one
+func (s *SessionManager) updateConnectionStatus(ctx context.Context, sessionID string, isConnected bool) error {
 	s.logger.InfoWithFields("Updating session connection status", map[string]interface{}{
 		"session_id":   sessionID,
 		"is_connected": isConnected,
@@ -198,19 +211,12 @@ func (s *SessionManager) UpdateConnectionStatus(sessionID string, isConnected bo
 		s.logger.WarnWithFields("No session repository available", map[string]interface{}{
 			"session_id": sessionID,
 		})
-		return
+		return nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
 	sessionEntity, err := s.sessionRepo.GetByID(ctx, sessionID)
 	if err != nil {
-		s.logger.ErrorWithFields("Failed to get session", map[string]interface{}{
-			"session_id": sessionID,
-			"error":      err.Error(),
-		})
-		return
+		return fmt.Errorf("failed to get session: %w", err)
 	}
 
 	currentConnectionStatus := sessionEntity.IsConnected
@@ -219,34 +225,57 @@ func (s *SessionManager) UpdateConnectionStatus(sessionID string, isConnected bo
 			"session_id":   sessionID,
 			"is_connected": isConnected,
 		})
-		return
+		return nil
 	}
 
-	// Update connection status
-	sessionEntity.SetConnected(isConnected)
+	// Only the connection columns are written, so events processed in parallel
+	// lanes cannot overwrite each other's changes. Connecting also clears the QR code.
+	if err := s.sessionRepo.UpdateConnectionStatus(ctx, sessionID, isConnected); err != nil {
+		return fmt.Errorf("failed to update session in database: %w", err)
+	}
 
-	// Clear QR code when successfully connected
-	if isConnected {
-		s.logger.InfoWithFields("Clearing QR code after successful connection", map[string]interface{}{
+	s.logger.InfoWithFields("Successfully updated session connection status", map[string]interface{}{
+		"session_id":       sessionID,
+		"old_is_connected": currentConnectionStatus,
+		"new_is_connected": isConnected,
+	})
+
+	return nil
+}
+
+// SetConnectionError marks a session as disconnected and records why.
+// It is used for failures WhatsApp does not recover from on its own, such as bans or replaced streams.
+func (s *SessionManager) SetConnectionError(sessionID, errorMsg string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.setConnectionError(ctx, sessionID, errorMsg); err != nil {
+		s.logger.ErrorWithFields("Failed to record session connection error", map[string]interface{}{
 			"session_id": sessionID,
+			"error":      err.Error(),
 		})
-		sessionEntity.QRCode = ""
-		sessionEntity.QRCodeExpiresAt = nil
 	}
+}
+
+// setConnectionError records a connection error, joining the transaction carried by ctx if there is one
+func (s *SessionManager) setConnectionError(ctx context.Context, sessionID, errorMsg string) error {
+	s.logger.WarnWithFields("Recording session connection error", map[string]interface{}{
+		"session_id": sessionID,
+		"error":      errorMsg,
+	})
 
-	if err := s.sessionRepo.Update(ctx, sessionEntity); err != nil {
-		s.logger.ErrorWithFields("Failed to update session in database", map[string]interface{}{
+	if s.sessionRepo == nil {
+		s.logger.WarnWithFields("No session repository available", map[string]interface{}{
 			"session_id": sessionID,
-			"error":      err.Error(),
 		})
-		return
+		return nil
 	}
 
-	s.logger.InfoWithFields("Successfully updated session connection status", map[string]interface{}{
-		"session_id":       sessionID,
-		"old_is_connected": currentConnectionStatus,
-		"new_is_connected": isConnected,
-	})
+	if err := s.sessionRepo.SetConnectionError(ctx, sessionID, errorMsg); err != nil {
+		return fmt.Errorf("failed to update session in database: %w", err)
+	}
+
+	return nil
 }
 
 // GetSession retrieves a session by ID
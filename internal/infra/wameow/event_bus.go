@@ -0,0 +1,373 @@
+package wameow
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"zpwoot/internal/ports"
+	"zpwoot/platform/logger"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// AllSessions registers an event handler for the events of every session
+const AllSessions = "*"
+
+// errHandlerPanic marks errors recovered from a panicking event handler
+var errHandlerPanic = errors.New("event handler panicked")
+
+// EventBusConfig holds settings for the in-process event bus
+type EventBusConfig struct {
+	// Workers is the number of goroutines invoking handlers. Each handler is
+	// pinned to one worker, so it sees the events of a session in order.
+	Workers int
+	// QueueSize is the number of events each worker buffers before dropping
+	QueueSize int
+}
+
+// DefaultEventBusConfig returns the default event bus settings
+func DefaultEventBusConfig() *EventBusConfig {
+	return &EventBusConfig{
+		Workers:   4,
+		QueueSize: 1024,
+	}
+}
+
+// EventHandlerStats reports the activity of a registered event handler
+type EventHandlerStats struct {
+	HandlerID   string     `json:"handler_id"`
+	SessionID   string     `json:"session_id"`
+	Delivered   int64      `json:"delivered"`
+	Failed      int64      `json:"failed"`
+	Panics      int64      `json:"panics"`
+	Dropped     int64      `json:"dropped"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// handlerCall invokes the method of a registered handler that matches an event
+type handlerCall struct {
+	eventType string
+	invoke    func(handler ports.EventHandler) error
+}
+
+// busJob is an event waiting to be delivered to one handler
+type busJob struct {
+	sessionID string
+	info      *EventHandlerInfo
+	call      *handlerCall
+}
+
+// EventBus fans Wameow events out to the handlers registered through
+// Manager.RegisterEventHandler. Handlers run on worker goroutines so a slow
+// or failing handler never blocks the whatsmeow client, and panics are
+// recovered and reported per handler.
+type EventBus struct {
+	config *EventBusConfig
+	logger *logger.Logger
+
+	handlers map[string]map[string]*EventHandlerInfo // sessionID -> handlerID -> handler
+	queues   []chan busJob
+	closed   bool
+	mutex    sync.RWMutex
+
+	nextID uint64
+	wg     sync.WaitGroup
+}
+
+// NewEventBus creates a new event bus
+func NewEventBus(config *EventBusConfig, logger *logger.Logger) *EventBus {
+	if config == nil {
+		config = DefaultEventBusConfig()
+	}
+	if config.Workers <= 0 {
+		config.Workers = DefaultEventBusConfig().Workers
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultEventBusConfig().QueueSize
+	}
+
+	queues := make([]chan busJob, config.Workers)
+	for i := range queues {
+		queues[i] = make(chan busJob, config.QueueSize)
+	}
+
+	return &EventBus{
+		config:   config,
+		logger:   logger,
+		handlers: make(map[string]map[string]*EventHandlerInfo),
+		queues:   queues,
+	}
+}
+
+// Start launches the worker goroutines
+func (b *EventBus) Start() {
+	b.logger.InfoWithFields("Starting event bus", map[string]interface{}{
+		"workers":    b.config.Workers,
+		"queue_size": b.config.QueueSize,
+	})
+
+	for _, queue := range b.queues {
+		b.wg.Add(1)
+		go b.work(queue)
+	}
+}
+
+// Stop stops accepting events and waits for queued events to be delivered
+func (b *EventBus) Stop() {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return
+	}
+	b.closed = true
+	for _, queue := range b.queues {
+		close(queue)
+	}
+	b.mutex.Unlock()
+
+	b.wg.Wait()
+	b.logger.Info("Event bus stopped")
+}
+
+// Register adds a handler for the events of a session, or of every session
+// when sessionID is AllSessions, and returns its handler ID
+func (b *EventBus) Register(sessionID string, handler ports.EventHandler) (string, error) {
+	if handler == nil {
+		return "", fmt.Errorf("event handler is nil")
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.nextID++
+	handlerID := fmt.Sprintf("handler_%d_%d", time.Now().UnixNano(), b.nextID)
+
+	if b.handlers[sessionID] == nil {
+		b.handlers[sessionID] = make(map[string]*EventHandlerInfo)
+	}
+	b.handlers[sessionID][handlerID] = &EventHandlerInfo{
+		ID:        handlerID,
+		SessionID: sessionID,
+		Handler:   handler,
+		queue:     b.queues[b.nextID%uint64(len(b.queues))],
+	}
+
+	return handlerID, nil
+}
+
+// Unregister removes a handler
+func (b *EventBus) Unregister(sessionID, handlerID string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	sessionHandlers, exists := b.handlers[sessionID]
+	if !exists {
+		return fmt.Errorf("no event handlers found for session %s", sessionID)
+	}
+
+	if _, exists := sessionHandlers[handlerID]; !exists {
+		return fmt.Errorf("event handler %s not found for session %s", handlerID, sessionID)
+	}
+
+	delete(sessionHandlers, handlerID)
+	if len(sessionHandlers) == 0 {
+		delete(b.handlers, sessionID)
+	}
+
+	return nil
+}
+
+// Publish queues an event for every handler of the session. It never blocks:
+// when a worker queue is full the event is dropped for that handler.
+func (b *EventBus) Publish(sessionID string, evt interface{}) {
+	call := newHandlerCall(sessionID, evt)
+	if call == nil {
+		return
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.closed {
+		return
+	}
+
+	for _, handlers := range []map[string]*EventHandlerInfo{b.handlers[sessionID], b.handlers[AllSessions]} {
+		for _, info := range handlers {
+			select {
+			case info.queue <- busJob{sessionID: sessionID, info: info, call: call}:
+			default:
+				atomic.AddInt64(&info.dropped, 1)
+				b.logger.WarnWithFields("Event handler queue full, dropping event", map[string]interface{}{
+					"session_id": sessionID,
+					"handler_id": info.ID,
+					"event_type": call.eventType,
+				})
+			}
+		}
+	}
+}
+
+// Stats returns the activity of the handlers registered for a session.
+// An empty sessionID returns every handler.
+func (b *EventBus) Stats(sessionID string) []EventHandlerStats {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	stats := []EventHandlerStats{}
+	for handlersSessionID, handlers := range b.handlers {
+		if sessionID != "" && handlersSessionID != sessionID {
+			continue
+		}
+		for _, info := range handlers {
+			stats = append(stats, info.stats())
+		}
+	}
+	return stats
+}
+
+// work delivers the events of one queue until it is closed
+func (b *EventBus) work(queue chan busJob) {
+	defer b.wg.Done()
+
+	for job := range queue {
+		b.deliver(job)
+	}
+}
+
+// deliver invokes a handler and records the outcome
+func (b *EventBus) deliver(job busJob) {
+	err := b.invoke(job)
+	if err == nil {
+		atomic.AddInt64(&job.info.delivered, 1)
+		return
+	}
+
+	atomic.AddInt64(&job.info.failed, 1)
+	job.info.recordError(err)
+
+	b.logger.ErrorWithFields("Event handler failed", map[string]interface{}{
+		"session_id": job.sessionID,
+		"handler_id": job.info.ID,
+		"event_type": job.call.eventType,
+		"error":      err.Error(),
+	})
+}
+
+// invoke calls the handler, turning a panic into an error
+func (b *EventBus) invoke(job busJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddInt64(&job.info.panics, 1)
+			err = fmt.Errorf("%w: %v", errHandlerPanic, r)
+
+			b.logger.ErrorWithFields("Recovered panic in event handler", map[string]interface{}{
+				"session_id": job.sessionID,
+				"handler_id": job.info.ID,
+				"event_type": job.call.eventType,
+				"panic":      fmt.Sprint(r),
+				"stack":      string(debug.Stack()),
+			})
+		}
+	}()
+
+	return job.call.invoke(job.info.Handler)
+}
+
+// recordError keeps the last error of the handler for its stats
+func (info *EventHandlerInfo) recordError(err error) {
+	info.statsMutex.Lock()
+	defer info.statsMutex.Unlock()
+
+	now := time.Now()
+	info.lastError = err.Error()
+	info.lastErrorAt = &now
+}
+
+// stats returns a snapshot of the handler activity
+func (info *EventHandlerInfo) stats() EventHandlerStats {
+	info.statsMutex.Lock()
+	defer info.statsMutex.Unlock()
+
+	return EventHandlerStats{
+		HandlerID:   info.ID,
+		SessionID:   info.SessionID,
+		Delivered:   atomic.LoadInt64(&info.delivered),
+		Failed:      atomic.LoadInt64(&info.failed),
+		Panics:      atomic.LoadInt64(&info.panics),
+		Dropped:     atomic.LoadInt64(&info.dropped),
+		LastError:   info.lastError,
+		LastErrorAt: info.lastErrorAt,
+	}
+}
+
+// newHandlerCall maps a whatsmeow event to the ports.EventHandler method that receives it.
+// It returns nil for events registered handlers have no method for.
+func newHandlerCall(sessionID string, evt interface{}) *handlerCall {
+	eventType := getEventType(evt)
+
+	switch e := evt.(type) {
+	case *events.Message:
+		data := newMessageEventData(e)
+		msg := &ports.WameowMessage{
+			ID:        data.ID,
+			From:      data.Sender,
+			To:        data.Chat,
+			Body:      data.Text,
+			Timestamp: data.Timestamp.Unix(),
+			Type:      data.MessageType,
+			Caption:   data.Caption,
+		}
+		return &handlerCall{eventType, func(h ports.EventHandler) error { return h.HandleMessage(sessionID, msg) }}
+	case *events.Connected:
+		return &handlerCall{eventType, func(h ports.EventHandler) error { return h.HandleConnection(sessionID, true) }}
+	case *events.Disconnected, *events.LoggedOut:
+		return &handlerCall{eventType, func(h ports.EventHandler) error { return h.HandleConnection(sessionID, false) }}
+	case *QRChannelEvent:
+		// The QR channel shows the codes of an events.QR one at a time, so handlers get each rotation
+		if e.Event == whatsmeow.QRChannelEventCode {
+			code := e.Code
+			return &handlerCall{eventType, func(h ports.EventHandler) error { return h.HandleQRCode(sessionID, code) }}
+		}
+	case *events.PairSuccess:
+		return &handlerCall{eventType, func(h ports.EventHandler) error { return h.HandlePairSuccess(sessionID) }}
+	}
+
+	if err := sessionEventError(evt); err != nil {
+		return &handlerCall{eventType, func(h ports.EventHandler) error { return h.HandleError(sessionID, err) }}
+	}
+
+	return nil
+}
+
+// sessionEventError describes the events that report a session failure, or returns nil
+func sessionEventError(evt interface{}) error {
+	switch e := evt.(type) {
+	case *QRChannelEvent:
+		// Pairing errors of the QR channel are also dispatched as events.PairError
+		if e.Event == whatsmeow.QRChannelTimeout.Event {
+			return errors.New("QR code was not scanned in time")
+		}
+	case *events.PairError:
+		return fmt.Errorf("pairing failed: %w", e.Error)
+	case *events.ConnectFailure:
+		return fmt.Errorf("connect failure: %s", e.Reason.String())
+	case *events.TemporaryBan:
+		return errors.New(e.String())
+	case *events.StreamError:
+		return fmt.Errorf("stream error: %s", e.Code)
+	case *events.StreamReplaced:
+		return errors.New("stream replaced: another client connected with the same session")
+	case *events.ClientOutdated:
+		return errors.New("client version rejected by WhatsApp as outdated")
+	case *events.CATRefreshError:
+		return fmt.Errorf("client access token refresh failed: %w", e.Error)
+	}
+	return nil
+}
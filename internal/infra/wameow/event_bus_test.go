@@ -0,0 +1,108 @@
+package wameow
+
+import (
+	"errors"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"zpwoot/internal/ports"
+)
+
+// recordingHandler records the ports.EventHandler calls it receives
+type recordingHandler struct {
+	calls  []string
+	qrCode string
+	err    error
+}
+
+func (h *recordingHandler) HandleMessage(sessionID string, message *ports.WameowMessage) error {
+	h.calls = append(h.calls, "HandleMessage")
+	return nil
+}
+
+func (h *recordingHandler) HandleConnection(sessionID string, connected bool) error {
+	h.calls = append(h.calls, "HandleConnection")
+	return nil
+}
+
+func (h *recordingHandler) HandleQRCode(sessionID string, qrCode string) error {
+	h.calls = append(h.calls, "HandleQRCode")
+	h.qrCode = qrCode
+	return nil
+}
+
+func (h *recordingHandler) HandlePairSuccess(sessionID string) error {
+	h.calls = append(h.calls, "HandlePairSuccess")
+	return nil
+}
+
+func (h *recordingHandler) HandleError(sessionID string, err error) error {
+	h.calls = append(h.calls, "HandleError")
+	h.err = err
+	return nil
+}
+
+func TestNewHandlerCallQRChannel(t *testing.T) {
+	tests := []struct {
+		name     string
+		evt      interface{}
+		wantCall string
+		wantCode string
+	}{
+		{
+			name:     "code",
+			evt:      &QRChannelEvent{QRChannelItem: whatsmeow.QRChannelItem{Event: whatsmeow.QRChannelEventCode, Code: "2@abc"}},
+			wantCall: "HandleQRCode",
+			wantCode: "2@abc",
+		},
+		{
+			name:     "timeout",
+			evt:      &QRChannelEvent{QRChannelItem: whatsmeow.QRChannelTimeout},
+			wantCall: "HandleError",
+		},
+		{
+			name:     "pair error",
+			evt:      &events.PairError{Error: errors.New("bad signature")},
+			wantCall: "HandleError",
+		},
+		{
+			name: "success is reported by PairSuccess",
+			evt:  &QRChannelEvent{QRChannelItem: whatsmeow.QRChannelSuccess},
+		},
+		{
+			name: "raw QR codes are shown through the QR channel",
+			evt:  &events.QR{Codes: []string{"2@abc"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := newHandlerCall("session-1", tt.evt)
+			if tt.wantCall == "" {
+				if call != nil {
+					t.Fatalf("newHandlerCall(%T) = %+v, want nil", tt.evt, call)
+				}
+				return
+			}
+			if call == nil {
+				t.Fatalf("newHandlerCall(%T) = nil, want %s", tt.evt, tt.wantCall)
+			}
+
+			handler := &recordingHandler{}
+			if err := call.invoke(handler); err != nil {
+				t.Fatalf("invoke: %v", err)
+			}
+			if len(handler.calls) != 1 || handler.calls[0] != tt.wantCall {
+				t.Errorf("calls = %v, want [%s]", handler.calls, tt.wantCall)
+			}
+			if handler.qrCode != tt.wantCode {
+				t.Errorf("qr code = %q, want %q", handler.qrCode, tt.wantCode)
+			}
+			if tt.wantCall == "HandleError" && handler.err == nil {
+				t.Error("HandleError got a nil error")
+			}
+		})
+	}
+}
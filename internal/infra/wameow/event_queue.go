@@ -0,0 +1,465 @@
+package wameow
+
+import (
+	"fmt"
+	"hash/fnv"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"zpwoot/platform/logger"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// OverflowPolicy decides what happens to an event when its session queue is full
+type OverflowPolicy string
+
+// Overflow policies
+const (
+	// OverflowBlock waits up to BlockTimeout for room, then drops the new event
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropNewest drops the new event right away
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+	// OverflowDropOldest discards the oldest queued event of the chat to make room
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+)
+
+// dropLogInterval limits how often dropped events are logged per session
+const dropLogInterval = 10 * time.Second
+
+// EventQueueConfig holds settings for the per-session event queues
+type EventQueueConfig struct {
+	// Size is the number of events a session may have waiting
+	Size int
+	// Workers is the number of goroutines processing the events of a session.
+	// Events of the same chat always go to the same worker, so they stay ordered.
+	Workers int
+	// Overflow is the policy applied to chat events when the queue is full.
+	// Session events (connection, QR, pairing) always wait up to SessionTimeout.
+	Overflow OverflowPolicy
+	// BlockTimeout bounds how long OverflowBlock waits for room
+	BlockTimeout time.Duration
+	// SessionTimeout bounds how long session events wait for room. They share
+	// the first lane with chat traffic, so the wait is longer but never unbounded.
+	SessionTimeout time.Duration
+}
+
+// DefaultEventQueueConfig returns the default event queue settings
+func DefaultEventQueueConfig() *EventQueueConfig {
+	return &EventQueueConfig{
+		Size:           1000,
+		Workers:        4,
+		Overflow:       OverflowBlock,
+		BlockTimeout:   5 * time.Second,
+		SessionTimeout: 30 * time.Second,
+	}
+}
+
+// EventQueueStats reports the backpressure of a session event queue
+type EventQueueStats struct {
+	SessionID      string     `json:"session_id"`
+	Depth          int64      `json:"depth"`
+	Capacity       int        `json:"capacity"`
+	HighWatermark  int64      `json:"high_watermark"`
+	Enqueued       int64      `json:"enqueued"`
+	Processed      int64      `json:"processed"`
+	Dropped        int64      `json:"dropped"`
+	Blocked        int64      `json:"blocked"`
+	BlockedTimeMs  int64      `json:"blocked_time_ms"`
+	OverflowPolicy string     `json:"overflow_policy"`
+	LastDropAt     *time.Time `json:"last_drop_at,omitempty"`
+}
+
+// sessionQueue holds the worker lanes of one session
+type sessionQueue struct {
+	sessionID string
+	lanes     []chan interface{}
+	// done is closed on Stop. Lanes are never closed, so senders that raced
+	// with Stop give up on done instead of panicking on a closed channel.
+	done chan struct{}
+
+	depth         int64
+	highWatermark int64
+	enqueued      int64
+	processed     int64
+	dropped       int64
+	blocked       int64
+	blockedTimeNs int64
+	lastDropAt    int64 // unix nanoseconds
+	lastDropLog   int64 // unix nanoseconds
+}
+
+// EventDispatcher moves event processing off the whatsmeow callback.
+// Each session gets a bounded queue split in lanes; events are routed to a
+// lane by chat, so a chat is processed in order while different chats and
+// sessions run in parallel and a slow receiver never stalls the socket reader.
+type EventDispatcher struct {
+	config  *EventQueueConfig
+	process func(sessionID string, evt interface{})
+	logger  *logger.Logger
+
+	queues map[string]*sessionQueue
+	closed bool
+	mutex  sync.RWMutex
+	wg     sync.WaitGroup
+}
+
+// NewEventDispatcher creates a new event dispatcher that hands events to process
+func NewEventDispatcher(config *EventQueueConfig, process func(sessionID string, evt interface{}), logger *logger.Logger) *EventDispatcher {
+	defaults := DefaultEventQueueConfig()
+	if config == nil {
+		config = defaults
+	}
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.Size < config.Workers {
+		config.Size = defaults.Size
+	}
+	if config.BlockTimeout <= 0 {
+		config.BlockTimeout = defaults.BlockTimeout
+	}
+	if config.SessionTimeout <= 0 {
+		config.SessionTimeout = defaults.SessionTimeout
+	}
+	switch config.Overflow {
+	case OverflowBlock, OverflowDropNewest, OverflowDropOldest:
+	default:
+		logger.WarnWithFields("Unknown event queue overflow policy, using default", map[string]interface{}{
+			"overflow": string(config.Overflow),
+			"default":  string(defaults.Overflow),
+		})
+		config.Overflow = defaults.Overflow
+	}
+
+	return &EventDispatcher{
+		config:  config,
+		process: process,
+		logger:  logger,
+		queues:  make(map[string]*sessionQueue),
+	}
+}
+
+// Dispatch queues an event of a session for processing.
+// The dispatcher lock is not held while waiting for room, so a stalled lane
+// only holds back its own session and never the callbacks of other sessions.
+func (d *EventDispatcher) Dispatch(sessionID string, evt interface{}) {
+	queue := d.sessionQueue(sessionID)
+	if queue == nil {
+		return
+	}
+
+	select {
+	case <-queue.done:
+		return
+	default:
+	}
+
+	chat := eventChatKey(evt)
+	lane := queue.lanes[laneIndex(chat, len(queue.lanes))]
+
+	atomic.AddInt64(&queue.enqueued, 1)
+	queue.updateDepth(1)
+
+	// Fast path: there is room in the lane
+	select {
+	case lane <- evt:
+		return
+	default:
+	}
+
+	policy := d.config.Overflow
+	if chat == "" {
+		// Session events drive the session state, so they wait longer than chat events
+		if !d.block(queue, lane, evt, d.config.SessionTimeout) {
+			d.drop(queue, evt)
+		}
+		return
+	}
+
+	switch policy {
+	case OverflowDropNewest:
+		d.drop(queue, evt)
+	case OverflowDropOldest:
+		for {
+			select {
+			case lane <- evt:
+				return
+			default:
+			}
+			select {
+			case oldest := <-lane:
+				d.drop(queue, oldest)
+			default:
+			}
+		}
+	default:
+		if !d.block(queue, lane, evt, d.config.BlockTimeout) {
+			d.drop(queue, evt)
+		}
+	}
+}
+
+// Stop stops accepting events and waits for queued events to be processed
+func (d *EventDispatcher) Stop() {
+	d.mutex.Lock()
+	if d.closed {
+		d.mutex.Unlock()
+		return
+	}
+	d.closed = true
+	for _, queue := range d.queues {
+		close(queue.done)
+	}
+	d.mutex.Unlock()
+
+	d.wg.Wait()
+	d.logger.Info("Event dispatcher stopped")
+}
+
+// Stats returns the backpressure metrics of every session queue
+func (d *EventDispatcher) Stats() []EventQueueStats {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	stats := make([]EventQueueStats, 0, len(d.queues))
+	for _, queue := range d.queues {
+		stats = append(stats, queue.stats(d.config))
+	}
+	return stats
+}
+
+// sessionQueue returns the queue of a session, starting its workers on first use.
+// It returns nil once the dispatcher is stopped.
+func (d *EventDispatcher) sessionQueue(sessionID string) *sessionQueue {
+	d.mutex.RLock()
+	queue, ok := d.queues[sessionID]
+	d.mutex.RUnlock()
+	if ok {
+		return queue
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed {
+		return nil
+	}
+	if queue, ok := d.queues[sessionID]; ok {
+		return queue
+	}
+
+	laneSize := d.config.Size / d.config.Workers
+	queue = &sessionQueue{
+		sessionID: sessionID,
+		lanes:     make([]chan interface{}, d.config.Workers),
+		done:      make(chan struct{}),
+	}
+	for i := range queue.lanes {
+		queue.lanes[i] = make(chan interface{}, laneSize)
+		d.wg.Add(1)
+		go d.work(queue, queue.lanes[i])
+	}
+	d.queues[sessionID] = queue
+
+	d.logger.DebugWithFields("Started session event queue", map[string]interface{}{
+		"session_id": sessionID,
+		"workers":    d.config.Workers,
+		"capacity":   d.config.Size,
+	})
+
+	return queue
+}
+
+// block waits up to timeout for room in the lane, giving up early when the dispatcher stops
+func (d *EventDispatcher) block(queue *sessionQueue, lane chan interface{}, evt interface{}, timeout time.Duration) bool {
+	atomic.AddInt64(&queue.blocked, 1)
+	start := time.Now()
+	defer func() {
+		atomic.AddInt64(&queue.blockedTimeNs, int64(time.Since(start)))
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case lane <- evt:
+		return true
+	case <-queue.done:
+		return false
+	case <-timer.C:
+		return false
+	}
+}
+
+// drop records a discarded event, logging at most once per dropLogInterval
+func (d *EventDispatcher) drop(queue *sessionQueue, evt interface{}) {
+	queue.updateDepth(-1)
+	dropped := atomic.AddInt64(&queue.dropped, 1)
+
+	now := time.Now().UnixNano()
+	atomic.StoreInt64(&queue.lastDropAt, now)
+
+	lastLog := atomic.LoadInt64(&queue.lastDropLog)
+	if now-lastLog < int64(dropLogInterval) || !atomic.CompareAndSwapInt64(&queue.lastDropLog, lastLog, now) {
+		return
+	}
+
+	d.logger.WarnWithFields("Session event queue full, dropping events", map[string]interface{}{
+		"session_id": queue.sessionID,
+		"event_type": getEventType(evt),
+		"overflow":   string(d.config.Overflow),
+		"capacity":   d.config.Size,
+		"dropped":    dropped,
+	})
+}
+
+// work processes the events of one lane until the dispatcher stops,
+// then drains what is still queued in the lane
+func (d *EventDispatcher) work(queue *sessionQueue, lane chan interface{}) {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case evt := <-lane:
+			d.handle(queue, evt)
+		case <-queue.done:
+			for {
+				select {
+				case evt := <-lane:
+					d.handle(queue, evt)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handle processes one dequeued event and updates the queue metrics
+func (d *EventDispatcher) handle(queue *sessionQueue, evt interface{}) {
+	queue.updateDepth(-1)
+	d.processSafely(queue.sessionID, evt)
+	atomic.AddInt64(&queue.processed, 1)
+}
+
+// processSafely processes an event, recovering from panics so the lane keeps running
+func (d *EventDispatcher) processSafely(sessionID string, evt interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.ErrorWithFields("Recovered panic while processing event", map[string]interface{}{
+				"session_id": sessionID,
+				"event_type": getEventType(evt),
+				"panic":      fmt.Sprint(r),
+				"stack":      string(debug.Stack()),
+			})
+		}
+	}()
+
+	d.process(sessionID, evt)
+}
+
+// updateDepth changes the queue depth and tracks its high watermark
+func (q *sessionQueue) updateDepth(delta int64) {
+	depth := atomic.AddInt64(&q.depth, delta)
+	for {
+		high := atomic.LoadInt64(&q.highWatermark)
+		if depth <= high || atomic.CompareAndSwapInt64(&q.highWatermark, high, depth) {
+			return
+		}
+	}
+}
+
+// stats returns a snapshot of the queue metrics
+func (q *sessionQueue) stats(config *EventQueueConfig) EventQueueStats {
+	stats := EventQueueStats{
+		SessionID:      q.sessionID,
+		Depth:          atomic.LoadInt64(&q.depth),
+		Capacity:       config.Size,
+		HighWatermark:  atomic.LoadInt64(&q.highWatermark),
+		Enqueued:       atomic.LoadInt64(&q.enqueued),
+		Processed:      atomic.LoadInt64(&q.processed),
+		Dropped:        atomic.LoadInt64(&q.dropped),
+		Blocked:        atomic.LoadInt64(&q.blocked),
+		BlockedTimeMs:  time.Duration(atomic.LoadInt64(&q.blockedTimeNs)).Milliseconds(),
+		OverflowPolicy: string(config.Overflow),
+	}
+	if lastDropAt := atomic.LoadInt64(&q.lastDropAt); lastDropAt != 0 {
+		t := time.Unix(0, lastDropAt)
+		stats.LastDropAt = &t
+	}
+	return stats
+}
+
+// laneIndex picks the lane of a chat. Session events without a chat use the first lane.
+func laneIndex(chat string, lanes int) int {
+	if chat == "" || lanes <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(chat))
+	return int(h.Sum32() % uint32(lanes))
+}
+
+// eventChatKey returns the chat an event belongs to, or an empty string for session events
+func eventChatKey(evt interface{}) string {
+	switch e := evt.(type) {
+	case *OfflineSyncEvent:
+		return eventChatKey(e.Event)
+	case *events.Message:
+		return e.Info.Chat.String()
+	case *events.UndecryptableMessage:
+		return e.Info.Chat.String()
+	case *events.FBMessage:
+		return e.Info.Chat.String()
+	case *events.Receipt:
+		return e.Chat.String()
+	case *events.ChatPresence:
+		return e.Chat.String()
+	case *events.Presence:
+		return e.From.String()
+	case *events.MediaRetry:
+		return e.ChatID.String()
+	case *events.GroupInfo:
+		return e.JID.String()
+	case *events.JoinedGroup:
+		return e.JID.String()
+	case *events.Picture:
+		return e.JID.String()
+	case *events.UserAbout:
+		return e.JID.String()
+	case *events.IdentityChange:
+		return e.JID.String()
+	case *events.Contact:
+		return e.JID.String()
+	case *events.PushName:
+		return e.JID.String()
+	case *events.BusinessName:
+		return e.JID.String()
+	case *events.Archive:
+		return e.JID.String()
+	case *events.Pin:
+		return e.JID.String()
+	case *events.Mute:
+		return e.JID.String()
+	case *events.MarkChatAsRead:
+		return e.JID.String()
+	case *events.DeleteForMe:
+		return e.ChatJID.String()
+	case *events.NewsletterLiveUpdate:
+		return e.JID.String()
+	case *events.CallOffer:
+		return e.From.String()
+	case *events.CallAccept:
+		return e.From.String()
+	case *events.CallTerminate:
+		return e.From.String()
+	case *events.CallOfferNotice:
+		return e.From.String()
+	case *events.CallRelayLatency:
+		return e.From.String()
+	}
+	return ""
+}
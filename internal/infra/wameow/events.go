@@ -2,19 +2,61 @@ package wameow
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 
+	"zpwoot/internal/domain/webhook"
 	"zpwoot/platform/logger"
 
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 )
 
+// webhookProcessTimeout bounds how long recording a single event and its session change may take
+const webhookProcessTimeout = 60 * time.Second
+
+// QRChannelEvent is an item of the whatsmeow QR channel: a code rotation or the
+// outcome of the pairing attempt. The QR loop queues it with the whatsmeow events
+// of the session, so it is handled in order with PairSuccess and Connected.
+type QRChannelEvent struct {
+	whatsmeow.QRChannelItem
+	ReceivedAt time.Time
+}
+
+// OfflineSyncEvent wraps a whatsmeow event the server replayed while the session was
+// catching up on what it missed offline, between OfflineSyncPreview and OfflineSyncCompleted.
+// The webhook events it produces are flagged with is_offline_sync.
+type OfflineSyncEvent struct {
+	Event interface{}
+}
+
+// offlineSyncKey marks the context of an event replayed during offline sync
+type offlineSyncKey struct{}
+
+// withOfflineSync returns a context that flags the webhook events it produces as replayed
+func withOfflineSync(ctx context.Context) context.Context {
+	return context.WithValue(ctx, offlineSyncKey{}, true)
+}
+
+// isOfflineSync reports whether the context belongs to an event replayed during offline sync
+func isOfflineSync(ctx context.Context) bool {
+	replayed, _ := ctx.Value(offlineSyncKey{}).(bool)
+	return replayed
+}
+
 // EventHandler handles Wameow events
 type EventHandler struct {
 	manager    *Manager
 	sessionMgr *SessionManager
 	qrGen      *QRCodeGenerator
 	logger     *logger.Logger
+
+	// sessionNames caches session names used to tag webhook events
+	sessionNames sync.Map
 }
 
 // NewEventHandler creates a new event handler
@@ -30,67 +72,117 @@ func NewEventHandler(manager *Manager, sessionMgr *SessionManager, qrGen *QRCode
 // SetupEventHandlers is now defined in manager.go to avoid circular imports
 
 // HandleEvent handles all Wameow events
-func (h *EventHandler) HandleEvent(evt interface{}, sessionID string) {
+func (h *EventHandler) HandleEvent(ctx context.Context, evt interface{}, sessionID string) {
 	switch v := evt.(type) {
 	case *events.Connected:
-		h.handleConnected(v, sessionID)
+		h.handleConnected(ctx, v, sessionID)
 	case *events.Disconnected:
-		h.handleDisconnected(v, sessionID)
+		h.handleDisconnected(ctx, v, sessionID)
 	case *events.LoggedOut:
-		h.handleLoggedOut(v, sessionID)
+		h.handleLoggedOut(ctx, v, sessionID)
 	case *events.QR:
-		h.handleQR(v, sessionID)
+		h.handleQR(ctx, v, sessionID)
+	case *QRChannelEvent:
+		h.handleQRChannelEvent(ctx, v, sessionID)
 	case *events.PairSuccess:
-		h.handlePairSuccess(v, sessionID)
+		h.handlePairSuccess(ctx, v, sessionID)
 	case *events.PairError:
-		h.handlePairError(v, sessionID)
+		h.handlePairError(ctx, v, sessionID)
 	case *events.Message:
-		h.handleMessage(v, sessionID)
+		h.handleMessage(ctx, v, sessionID)
 	case *events.Receipt:
-		h.handleReceipt(v, sessionID)
+		h.handleReceipt(ctx, v, sessionID)
 	case *events.Presence:
-		h.handlePresence(v, sessionID)
+		h.handlePresence(ctx, v, sessionID)
 	case *events.ChatPresence:
-		h.handleChatPresence(v, sessionID)
+		h.handleChatPresence(ctx, v, sessionID)
 	case *events.HistorySync:
-		h.handleHistorySync(v, sessionID)
+		h.handleHistorySync(ctx, v, sessionID)
 	// Add more common event types to reduce noise
 	case *events.AppState:
-		h.handleAppState(v, sessionID)
+		h.handleAppState(ctx, v, sessionID)
 	case *events.AppStateSyncComplete:
-		h.handleAppStateSyncComplete(v, sessionID)
+		h.handleAppStateSyncComplete(ctx, v, sessionID)
 	case *events.KeepAliveTimeout:
-		h.handleKeepAliveTimeout(v, sessionID)
+		h.handleKeepAliveTimeout(ctx, v, sessionID)
 	case *events.KeepAliveRestored:
-		h.handleKeepAliveRestored(v, sessionID)
+		h.handleKeepAliveRestored(ctx, v, sessionID)
 	case *events.Contact:
-		h.handleContact(v, sessionID)
+		h.handleContact(ctx, v, sessionID)
 	case *events.GroupInfo:
-		h.handleGroupInfo(v, sessionID)
+		h.handleGroupInfo(ctx, v, sessionID)
 	case *events.Picture:
-		h.handlePicture(v, sessionID)
+		h.handlePicture(ctx, v, sessionID)
 	case *events.BusinessName:
-		h.handleBusinessName(v, sessionID)
+		h.handleBusinessName(ctx, v, sessionID)
 	case *events.PushName:
-		h.handlePushName(v, sessionID)
+		h.handlePushName(ctx, v, sessionID)
 	case *events.Archive:
-		h.handleArchive(v, sessionID)
+		h.handleArchive(ctx, v, sessionID)
 	case *events.Pin:
-		h.handlePin(v, sessionID)
+		h.handlePin(ctx, v, sessionID)
 	case *events.Mute:
-		h.handleMute(v, sessionID)
+		h.handleMute(ctx, v, sessionID)
 	case *events.Star:
-		h.handleStar(v, sessionID)
+		h.handleStar(ctx, v, sessionID)
 	case *events.DeleteForMe:
-		h.handleDeleteForMe(v, sessionID)
+		h.handleDeleteForMe(ctx, v, sessionID)
 	case *events.MarkChatAsRead:
-		h.handleMarkChatAsRead(v, sessionID)
+		h.handleMarkChatAsRead(ctx, v, sessionID)
 	case *events.UndecryptableMessage:
-		h.handleUndecryptableMessage(v, sessionID)
+		h.handleUndecryptableMessage(ctx, v, sessionID)
 	case *events.OfflineSyncPreview:
-		h.handleOfflineSyncPreview(v, sessionID)
+		h.handleOfflineSyncPreview(ctx, v, sessionID)
 	case *events.OfflineSyncCompleted:
-		h.handleOfflineSyncCompleted(v, sessionID)
+		h.handleOfflineSyncCompleted(ctx, v, sessionID)
+	case *events.MediaRetry:
+		h.handleMediaRetry(ctx, v, sessionID)
+	case *events.JoinedGroup:
+		h.handleJoinedGroup(ctx, v, sessionID)
+	case *events.Blocklist:
+		h.handleBlocklist(ctx, v, sessionID)
+	case *events.ConnectFailure:
+		h.handleConnectFailure(ctx, v, sessionID)
+	case *events.ClientOutdated:
+		h.handleClientOutdated(ctx, v, sessionID)
+	case *events.TemporaryBan:
+		h.handleTemporaryBan(ctx, v, sessionID)
+	case *events.StreamError:
+		h.handleStreamError(ctx, v, sessionID)
+	case *events.StreamReplaced:
+		h.handleStreamReplaced(ctx, v, sessionID)
+	case *events.QRScannedWithoutMultidevice:
+		h.handleQRScannedWithoutMultidevice(ctx, v, sessionID)
+	case *events.PrivacySettings:
+		h.handlePrivacySettings(ctx, v, sessionID)
+	case *events.PushNameSetting:
+		h.handlePushNameSetting(ctx, v, sessionID)
+	case *events.UserAbout:
+		h.handleUserAbout(ctx, v, sessionID)
+	case *events.CallOffer:
+		h.handleCallOffer(ctx, v, sessionID)
+	case *events.CallAccept:
+		h.handleCallAccept(ctx, v, sessionID)
+	case *events.CallTerminate:
+		h.handleCallTerminate(ctx, v, sessionID)
+	case *events.CallOfferNotice:
+		h.handleCallOfferNotice(ctx, v, sessionID)
+	case *events.CallRelayLatency:
+		h.handleCallRelayLatency(ctx, v, sessionID)
+	case *events.IdentityChange:
+		h.handleIdentityChange(ctx, v, sessionID)
+	case *events.CATRefreshError:
+		h.handleCATRefreshError(ctx, v, sessionID)
+	case *events.NewsletterJoin:
+		h.handleNewsletterJoin(ctx, v, sessionID)
+	case *events.NewsletterLeave:
+		h.handleNewsletterLeave(ctx, v, sessionID)
+	case *events.NewsletterMuteChange:
+		h.handleNewsletterMuteChange(ctx, v, sessionID)
+	case *events.NewsletterLiveUpdate:
+		h.handleNewsletterLiveUpdate(ctx, v, sessionID)
+	case *events.FBMessage:
+		h.handleFBMessage(ctx, v, sessionID)
 	default:
 		// Use DEBUG level instead of INFO to reduce noise for truly unknown events
 		h.logger.DebugWithFields("Unhandled event", map[string]interface{}{
@@ -101,7 +193,7 @@ func (h *EventHandler) HandleEvent(evt interface{}, sessionID string) {
 }
 
 // handleConnected handles connection events
-func (h *EventHandler) handleConnected(evt *events.Connected, sessionID string) {
+func (h *EventHandler) handleConnected(ctx context.Context, evt *events.Connected, sessionID string) {
 	h.logger.InfoWithFields("Wameow connected", map[string]interface{}{
 		"session_id":   sessionID,
 		"event_type":   "Connected",
@@ -111,11 +203,15 @@ func (h *EventHandler) handleConnected(evt *events.Connected, sessionID string)
 	// Use evt to avoid unused parameter warning
 	_ = evt
 
-	h.sessionMgr.UpdateConnectionStatus(sessionID, true)
+	h.emitStateChange(ctx, sessionID, "Connected", &webhook.ConnectionEventData{
+		Connected: true,
+	}, func(ctx context.Context) error {
+		return h.sessionMgr.updateConnectionStatus(ctx, sessionID, true)
+	})
 }
 
 // handleDisconnected handles disconnection events
-func (h *EventHandler) handleDisconnected(evt *events.Disconnected, sessionID string) {
+func (h *EventHandler) handleDisconnected(ctx context.Context, evt *events.Disconnected, sessionID string) {
 	h.logger.InfoWithFields("Wameow disconnected", map[string]interface{}{
 		"session_id":      sessionID,
 		"event_type":      "Disconnected",
@@ -125,63 +221,109 @@ func (h *EventHandler) handleDisconnected(evt *events.Disconnected, sessionID st
 	// Use evt to avoid unused parameter warning
 	_ = evt
 
-	h.sessionMgr.UpdateConnectionStatus(sessionID, false)
+	h.emitStateChange(ctx, sessionID, "Disconnected", &webhook.ConnectionEventData{
+		Connected: false,
+	}, func(ctx context.Context) error {
+		return h.sessionMgr.updateConnectionStatus(ctx, sessionID, false)
+	})
 }
 
 // handleLoggedOut handles logout events
-func (h *EventHandler) handleLoggedOut(evt *events.LoggedOut, sessionID string) {
+func (h *EventHandler) handleLoggedOut(ctx context.Context, evt *events.LoggedOut, sessionID string) {
 	h.logger.InfoWithFields("Wameow logged out", map[string]interface{}{
 		"session_id": sessionID,
 		"reason":     evt.Reason,
 	})
 
-	h.sessionMgr.UpdateConnectionStatus(sessionID, false)
+	h.emitStateChange(ctx, sessionID, "LoggedOut", &webhook.LoggedOutEventData{
+		Reason:    evt.Reason.String(),
+		OnConnect: evt.OnConnect,
+	}, func(ctx context.Context) error {
+		return h.sessionMgr.updateConnectionStatus(ctx, sessionID, false)
+	})
 }
 
 // handleQR handles QR code events
-func (h *EventHandler) handleQR(evt *events.QR, sessionID string) {
-	h.logger.InfoWithFields("QR code received", map[string]interface{}{
+func (h *EventHandler) handleQR(ctx context.Context, evt *events.QR, sessionID string) {
+	// The codes are shown one at a time by the QR channel, which reports each of
+	// them as a QRChannelEvent; the session and webhooks are updated from there.
+	h.logger.InfoWithFields("QR codes received", map[string]interface{}{
 		"session_id":  sessionID,
 		"codes_count": len(evt.Codes),
 	})
+}
 
-	// Generate QR code image
-	qrImage := h.qrGen.GenerateQRCodeImage(evt.Codes[0])
+// handleQRChannelEvent handles QR code rotations and the QR timeout reported by the QR channel
+func (h *EventHandler) handleQRChannelEvent(ctx context.Context, evt *QRChannelEvent, sessionID string) {
+	switch evt.Event {
+	case whatsmeow.QRChannelEventCode:
+		qrImage := h.qrGen.GenerateQRCodeImage(evt.Code)
+		expiresAt := evt.ReceivedAt.Add(evt.Timeout)
+
+		h.emitStateChange(ctx, sessionID, "QR", &webhook.QREventData{
+			Code:      evt.Code,
+			Image:     qrImage,
+			ExpiresAt: &expiresAt,
+		}, func(ctx context.Context) error {
+			return h.updateSessionQRCode(ctx, sessionID, qrImage, &expiresAt)
+		})
 
-	// Update session with QR code
-	h.updateSessionQRCode(sessionID, qrImage)
+	case whatsmeow.QRChannelTimeout.Event:
+		h.logger.WarnWithFields("QR code was not scanned in time", map[string]interface{}{
+			"session_id": sessionID,
+		})
 
-	// Note: QR code display is handled in client.go to avoid duplication
+		h.emitStateChange(ctx, sessionID, "QRTimeout", &webhook.SessionNoticeEventData{
+			Message: "no QR code was scanned before the pairing window closed",
+		}, func(ctx context.Context) error {
+			return h.clearSessionQRCode(ctx, sessionID)
+		})
+	}
 }
 
 // handlePairSuccess handles successful pairing
-func (h *EventHandler) handlePairSuccess(evt *events.PairSuccess, sessionID string) {
+func (h *EventHandler) handlePairSuccess(ctx context.Context, evt *events.PairSuccess, sessionID string) {
 	h.logger.InfoWithFields("Pairing successful", map[string]interface{}{
 		"session_id": sessionID,
 		"device_jid": evt.ID.String(),
 	})
 
-	h.sessionMgr.UpdateConnectionStatus(sessionID, true)
-
-	// Update session with device JID
-	h.updateSessionDeviceJID(sessionID, evt.ID.String())
-
-	// Clear QR code after successful pairing
-	h.clearSessionQRCode(sessionID)
+	h.emitStateChange(ctx, sessionID, "PairSuccess", &webhook.PairSuccessEventData{
+		DeviceJID:    evt.ID.String(),
+		BusinessName: evt.BusinessName,
+		Platform:     evt.Platform,
+	}, func(ctx context.Context) error {
+		if err := h.sessionMgr.updateConnectionStatus(ctx, sessionID, true); err != nil {
+			return err
+		}
+
+		// Update session with device JID
+		if err := h.updateSessionDeviceJID(ctx, sessionID, evt.ID.String()); err != nil {
+			return err
+		}
+
+		// Clear QR code after successful pairing
+		return h.clearSessionQRCode(ctx, sessionID)
+	})
 }
 
 // handlePairError handles pairing errors
-func (h *EventHandler) handlePairError(evt *events.PairError, sessionID string) {
+func (h *EventHandler) handlePairError(ctx context.Context, evt *events.PairError, sessionID string) {
 	h.logger.ErrorWithFields("Pairing failed", map[string]interface{}{
 		"session_id": sessionID,
 		"error":      evt.Error.Error(),
 	})
 
-	h.sessionMgr.UpdateConnectionStatus(sessionID, false)
+	h.emitStateChange(ctx, sessionID, "PairError", &webhook.PairErrorEventData{
+		DeviceJID: evt.ID.String(),
+		Error:     evt.Error.Error(),
+	}, func(ctx context.Context) error {
+		return h.sessionMgr.updateConnectionStatus(ctx, sessionID, false)
+	})
 }
 
 // handleMessage handles incoming messages
-func (h *EventHandler) handleMessage(evt *events.Message, sessionID string) {
+func (h *EventHandler) handleMessage(ctx context.Context, evt *events.Message, sessionID string) {
 	h.logger.InfoWithFields("Message received", map[string]interface{}{
 		"session_id": sessionID,
 		"from":       evt.Info.Sender.String(),
@@ -189,87 +331,287 @@ func (h *EventHandler) handleMessage(evt *events.Message, sessionID string) {
 		"timestamp":  evt.Info.Timestamp,
 	})
 
-	// Update last seen
-	h.updateSessionLastSeen(sessionID)
+	h.emitStateChange(ctx, sessionID, "Message", newMessageEventData(evt), func(ctx context.Context) error {
+		// Keep the message in the history
+		if err := h.manager.storeMessage(ctx, newStoredMessage(sessionID, evt)); err != nil {
+			return err
+		}
+
+		// Update last seen
+		return h.updateSessionLastSeen(ctx, sessionID)
+	})
+
+	// Keep the media before WhatsApp expires it, when automatic download is enabled
+	h.manager.autoDownloadMedia(sessionID, evt)
+}
+
+// newMessageEventData builds the webhook payload of a message event
+func newMessageEventData(evt *events.Message) *webhook.MessageEventData {
+	msg := evt.Message
+
+	data := &webhook.MessageEventData{
+		ID:          evt.Info.ID,
+		Chat:        evt.Info.Chat.String(),
+		Sender:      evt.Info.Sender.String(),
+		FromMe:      evt.Info.IsFromMe,
+		IsGroup:     evt.Info.IsGroup,
+		PushName:    evt.Info.PushName,
+		Type:        evt.Info.Type,
+		MessageType: messageContentType(msg),
+		Timestamp:   evt.Info.Timestamp,
+		IsViewOnce:  evt.IsViewOnce,
+		IsEphemeral: evt.IsEphemeral,
+		IsEdit:      evt.IsEdit,
+	}
+
+	data.Text = msg.GetConversation()
+	if data.Text == "" {
+		data.Text = msg.GetExtendedTextMessage().GetText()
+	}
 
-	// Here you would typically:
-	// 1. Process the message
-	// 2. Send to webhooks
-	// 3. Forward to Chatwoot if configured
-	// 4. Store in database if needed
+	switch {
+	case msg.GetImageMessage() != nil:
+		data.Caption = msg.GetImageMessage().GetCaption()
+		data.MimeType = msg.GetImageMessage().GetMimetype()
+	case msg.GetVideoMessage() != nil:
+		data.Caption = msg.GetVideoMessage().GetCaption()
+		data.MimeType = msg.GetVideoMessage().GetMimetype()
+	case msg.GetAudioMessage() != nil:
+		data.MimeType = msg.GetAudioMessage().GetMimetype()
+	case msg.GetStickerMessage() != nil:
+		data.MimeType = msg.GetStickerMessage().GetMimetype()
+	case msg.GetDocumentMessage() != nil:
+		data.Caption = msg.GetDocumentMessage().GetCaption()
+		data.MimeType = msg.GetDocumentMessage().GetMimetype()
+		data.FileName = msg.GetDocumentMessage().GetFileName()
+	}
+
+	if contextInfo := messageContextInfo(msg); contextInfo != nil {
+		data.QuotedMessageID = contextInfo.GetStanzaID()
+	}
+
+	return data
+}
+
+// messageContextInfo returns the context info of the message content, which holds reply details
+func messageContextInfo(msg *waE2E.Message) *waE2E.ContextInfo {
+	switch {
+	case msg.GetExtendedTextMessage() != nil:
+		return msg.GetExtendedTextMessage().GetContextInfo()
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetContextInfo()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetContextInfo()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage().GetContextInfo()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetContextInfo()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage().GetContextInfo()
+	}
+	return nil
+}
+
+// messageContentType classifies a message by its content so webhooks can filter on it
+func messageContentType(msg *waE2E.Message) string {
+	switch {
+	case msg == nil:
+		return webhook.MessageTypeUnknown
+	case msg.GetConversation() != "", msg.GetExtendedTextMessage() != nil:
+		return webhook.MessageTypeText
+	case msg.GetImageMessage() != nil:
+		return webhook.MessageTypeImage
+	case msg.GetVideoMessage() != nil, msg.GetPtvMessage() != nil:
+		return webhook.MessageTypeVideo
+	case msg.GetAudioMessage() != nil:
+		return webhook.MessageTypeAudio
+	case msg.GetDocumentMessage() != nil, msg.GetDocumentWithCaptionMessage() != nil:
+		return webhook.MessageTypeDocument
+	case msg.GetStickerMessage() != nil:
+		return webhook.MessageTypeSticker
+	case msg.GetLocationMessage() != nil:
+		return webhook.MessageTypeLocation
+	case msg.GetLiveLocationMessage() != nil:
+		return webhook.MessageTypeLiveLocation
+	case msg.GetContactMessage() != nil, msg.GetContactsArrayMessage() != nil:
+		return webhook.MessageTypeContact
+	case msg.GetReactionMessage() != nil, msg.GetEncReactionMessage() != nil:
+		return webhook.MessageTypeReaction
+	case msg.GetPollCreationMessage() != nil, msg.GetPollCreationMessageV2() != nil, msg.GetPollCreationMessageV3() != nil:
+		return webhook.MessageTypePoll
+	case msg.GetPollUpdateMessage() != nil:
+		return webhook.MessageTypePollVote
+	case msg.GetEditedMessage() != nil:
+		return webhook.MessageTypeEdit
+	case msg.GetButtonsResponseMessage() != nil, msg.GetTemplateButtonReplyMessage() != nil:
+		return webhook.MessageTypeButtonReply
+	case msg.GetListResponseMessage() != nil:
+		return webhook.MessageTypeListReply
+	case msg.GetGroupInviteMessage() != nil:
+		return webhook.MessageTypeGroupInvite
+	case msg.GetProtocolMessage() != nil:
+		switch msg.GetProtocolMessage().GetType() {
+		case waE2E.ProtocolMessage_REVOKE:
+			return webhook.MessageTypeRevoke
+		case waE2E.ProtocolMessage_MESSAGE_EDIT:
+			return webhook.MessageTypeEdit
+		}
+		return webhook.MessageTypeProtocol
+	}
+	return webhook.MessageTypeUnknown
 }
 
 // handleReceipt handles message receipts
-func (h *EventHandler) handleReceipt(evt *events.Receipt, sessionID string) {
+func (h *EventHandler) handleReceipt(ctx context.Context, evt *events.Receipt, sessionID string) {
 	h.logger.InfoWithFields("Receipt received", map[string]interface{}{
 		"session_id": sessionID,
 		"type":       evt.Type,
 		"sender":     evt.Sender.String(),
 		"timestamp":  evt.Timestamp,
 	})
+
+	messageIDs := make([]string, 0, len(evt.MessageIDs))
+	for _, id := range evt.MessageIDs {
+		messageIDs = append(messageIDs, string(id))
+	}
+
+	newReceiptEventData := func() *webhook.ReceiptEventData {
+		return &webhook.ReceiptEventData{
+			MessageIDs: messageIDs,
+			Chat:       evt.Chat.String(),
+			Sender:     evt.Sender.String(),
+			IsGroup:    evt.IsGroup,
+			Type:       string(evt.Type),
+			Timestamp:  evt.Timestamp,
+		}
+	}
+
+	h.emitWebhookEvent(ctx, sessionID, "Receipt", newReceiptEventData())
+
+	// Read receipts are also published on their own so webhooks can skip delivery receipts
+	if evt.Type == types.ReceiptTypeRead || evt.Type == types.ReceiptTypeReadSelf {
+		var change func(ctx context.Context) error
+		if evt.Type == types.ReceiptTypeReadSelf {
+			// Messages read on another device of the account are read in the history too
+			change = func(ctx context.Context) error {
+				return h.manager.markMessagesRead(ctx, sessionID, evt.Chat.String(), messageIDs, evt.Timestamp)
+			}
+		}
+		h.emitStateChange(ctx, sessionID, "ReadReceipt", newReceiptEventData(), change)
+	}
 }
 
 // handlePresence handles presence updates
-func (h *EventHandler) handlePresence(evt *events.Presence, sessionID string) {
+func (h *EventHandler) handlePresence(ctx context.Context, evt *events.Presence, sessionID string) {
 	h.logger.InfoWithFields("Presence update", map[string]interface{}{
 		"session_id":  sessionID,
 		"from":        evt.From.String(),
 		"unavailable": evt.Unavailable,
 		"last_seen":   evt.LastSeen,
 	})
+
+	data := &webhook.PresenceEventData{
+		From:        evt.From.String(),
+		Unavailable: evt.Unavailable,
+	}
+	if !evt.LastSeen.IsZero() {
+		lastSeen := evt.LastSeen
+		data.LastSeen = &lastSeen
+	}
+
+	h.emitWebhookEvent(ctx, sessionID, "Presence", data)
 }
 
 // handleChatPresence handles chat presence updates
-func (h *EventHandler) handleChatPresence(evt *events.ChatPresence, sessionID string) {
+func (h *EventHandler) handleChatPresence(ctx context.Context, evt *events.ChatPresence, sessionID string) {
 	h.logger.InfoWithFields("Chat presence update", map[string]interface{}{
 		"session_id": sessionID,
 		"chat":       evt.Chat.String(),
 		"state":      evt.State,
 	})
+
+	h.emitWebhookEvent(ctx, sessionID, "ChatPresence", &webhook.ChatPresenceEventData{
+		Chat:    evt.Chat.String(),
+		Sender:  evt.Sender.String(),
+		IsGroup: evt.IsGroup,
+		State:   string(evt.State),
+		Media:   string(evt.Media),
+	})
 }
 
 // handleHistorySync handles history sync events
-func (h *EventHandler) handleHistorySync(evt *events.HistorySync, sessionID string) {
+func (h *EventHandler) handleHistorySync(ctx context.Context, evt *events.HistorySync, sessionID string) {
 	h.logger.InfoWithFields("History sync", map[string]interface{}{
 		"session_id": sessionID,
 		"data_size":  len(evt.Data.String()), // Just log the data size for now
 	})
+
+	h.emitWebhookEvent(ctx, sessionID, "HistorySync", &webhook.HistorySyncEventData{
+		SyncType:      evt.Data.GetSyncType().String(),
+		ChunkOrder:    int(evt.Data.GetChunkOrder()),
+		Progress:      int(evt.Data.GetProgress()),
+		Conversations: len(evt.Data.GetConversations()),
+		PushNames:     len(evt.Data.GetPushnames()),
+	})
 }
 
 // handleAppState handles app state events
-func (h *EventHandler) handleAppState(evt *events.AppState, sessionID string) {
+func (h *EventHandler) handleAppState(ctx context.Context, evt *events.AppState, sessionID string) {
 	h.logger.DebugWithFields("App state update", map[string]interface{}{
 		"session_id": sessionID,
+		"index":      evt.Index,
 	})
-	_ = evt // Avoid unused parameter warning
+
+	data := &webhook.AppStateEventData{
+		Index: evt.Index,
+	}
+	if evt.SyncActionValue != nil && evt.GetTimestamp() != 0 {
+		data.Timestamp = time.UnixMilli(evt.GetTimestamp())
+	}
+
+	h.emitWebhookEvent(ctx, sessionID, "AppState", data)
 }
 
 // handleAppStateSyncComplete handles app state sync completion
-func (h *EventHandler) handleAppStateSyncComplete(evt *events.AppStateSyncComplete, sessionID string) {
+func (h *EventHandler) handleAppStateSyncComplete(ctx context.Context, evt *events.AppStateSyncComplete, sessionID string) {
 	h.logger.DebugWithFields("App state sync complete", map[string]interface{}{
 		"session_id": sessionID,
 		"name":       evt.Name,
 	})
+
+	h.emitWebhookEvent(ctx, sessionID, "AppStateSyncComplete", &webhook.AppStateSyncCompleteEventData{
+		Name: string(evt.Name),
+	})
 }
 
 // handleKeepAliveTimeout handles keep alive timeout events
-func (h *EventHandler) handleKeepAliveTimeout(evt *events.KeepAliveTimeout, sessionID string) {
-	h.logger.DebugWithFields("Keep alive timeout", map[string]interface{}{
-		"session_id": sessionID,
+func (h *EventHandler) handleKeepAliveTimeout(ctx context.Context, evt *events.KeepAliveTimeout, sessionID string) {
+	h.logger.WarnWithFields("Keep alive timeout", map[string]interface{}{
+		"session_id":   sessionID,
+		"error_count":  evt.ErrorCount,
+		"last_success": evt.LastSuccess,
+	})
+
+	h.emitWebhookEvent(ctx, sessionID, "KeepAliveTimeout", &webhook.KeepAliveTimeoutEventData{
+		ErrorCount:  evt.ErrorCount,
+		LastSuccess: evt.LastSuccess,
 	})
-	_ = evt // Avoid unused parameter warning
 }
 
 // handleKeepAliveRestored handles keep alive restored events
-func (h *EventHandler) handleKeepAliveRestored(evt *events.KeepAliveRestored, sessionID string) {
+func (h *EventHandler) handleKeepAliveRestored(ctx context.Context, evt *events.KeepAliveRestored, sessionID string) {
 	h.logger.DebugWithFields("Keep alive restored", map[string]interface{}{
 		"session_id": sessionID,
 	})
 	_ = evt // Avoid unused parameter warning
+
+	h.emitWebhookEvent(ctx, sessionID, "KeepAliveRestored", &webhook.SessionNoticeEventData{
+		Message: "keepalive pings succeed again",
+	})
 }
 
 // handleContact handles contact events
-func (h *EventHandler) handleContact(evt *events.Contact, sessionID string) {
+func (h *EventHandler) handleContact(ctx context.Context, evt *events.Contact, sessionID string) {
 	h.logger.DebugWithFields("Contact update", map[string]interface{}{
 		"session_id": sessionID,
 		"jid":        evt.JID.String(),
@@ -277,23 +619,64 @@ func (h *EventHandler) handleContact(evt *events.Contact, sessionID string) {
 }
 
 // handleGroupInfo handles group info events
-func (h *EventHandler) handleGroupInfo(evt *events.GroupInfo, sessionID string) {
+func (h *EventHandler) handleGroupInfo(ctx context.Context, evt *events.GroupInfo, sessionID string) {
 	h.logger.DebugWithFields("Group info update", map[string]interface{}{
 		"session_id": sessionID,
 		"jid":        evt.JID.String(),
 	})
+
+	data := &webhook.GroupInfoEventData{
+		JID:       evt.JID.String(),
+		Timestamp: evt.Timestamp,
+		Join:      jidStrings(evt.Join),
+		Leave:     jidStrings(evt.Leave),
+		Promote:   jidStrings(evt.Promote),
+		Demote:    jidStrings(evt.Demote),
+	}
+	if evt.Sender != nil {
+		data.Sender = evt.Sender.String()
+	}
+	if evt.Name != nil {
+		data.Name = evt.Name.Name
+	}
+	if evt.Topic != nil {
+		data.Topic = evt.Topic.Topic
+	}
+	if evt.Locked != nil {
+		data.Locked = &evt.Locked.IsLocked
+	}
+	if evt.Announce != nil {
+		data.Announce = &evt.Announce.IsAnnounce
+	}
+	if evt.Ephemeral != nil {
+		data.Ephemeral = &evt.Ephemeral.IsEphemeral
+	}
+
+	h.emitWebhookEvent(ctx, sessionID, "GroupInfo", data)
 }
 
 // handlePicture handles picture events
-func (h *EventHandler) handlePicture(evt *events.Picture, sessionID string) {
+func (h *EventHandler) handlePicture(ctx context.Context, evt *events.Picture, sessionID string) {
 	h.logger.DebugWithFields("Picture update", map[string]interface{}{
 		"session_id": sessionID,
 		"jid":        evt.JID.String(),
 	})
+
+	data := &webhook.PictureEventData{
+		JID:       evt.JID.String(),
+		Timestamp: evt.Timestamp,
+		Remove:    evt.Remove,
+		PictureID: evt.PictureID,
+	}
+	if !evt.Author.IsEmpty() {
+		data.Author = evt.Author.String()
+	}
+
+	h.emitWebhookEvent(ctx, sessionID, "Picture", data)
 }
 
 // handleBusinessName handles business name events
-func (h *EventHandler) handleBusinessName(evt *events.BusinessName, sessionID string) {
+func (h *EventHandler) handleBusinessName(ctx context.Context, evt *events.BusinessName, sessionID string) {
 	h.logger.DebugWithFields("Business name update", map[string]interface{}{
 		"session_id": sessionID,
 		"jid":        evt.JID.String(),
@@ -301,7 +684,7 @@ func (h *EventHandler) handleBusinessName(evt *events.BusinessName, sessionID st
 }
 
 // handlePushName handles push name events
-func (h *EventHandler) handlePushName(evt *events.PushName, sessionID string) {
+func (h *EventHandler) handlePushName(ctx context.Context, evt *events.PushName, sessionID string) {
 	h.logger.DebugWithFields("Push name update", map[string]interface{}{
 		"session_id": sessionID,
 		"jid":        evt.JID.String(),
@@ -309,7 +692,7 @@ func (h *EventHandler) handlePushName(evt *events.PushName, sessionID string) {
 }
 
 // handleArchive handles archive events
-func (h *EventHandler) handleArchive(evt *events.Archive, sessionID string) {
+func (h *EventHandler) handleArchive(ctx context.Context, evt *events.Archive, sessionID string) {
 	h.logger.DebugWithFields("Archive update", map[string]interface{}{
 		"session_id": sessionID,
 		"jid":        evt.JID.String(),
@@ -317,7 +700,7 @@ func (h *EventHandler) handleArchive(evt *events.Archive, sessionID string) {
 }
 
 // handlePin handles pin events
-func (h *EventHandler) handlePin(evt *events.Pin, sessionID string) {
+func (h *EventHandler) handlePin(ctx context.Context, evt *events.Pin, sessionID string) {
 	h.logger.DebugWithFields("Pin update", map[string]interface{}{
 		"session_id": sessionID,
 		"jid":        evt.JID.String(),
@@ -325,7 +708,7 @@ func (h *EventHandler) handlePin(evt *events.Pin, sessionID string) {
 }
 
 // handleMute handles mute events
-func (h *EventHandler) handleMute(evt *events.Mute, sessionID string) {
+func (h *EventHandler) handleMute(ctx context.Context, evt *events.Mute, sessionID string) {
 	h.logger.DebugWithFields("Mute update", map[string]interface{}{
 		"session_id": sessionID,
 		"jid":        evt.JID.String(),
@@ -333,7 +716,7 @@ func (h *EventHandler) handleMute(evt *events.Mute, sessionID string) {
 }
 
 // handleStar handles star events
-func (h *EventHandler) handleStar(evt *events.Star, sessionID string) {
+func (h *EventHandler) handleStar(ctx context.Context, evt *events.Star, sessionID string) {
 	h.logger.DebugWithFields("Star update", map[string]interface{}{
 		"session_id": sessionID,
 	})
@@ -341,7 +724,7 @@ func (h *EventHandler) handleStar(evt *events.Star, sessionID string) {
 }
 
 // handleDeleteForMe handles delete for me events
-func (h *EventHandler) handleDeleteForMe(evt *events.DeleteForMe, sessionID string) {
+func (h *EventHandler) handleDeleteForMe(ctx context.Context, evt *events.DeleteForMe, sessionID string) {
 	h.logger.DebugWithFields("Delete for me", map[string]interface{}{
 		"session_id": sessionID,
 		"chat":       evt.ChatJID.String(),
@@ -349,148 +732,669 @@ func (h *EventHandler) handleDeleteForMe(evt *events.DeleteForMe, sessionID stri
 }
 
 // handleMarkChatAsRead handles mark chat as read events
-func (h *EventHandler) handleMarkChatAsRead(evt *events.MarkChatAsRead, sessionID string) {
+func (h *EventHandler) handleMarkChatAsRead(ctx context.Context, evt *events.MarkChatAsRead, sessionID string) {
 	h.logger.DebugWithFields("Mark chat as read", map[string]interface{}{
 		"session_id": sessionID,
 		"chat":       evt.JID.String(),
 	})
+
+	if !evt.Action.GetRead() {
+		return
+	}
+
+	if err := h.manager.markChatRead(ctx, sessionID, evt.JID.String(), evt.Timestamp); err != nil {
+		h.logger.WarnWithFields("Failed to mark chat as read", map[string]interface{}{
+			"session_id": sessionID,
+			"chat":       evt.JID.String(),
+			"error":      err.Error(),
+		})
+	}
 }
 
 // handleUndecryptableMessage handles undecryptable message events
-func (h *EventHandler) handleUndecryptableMessage(evt *events.UndecryptableMessage, sessionID string) {
+func (h *EventHandler) handleUndecryptableMessage(ctx context.Context, evt *events.UndecryptableMessage, sessionID string) {
 	h.logger.DebugWithFields("Undecryptable message", map[string]interface{}{
 		"session_id": sessionID,
 		"from":       evt.Info.Sender.String(),
 	})
+
+	h.emitWebhookEvent(ctx, sessionID, "UndecryptableMessage", &webhook.UndecryptableMessageEventData{
+		ID:              evt.Info.ID,
+		Chat:            evt.Info.Chat.String(),
+		Sender:          evt.Info.Sender.String(),
+		FromMe:          evt.Info.IsFromMe,
+		IsGroup:         evt.Info.IsGroup,
+		Timestamp:       evt.Info.Timestamp,
+		IsUnavailable:   evt.IsUnavailable,
+		UnavailableType: string(evt.UnavailableType),
+		DecryptFailMode: string(evt.DecryptFailMode),
+	})
 }
 
 // handleOfflineSyncPreview handles offline sync preview events
-func (h *EventHandler) handleOfflineSyncPreview(evt *events.OfflineSyncPreview, sessionID string) {
+func (h *EventHandler) handleOfflineSyncPreview(ctx context.Context, evt *events.OfflineSyncPreview, sessionID string) {
 	h.logger.DebugWithFields("Offline sync preview", map[string]interface{}{
 		"session_id": sessionID,
 		"messages":   evt.Messages,
 	})
+
+	h.emitWebhookEvent(ctx, sessionID, "OfflineSyncPreview", &webhook.OfflineSyncPreviewEventData{
+		Total:          evt.Total,
+		AppDataChanges: evt.AppDataChanges,
+		Messages:       evt.Messages,
+		Notifications:  evt.Notifications,
+		Receipts:       evt.Receipts,
+	})
 }
 
 // handleOfflineSyncCompleted handles offline sync completed events
-func (h *EventHandler) handleOfflineSyncCompleted(evt *events.OfflineSyncCompleted, sessionID string) {
+func (h *EventHandler) handleOfflineSyncCompleted(ctx context.Context, evt *events.OfflineSyncCompleted, sessionID string) {
 	h.logger.DebugWithFields("Offline sync completed", map[string]interface{}{
 		"session_id": sessionID,
 		"count":      evt.Count,
 	})
+
+	h.emitWebhookEvent(ctx, sessionID, "OfflineSyncCompleted", &webhook.OfflineSyncCompletedEventData{
+		Count: evt.Count,
+	})
 }
 
-// updateSessionQRCode updates the QR code for a session
-func (h *EventHandler) updateSessionQRCode(sessionID, qrCode string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// handleMediaRetry handles answers to media re-upload requests
+func (h *EventHandler) handleMediaRetry(ctx context.Context, evt *events.MediaRetry, sessionID string) {
+	h.logger.DebugWithFields("Media retry", map[string]interface{}{
+		"session_id": sessionID,
+		"message_id": evt.MessageID,
+	})
 
-	sess, err := h.sessionMgr.sessionRepo.GetByID(ctx, sessionID)
-	if err != nil {
-		h.logger.ErrorWithFields("Failed to get session for QR update", map[string]interface{}{
-			"session_id": sessionID,
-			"error":      err.Error(),
-		})
-		return
+	data := &webhook.MediaRetryEventData{
+		MessageID: evt.MessageID,
+		Chat:      evt.ChatID.String(),
+		FromMe:    evt.FromMe,
+		Timestamp: evt.Timestamp,
+	}
+	if !evt.SenderID.IsEmpty() {
+		data.Sender = evt.SenderID.String()
+	}
+	if evt.Error != nil {
+		data.ErrorCode = evt.Error.Code
 	}
 
-	// Update QR code
-	sess.QRCode = qrCode
-	sess.UpdatedAt = time.Now()
+	h.emitWebhookEvent(ctx, sessionID, "MediaRetry", data)
+}
 
-	if err := h.sessionMgr.sessionRepo.Update(ctx, sess); err != nil {
-		h.logger.ErrorWithFields("Failed to update session QR code", map[string]interface{}{
-			"session_id": sessionID,
-			"error":      err.Error(),
-		})
+// handleJoinedGroup handles events for groups the account joined or was added to
+func (h *EventHandler) handleJoinedGroup(ctx context.Context, evt *events.JoinedGroup, sessionID string) {
+	h.logger.InfoWithFields("Joined group", map[string]interface{}{
+		"session_id": sessionID,
+		"jid":        evt.JID.String(),
+		"reason":     evt.Reason,
+	})
+
+	participants := make([]string, 0, len(evt.Participants))
+	for _, participant := range evt.Participants {
+		participants = append(participants, participant.JID.String())
+	}
+
+	data := &webhook.JoinedGroupEventData{
+		JID:          evt.JID.String(),
+		Name:         evt.Name,
+		Topic:        evt.Topic,
+		Reason:       evt.Reason,
+		Type:         evt.Type,
+		CreatedAt:    evt.GroupCreated,
+		Participants: participants,
+	}
+	if !evt.OwnerJID.IsEmpty() {
+		data.Owner = evt.OwnerJID.String()
+	}
+	if evt.Sender != nil {
+		data.Sender = evt.Sender.String()
 	}
+
+	h.emitWebhookEvent(ctx, sessionID, "JoinedGroup", data)
 }
 
-// updateSessionDeviceJID updates the device JID for a session
-func (h *EventHandler) updateSessionDeviceJID(sessionID, deviceJID string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// handleBlocklist handles blocklist updates, emitting one BlocklistChange per contact
+func (h *EventHandler) handleBlocklist(ctx context.Context, evt *events.Blocklist, sessionID string) {
+	h.logger.DebugWithFields("Blocklist update", map[string]interface{}{
+		"session_id": sessionID,
+		"action":     evt.Action,
+		"changes":    len(evt.Changes),
+	})
 
-	sess, err := h.sessionMgr.sessionRepo.GetByID(ctx, sessionID)
-	if err != nil {
-		h.logger.ErrorWithFields("Failed to get session for device JID update", map[string]interface{}{
-			"session_id": sessionID,
-			"error":      err.Error(),
+	changes := make([]webhook.BlocklistChangeEventData, 0, len(evt.Changes))
+	for _, change := range evt.Changes {
+		changes = append(changes, webhook.BlocklistChangeEventData{
+			JID:    change.JID.String(),
+			Action: string(change.Action),
 		})
-		return
 	}
 
-	sess.DeviceJid = deviceJID
-	sess.UpdatedAt = time.Now()
+	h.emitWebhookEvent(ctx, sessionID, "Blocklist", &webhook.BlocklistEventData{
+		Action:  string(evt.Action),
+		Changes: changes,
+	})
 
-	if err := h.sessionMgr.sessionRepo.Update(ctx, sess); err != nil {
-		h.logger.ErrorWithFields("Failed to update session device JID", map[string]interface{}{
-			"session_id": sessionID,
-			"error":      err.Error(),
+	for i := range changes {
+		change := changes[i]
+		h.emitWebhookEvent(ctx, sessionID, "BlocklistChange", &change)
+	}
+}
+
+// handleConnectFailure handles connections refused by WhatsApp
+func (h *EventHandler) handleConnectFailure(ctx context.Context, evt *events.ConnectFailure, sessionID string) {
+	h.logger.ErrorWithFields("Connection refused by WhatsApp", map[string]interface{}{
+		"session_id": sessionID,
+		"reason":     evt.Reason.String(),
+		"message":    evt.Message,
+	})
+
+	h.emitStateChange(ctx, sessionID, "ConnectFailure", &webhook.ConnectFailureEventData{
+		Code:    int(evt.Reason),
+		Reason:  evt.Reason.String(),
+		Message: evt.Message,
+	}, func(ctx context.Context) error {
+		return h.sessionMgr.setConnectionError(ctx, sessionID, fmt.Sprintf("connect failure: %s", evt.Reason.String()))
+	})
+}
+
+// handleClientOutdated handles rejections of the client version
+func (h *EventHandler) handleClientOutdated(ctx context.Context, evt *events.ClientOutdated, sessionID string) {
+	const message = "client version rejected by WhatsApp as outdated"
+
+	h.logger.ErrorWithFields("Client outdated", map[string]interface{}{
+		"session_id": sessionID,
+	})
+	_ = evt // Avoid unused parameter warning
+
+	h.emitStateChange(ctx, sessionID, "ClientOutdated", &webhook.SessionNoticeEventData{
+		Message: message,
+	}, func(ctx context.Context) error {
+		return h.sessionMgr.setConnectionError(ctx, sessionID, message)
+	})
+}
+
+// handleTemporaryBan handles temporary bans of the account
+func (h *EventHandler) handleTemporaryBan(ctx context.Context, evt *events.TemporaryBan, sessionID string) {
+	h.logger.ErrorWithFields("Account temporarily banned", map[string]interface{}{
+		"session_id": sessionID,
+		"reason":     evt.Code.String(),
+		"expire":     evt.Expire.String(),
+	})
+
+	data := &webhook.TemporaryBanEventData{
+		Code:             int(evt.Code),
+		Reason:           evt.Code.String(),
+		ExpiresInSeconds: int64(evt.Expire.Seconds()),
+	}
+	if evt.Expire > 0 {
+		expiresAt := time.Now().Add(evt.Expire)
+		data.ExpiresAt = &expiresAt
+	}
+
+	h.emitStateChange(ctx, sessionID, "TemporaryBan", data, func(ctx context.Context) error {
+		return h.sessionMgr.setConnectionError(ctx, sessionID, evt.String())
+	})
+}
+
+// handleStreamError handles unknown stream errors
+func (h *EventHandler) handleStreamError(ctx context.Context, evt *events.StreamError, sessionID string) {
+	h.logger.ErrorWithFields("Stream error", map[string]interface{}{
+		"session_id": sessionID,
+		"code":       evt.Code,
+	})
+
+	h.emitWebhookEvent(ctx, sessionID, "StreamError", &webhook.StreamErrorEventData{
+		Code: evt.Code,
+	})
+}
+
+// handleStreamReplaced handles another client taking over the session
+func (h *EventHandler) handleStreamReplaced(ctx context.Context, evt *events.StreamReplaced, sessionID string) {
+	const message = "stream replaced: another client connected with the same session"
+
+	h.logger.WarnWithFields("Stream replaced", map[string]interface{}{
+		"session_id": sessionID,
+	})
+	_ = evt // Avoid unused parameter warning
+
+	// WhatsApp does not reconnect after a replaced stream, so the session stays down
+	h.emitStateChange(ctx, sessionID, "StreamReplaced", &webhook.SessionNoticeEventData{
+		Message: message,
+	}, func(ctx context.Context) error {
+		return h.sessionMgr.setConnectionError(ctx, sessionID, message)
+	})
+}
+
+// handleQRScannedWithoutMultidevice handles QR codes scanned by phones without multi-device
+func (h *EventHandler) handleQRScannedWithoutMultidevice(ctx context.Context, evt *events.QRScannedWithoutMultidevice, sessionID string) {
+	h.logger.WarnWithFields("QR code scanned without multi-device", map[string]interface{}{
+		"session_id": sessionID,
+	})
+	_ = evt // Avoid unused parameter warning
+
+	h.emitWebhookEvent(ctx, sessionID, "QRScannedWithoutMultidevice", &webhook.SessionNoticeEventData{
+		Message: "QR code scanned by a phone without multi-device enabled",
+	})
+}
+
+// handlePrivacySettings handles privacy settings changes
+func (h *EventHandler) handlePrivacySettings(ctx context.Context, evt *events.PrivacySettings, sessionID string) {
+	h.logger.DebugWithFields("Privacy settings update", map[string]interface{}{
+		"session_id": sessionID,
+	})
+
+	settings := evt.NewSettings
+	changed := []string{}
+	for name, isChanged := range map[string]bool{
+		"group_add":     evt.GroupAddChanged,
+		"last_seen":     evt.LastSeenChanged,
+		"status":        evt.StatusChanged,
+		"profile":       evt.ProfileChanged,
+		"read_receipts": evt.ReadReceiptsChanged,
+		"call_add":      evt.CallAddChanged,
+		"online":        evt.OnlineChanged,
+	} {
+		if isChanged {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+
+	h.emitWebhookEvent(ctx, sessionID, "PrivacySettings", &webhook.PrivacySettingsEventData{
+		GroupAdd:     string(settings.GroupAdd),
+		LastSeen:     string(settings.LastSeen),
+		Status:       string(settings.Status),
+		Profile:      string(settings.Profile),
+		ReadReceipts: string(settings.ReadReceipts),
+		CallAdd:      string(settings.CallAdd),
+		Online:       string(settings.Online),
+		Changed:      changed,
+	})
+}
+
+// handlePushNameSetting handles changes of the account display name
+func (h *EventHandler) handlePushNameSetting(ctx context.Context, evt *events.PushNameSetting, sessionID string) {
+	h.logger.DebugWithFields("Push name setting update", map[string]interface{}{
+		"session_id": sessionID,
+	})
+
+	h.emitWebhookEvent(ctx, sessionID, "PushNameSetting", &webhook.PushNameSettingEventData{
+		Name:      evt.Action.GetName(),
+		Timestamp: evt.Timestamp,
+	})
+}
+
+// handleUserAbout handles about text changes of contacts
+func (h *EventHandler) handleUserAbout(ctx context.Context, evt *events.UserAbout, sessionID string) {
+	h.logger.DebugWithFields("User about update", map[string]interface{}{
+		"session_id": sessionID,
+		"jid":        evt.JID.String(),
+	})
+
+	h.emitWebhookEvent(ctx, sessionID, "UserAbout", &webhook.UserAboutEventData{
+		JID:       evt.JID.String(),
+		Status:    evt.Status,
+		Timestamp: evt.Timestamp,
+	})
+}
+
+// handleCallOffer handles incoming calls
+func (h *EventHandler) handleCallOffer(ctx context.Context, evt *events.CallOffer, sessionID string) {
+	h.logger.InfoWithFields("Call offer received", map[string]interface{}{
+		"session_id": sessionID,
+		"call_id":    evt.CallID,
+		"from":       evt.From.String(),
+	})
+
+	data := newCallEventData(evt.BasicCallMeta)
+	data.RemotePlatform = evt.RemotePlatform
+	data.RemoteVersion = evt.RemoteVersion
+
+	h.emitWebhookEvent(ctx, sessionID, "CallOffer", data)
+}
+
+// handleCallAccept handles accepted calls
+func (h *EventHandler) handleCallAccept(ctx context.Context, evt *events.CallAccept, sessionID string) {
+	h.logger.InfoWithFields("Call accepted", map[string]interface{}{
+		"session_id": sessionID,
+		"call_id":    evt.CallID,
+	})
+
+	data := newCallEventData(evt.BasicCallMeta)
+	data.RemotePlatform = evt.RemotePlatform
+	data.RemoteVersion = evt.RemoteVersion
+
+	h.emitWebhookEvent(ctx, sessionID, "CallAccept", data)
+}
+
+// handleCallTerminate handles ended calls
+func (h *EventHandler) handleCallTerminate(ctx context.Context, evt *events.CallTerminate, sessionID string) {
+	h.logger.InfoWithFields("Call terminated", map[string]interface{}{
+		"session_id": sessionID,
+		"call_id":    evt.CallID,
+		"reason":     evt.Reason,
+	})
+
+	data := newCallEventData(evt.BasicCallMeta)
+	data.Reason = evt.Reason
+
+	h.emitWebhookEvent(ctx, sessionID, "CallTerminate", data)
+}
+
+// handleCallOfferNotice handles incoming group calls
+func (h *EventHandler) handleCallOfferNotice(ctx context.Context, evt *events.CallOfferNotice, sessionID string) {
+	h.logger.InfoWithFields("Call offer notice received", map[string]interface{}{
+		"session_id": sessionID,
+		"call_id":    evt.CallID,
+		"from":       evt.From.String(),
+	})
+
+	data := newCallEventData(evt.BasicCallMeta)
+	data.Media = evt.Media
+	data.IsGroup = data.IsGroup || evt.Type == "group"
+
+	h.emitWebhookEvent(ctx, sessionID, "CallOfferNotice", data)
+}
+
+// handleCallRelayLatency handles relay latency reports of calls
+func (h *EventHandler) handleCallRelayLatency(ctx context.Context, evt *events.CallRelayLatency, sessionID string) {
+	h.logger.DebugWithFields("Call relay latency", map[string]interface{}{
+		"session_id": sessionID,
+		"call_id":    evt.CallID,
+	})
+
+	h.emitWebhookEvent(ctx, sessionID, "CallRelayLatency", newCallEventData(evt.BasicCallMeta))
+}
+
+// newCallEventData builds the webhook payload fields shared by every call event
+func newCallEventData(meta types.BasicCallMeta) *webhook.CallEventData {
+	data := &webhook.CallEventData{
+		CallID:    meta.CallID,
+		From:      meta.From.String(),
+		Creator:   meta.CallCreator.String(),
+		Timestamp: meta.Timestamp,
+	}
+	if !meta.GroupJID.IsEmpty() {
+		data.IsGroup = true
+		data.GroupJID = meta.GroupJID.String()
+	}
+	return data
+}
+
+// handleIdentityChange handles encryption identity changes of contacts
+func (h *EventHandler) handleIdentityChange(ctx context.Context, evt *events.IdentityChange, sessionID string) {
+	h.logger.InfoWithFields("Identity changed", map[string]interface{}{
+		"session_id": sessionID,
+		"jid":        evt.JID.String(),
+		"implicit":   evt.Implicit,
+	})
+
+	h.emitWebhookEvent(ctx, sessionID, "IdentityChange", &webhook.IdentityChangeEventData{
+		JID:       evt.JID.String(),
+		Timestamp: evt.Timestamp,
+		Implicit:  evt.Implicit,
+	})
+}
+
+// handleCATRefreshError handles failures to refresh the client access token
+func (h *EventHandler) handleCATRefreshError(ctx context.Context, evt *events.CATRefreshError, sessionID string) {
+	message := "client access token refresh failed"
+	if evt.Error != nil {
+		message = fmt.Sprintf("%s: %s", message, evt.Error.Error())
+	}
+
+	h.logger.ErrorWithFields("CAT refresh error", map[string]interface{}{
+		"session_id": sessionID,
+		"error":      message,
+	})
+
+	h.emitStateChange(ctx, sessionID, "CATRefreshError", &webhook.SessionNoticeEventData{
+		Message: message,
+	}, func(ctx context.Context) error {
+		return h.sessionMgr.setConnectionError(ctx, sessionID, message)
+	})
+}
+
+// handleNewsletterJoin handles channels the account joined
+func (h *EventHandler) handleNewsletterJoin(ctx context.Context, evt *events.NewsletterJoin, sessionID string) {
+	h.logger.DebugWithFields("Newsletter joined", map[string]interface{}{
+		"session_id": sessionID,
+		"id":         evt.ID.String(),
+	})
+
+	data := &webhook.NewsletterEventData{
+		ID:   evt.ID.String(),
+		Name: evt.ThreadMeta.Name.Text,
+	}
+	if evt.ViewerMeta != nil {
+		data.Role = string(evt.ViewerMeta.Role)
+		data.Mute = string(evt.ViewerMeta.Mute)
+	}
+
+	h.emitWebhookEvent(ctx, sessionID, "NewsletterJoin", data)
+}
+
+// handleNewsletterLeave handles channels the account left
+func (h *EventHandler) handleNewsletterLeave(ctx context.Context, evt *events.NewsletterLeave, sessionID string) {
+	h.logger.DebugWithFields("Newsletter left", map[string]interface{}{
+		"session_id": sessionID,
+		"id":         evt.ID.String(),
+	})
+
+	h.emitWebhookEvent(ctx, sessionID, "NewsletterLeave", &webhook.NewsletterEventData{
+		ID:   evt.ID.String(),
+		Role: string(evt.Role),
+	})
+}
+
+// handleNewsletterMuteChange handles channels being muted or unmuted
+func (h *EventHandler) handleNewsletterMuteChange(ctx context.Context, evt *events.NewsletterMuteChange, sessionID string) {
+	h.logger.DebugWithFields("Newsletter mute change", map[string]interface{}{
+		"session_id": sessionID,
+		"id":         evt.ID.String(),
+		"mute":       evt.Mute,
+	})
+
+	h.emitWebhookEvent(ctx, sessionID, "NewsletterMuteChange", &webhook.NewsletterEventData{
+		ID:   evt.ID.String(),
+		Mute: string(evt.Mute),
+	})
+}
+
+// handleNewsletterLiveUpdate handles new messages and reactions in channels
+func (h *EventHandler) handleNewsletterLiveUpdate(ctx context.Context, evt *events.NewsletterLiveUpdate, sessionID string) {
+	h.logger.DebugWithFields("Newsletter live update", map[string]interface{}{
+		"session_id": sessionID,
+		"id":         evt.JID.String(),
+		"messages":   len(evt.Messages),
+	})
+
+	messages := make([]webhook.NewsletterMessageData, 0, len(evt.Messages))
+	for _, msg := range evt.Messages {
+		messages = append(messages, webhook.NewsletterMessageData{
+			ID:        msg.MessageID,
+			ServerID:  msg.MessageServerID,
+			Type:      msg.Type,
+			Timestamp: msg.Timestamp,
+			Views:     msg.ViewsCount,
+			Reactions: msg.ReactionCounts,
 		})
 	}
+
+	h.emitWebhookEvent(ctx, sessionID, "NewsletterLiveUpdate", &webhook.NewsletterLiveUpdateEventData{
+		ID:        evt.JID.String(),
+		Timestamp: evt.Time,
+		Messages:  messages,
+	})
 }
 
-// updateSessionLastSeen updates the last seen timestamp for a session
-func (h *EventHandler) updateSessionLastSeen(sessionID string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// handleFBMessage handles messages received through the Meta bridge
+func (h *EventHandler) handleFBMessage(ctx context.Context, evt *events.FBMessage, sessionID string) {
+	h.logger.InfoWithFields("FB message received", map[string]interface{}{
+		"session_id": sessionID,
+		"from":       evt.Info.Sender.String(),
+		"message_id": evt.Info.ID,
+	})
+
+	h.emitWebhookEvent(ctx, sessionID, "FBMessage", &webhook.FBMessageEventData{
+		ID:         evt.Info.ID,
+		Chat:       evt.Info.Chat.String(),
+		Sender:     evt.Info.Sender.String(),
+		FromMe:     evt.Info.IsFromMe,
+		IsGroup:    evt.Info.IsGroup,
+		Timestamp:  evt.Info.Timestamp,
+		RetryCount: evt.RetryCount,
+	})
+}
+
+// jidStrings converts a list of JIDs to their string form
+func jidStrings(jids []types.JID) []string {
+	if len(jids) == 0 {
+		return nil
+	}
+	values := make([]string, 0, len(jids))
+	for _, jid := range jids {
+		values = append(values, jid.String())
+	}
+	return values
+}
+
+// emitWebhookEvent forwards a Wameow event to the webhook handler.
+// It runs on the session event queue worker of the chat, so events of a chat
+// are recorded in order.
+func (h *EventHandler) emitWebhookEvent(ctx context.Context, sessionID, eventType string, data interface{}) {
+	h.emitStateChange(ctx, sessionID, eventType, data, nil)
+}
+
+// emitStateChange applies a change to the stored session and records the event
+// describing it in one transaction, so a crash cannot keep one without the other.
+// If the change fails the event is still recorded on its own, since it did happen.
+func (h *EventHandler) emitStateChange(ctx context.Context, sessionID, eventType string, data interface{}, change func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookProcessTimeout)
 	defer cancel()
 
-	sess, err := h.sessionMgr.sessionRepo.GetByID(ctx, sessionID)
-	if err != nil {
-		h.logger.ErrorWithFields("Failed to get session for last seen update", map[string]interface{}{
-			"session_id": sessionID,
-			"error":      err.Error(),
-		})
+	handler := h.manager.getWebhookHandler()
+	if handler == nil {
+		if change != nil {
+			if err := change(ctx); err != nil {
+				h.logger.ErrorWithFields("Failed to update session", map[string]interface{}{
+					"session_id": sessionID,
+					"event_type": eventType,
+					"error":      err.Error(),
+				})
+			}
+		}
 		return
 	}
 
-	now := time.Now()
-	sess.LastSeen = &now
-	sess.UpdatedAt = now
+	event := webhook.NewWebhookEvent(sessionID, eventType, data)
+	event.IsOfflineSync = isOfflineSync(ctx)
+
+	// Tag the event with the session name so global subscribers can tell sessions apart
+	event.SessionName = h.sessionName(ctx, sessionID)
 
-	if err := h.sessionMgr.sessionRepo.Update(ctx, sess); err != nil {
-		h.logger.ErrorWithFields("Failed to update session last seen", map[string]interface{}{
+	var changeErr error
+	record := func(ctx context.Context) error {
+		if change != nil {
+			if changeErr = change(ctx); changeErr != nil {
+				return changeErr
+			}
+		}
+		return handler.ProcessWebhookEvent(ctx, event)
+	}
+
+	var err error
+	if transactor := h.manager.getEventTransactor(); transactor != nil {
+		err = transactor.WithTransaction(ctx, record)
+	} else {
+		err = record(ctx)
+	}
+
+	if changeErr != nil {
+		h.logger.ErrorWithFields("Failed to update session", map[string]interface{}{
 			"session_id": sessionID,
+			"event_id":   event.ID,
+			"event_type": eventType,
+			"error":      changeErr.Error(),
+		})
+		err = handler.ProcessWebhookEvent(ctx, event)
+	}
+
+	if err != nil {
+		h.logger.ErrorWithFields("Failed to process webhook event", map[string]interface{}{
+			"session_id": sessionID,
+			"event_id":   event.ID,
+			"event_type": eventType,
 			"error":      err.Error(),
 		})
 	}
 }
 
-// clearSessionQRCode clears the QR code for a session after successful connection
-func (h *EventHandler) clearSessionQRCode(sessionID string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// sessionName returns the name of a session, caching it after the first lookup
+func (h *EventHandler) sessionName(ctx context.Context, sessionID string) string {
+	if name, ok := h.sessionNames.Load(sessionID); ok {
+		return name.(string)
+	}
 
 	sess, err := h.sessionMgr.sessionRepo.GetByID(ctx, sessionID)
 	if err != nil {
-		h.logger.ErrorWithFields("Failed to get session for QR code clearing", map[string]interface{}{
+		h.logger.WarnWithFields("Failed to get session name for webhook event", map[string]interface{}{
 			"session_id": sessionID,
 			"error":      err.Error(),
 		})
-		return
+		return ""
 	}
 
-	// Clear QR code and expiration
-	sess.QRCode = ""
-	sess.QRCodeExpiresAt = nil
-	sess.UpdatedAt = time.Now()
+	h.sessionNames.Store(sessionID, sess.Name)
+	return sess.Name
+}
 
-	if err := h.sessionMgr.sessionRepo.Update(ctx, sess); err != nil {
-		h.logger.ErrorWithFields("Failed to clear session QR code", map[string]interface{}{
-			"session_id": sessionID,
-			"error":      err.Error(),
-		})
-	} else {
-		h.logger.InfoWithFields("QR code cleared after successful connection", map[string]interface{}{
-			"session_id": sessionID,
-		})
+// updateSessionQRCode updates the QR code for a session and when it expires
+func (h *EventHandler) updateSessionQRCode(ctx context.Context, sessionID, qrCode string, expiresAt *time.Time) error {
+	if err := h.sessionMgr.sessionRepo.UpdateQRCode(ctx, sessionID, qrCode, expiresAt); err != nil {
+		return fmt.Errorf("failed to update session QR code: %w", err)
+	}
+
+	return nil
+}
+
+// updateSessionDeviceJID updates the device JID for a session
+func (h *EventHandler) updateSessionDeviceJID(ctx context.Context, sessionID, deviceJID string) error {
+	if err := h.sessionMgr.sessionRepo.UpdateDeviceJid(ctx, sessionID, deviceJID); err != nil {
+		return fmt.Errorf("failed to update session device JID: %w", err)
+	}
+
+	return nil
+}
+
+// updateSessionLastSeen updates the last seen timestamp for a session
+func (h *EventHandler) updateSessionLastSeen(ctx context.Context, sessionID string) error {
+	if err := h.sessionMgr.sessionRepo.UpdateLastSeen(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to update session last seen: %w", err)
+	}
+
+	return nil
+}
+
+// clearSessionQRCode clears the QR code for a session once it can no longer be scanned
+func (h *EventHandler) clearSessionQRCode(ctx context.Context, sessionID string) error {
+	if err := h.sessionMgr.sessionRepo.UpdateQRCode(ctx, sessionID, "", nil); err != nil {
+		return fmt.Errorf("failed to clear session QR code: %w", err)
 	}
+
+	h.logger.InfoWithFields("QR code cleared", map[string]interface{}{
+		"session_id": sessionID,
+	})
+
+	return nil
 }
 
 // getEventType returns the type name of an event
 func getEventType(evt interface{}) string {
-	switch evt.(type) {
+	switch e := evt.(type) {
+	case *OfflineSyncEvent:
+		return getEventType(e.Event)
 	case *events.Connected:
 		return "Connected"
 	case *events.Disconnected:
@@ -549,6 +1453,56 @@ func getEventType(evt interface{}) string {
 		return "OfflineSyncPreview"
 	case *events.OfflineSyncCompleted:
 		return "OfflineSyncCompleted"
+	case *events.MediaRetry:
+		return "MediaRetry"
+	case *events.JoinedGroup:
+		return "JoinedGroup"
+	case *events.Blocklist:
+		return "Blocklist"
+	case *events.ConnectFailure:
+		return "ConnectFailure"
+	case *events.ClientOutdated:
+		return "ClientOutdated"
+	case *events.TemporaryBan:
+		return "TemporaryBan"
+	case *events.StreamError:
+		return "StreamError"
+	case *events.StreamReplaced:
+		return "StreamReplaced"
+	case *events.QRScannedWithoutMultidevice:
+		return "QRScannedWithoutMultidevice"
+	case *QRChannelEvent:
+		return "QRChannel"
+	case *events.PrivacySettings:
+		return "PrivacySettings"
+	case *events.PushNameSetting:
+		return "PushNameSetting"
+	case *events.UserAbout:
+		return "UserAbout"
+	case *events.CallOffer:
+		return "CallOffer"
+	case *events.CallAccept:
+		return "CallAccept"
+	case *events.CallTerminate:
+		return "CallTerminate"
+	case *events.CallOfferNotice:
+		return "CallOfferNotice"
+	case *events.CallRelayLatency:
+		return "CallRelayLatency"
+	case *events.IdentityChange:
+		return "IdentityChange"
+	case *events.CATRefreshError:
+		return "CATRefreshError"
+	case *events.NewsletterJoin:
+		return "NewsletterJoin"
+	case *events.NewsletterLeave:
+		return "NewsletterLeave"
+	case *events.NewsletterMuteChange:
+		return "NewsletterMuteChange"
+	case *events.NewsletterLiveUpdate:
+		return "NewsletterLiveUpdate"
+	case *events.FBMessage:
+		return "FBMessage"
 	default:
 		return "Unknown"
 	}
@@ -55,8 +55,9 @@ func (w *WameowLogger) Sub(module string) waLog.Logger {
 
 // Factory creates and configures Wameow manager components
 type Factory struct {
-	logger      *logger.Logger
-	sessionRepo ports.SessionRepository
+	logger           *logger.Logger
+	sessionRepo      ports.SessionRepository
+	eventQueueConfig *EventQueueConfig
 }
 
 // NewFactory creates a new factory
@@ -67,6 +68,12 @@ func NewFactory(logger *logger.Logger, sessionRepo ports.SessionRepository) *Fac
 	}
 }
 
+// WithEventQueueConfig sets the per-session event queue settings of created managers
+func (f *Factory) WithEventQueueConfig(config *EventQueueConfig) *Factory {
+	f.eventQueueConfig = config
+	return f
+}
+
 // CreateManager creates a new Wameow manager with all dependencies
 func (f *Factory) CreateManager(db *sql.DB) (*Manager, error) {
 	// Create SQL store container
@@ -76,7 +83,7 @@ func (f *Factory) CreateManager(db *sql.DB) (*Manager, error) {
 	}
 
 	// Create manager
-	manager := NewManager(container, f.sessionRepo, f.logger)
+	manager := newManager(container, f.sessionRepo, f.eventQueueConfig, f.logger)
 
 	f.logger.Info("Wameow manager created successfully")
 	return manager, nil
@@ -181,6 +188,7 @@ func (m *Manager) HealthCheck() map[string]interface{} {
 		"total_sessions":     totalSessions,
 		"connected_sessions": connectedSessions,
 		"logged_in_sessions": loggedInSessions,
+		"event_queues":       m.eventQueue.Stats(),
 		"healthy":            true,
 		"timestamp":          time.Now().Unix(),
 	}
@@ -0,0 +1,186 @@
+package wameow
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"zpwoot/internal/ports"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// messageStoreTimeout bounds how long storing a sent message may take
+const messageStoreTimeout = 10 * time.Second
+
+// mediaMessage is the message content of images, videos, audios, documents and stickers
+type mediaMessage interface {
+	whatsmeow.DownloadableMessage
+	GetURL() string
+	GetMimetype() string
+	GetFileLength() uint64
+}
+
+// newStoredMessage builds the message history record of a received or sent message
+func newStoredMessage(sessionID string, evt *events.Message) *ports.StoredMessage {
+	data := newMessageEventData(evt)
+
+	stored := &ports.StoredMessage{
+		SessionID:       sessionID,
+		MessageID:       data.ID,
+		ChatJID:         data.Chat,
+		SenderJID:       data.Sender,
+		FromMe:          data.FromMe,
+		IsGroup:         data.IsGroup,
+		PushName:        data.PushName,
+		MessageType:     data.MessageType,
+		Text:            data.Text,
+		Caption:         data.Caption,
+		MimeType:        data.MimeType,
+		FileName:        data.FileName,
+		QuotedMessageID: data.QuotedMessageID,
+		Timestamp:       evt.Info.Timestamp.Unix(),
+	}
+
+	if media := messageMedia(evt.Message); media != nil {
+		stored.FileSize = int64(media.GetFileLength())
+		stored.MediaURL = media.GetURL()
+		stored.DirectPath = media.GetDirectPath()
+		stored.MediaKey = encodeMediaHash(media.GetMediaKey())
+		stored.FileSHA256 = encodeMediaHash(media.GetFileSHA256())
+		stored.FileEncSHA256 = encodeMediaHash(media.GetFileEncSHA256())
+		if stored.MimeType == "" {
+			stored.MimeType = media.GetMimetype()
+		}
+	}
+
+	// Keep the message as it arrived, before whatsmeow unwrapped ephemeral and view once content
+	raw := evt.RawMessage
+	if raw == nil {
+		raw = evt.Message
+	}
+	if raw != nil {
+		if encoded, err := protojson.Marshal(raw); err == nil {
+			stored.Raw = string(encoded)
+		}
+	}
+
+	return stored
+}
+
+// messageMedia returns the media of a message, nil for messages without media
+func messageMedia(msg *waE2E.Message) mediaMessage {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage()
+	case msg.GetPtvMessage() != nil:
+		return msg.GetPtvMessage()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage()
+	case msg.GetDocumentWithCaptionMessage().GetMessage().GetDocumentMessage() != nil:
+		return msg.GetDocumentWithCaptionMessage().GetMessage().GetDocumentMessage()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage()
+	}
+	return nil
+}
+
+// encodeMediaHash encodes a media key or hash for storage
+func encodeMediaHash(value []byte) string {
+	if len(value) == 0 {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(value)
+}
+
+// storeMessage saves a message in the history, when a message repository is set
+func (m *Manager) storeMessage(ctx context.Context, msg *ports.StoredMessage) error {
+	repo := m.getMessageRepository()
+	if repo == nil {
+		return nil
+	}
+	return repo.Save(ctx, msg)
+}
+
+// markMessagesRead marks received messages as read in the history, when a message repository is set
+func (m *Manager) markMessagesRead(ctx context.Context, sessionID, chatJID string, messageIDs []string, readAt time.Time) error {
+	repo := m.getMessageRepository()
+	if repo == nil {
+		return nil
+	}
+	return repo.MarkMessagesRead(ctx, sessionID, chatJID, messageIDs, readAt.Unix())
+}
+
+// markChatRead marks the received messages of a chat up to a time as read in the history,
+// when a message repository is set
+func (m *Manager) markChatRead(ctx context.Context, sessionID, chatJID string, upTo time.Time) error {
+	repo := m.getMessageRepository()
+	if repo == nil {
+		return nil
+	}
+	return repo.MarkChatRead(ctx, sessionID, chatJID, upTo.Unix())
+}
+
+// storeSentMessage saves a message sent through a session in the history, with its uploaded
+// media kept in the media store. Failures are only logged: the message was delivered to
+// WhatsApp and the send must still succeed.
+func (m *Manager) storeSentMessage(sessionID string, client *WameowClient, to types.JID, resp whatsmeow.SendResponse, msg *waE2E.Message, media []byte) {
+	if m.getMessageRepository() == nil {
+		return
+	}
+
+	sender := resp.Sender
+	if sender.IsEmpty() && client.GetClient().Store.ID != nil {
+		sender = client.GetClient().Store.ID.ToNonAD()
+	}
+
+	evt := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{
+				Chat:     to,
+				Sender:   sender,
+				IsFromMe: true,
+				IsGroup:  to.Server == types.GroupServer,
+			},
+			ID:        resp.ID,
+			PushName:  client.GetClient().Store.PushName,
+			Timestamp: resp.Timestamp,
+		},
+		Message: msg,
+	}
+
+	stored := newStoredMessage(sessionID, evt)
+	if len(media) > 0 {
+		stored.MediaStoreKey = m.putSentMedia(sessionID, stored, media)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), messageStoreTimeout)
+	defer cancel()
+
+	if err := m.storeMessage(ctx, stored); err != nil {
+		m.logger.ErrorWithFields("Failed to store sent message", map[string]interface{}{
+			"session_id": sessionID,
+			"to":         to.String(),
+			"message_id": resp.ID,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	// Answering a chat means its messages were seen
+	if err := m.markChatRead(ctx, sessionID, to.String(), resp.Timestamp); err != nil {
+		m.logger.WarnWithFields("Failed to mark chat as read", map[string]interface{}{
+			"session_id": sessionID,
+			"to":         to.String(),
+			"error":      err.Error(),
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"zpwoot/internal/domain/message"
 	"zpwoot/internal/domain/session"
+	"zpwoot/internal/domain/webhook"
 	"zpwoot/internal/ports"
 	"zpwoot/platform/logger"
 
@@ -30,8 +31,26 @@ type SessionStats struct {
 
 // EventHandlerInfo stores information about registered event handlers
 type EventHandlerInfo struct {
-	ID      string
-	Handler ports.EventHandler
+	ID        string
+	SessionID string
+	Handler   ports.EventHandler
+
+	// queue is the event bus worker queue the handler is pinned to
+	queue chan busJob
+
+	// Delivery statistics
+	delivered   int64
+	failed      int64
+	panics      int64
+	dropped     int64
+	lastError   string
+	lastErrorAt *time.Time
+	statsMutex  sync.Mutex
+}
+
+// WebhookEventHandler receives webhook events produced from Wameow events
+type WebhookEventHandler interface {
+	ProcessWebhookEvent(ctx context.Context, event *webhook.WebhookEvent) error
 }
 
 // Manager implements the WameowManager interface
@@ -48,9 +67,27 @@ type Manager struct {
 	sessionStats map[string]*SessionStats
 	statsMutex   sync.RWMutex
 
-	// Event handlers
-	eventHandlers map[string]map[string]*EventHandlerInfo // sessionID -> handlerID -> handler
-	handlersMutex sync.RWMutex
+	// Event bus for handlers registered through RegisterEventHandler
+	eventBus *EventBus
+
+	// Per-session queues that move event processing off the whatsmeow callback
+	eventQueue   *EventDispatcher
+	eventHandler *EventHandler
+
+	// Sessions the server is replaying missed events to, between OfflineSyncPreview and OfflineSyncCompleted
+	offlineSyncs sync.Map
+
+	// Webhook event handler, the transactor that records its events
+	// together with the session changes they describe, and the message history
+	webhookHandler  WebhookEventHandler
+	eventTransactor ports.Transactor
+	messageRepo     ports.MessageRepository
+	webhookMutex    sync.RWMutex
+
+	// Media download settings and the workers of automatic downloads
+	mediaConfig     *MediaConfig
+	mediaDownloader *mediaDownloader
+	mediaMutex      sync.RWMutex
 }
 
 // NewManager creates a new Wameow manager
@@ -59,7 +96,20 @@ func NewManager(
 	sessionRepo ports.SessionRepository,
 	logger *logger.Logger,
 ) *Manager {
-	return &Manager{
+	return newManager(container, sessionRepo, nil, logger)
+}
+
+// newManager creates a new Wameow manager with the given event queue settings
+func newManager(
+	container *sqlstore.Container,
+	sessionRepo ports.SessionRepository,
+	eventQueueConfig *EventQueueConfig,
+	logger *logger.Logger,
+) *Manager {
+	eventBus := NewEventBus(DefaultEventBusConfig(), logger)
+	eventBus.Start()
+
+	m := &Manager{
 		clients:       make(map[string]*WameowClient),
 		container:     container,
 		connectionMgr: NewConnectionManager(logger),
@@ -67,8 +117,14 @@ func NewManager(
 		sessionMgr:    NewSessionManager(sessionRepo, logger),
 		logger:        logger,
 		sessionStats:  make(map[string]*SessionStats),
-		eventHandlers: make(map[string]map[string]*EventHandlerInfo),
+		eventBus:      eventBus,
+		mediaConfig:   DefaultMediaConfig(),
 	}
+
+	m.eventHandler = NewEventHandler(m, m.sessionMgr, m.qrGenerator, logger)
+	m.eventQueue = NewEventDispatcher(eventQueueConfig, m.processEvent, logger)
+
+	return m
 }
 
 // CreateSession creates a new Wameow session
@@ -94,6 +150,16 @@ func (m *Manager) CreateSession(sessionID string, config *session.ProxyConfig) e
 	// Set up event handlers
 	m.setupEventHandlers(client.GetClient(), sessionID)
 
+	// QR code rotations go through the session queue so they stay ordered with pairing events
+	client.SetQRChannelHandler(func(evt *QRChannelEvent) {
+		m.eventQueue.Dispatch(sessionID, evt)
+	})
+
+	// Sent messages are kept in the message history like the received ones
+	client.SetSentMessageHandler(func(to types.JID, resp whatsmeow.SendResponse, msg *waE2E.Message, media []byte) {
+		m.storeSentMessage(sessionID, client, to, resp, msg, media)
+	})
+
 	// Apply proxy configuration if provided
 	if config != nil {
 		if err := m.applyProxyConfig(client.GetClient(), config); err != nil {
@@ -492,7 +558,7 @@ func (m *Manager) SendMediaMessage(sessionID, to string, media []byte, mediaType
 	}
 
 	// Send the message
-	_, err = client.GetClient().SendMessage(context.Background(), recipientJID, msg)
+	_, err = client.sendMediaMessage(context.Background(), recipientJID, msg, media)
 	if err != nil {
 		m.logger.ErrorWithFields("Failed to send media message", map[string]interface{}{
 			"session_id": sessionID,
@@ -515,51 +581,13 @@ func (m *Manager) SendMediaMessage(sessionID, to string, media []byte, mediaType
 	return nil
 }
 
-// RegisterEventHandler registers an event handler for Wameow events
+// RegisterEventHandler registers an event handler for Wameow events.
+// Use AllSessions as sessionID to receive the events of every session.
+// Handlers run on event bus workers, so they may be registered before the session exists.
 func (m *Manager) RegisterEventHandler(sessionID string, handler ports.EventHandler) error {
-	m.handlersMutex.Lock()
-	defer m.handlersMutex.Unlock()
-
-	// Initialize session handlers map if it doesn't exist
-	if m.eventHandlers[sessionID] == nil {
-		m.eventHandlers[sessionID] = make(map[string]*EventHandlerInfo)
-	}
-
-	// Generate a unique handler ID
-	handlerID := fmt.Sprintf("handler_%d", time.Now().UnixNano())
-
-	// Store the handler
-	m.eventHandlers[sessionID][handlerID] = &EventHandlerInfo{
-		ID:      handlerID,
-		Handler: handler,
-	}
-
-	// Get the client and register the actual event handler
-	client := m.getClient(sessionID)
-	if client != nil {
-		client.GetClient().AddEventHandler(func(evt interface{}) {
-			// Handle different event types and call appropriate handler methods
-			switch e := evt.(type) {
-			case *events.Message:
-				m.incrementMessagesReceived(sessionID)
-				// Convert to WameowMessage and call handler
-				msg := &ports.WameowMessage{
-					ID:   e.Info.ID,
-					From: e.Info.Sender.String(),
-					To:   e.Info.Chat.String(),
-					Body: e.Message.GetConversation(),
-				}
-				handler.HandleMessage(sessionID, msg)
-			case *events.Connected:
-				handler.HandleConnection(sessionID, true)
-			case *events.Disconnected:
-				handler.HandleConnection(sessionID, false)
-			case *events.QR:
-				handler.HandleQRCode(sessionID, e.Codes[0])
-			case *events.PairSuccess:
-				handler.HandlePairSuccess(sessionID)
-			}
-		})
+	handlerID, err := m.eventBus.Register(sessionID, handler)
+	if err != nil {
+		return fmt.Errorf("failed to register event handler: %w", err)
 	}
 
 	m.logger.InfoWithFields("Event handler registered", map[string]interface{}{
@@ -572,27 +600,8 @@ func (m *Manager) RegisterEventHandler(sessionID string, handler ports.EventHand
 
 // UnregisterEventHandler removes an event handler
 func (m *Manager) UnregisterEventHandler(sessionID string, handlerID string) error {
-	m.handlersMutex.Lock()
-	defer m.handlersMutex.Unlock()
-
-	// Check if session has handlers
-	sessionHandlers, exists := m.eventHandlers[sessionID]
-	if !exists {
-		return fmt.Errorf("no event handlers found for session %s", sessionID)
-	}
-
-	// Check if handler exists
-	_, exists = sessionHandlers[handlerID]
-	if !exists {
-		return fmt.Errorf("event handler %s not found for session %s", handlerID, sessionID)
-	}
-
-	// Remove the handler
-	delete(sessionHandlers, handlerID)
-
-	// Clean up empty session map
-	if len(sessionHandlers) == 0 {
-		delete(m.eventHandlers, sessionID)
+	if err := m.eventBus.Unregister(sessionID, handlerID); err != nil {
+		return err
 	}
 
 	m.logger.InfoWithFields("Event handler unregistered", map[string]interface{}{
@@ -603,6 +612,71 @@ func (m *Manager) UnregisterEventHandler(sessionID string, handlerID string) err
 	return nil
 }
 
+// GetEventHandlerStats returns delivery statistics of the registered event handlers.
+// An empty sessionID returns every handler.
+func (m *Manager) GetEventHandlerStats(sessionID string) []EventHandlerStats {
+	return m.eventBus.Stats(sessionID)
+}
+
+// StopEventBus waits for queued events to reach the registered handlers and stops the bus workers
+func (m *Manager) StopEventBus() {
+	m.eventBus.Stop()
+}
+
+// GetEventQueueStats returns the backpressure metrics of the session event queues
+func (m *Manager) GetEventQueueStats() []EventQueueStats {
+	return m.eventQueue.Stats()
+}
+
+// StopEventQueue waits for queued events to be processed and stops the queue workers
+func (m *Manager) StopEventQueue() {
+	m.eventQueue.Stop()
+}
+
+// SetWebhookHandler sets the handler that receives webhook events for all sessions
+func (m *Manager) SetWebhookHandler(handler WebhookEventHandler) {
+	m.webhookMutex.Lock()
+	defer m.webhookMutex.Unlock()
+	m.webhookHandler = handler
+}
+
+// getWebhookHandler safely gets the webhook event handler
+func (m *Manager) getWebhookHandler() WebhookEventHandler {
+	m.webhookMutex.RLock()
+	defer m.webhookMutex.RUnlock()
+	return m.webhookHandler
+}
+
+// SetEventTransactor sets the transactor used to store session changes and the
+// webhook events describing them atomically
+func (m *Manager) SetEventTransactor(transactor ports.Transactor) {
+	m.webhookMutex.Lock()
+	defer m.webhookMutex.Unlock()
+	m.eventTransactor = transactor
+}
+
+// getEventTransactor safely gets the event transactor
+func (m *Manager) getEventTransactor() ports.Transactor {
+	m.webhookMutex.RLock()
+	defer m.webhookMutex.RUnlock()
+	return m.eventTransactor
+}
+
+// SetMessageRepository sets the repository that keeps the history of the messages
+// received and sent by all sessions
+func (m *Manager) SetMessageRepository(repo ports.MessageRepository) {
+	m.webhookMutex.Lock()
+	defer m.webhookMutex.Unlock()
+	m.messageRepo = repo
+}
+
+// getMessageRepository safely gets the message repository
+func (m *Manager) getMessageRepository() ports.MessageRepository {
+	m.webhookMutex.RLock()
+	defer m.webhookMutex.RUnlock()
+	return m.messageRepo
+}
+
 // getClient safely gets a client by session ID
 func (m *Manager) getClient(sessionID string) *WameowClient {
 	m.clientsMutex.RLock()
@@ -843,11 +917,48 @@ func (m *Manager) setupEventHandlers(client *whatsmeow.Client, sessionID string)
 	m.SetupEventHandlers(client, sessionID)
 }
 
-// SetupEventHandlers sets up all event handlers for a Wameow client
+// SetupEventHandlers sets up all event handlers for a Wameow client.
+// The whatsmeow callback only queues the event, so database writes and
+// webhook deliveries never block the socket reader.
 func (m *Manager) SetupEventHandlers(client *whatsmeow.Client, sessionID string) {
-	eventHandler := NewEventHandler(m, m.sessionMgr, m.qrGenerator, m.logger)
-
 	client.AddEventHandler(func(evt interface{}) {
-		eventHandler.HandleEvent(evt, sessionID)
+		if _, ok := evt.(*events.Message); ok {
+			m.incrementMessagesReceived(sessionID)
+		}
+
+		m.eventQueue.Dispatch(sessionID, m.markOfflineSync(sessionID, evt))
 	})
 }
+
+// markOfflineSync wraps the events the server replays while the session catches up on what
+// it missed offline. It runs in the whatsmeow callback, where events arrive in the order the
+// server sent them; the queue workers process chats concurrently and could not tell.
+func (m *Manager) markOfflineSync(sessionID string, evt interface{}) interface{} {
+	switch evt.(type) {
+	case *events.OfflineSyncPreview:
+		m.offlineSyncs.Store(sessionID, struct{}{})
+		return evt
+	case *events.OfflineSyncCompleted, *events.Disconnected, *events.LoggedOut, *events.StreamReplaced:
+		m.offlineSyncs.Delete(sessionID)
+		return evt
+	}
+
+	if _, syncing := m.offlineSyncs.Load(sessionID); syncing {
+		return &OfflineSyncEvent{Event: evt}
+	}
+	return evt
+}
+
+// processEvent handles a queued event on a session queue worker
+func (m *Manager) processEvent(sessionID string, evt interface{}) {
+	ctx := context.Background()
+	if replayed, ok := evt.(*OfflineSyncEvent); ok {
+		ctx = withOfflineSync(ctx)
+		evt = replayed.Event
+	}
+
+	m.eventHandler.HandleEvent(ctx, evt, sessionID)
+
+	// Fan the event out to handlers registered through RegisterEventHandler
+	m.eventBus.Publish(sessionID, evt)
+}
@@ -0,0 +1,365 @@
+package wameow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+	"time"
+
+	"zpwoot/internal/domain/message"
+	"zpwoot/internal/domain/session"
+	"zpwoot/internal/ports"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// mediaTransferTimeout bounds how long downloading, decrypting and storing a media file may take
+const mediaTransferTimeout = 2 * time.Minute
+
+// errMediaStoreNotConfigured is returned when media is requested before a media store was set
+var errMediaStoreNotConfigured = errors.New("media store is not configured")
+
+// MediaConfig controls where media is kept and when received media is downloaded
+type MediaConfig struct {
+	// Store keeps the media of received and sent messages
+	Store ports.MediaStore
+	// AutoDownload downloads the media of messages as they arrive, instead of on first access,
+	// for sessions that do not set it themselves
+	AutoDownload bool
+	// MaxConcurrentDownloads is the number of workers running automatic downloads
+	MaxConcurrentDownloads int
+	// DownloadQueueSize bounds the automatic downloads waiting for a worker. Messages arriving
+	// while the queue is full are skipped, their media is downloaded on first access.
+	DownloadQueueSize int
+}
+
+// DefaultMediaConfig returns the default media settings, without a media store
+func DefaultMediaConfig() *MediaConfig {
+	return &MediaConfig{
+		AutoDownload:           false,
+		MaxConcurrentDownloads: 4,
+		DownloadQueueSize:      1000,
+	}
+}
+
+// mediaDownload is a received message waiting for its media to be downloaded automatically
+type mediaDownload struct {
+	sessionID string
+	messageID string
+}
+
+// mediaDownloader runs the automatic downloads of received media on a fixed number of workers
+type mediaDownloader struct {
+	queue  chan mediaDownload
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// SetMediaConfig sets where media is kept and whether media is downloaded as messages arrive,
+// and starts the workers of automatic downloads
+func (m *Manager) SetMediaConfig(config *MediaConfig) error {
+	if config == nil || config.Store == nil {
+		return errMediaStoreNotConfigured
+	}
+	if config.MaxConcurrentDownloads <= 0 {
+		config.MaxConcurrentDownloads = DefaultMediaConfig().MaxConcurrentDownloads
+	}
+	if config.DownloadQueueSize <= 0 {
+		config.DownloadQueueSize = DefaultMediaConfig().DownloadQueueSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	downloader := &mediaDownloader{
+		queue:  make(chan mediaDownload, config.DownloadQueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < config.MaxConcurrentDownloads; i++ {
+		downloader.wg.Add(1)
+		go m.runMediaDownloads(downloader)
+	}
+
+	m.mediaMutex.Lock()
+	previous := m.mediaDownloader
+	m.mediaConfig = config
+	m.mediaDownloader = downloader
+	m.mediaMutex.Unlock()
+
+	if previous != nil {
+		previous.stop()
+	}
+	return nil
+}
+
+// StopMediaDownloads stops the workers of automatic downloads, abandoning the queued ones.
+// Their media is still downloaded on first access.
+func (m *Manager) StopMediaDownloads() {
+	m.mediaMutex.Lock()
+	downloader := m.mediaDownloader
+	m.mediaDownloader = nil
+	m.mediaMutex.Unlock()
+
+	if downloader != nil {
+		downloader.stop()
+	}
+}
+
+// stop cancels the running downloads and waits for the workers to exit
+func (d *mediaDownloader) stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// getMediaConfig safely gets the media settings and the downloader of automatic downloads
+func (m *Manager) getMediaConfig() (*MediaConfig, *mediaDownloader) {
+	m.mediaMutex.RLock()
+	defer m.mediaMutex.RUnlock()
+	return m.mediaConfig, m.mediaDownloader
+}
+
+// SetMediaAutoDownload sets whether a session downloads received media as messages arrive,
+// nil follows the server setting. Sessions not loaded yet read it when they are.
+func (m *Manager) SetMediaAutoDownload(sessionID string, enabled *bool) error {
+	if client := m.getClient(sessionID); client != nil {
+		client.SetMediaAutoDownload(enabled)
+	}
+	return nil
+}
+
+// mediaAutoDownloadEnabled reports whether a session downloads received media as messages arrive
+func (m *Manager) mediaAutoDownloadEnabled(sessionID string, config *MediaConfig) bool {
+	if client := m.getClient(sessionID); client != nil {
+		if enabled := client.getMediaAutoDownload(); enabled != nil {
+			return *enabled
+		}
+	}
+	return config.AutoDownload
+}
+
+// DownloadMedia returns the media of a stored message. The first access downloads and decrypts
+// it from WhatsApp, which needs the session to be connected; later ones find it in the media store.
+func (m *Manager) DownloadMedia(ctx context.Context, sessionID, messageID string) (*ports.MediaFile, error) {
+	repo := m.getMessageRepository()
+	if repo == nil {
+		return nil, message.ErrMessageNotFound
+	}
+
+	stored, err := repo.GetByMessageID(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.downloadMedia(ctx, sessionID, stored)
+}
+
+// downloadMedia returns the kept media of a stored message, downloading it when missing
+func (m *Manager) downloadMedia(ctx context.Context, sessionID string, stored *ports.StoredMessage) (*ports.MediaFile, error) {
+	config, _ := m.getMediaConfig()
+	if config.Store == nil {
+		return nil, errMediaStoreNotConfigured
+	}
+
+	if stored.MediaStoreKey != "" {
+		object, err := config.Store.Stat(ctx, stored.MediaStoreKey)
+		if err == nil {
+			return newMediaFile(object.Key, stored, object.Size), nil
+		}
+		if !errors.Is(err, message.ErrMediaObjectNotFound) {
+			return nil, err
+		}
+		// The file expired from the media store or was removed, download it again
+	}
+
+	media, err := storedMessageMedia(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	client := m.getClient(sessionID)
+	if client == nil || !client.IsConnected() {
+		return nil, fmt.Errorf("failed to download media: %w", session.ErrSessionNotConnected)
+	}
+
+	data, err := client.GetClient().Download(ctx, media)
+	if err != nil {
+		if errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith404) || errors.Is(err, whatsmeow.ErrMediaDownloadFailedWith410) {
+			return nil, message.ErrMediaExpired
+		}
+		return nil, fmt.Errorf("failed to download media: %w", err)
+	}
+
+	object, err := config.Store.Put(ctx, data, stored.MimeType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store media: %w", err)
+	}
+
+	// The file is served even if it could not be recorded, the next access will download it again
+	if err := m.getMessageRepository().SetMediaStoreKey(ctx, stored.ID, object.Key); err != nil {
+		m.logger.WarnWithFields("Failed to record downloaded media", map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": stored.MessageID,
+			"error":      err.Error(),
+		})
+	}
+
+	return newMediaFile(object.Key, stored, object.Size), nil
+}
+
+// putSentMedia keeps the media uploaded with a sent message in the media store and returns
+// its key, empty when it could not be kept
+func (m *Manager) putSentMedia(sessionID string, stored *ports.StoredMessage, media []byte) string {
+	config, _ := m.getMediaConfig()
+	if config.Store == nil {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mediaTransferTimeout)
+	defer cancel()
+
+	object, err := config.Store.Put(ctx, media, stored.MimeType)
+	if err != nil {
+		m.logger.WarnWithFields("Failed to store sent media", map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": stored.MessageID,
+			"error":      err.Error(),
+		})
+		return ""
+	}
+
+	return object.Key
+}
+
+// autoDownloadMedia queues the media of a received message for download in the background,
+// when automatic download is enabled for the session
+func (m *Manager) autoDownloadMedia(sessionID string, evt *events.Message) {
+	config, downloader := m.getMediaConfig()
+	if downloader == nil || messageMedia(evt.Message) == nil || m.getMessageRepository() == nil {
+		return
+	}
+	if !m.mediaAutoDownloadEnabled(sessionID, config) {
+		return
+	}
+
+	select {
+	case downloader.queue <- mediaDownload{sessionID: sessionID, messageID: evt.Info.ID}:
+	default:
+		m.logger.WarnWithFields("Media download queue is full, media will be downloaded on first access", map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": evt.Info.ID,
+			"queue_size": cap(downloader.queue),
+		})
+	}
+}
+
+// runMediaDownloads is a worker of automatic downloads, running until the downloader stops
+func (m *Manager) runMediaDownloads(downloader *mediaDownloader) {
+	defer downloader.wg.Done()
+
+	for {
+		select {
+		case <-downloader.ctx.Done():
+			return
+		case download := <-downloader.queue:
+			m.runMediaDownload(downloader.ctx, download)
+		}
+	}
+}
+
+// runMediaDownload downloads the media of a queued message
+func (m *Manager) runMediaDownload(ctx context.Context, download mediaDownload) {
+	ctx, cancel := context.WithTimeout(ctx, mediaTransferTimeout)
+	defer cancel()
+
+	stored, err := m.getMessageRepository().GetByMessageID(ctx, download.sessionID, download.messageID)
+	if err == nil {
+		_, err = m.downloadMedia(ctx, download.sessionID, stored)
+	}
+	if err != nil {
+		// The downloader stopped, the media is downloaded on first access
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return
+		}
+		m.logger.WarnWithFields("Failed to download media", map[string]interface{}{
+			"session_id": download.sessionID,
+			"message_id": download.messageID,
+			"error":      err.Error(),
+		})
+		return
+	}
+
+	m.logger.DebugWithFields("Media downloaded", map[string]interface{}{
+		"session_id": download.sessionID,
+		"message_id": download.messageID,
+	})
+}
+
+// storedMessageMedia returns the downloadable media of a stored message
+func storedMessageMedia(stored *ports.StoredMessage) (mediaMessage, error) {
+	if stored.Raw == "" {
+		return nil, message.ErrMessageHasNoMedia
+	}
+
+	var raw waE2E.Message
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal([]byte(stored.Raw), &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode stored message: %w", err)
+	}
+
+	// Stored messages are kept as they arrived, unwrap ephemeral and view once content
+	evt := (&events.Message{RawMessage: &raw}).UnwrapRaw()
+	media := messageMedia(evt.Message)
+	if media == nil {
+		return nil, message.ErrMessageHasNoMedia
+	}
+
+	return media, nil
+}
+
+// newMediaFile describes the kept media file of a stored message
+func newMediaFile(key string, stored *ports.StoredMessage, size int64) *ports.MediaFile {
+	fileName := stored.FileName
+	if fileName == "" {
+		fileName = stored.MessageID + mediaExtension(stored.MimeType)
+	}
+
+	mimeType := stored.MimeType
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	return &ports.MediaFile{
+		Key:      key,
+		MimeType: mimeType,
+		FileName: fileName,
+		Size:     size,
+	}
+}
+
+// mediaExtension returns the file extension of a media MIME type, empty when unknown
+func mediaExtension(mimeType string) string {
+	base, _, _ := strings.Cut(mimeType, ";")
+	base = strings.TrimSpace(base)
+
+	// Prefer the usual extensions of WhatsApp media, mime lists the alternatives alphabetically
+	switch base {
+	case "image/jpeg":
+		return ".jpg"
+	case "audio/ogg":
+		return ".ogg"
+	case "audio/mpeg":
+		return ".mp3"
+	case "video/mp4":
+		return ".mp4"
+	}
+
+	if extensions, err := mime.ExtensionsByType(base); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package ports
+
+import (
+	"context"
+)
+
+// OutboxEvent is an event recorded in the outbox, waiting to be consumed by the dispatchers
+type OutboxEvent struct {
+	Sequence  int64  `json:"sequence" db:"sequence"`
+	EventID   string `json:"event_id" db:"event_id"`
+	SessionID string `json:"session_id,omitempty" db:"session_id"`
+	EventType string `json:"event_type" db:"event_type"`
+	Payload   string `json:"payload" db:"payload"`
+	CreatedAt int64  `json:"created_at" db:"created_at"`
+}
+
+// EventOutboxRepository defines the interface for the event outbox and the checkpoints of its consumers
+type EventOutboxRepository interface {
+	// Append records an event, joining the transaction carried by ctx if there is one.
+	// CreatedAt is set by the database when the event is inserted.
+	Append(ctx context.Context, event *OutboxEvent) error
+
+	// ListAfter retrieves events recorded after the given sequence, in sequence order
+	ListAfter(ctx context.Context, sequence int64, limit int) ([]*OutboxEvent, error)
+
+	// ListSequences retrieves the events recorded under the given sequences, in sequence order
+	ListSequences(ctx context.Context, sequences []int64) ([]*OutboxEvent, error)
+
+	// LatestSequence returns the sequence of the last recorded event, 0 when the outbox is empty
+	LatestSequence(ctx context.Context) (int64, error)
+
+	// GetCheckpoint returns the last sequence processed by a consumer; found is false when it has none yet
+	GetCheckpoint(ctx context.Context, consumer string) (sequence int64, found bool, err error)
+
+	// SaveCheckpoint stores the last sequence processed by a consumer
+	SaveCheckpoint(ctx context.Context, consumer string, sequence int64) error
+
+	// DeleteProcessed removes events up to the given sequence that were recorded before olderThan
+	DeleteProcessed(ctx context.Context, upToSequence, olderThan int64) (int64, error)
+
+	// ClaimDedupKey records the dedup key of an inbound event until expiresAt, joining the transaction
+	// carried by ctx if there is one. It returns false when the key is already held by an earlier event.
+	ClaimDedupKey(ctx context.Context, sessionID, key, eventID string, expiresAt int64) (bool, error)
+
+	// DeleteExpiredDedupKeys removes dedup keys that expired
+	DeleteExpiredDedupKeys(ctx context.Context) (int64, error)
+}
+
+// Transactor runs repository calls in a single database transaction
+type Transactor interface {
+	// WithTransaction runs fn in a transaction carried by the context it receives.
+	// Repositories called with that context join the transaction, which commits when fn returns nil.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// AfterCommit runs fn once the transaction carried by ctx commits, or right away outside a transaction
+	AfterCommit(ctx context.Context, fn func())
+}
@@ -0,0 +1,48 @@
+package ports
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"time"
+)
+
+// MediaObject describes a file kept in a media store
+type MediaObject struct {
+	Key         string
+	Size        int64
+	ContentType string // empty when the store does not keep it
+	ModifiedAt  int64  // unix time the content was last stored
+}
+
+// MediaStore keeps the media of received and sent messages under content-addressed keys,
+// so a file sent or received many times is kept once
+type MediaStore interface {
+	// Put stores content under the key derived from its SHA-256. Storing content that is
+	// already kept refreshes it, which restarts its retention period.
+	Put(ctx context.Context, data []byte, contentType string) (*MediaObject, error)
+
+	// Open returns the content stored under a key, the caller must close it
+	Open(ctx context.Context, key string) (io.ReadCloser, *MediaObject, error)
+
+	// Stat describes the content stored under a key
+	Stat(ctx context.Context, key string) (*MediaObject, error)
+
+	// Delete removes the content stored under a key
+	Delete(ctx context.Context, key string) error
+
+	// DeleteOlderThan removes the content-addressed content last stored before the given time
+	// and returns the keys it removed. Files kept under other keys are left alone.
+	DeleteOlderThan(ctx context.Context, before time.Time) ([]string, error)
+
+	// PresignGet returns a URL that downloads the content under a key without credentials
+	// until it expires, served with the given content type and file name
+	PresignGet(ctx context.Context, key string, expires time.Duration, contentType, fileName string) (string, error)
+}
+
+// SignedMediaServer is implemented by media stores whose presigned URLs point back at this API
+type SignedMediaServer interface {
+	// VerifyPresigned checks the query of a presigned URL for a key and returns the content type
+	// and file name it was signed with
+	VerifyPresigned(key string, query url.Values) (contentType, fileName string, err error)
+}
@@ -0,0 +1,127 @@
+package ports
+
+import (
+	"context"
+)
+
+// StoredMessage is a message received or sent by a session, as kept in the message history
+type StoredMessage struct {
+	ID              string `json:"id" db:"id"`
+	SessionID       string `json:"session_id" db:"session_id"`
+	MessageID       string `json:"message_id" db:"message_id"`
+	ChatJID         string `json:"chat_jid" db:"chat_jid"`
+	SenderJID       string `json:"sender_jid" db:"sender_jid"`
+	FromMe          bool   `json:"from_me" db:"from_me"`
+	IsGroup         bool   `json:"is_group" db:"is_group"`
+	PushName        string `json:"push_name,omitempty" db:"push_name"`
+	MessageType     string `json:"message_type" db:"message_type"`
+	Text            string `json:"text,omitempty" db:"text"`
+	Caption         string `json:"caption,omitempty" db:"caption"`
+	MimeType        string `json:"mime_type,omitempty" db:"mime_type"`
+	FileName        string `json:"file_name,omitempty" db:"file_name"`
+	FileSize        int64  `json:"file_size,omitempty" db:"file_size"`
+	MediaURL        string `json:"media_url,omitempty" db:"media_url"`
+	DirectPath      string `json:"direct_path,omitempty" db:"direct_path"`
+	MediaKey        string `json:"-" db:"media_key"` // base64, decrypts the media
+	FileSHA256      string `json:"file_sha256,omitempty" db:"file_sha256"`
+	FileEncSHA256   string `json:"file_enc_sha256,omitempty" db:"file_enc_sha256"`
+	QuotedMessageID string `json:"quoted_message_id,omitempty" db:"quoted_message_id"`
+	Timestamp       int64  `json:"timestamp" db:"timestamp"`
+	Raw             string `json:"raw,omitempty" db:"raw"`                         // message protobuf encoded as JSON
+	ReadAt          int64  `json:"read_at,omitempty" db:"read_at"`                 // 0 while a received message is unread
+	MediaStoreKey   string `json:"media_store_key,omitempty" db:"media_store_key"` // key of the media in the media store
+	CreatedAt       int64  `json:"created_at" db:"created_at"`
+	UpdatedAt       int64  `json:"updated_at" db:"updated_at"`
+}
+
+// ListCursor is the position of the last item of a page, where the next page starts
+type ListCursor struct {
+	Timestamp int64
+	Key       string // record ID for messages, chat JID for chats
+}
+
+// MessageFilter selects the messages of a chat, zero values are ignored
+type MessageFilter struct {
+	SessionID string
+	ChatJID   string
+	Types     []string
+	Before    int64 // only messages sent before this unix time
+	After     int64 // only messages sent after this unix time
+	Cursor    *ListCursor
+	Ascending bool // oldest first instead of newest first
+	Limit     int
+}
+
+// ChatFilter selects the chats of a session
+type ChatFilter struct {
+	SessionID string
+	Cursor    *ListCursor
+	Limit     int
+}
+
+// ChatSummary is a chat of a session with its latest message
+type ChatSummary struct {
+	ChatJID     string
+	IsGroup     bool
+	LastMessage *StoredMessage
+	UnreadCount int
+}
+
+// Search highlight markers wrap the matched words in the snippets of search results. They are
+// private use characters, so they never clash with message content and can be replaced safely.
+const (
+	SearchHighlightStart = "\uE000"
+	SearchHighlightStop  = "\uE001"
+)
+
+// MessageSearch selects the messages matching a full-text query, zero values are ignored
+type MessageSearch struct {
+	Query     string // web search syntax: quoted phrases, OR and -word are understood
+	SessionID string // empty searches the messages of every session
+	ChatJID   string
+	SenderJID string
+	Types     []string
+	Before    int64 // only messages sent before this unix time
+	After     int64 // only messages sent after this unix time
+	Limit     int
+	Offset    int
+}
+
+// MessageSearchResult is a message matching a search with an excerpt of its content
+type MessageSearchResult struct {
+	Message *StoredMessage
+	Snippet string  // matched words are wrapped in SearchHighlightStart and SearchHighlightStop
+	Rank    float64 // relevance of the message to the query, higher is better
+}
+
+// MessageRepository defines the interface for the message history
+type MessageRepository interface {
+	// Save stores a message, joining the transaction carried by ctx if there is one.
+	// Saving a message already stored updates it, so replays and redeliveries keep a single copy.
+	Save(ctx context.Context, message *StoredMessage) error
+
+	// GetByMessageID retrieves a message of a session by its WhatsApp ID
+	GetByMessageID(ctx context.Context, sessionID, messageID string) (*StoredMessage, error)
+
+	// ListMessages retrieves the messages of a chat matching the filter, newest first unless Ascending is set
+	ListMessages(ctx context.Context, filter *MessageFilter) ([]*StoredMessage, error)
+
+	// ListChats retrieves the chats of a session with their latest message and unread count, most recently active first
+	ListChats(ctx context.Context, filter *ChatFilter) ([]*ChatSummary, error)
+
+	// Search retrieves the messages matching a full-text query, most relevant first
+	Search(ctx context.Context, search *MessageSearch) ([]*MessageSearchResult, error)
+
+	// SetMediaStoreKey records the media store key the media of a message is kept under
+	SetMediaStoreKey(ctx context.Context, id, key string) error
+
+	// ClearSentMediaStoreKeys forgets the media store keys of sent messages whose media was removed
+	// from the media store, since it cannot be downloaded again, and returns how many were cleared
+	ClearSentMediaStoreKeys(ctx context.Context, keys []string) (int64, error)
+
+	// MarkMessagesRead marks received messages of a chat as read at the given time
+	MarkMessagesRead(ctx context.Context, sessionID, chatJID string, messageIDs []string, readAt int64) error
+
+	// MarkChatRead marks the received messages of a chat sent up to the given time as read
+	MarkChatRead(ctx context.Context, sessionID, chatJID string, upTo int64) error
+}
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"zpwoot/internal/domain/message"
 	"zpwoot/internal/domain/session"
@@ -24,7 +25,7 @@ type SessionRepository interface {
 	// List retrieves sessions with optional filters
 	List(ctx context.Context, req *session.ListSessionsRequest) ([]*session.Session, int, error)
 
-	// Update updates an existing session
+	// Update updates an existing session, joining the transaction carried by ctx if there is one
 	Update(ctx context.Context, session *session.Session) error
 
 	// Delete removes a session by ID
@@ -36,6 +37,19 @@ type SessionRepository interface {
 	// UpdateLastSeen updates the last seen timestamp
 	UpdateLastSeen(ctx context.Context, id string) error
 
+	// UpdateDeviceJid updates the device JID of a session
+	UpdateDeviceJid(ctx context.Context, id, deviceJid string) error
+
+	// UpdateQRCode updates the QR code of a session and when it expires; an empty code clears it
+	UpdateQRCode(ctx context.Context, id, qrCode string, expiresAt *time.Time) error
+
+	// SetConnectionError marks a session as disconnected and records why
+	SetConnectionError(ctx context.Context, id, errorMsg string) error
+
+	// UpdateMediaAutoDownload updates the automatic media download setting of a session;
+	// nil follows the server setting
+	UpdateMediaAutoDownload(ctx context.Context, id string, enabled *bool) error
+
 	// GetActiveSessions retrieves all connected sessions
 	GetActiveSessions(ctx context.Context) ([]*session.Session, error)
 
@@ -75,6 +89,10 @@ type WameowManager interface {
 	// GetProxy retrieves proxy configuration
 	GetProxy(sessionID string) (*session.ProxyConfig, error)
 
+	// SetMediaAutoDownload sets whether the session downloads received media as messages arrive;
+	// nil follows the server setting
+	SetMediaAutoDownload(sessionID string, enabled *bool) error
+
 	// SendMessage sends a message through Wameow with full support for all message types
 	SendMessage(sessionID, to, messageType, body, caption, file, filename string, latitude, longitude float64, contactName, contactPhone string) (*message.SendResult, error)
 
@@ -99,6 +117,9 @@ type WameowManager interface {
 	// DeleteMessage deletes an existing message
 	DeleteMessage(sessionID, to, messageID string, forAll bool) error
 
+	// DownloadMedia returns the media of a stored message, downloading and decrypting it on first access
+	DownloadMedia(ctx context.Context, sessionID, messageID string) (*MediaFile, error)
+
 	// GetSessionStats retrieves session statistics
 	GetSessionStats(sessionID string) (*SessionStats, error)
 
@@ -117,6 +138,14 @@ type SessionStats struct {
 	Uptime           int64 `json:"uptime"`
 }
 
+// MediaFile is the downloaded media of a message
+type MediaFile struct {
+	Key      string // key of the decrypted file in the media store
+	MimeType string
+	FileName string
+	Size     int64
+}
+
 // EventHandler defines the interface for handling Wameow events
 type EventHandler interface {
 	HandleMessage(sessionID string, message *WameowMessage) error
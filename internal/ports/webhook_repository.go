@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"zpwoot/internal/domain/webhook"
 )
@@ -32,6 +33,9 @@ type WebhookRepository interface {
 	// UpdateStatus updates only the active status of a webhook
 	UpdateStatus(ctx context.Context, id string, active bool) error
 
+	// SaveCircuit stores the open circuit of a webhook, a nil openedAt clears it
+	SaveCircuit(ctx context.Context, id string, failures int, openedAt *time.Time) error
+
 	// GetActiveWebhooks retrieves all active webhooks
 	GetActiveWebhooks(ctx context.Context) ([]*webhook.WebhookConfig, error)
 
@@ -62,17 +66,24 @@ type WebhookStats struct {
 
 // WebhookDelivery represents a webhook delivery attempt
 type WebhookDelivery struct {
-	ID           string `json:"id" db:"id"`
-	WebhookID    string `json:"webhook_id" db:"webhook_id"`
-	EventID      string `json:"event_id" db:"event_id"`
-	URL          string `json:"url" db:"url"`
-	Payload      string `json:"payload" db:"payload"`
-	StatusCode   int    `json:"status_code" db:"status_code"`
-	ResponseBody string `json:"response_body" db:"response_body"`
-	Latency      int64  `json:"latency" db:"latency"`
-	Success      bool   `json:"success" db:"success"`
-	Error        string `json:"error,omitempty" db:"error"`
-	CreatedAt    int64  `json:"created_at" db:"created_at"`
+	ID               string `json:"id" db:"id"`
+	WebhookID        string `json:"webhook_id" db:"webhook_id"`
+	SessionID        string `json:"session_id,omitempty" db:"session_id"`
+	EventID          string `json:"event_id" db:"event_id"`
+	EventType        string `json:"event_type" db:"event_type"`
+	URL              string `json:"url" db:"url"`
+	Payload          string `json:"payload" db:"payload"`
+	StatusCode       int    `json:"status_code" db:"status_code"`
+	ResponseBody     string `json:"response_body" db:"response_body"`
+	Latency          int64  `json:"latency" db:"latency"`
+	Success          bool   `json:"success" db:"success"`
+	Error            string `json:"error,omitempty" db:"error"`
+	Attempts         int    `json:"attempts" db:"attempts"`
+	NextRetryAt      int64  `json:"next_retry_at,omitempty" db:"next_retry_at"` // 0 when no retry is scheduled
+	Status           string `json:"status" db:"status"`
+	DeadLetterReason string `json:"dead_letter_reason,omitempty" db:"dead_letter_reason"`
+	CreatedAt        int64  `json:"created_at" db:"created_at"`
+	UpdatedAt        int64  `json:"updated_at" db:"updated_at"`
 }
 
 // WebhookDeliveryRepository defines the interface for webhook delivery persistence
@@ -86,12 +97,27 @@ type WebhookDeliveryRepository interface {
 	// GetByEventID retrieves deliveries for a specific event
 	GetByEventID(ctx context.Context, eventID string) ([]*WebhookDelivery, error)
 
-	// GetFailedDeliveries retrieves failed deliveries for retry
+	// GetFailedDeliveries retrieves failed deliveries whose next retry is due
 	GetFailedDeliveries(ctx context.Context, limit int) ([]*WebhookDelivery, error)
 
-	// UpdateDeliveryStatus updates the status of a delivery
+	// UpdateDeliveryStatus records the outcome of a new attempt and increments the attempt count
 	UpdateDeliveryStatus(ctx context.Context, deliveryID string, success bool, statusCode int, responseBody, error string) error
 
+	// ScheduleRetry sets when the delivery should be retried next, 0 clears the schedule
+	ScheduleRetry(ctx context.Context, deliveryID string, nextRetryAt int64) error
+
+	// GetByID retrieves a single delivery
+	GetByID(ctx context.Context, deliveryID string) (*WebhookDelivery, error)
+
+	// MarkDeadLetter stops retrying a delivery and moves it to the dead-letter state
+	MarkDeadLetter(ctx context.Context, deliveryID, reason string) error
+
+	// ListDeadLetters retrieves dead-lettered deliveries matching the filter
+	ListDeadLetters(ctx context.Context, filter *DeliveryFilter) ([]*WebhookDelivery, int, error)
+
+	// RequeueDeadLetters schedules dead-lettered deliveries matching the filter for an immediate retry
+	RequeueDeadLetters(ctx context.Context, filter *DeliveryFilter) (int, error)
+
 	// DeleteOldDeliveries removes old delivery records
 	DeleteOldDeliveries(ctx context.Context, olderThan int64) error
 
@@ -99,6 +125,16 @@ type WebhookDeliveryRepository interface {
 	GetDeliveryStats(ctx context.Context, webhookID string, from, to int64) (*DeliveryStats, error)
 }
 
+// DeliveryFilter narrows delivery queries, zero values are ignored
+type DeliveryFilter struct {
+	WebhookID string
+	SessionID string
+	Since     int64
+	Until     int64
+	Limit     int
+	Offset    int
+}
+
 // DeliveryStats represents delivery statistics for a time period
 type DeliveryStats struct {
 	WebhookID       string  `json:"webhook_id"`
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -20,9 +21,56 @@ type Config struct {
 	// Wameow
 	WameowLogLevel string
 
+	// Event dispatch
+	EventQueueSize                  int
+	EventQueueWorkers               int
+	EventQueueOverflow              string // block, drop_newest or drop_oldest
+	EventQueueBlockTimeoutSeconds   int
+	EventQueueSessionTimeoutSeconds int
+
 	// Global Webhooks
 	GlobalWebhookURL string
 	WebhookSecret    string
+	// WebhookEncryptionKey encrypts the credentials webhooks send to their receivers
+	WebhookEncryptionKey string
+
+	// Webhook delivery
+	WebhookTimeoutSeconds int
+	WebhookMaxConcurrency int
+
+	// Webhook retries
+	WebhookMaxRetries            int
+	WebhookRetryBaseDelaySeconds int
+	WebhookRetryMaxDelaySeconds  int
+	WebhookDeliveryRetentionDays int
+
+	// Webhook circuit breaker
+	WebhookCircuitFailureThreshold     int
+	WebhookCircuitProbeIntervalSeconds int
+	WebhookCircuitDisableAfterHours    int
+
+	// Event outbox
+	EventOutboxRetentionHours int
+	EventDedupTTLHours        int
+
+	// Media of received and sent messages
+	MediaStorage                string // local or s3
+	MediaDir                    string
+	MediaAutoDownload           bool
+	MediaMaxConcurrentDownloads int
+	MediaDownloadQueueSize      int
+	MediaRetentionDays          int
+	MediaURLSecret              string
+
+	// S3-compatible media storage
+	MediaS3Endpoint  string
+	MediaS3Region    string
+	MediaS3Bucket    string
+	MediaS3AccessKey string
+	MediaS3SecretKey string
+	MediaS3Prefix    string
+	MediaS3UseSSL    bool
+	MediaS3PathStyle bool
 
 	// Security
 	GlobalAPIKey string
@@ -46,9 +94,49 @@ func Load() *Config {
 
 		WameowLogLevel: getEnv("WA_LOG_LEVEL", "INFO"),
 
+		EventQueueSize:                  getEnvAsInt("EVENT_QUEUE_SIZE", 1000),
+		EventQueueWorkers:               getEnvAsInt("EVENT_QUEUE_WORKERS", 4),
+		EventQueueOverflow:              getEnv("EVENT_QUEUE_OVERFLOW", "block"),
+		EventQueueBlockTimeoutSeconds:   getEnvAsInt("EVENT_QUEUE_BLOCK_TIMEOUT_SECONDS", 5),
+		EventQueueSessionTimeoutSeconds: getEnvAsInt("EVENT_QUEUE_SESSION_TIMEOUT_SECONDS", 30),
+
 		GlobalWebhookURL: getEnv("GLOBAL_WEBHOOK_URL", ""),
 		WebhookSecret:    getEnv("WEBHOOK_SECRET", ""),
 
+		WebhookEncryptionKey: getEnv("WEBHOOK_ENCRYPTION_KEY", ""),
+
+		WebhookTimeoutSeconds: getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+		WebhookMaxConcurrency: getEnvAsInt("WEBHOOK_MAX_CONCURRENCY", 20),
+
+		WebhookMaxRetries:            getEnvAsInt("WEBHOOK_MAX_RETRIES", 5),
+		WebhookRetryBaseDelaySeconds: getEnvAsInt("WEBHOOK_RETRY_BASE_DELAY_SECONDS", 10),
+		WebhookRetryMaxDelaySeconds:  getEnvAsInt("WEBHOOK_RETRY_MAX_DELAY_SECONDS", 3600),
+		WebhookDeliveryRetentionDays: getEnvAsInt("WEBHOOK_DELIVERY_RETENTION_DAYS", 7),
+
+		WebhookCircuitFailureThreshold:     getEnvAsInt("WEBHOOK_CIRCUIT_FAILURE_THRESHOLD", 10),
+		WebhookCircuitProbeIntervalSeconds: getEnvAsInt("WEBHOOK_CIRCUIT_PROBE_INTERVAL_SECONDS", 60),
+		WebhookCircuitDisableAfterHours:    getEnvAsInt("WEBHOOK_CIRCUIT_DISABLE_AFTER_HOURS", 24),
+
+		EventOutboxRetentionHours: getEnvAsInt("EVENT_OUTBOX_RETENTION_HOURS", 24),
+		EventDedupTTLHours:        getEnvAsInt("EVENT_DEDUP_TTL_HOURS", 24),
+
+		MediaStorage:                getEnv("MEDIA_STORAGE", "local"),
+		MediaDir:                    getEnv("MEDIA_DIR", "media"),
+		MediaAutoDownload:           getEnvAsBool("MEDIA_AUTO_DOWNLOAD", false),
+		MediaMaxConcurrentDownloads: getEnvAsInt("MEDIA_MAX_CONCURRENT_DOWNLOADS", 4),
+		MediaDownloadQueueSize:      getEnvAsInt("MEDIA_DOWNLOAD_QUEUE_SIZE", 1000),
+		MediaRetentionDays:          getEnvAsInt("MEDIA_RETENTION_DAYS", 0),
+		MediaURLSecret:              getEnv("MEDIA_URL_SECRET", ""),
+
+		MediaS3Endpoint:  getEnv("MEDIA_S3_ENDPOINT", ""),
+		MediaS3Region:    getEnv("MEDIA_S3_REGION", "us-east-1"),
+		MediaS3Bucket:    getEnv("MEDIA_S3_BUCKET", "zpwoot-media"),
+		MediaS3AccessKey: getEnv("MEDIA_S3_ACCESS_KEY", ""),
+		MediaS3SecretKey: getEnv("MEDIA_S3_SECRET_KEY", ""),
+		MediaS3Prefix:    getEnv("MEDIA_S3_PREFIX", ""),
+		MediaS3UseSSL:    getEnvAsBool("MEDIA_S3_USE_SSL", true),
+		MediaS3PathStyle: getEnvAsBool("MEDIA_S3_PATH_STYLE", false),
+
 		GlobalAPIKey: getEnv("ZP_API_KEY", "a0b1125a0eb3364d98e2c49ec6f7d6ba"),
 
 		NodeEnv: getEnv("NODE_ENV", "development"),
@@ -62,8 +150,23 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// Removed unused helper functions getEnvAsInt and getEnvAsBool
-// They can be added back when needed
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
 
 // Helper methods for configuration
 
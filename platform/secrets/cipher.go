@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ciphertextPrefix marks values encrypted by Cipher and the format version
+const ciphertextPrefix = "enc:v1:"
+
+// ErrNoKey is returned when a value must be encrypted or decrypted but no key is configured
+var ErrNoKey = errors.New("encryption key is not configured")
+
+// Cipher encrypts secrets stored in the database with AES-256-GCM.
+// The key is derived from a passphrase with SHA-256, so any sufficiently random string works.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher from a passphrase. An empty passphrase creates a cipher
+// without key, whose Encrypt and Decrypt calls fail with ErrNoKey.
+func NewCipher(passphrase string) (*Cipher, error) {
+	if passphrase == "" {
+		return &Cipher{}, nil
+	}
+
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// HasKey reports whether the cipher can encrypt and decrypt
+func (c *Cipher) HasKey() bool {
+	return c != nil && c.aead != nil
+}
+
+// Encrypt seals plaintext with a random nonce and returns it as printable text
+func (c *Cipher) Encrypt(plaintext []byte) (string, error) {
+	if !c.HasKey() {
+		return "", ErrNoKey
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, plaintext, nil)
+	return ciphertextPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value returned by Encrypt
+func (c *Cipher) Decrypt(ciphertext string) ([]byte, error) {
+	if !c.HasKey() {
+		return nil, ErrNoKey
+	}
+
+	encoded, ok := strings.CutPrefix(ciphertext, ciphertextPrefix)
+	if !ok {
+		return nil, errors.New("value is not encrypted")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted value: %w", err)
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("encrypted value is too short")
+	}
+
+	plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		// A different key was used, or the value was tampered with
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return plaintext, nil
+}
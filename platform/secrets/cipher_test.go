@@ -0,0 +1,116 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCipherRoundTrip(t *testing.T) {
+	cipher, err := NewCipher("a sufficiently random passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		plaintext string
+	}{
+		{name: "credentials", plaintext: `{"type":"bearer","token":"receiver-token"}`},
+		{name: "empty", plaintext: ""},
+		{name: "binary", plaintext: "\x00\xff\x10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := cipher.Encrypt([]byte(tt.plaintext))
+			if err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if !strings.HasPrefix(encrypted, ciphertextPrefix) {
+				t.Errorf("Encrypt() = %q, want the %q prefix", encrypted, ciphertextPrefix)
+			}
+			if tt.plaintext != "" && strings.Contains(encrypted, tt.plaintext) {
+				t.Error("ciphertext contains the plaintext")
+			}
+
+			decrypted, err := cipher.Decrypt(encrypted)
+			if err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+			if string(decrypted) != tt.plaintext {
+				t.Errorf("Decrypt() = %q, want %q", decrypted, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestCipherUsesARandomNonce(t *testing.T) {
+	cipher, _ := NewCipher("key")
+
+	first, _ := cipher.Encrypt([]byte("secret"))
+	second, _ := cipher.Encrypt([]byte("secret"))
+	if first == second {
+		t.Error("encrypting the same value twice gave the same ciphertext")
+	}
+}
+
+func TestCipherDecryptFailures(t *testing.T) {
+	cipher, _ := NewCipher("current-key")
+	encrypted, err := cipher.Encrypt([]byte(`{"token":"receiver-token"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, ciphertextPrefix))
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 0x01
+
+	wrongKey, _ := NewCipher("other-key")
+
+	tests := []struct {
+		name       string
+		cipher     *Cipher
+		ciphertext string
+	}{
+		{name: "wrong key", cipher: wrongKey, ciphertext: encrypted},
+		{name: "tampered ciphertext", cipher: cipher, ciphertext: ciphertextPrefix + base64.StdEncoding.EncodeToString(tampered)},
+		{name: "plain value", cipher: cipher, ciphertext: `{"token":"receiver-token"}`},
+		{name: "invalid base64", cipher: cipher, ciphertext: ciphertextPrefix + "not base64!"},
+		{name: "too short", cipher: cipher, ciphertext: ciphertextPrefix + base64.StdEncoding.EncodeToString([]byte("short"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if plaintext, err := tt.cipher.Decrypt(tt.ciphertext); err == nil {
+				t.Fatalf("Decrypt() = %q, want an error", plaintext)
+			}
+		})
+	}
+}
+
+func TestCipherWithoutKey(t *testing.T) {
+	cipher, err := NewCipher("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cipher.HasKey() {
+		t.Error("cipher without passphrase reports a key")
+	}
+
+	if _, err := cipher.Encrypt([]byte("secret")); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Encrypt() error = %v, want ErrNoKey", err)
+	}
+
+	withKey, _ := NewCipher("key")
+	encrypted, _ := withKey.Encrypt([]byte("secret"))
+	if _, err := cipher.Decrypt(encrypted); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Decrypt() error = %v, want ErrNoKey", err)
+	}
+
+	var nilCipher *Cipher
+	if nilCipher.HasKey() {
+		t.Error("nil cipher reports a key")
+	}
+}